@@ -0,0 +1,344 @@
+// Package data_control provides Go bindings for the wlr-data-control and ext-data-control Wayland protocols.
+//
+// These protocols let privileged clients such as clipboard managers watch and set
+// the selection of a seat without having keyboard focus. The primary selection
+// (middle-click paste) is available as well where the compositor supports it.
+//
+// # Basic Usage
+//
+//	manager, err := data_control.NewDataControlManager(ctx, data_control.Handlers{
+//		OnSelection: func(offer *data_control.Offer) {
+//			if offer != nil && offer.HasMimeType("text/plain") {
+//				data, _ := offer.Receive(ctx, "text/plain", 1<<20)
+//				fmt.Printf("clipboard: %s\n", data)
+//			}
+//		},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer manager.Close()
+//
+//	// Own the clipboard; data is produced when another client pastes
+//	manager.SetSelection([]string{"text/plain"}, func(mimeType string, w io.Writer) error {
+//		_, err := io.WriteString(w, "Hello World!")
+//		return err
+//	})
+//
+// # Protocol Specification
+//
+// ext_data_control_manager_v1 is used when the compositor provides it, otherwise
+// zwlr_data_control_manager_v1. Supported by wlroots-based compositors (Sway,
+// Hyprland, etc.) and KDE Plasma.
+package data_control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/bnema/libwldevices-go/internal/client"
+	"github.com/bnema/libwldevices-go/internal/protocols"
+)
+
+// ErrTooLarge is returned by Offer.Receive when the data exceeds the limit
+var ErrTooLarge = errors.New("selection data exceeds size limit")
+
+// SendFunc writes the data for a MIME type when another client pastes
+type SendFunc func(mimeType string, w io.Writer) error
+
+// Handlers contains callback functions for selection events
+type Handlers struct {
+	// OnSelection is called when the selection changes, with a nil offer when
+	// it is cleared. It runs on the event loop and must not block.
+	OnSelection func(offer *Offer)
+
+	// OnPrimarySelection is called when the primary selection changes, like
+	// OnSelection
+	OnPrimarySelection func(offer *Offer)
+}
+
+// DataControlManager watches and sets the selection of the default seat
+type DataControlManager struct {
+	client   *client.Client
+	manager  *protocols.DataControlManager
+	device   *protocols.DataControlDevice
+	handlers Handlers
+	primary  bool // Whether the bound version has the primary selection
+
+	mu            sync.Mutex
+	announced     map[*protocols.DataControlOffer][]string // MIME types of offers not yet selected
+	selection     *Offer
+	source        *protocols.DataControlSource
+	primarySel    *Offer
+	primarySource *protocols.DataControlSource
+}
+
+// Offer is the current selection of another client
+type Offer struct {
+	// MimeTypes lists the formats the data is available in
+	MimeTypes []string
+	offer     *protocols.DataControlOffer
+}
+
+// NewDataControlManager connects to the compositor and starts watching the selection
+func NewDataControlManager(ctx context.Context, handlers Handlers) (*DataControlManager, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	c, err := client.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Wayland client: %w", err)
+	}
+
+	if !c.HasDataControl() {
+		_ = c.Close()
+		return nil, fmt.Errorf("no data control protocol available - compositor may not support ext-data-control or wlr-data-control")
+	}
+	if c.GetSeat() == nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("no seat available")
+	}
+
+	global := c.GetDataControlManager()
+	version := min(global.Version, 2)
+	manager := protocols.NewDataControlManager(c.GetContext())
+	if err := c.GetRegistry().Bind(global.Name, global.Interface, version, manager); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to bind %s: %w", global.Interface, err)
+	}
+
+	dm := &DataControlManager{
+		client:    c,
+		manager:   manager,
+		handlers:  handlers,
+		primary:   global.Interface == protocols.ExtDataControlManagerInterface || version >= 2,
+		announced: make(map[*protocols.DataControlOffer][]string),
+	}
+
+	device, err := manager.GetDataDevice(c.GetSeat())
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to get data device: %w", err)
+	}
+	device.SetDataOfferHandler(dm.handleDataOffer)
+	device.SetSelectionHandler(dm.handleSelection)
+	device.SetPrimarySelectionHandler(dm.handlePrimarySelection)
+	dm.device = device
+
+	// The current selection is announced right away
+	go func() {
+		for {
+			if err := c.GetDisplay().Dispatch(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return dm, nil
+}
+
+// Selection returns the current selection, nil when it is empty. A selection
+// set with SetSelection is reported like any other.
+func (dm *DataControlManager) Selection() *Offer {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.selection
+}
+
+// SetSelection takes ownership of the selection, offering the given MIME
+// types. send is called in its own goroutine each time a client pastes.
+func (dm *DataControlManager) SetSelection(mimeTypes []string, send SendFunc) error {
+	return dm.setSelection(false, mimeTypes, send)
+}
+
+// ClearSelection empties the selection
+func (dm *DataControlManager) ClearSelection() error {
+	return dm.device.SetSelection(nil)
+}
+
+// HasPrimarySelection reports whether the compositor supports the primary selection
+func (dm *DataControlManager) HasPrimarySelection() bool {
+	return dm.primary
+}
+
+// PrimarySelection returns the current primary selection, nil when it is empty
+func (dm *DataControlManager) PrimarySelection() *Offer {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.primarySel
+}
+
+// SetPrimarySelection takes ownership of the primary selection, like SetSelection
+func (dm *DataControlManager) SetPrimarySelection(mimeTypes []string, send SendFunc) error {
+	if !dm.primary {
+		return fmt.Errorf("primary selection not supported by the compositor")
+	}
+	return dm.setSelection(true, mimeTypes, send)
+}
+
+// ClearPrimarySelection empties the primary selection
+func (dm *DataControlManager) ClearPrimarySelection() error {
+	if !dm.primary {
+		return fmt.Errorf("primary selection not supported by the compositor")
+	}
+	return dm.device.SetPrimarySelection(nil)
+}
+
+func (dm *DataControlManager) setSelection(primary bool, mimeTypes []string, send SendFunc) error {
+	source, err := dm.manager.CreateDataSource()
+	if err != nil {
+		return fmt.Errorf("failed to create data source: %w", err)
+	}
+	for _, mimeType := range mimeTypes {
+		if err := source.Offer(mimeType); err != nil {
+			_ = source.Destroy()
+			return fmt.Errorf("failed to offer %s: %w", mimeType, err)
+		}
+	}
+
+	current := &dm.source
+	if primary {
+		current = &dm.primarySource
+	}
+
+	source.SetSendHandler(func(mimeType string, fd int) {
+		go func() {
+			f := os.NewFile(uintptr(fd), "data-control-send")
+			defer func() { _ = f.Close() }()
+			_ = send(mimeType, f)
+		}()
+	})
+	source.SetCancelledHandler(func() {
+		dm.mu.Lock()
+		if *current == source {
+			*current = nil
+		}
+		dm.mu.Unlock()
+		_ = source.Destroy()
+	})
+
+	dm.mu.Lock()
+	*current = source
+	dm.mu.Unlock()
+
+	if primary {
+		err = dm.device.SetPrimarySelection(source)
+	} else {
+		err = dm.device.SetSelection(source)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set selection: %w", err)
+	}
+	return nil
+}
+
+// Close releases the data device and closes the connection
+func (dm *DataControlManager) Close() error {
+	if dm == nil {
+		return nil
+	}
+
+	if dm.device != nil {
+		_ = dm.device.Destroy()
+	}
+	if dm.manager != nil {
+		_ = dm.manager.Destroy()
+	}
+	if dm.client != nil {
+		return dm.client.Close()
+	}
+	return nil
+}
+
+// HasMimeType reports whether the data is offered in a MIME type
+func (o *Offer) HasMimeType(mimeType string) bool {
+	return slices.Contains(o.MimeTypes, mimeType)
+}
+
+// Receive reads the data for a MIME type. It fails with ErrTooLarge when the
+// data exceeds limit bytes, and with the context's error when ctx is done
+// before the source finished writing.
+func (o *Offer) Receive(ctx context.Context, mimeType string, limit int64) ([]byte, error) {
+	var fds [2]int
+	if err := syscall.Pipe2(fds[:], syscall.O_CLOEXEC); err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	// Only our end is non-blocking, so it can be read with a deadline
+	if err := syscall.SetNonblock(fds[0], true); err != nil {
+		_ = syscall.Close(fds[0])
+		_ = syscall.Close(fds[1])
+		return nil, fmt.Errorf("failed to set pipe non-blocking: %w", err)
+	}
+	r := os.NewFile(uintptr(fds[0]), "data-control-receive")
+	defer func() { _ = r.Close() }()
+
+	err := o.offer.Receive(mimeType, fds[1])
+	_ = syscall.Close(fds[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", mimeType, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = r.SetReadDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = r.Close() })
+	defer stop()
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", mimeType, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Event handlers, called on the dispatch goroutine
+
+func (dm *DataControlManager) handleDataOffer(offer *protocols.DataControlOffer) {
+	offer.SetOfferHandler(func(mimeType string) {
+		dm.mu.Lock()
+		dm.announced[offer] = append(dm.announced[offer], mimeType)
+		dm.mu.Unlock()
+	})
+}
+
+func (dm *DataControlManager) handleSelection(offer *protocols.DataControlOffer) {
+	dm.updateSelection(&dm.selection, offer, dm.handlers.OnSelection)
+}
+
+func (dm *DataControlManager) handlePrimarySelection(offer *protocols.DataControlOffer) {
+	dm.updateSelection(&dm.primarySel, offer, dm.handlers.OnPrimarySelection)
+}
+
+func (dm *DataControlManager) updateSelection(current **Offer, offer *protocols.DataControlOffer, handler func(*Offer)) {
+	dm.mu.Lock()
+	previous := *current
+	var selection *Offer
+	if offer != nil {
+		selection = &Offer{MimeTypes: dm.announced[offer], offer: offer}
+		delete(dm.announced, offer)
+	}
+	*current = selection
+	dm.mu.Unlock()
+
+	if previous != nil {
+		_ = previous.offer.Destroy()
+	}
+	if handler != nil {
+		handler(selection)
+	}
+}
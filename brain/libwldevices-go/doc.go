@@ -13,6 +13,7 @@
 // • zwp_virtual_keyboard_v1: Keyboard input injection (keys, modifiers, text typing)
 // • zwp_pointer_constraints_v1: Pointer locking and confinement for gaming/applications
 // • zwlr_output_management_v1: Real-time monitor detection and configuration
+// • ext_data_control_v1 / zwlr_data_control_v1: Clipboard monitoring and ownership
 //
 // **Planned (Interface Ready):**
 // • zwp_relative_pointer_v1: High-precision relative mouse movement
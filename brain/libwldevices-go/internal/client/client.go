@@ -20,11 +20,19 @@ type Client struct {
 	keyboardManager    uint32
 	constraintsManager uint32
 	outputManager      uint32
+	dataControlManager Global
 
 	mu      sync.Mutex
 	globals map[uint32]string
 }
 
+// Global identifies an advertised protocol global
+type Global struct {
+	Name      uint32
+	Interface string
+	Version   uint32
+}
+
 // NewClient creates a new Wayland client
 func NewClient() (*Client, error) {
 	// fmt.Println("[DEBUG] Connecting to Wayland display...")
@@ -98,6 +106,15 @@ func (c *Client) HandleRegistryGlobal(event wl.RegistryGlobalEvent) {
 	case "zwlr_output_manager_v1":
 		// fmt.Printf("[DEBUG] Setting outputManager to %d\n", event.Name)
 		c.outputManager = event.Name
+
+	case "ext_data_control_manager_v1":
+		// Preferred over the wlr protocol it standardises
+		c.dataControlManager = Global{Name: event.Name, Interface: event.Interface, Version: event.Version}
+
+	case "zwlr_data_control_manager_v1":
+		if c.dataControlManager.Interface != "ext_data_control_manager_v1" {
+			c.dataControlManager = Global{Name: event.Name, Interface: event.Interface, Version: event.Version}
+		}
 	}
 }
 
@@ -185,6 +202,21 @@ func (c *Client) GetOutputManagerName() uint32 {
 	return c.outputManager
 }
 
+// HasDataControl returns true if a data control protocol is available
+func (c *Client) HasDataControl() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dataControlManager.Name != 0
+}
+
+// GetDataControlManager returns the data control global to bind, preferring
+// ext_data_control_manager_v1 over zwlr_data_control_manager_v1
+func (c *Client) GetDataControlManager() Global {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dataControlManager
+}
+
 // Close closes the Wayland connection
 func (c *Client) Close() error {
 	if c.context != nil {
@@ -0,0 +1,295 @@
+package protocols
+
+import (
+	"syscall"
+
+	"github.com/bnema/wlturbo/wl"
+)
+
+// Protocol interface names for data control. The ext protocol is the
+// standardised successor of the wlr one and keeps the same requests and events.
+const (
+	DataControlManagerInterface    = "zwlr_data_control_manager_v1"
+	ExtDataControlManagerInterface = "ext_data_control_manager_v1"
+)
+
+// DataControlManager creates data sources and per-seat data devices
+type DataControlManager struct {
+	wl.BaseProxy
+}
+
+// NewDataControlManager creates a new data control manager
+func NewDataControlManager(ctx *wl.Context) *DataControlManager {
+	manager := &DataControlManager{}
+	manager.SetContext(ctx)
+	// Note: Manager ID will be set by Registry.Bind
+	return manager
+}
+
+// CreateDataSource creates a new data source
+func (m *DataControlManager) CreateDataSource() (*DataControlSource, error) {
+	source := NewDataControlSource(m.Context())
+
+	// Opcode 0: create_data_source
+	const opcode = 0
+
+	err := m.Context().SendRequest(m, opcode, source)
+	if err != nil {
+		m.Context().Unregister(source)
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// GetDataDevice creates a data device for a seat
+func (m *DataControlManager) GetDataDevice(seat *wl.Seat) (*DataControlDevice, error) {
+	device := NewDataControlDevice(m.Context())
+
+	// Opcode 1: get_data_device
+	const opcode = 1
+
+	err := m.Context().SendRequest(m, opcode, device, seat)
+	if err != nil {
+		m.Context().Unregister(device)
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// Destroy destroys the data control manager
+func (m *DataControlManager) Destroy() error {
+	// Opcode 2: destroy
+	const opcode = 2
+	err := m.Context().SendRequest(m, opcode)
+	m.Context().Unregister(m)
+	return err
+}
+
+// Dispatch handles incoming events (manager has no events)
+func (m *DataControlManager) Dispatch(event *wl.Event) {
+	// Data control manager has no events
+}
+
+// DataControlDevice reports and sets the selection of a seat
+type DataControlDevice struct {
+	wl.BaseProxy
+	dataOfferHandler        func(*DataControlOffer)
+	selectionHandler        func(*DataControlOffer)
+	finishedHandler         func()
+	primarySelectionHandler func(*DataControlOffer)
+	offers                  map[uint32]*DataControlOffer
+}
+
+// NewDataControlDevice creates a new data control device
+func NewDataControlDevice(ctx *wl.Context) *DataControlDevice {
+	device := &DataControlDevice{offers: make(map[uint32]*DataControlOffer)}
+	device.SetContext(ctx)
+	id := ctx.AllocateID()
+	device.SetID(id)
+	ctx.Register(device)
+	return device
+}
+
+// SetDataOfferHandler sets the handler for new offers, called before the
+// offer's MIME types are announced
+func (d *DataControlDevice) SetDataOfferHandler(handler func(*DataControlOffer)) {
+	d.dataOfferHandler = handler
+}
+
+// SetSelectionHandler sets the handler for selection changes. The offer is
+// nil when the selection is cleared.
+func (d *DataControlDevice) SetSelectionHandler(handler func(*DataControlOffer)) {
+	d.selectionHandler = handler
+}
+
+// SetFinishedHandler sets the handler for the finished event, sent when the
+// device is no longer valid
+func (d *DataControlDevice) SetFinishedHandler(handler func()) {
+	d.finishedHandler = handler
+}
+
+// SetPrimarySelectionHandler sets the handler for primary selection changes.
+// The offer is nil when the primary selection is cleared. Only sent from
+// version 2 of the wlr protocol.
+func (d *DataControlDevice) SetPrimarySelectionHandler(handler func(*DataControlOffer)) {
+	d.primarySelectionHandler = handler
+}
+
+// SetSelection sets the selection to a source, or clears it when source is nil
+func (d *DataControlDevice) SetSelection(source *DataControlSource) error {
+	// Opcode 0: set_selection
+	const opcode = 0
+	if source == nil {
+		return d.Context().SendRequest(d, opcode, nil)
+	}
+	return d.Context().SendRequest(d, opcode, source)
+}
+
+// SetPrimarySelection sets the primary selection to a source, or clears it
+// when source is nil. Requires version 2 of the wlr protocol.
+func (d *DataControlDevice) SetPrimarySelection(source *DataControlSource) error {
+	// Opcode 2: set_primary_selection
+	const opcode = 2
+	if source == nil {
+		return d.Context().SendRequest(d, opcode, nil)
+	}
+	return d.Context().SendRequest(d, opcode, source)
+}
+
+// Destroy destroys the data control device
+func (d *DataControlDevice) Destroy() error {
+	// Opcode 1: destroy
+	const opcode = 1
+	err := d.Context().SendRequest(d, opcode)
+	d.Context().Unregister(d)
+	return err
+}
+
+// Dispatch handles incoming events
+func (d *DataControlDevice) Dispatch(event *wl.Event) {
+	switch event.Opcode {
+	case 0: // data_offer
+		// The server allocates the offer ID; register it before its offer events arrive
+		offer := NewDataControlOffer(d.Context())
+		offer.SetID(event.Uint32())
+		d.Context().Register(offer)
+		d.offers[offer.ID()] = offer
+		if d.dataOfferHandler != nil {
+			d.dataOfferHandler(offer)
+		}
+	case 1: // selection
+		offer := d.takeOffer(event.Uint32())
+		if d.selectionHandler != nil {
+			d.selectionHandler(offer)
+		}
+	case 2: // finished
+		if d.finishedHandler != nil {
+			d.finishedHandler()
+		}
+	case 3: // primary_selection
+		offer := d.takeOffer(event.Uint32())
+		if d.primarySelectionHandler != nil {
+			d.primarySelectionHandler(offer)
+		}
+	}
+}
+
+// takeOffer returns the offer introduced with an ID, nil for a null object
+func (d *DataControlDevice) takeOffer(id uint32) *DataControlOffer {
+	if id == 0 {
+		return nil
+	}
+	offer := d.offers[id]
+	delete(d.offers, id)
+	return offer
+}
+
+// DataControlSource provides data to other clients
+type DataControlSource struct {
+	wl.BaseProxy
+	sendHandler      func(mimeType string, fd int)
+	cancelledHandler func()
+}
+
+// NewDataControlSource creates a new data control source
+func NewDataControlSource(ctx *wl.Context) *DataControlSource {
+	source := &DataControlSource{}
+	source.SetContext(ctx)
+	id := ctx.AllocateID()
+	source.SetID(id)
+	ctx.Register(source)
+	return source
+}
+
+// SetSendHandler sets the handler for send events. The handler owns the fd
+// and must write the data for the MIME type to it and close it.
+func (s *DataControlSource) SetSendHandler(handler func(mimeType string, fd int)) {
+	s.sendHandler = handler
+}
+
+// SetCancelledHandler sets the handler for the cancelled event, sent when the
+// source has been replaced
+func (s *DataControlSource) SetCancelledHandler(handler func()) {
+	s.cancelledHandler = handler
+}
+
+// Offer adds a MIME type the source can provide
+func (s *DataControlSource) Offer(mimeType string) error {
+	// Opcode 0: offer
+	const opcode = 0
+	return s.Context().SendRequest(s, opcode, mimeType)
+}
+
+// Destroy destroys the data control source
+func (s *DataControlSource) Destroy() error {
+	// Opcode 1: destroy
+	const opcode = 1
+	err := s.Context().SendRequest(s, opcode)
+	s.Context().Unregister(s)
+	return err
+}
+
+// Dispatch handles incoming events
+func (s *DataControlSource) Dispatch(event *wl.Event) {
+	switch event.Opcode {
+	case 0: // send
+		mimeType := event.String()
+		fd := int(event.Fd())
+		if s.sendHandler != nil {
+			s.sendHandler(mimeType, fd)
+		} else if fd > 0 {
+			_ = syscall.Close(fd)
+		}
+	case 1: // cancelled
+		if s.cancelledHandler != nil {
+			s.cancelledHandler()
+		}
+	}
+}
+
+// DataControlOffer is data offered by another client
+type DataControlOffer struct {
+	wl.BaseProxy
+	offerHandler func(mimeType string)
+}
+
+// NewDataControlOffer creates a data control offer for a server-allocated ID
+func NewDataControlOffer(ctx *wl.Context) *DataControlOffer {
+	offer := &DataControlOffer{}
+	offer.SetContext(ctx)
+	return offer
+}
+
+// SetOfferHandler sets the handler for announced MIME types
+func (o *DataControlOffer) SetOfferHandler(handler func(mimeType string)) {
+	o.offerHandler = handler
+}
+
+// Receive asks the source to write the data for a MIME type to fd
+func (o *DataControlOffer) Receive(mimeType string, fd int) error {
+	// Opcode 0: receive
+	const opcode = 0
+	return o.Context().SendRequestWithFDs(o, opcode, []int{fd}, mimeType, uintptr(fd))
+}
+
+// Destroy destroys the data control offer
+func (o *DataControlOffer) Destroy() error {
+	// Opcode 1: destroy
+	const opcode = 1
+	err := o.Context().SendRequest(o, opcode)
+	o.Context().Unregister(o)
+	return err
+}
+
+// Dispatch handles incoming events
+func (o *DataControlOffer) Dispatch(event *wl.Event) {
+	switch event.Opcode {
+	case 0: // offer
+		mimeType := event.String()
+		if o.offerHandler != nil {
+			o.offerHandler(mimeType)
+		}
+	}
+}
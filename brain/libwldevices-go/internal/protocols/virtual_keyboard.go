@@ -123,6 +123,11 @@ func CreateDefaultKeymap() (int, uint32, error) {
 	xkb_geometry  { include "pc(pc105)"	};
 };`
 
+	return CreateKeymap(keymap)
+}
+
+// CreateKeymap creates a file descriptor holding an XKB keymap in text form
+func CreateKeymap(keymap string) (int, uint32, error) {
 	// Create anonymous shared memory file
 	size := len(keymap) + 1 // +1 for null terminator
 	fd, err := wl.CreateAnonymousFile(int64(size))
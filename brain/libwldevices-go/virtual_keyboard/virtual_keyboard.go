@@ -272,6 +272,27 @@ func (k *VirtualKeyboard) setDefaultKeymap() error {
 	return nil
 }
 
+// SetKeymap replaces the keymap with an XKB keymap in text form, as produced
+// by xkbcomp or xkbcli compile-keymap. Keys pressed afterwards are interpreted
+// with the new keymap.
+func (k *VirtualKeyboard) SetKeymap(keymap string) error {
+	fd, size, err := protocols.CreateKeymap(keymap)
+	if err != nil {
+		return fmt.Errorf("failed to create keymap: %w", err)
+	}
+
+	if err := k.keyboard.Keymap(KEYMAP_FORMAT_XKB_V1, fd, size); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("failed to send keymap: %w", err)
+	}
+	k.keymapSet = true
+
+	if err := k.client.GetDisplay().Roundtrip(); err != nil {
+		return fmt.Errorf("failed to roundtrip after keymap: %w", err)
+	}
+	return nil
+}
+
 // Key sends a key press/release event
 func (k *VirtualKeyboard) Key(timestamp time.Time, key uint32, state KeyState) error {
 	if !k.keymapSet {
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/ipc"
+	"github.com/bnema/waymon/internal/network"
+	pb "github.com/bnema/waymon/internal/proto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authDenyAll bool
+	pairTimeout time.Duration
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Approve or deny client SSH keys waiting on the server",
+	Long: `Approve or deny client SSH keys waiting on a running waymon server.
+
+When ssh_whitelist_only is enabled, a client connecting with an unknown key
+waits until the key is approved in the server TUI, approved with these
+commands, or the request times out. Approved keys are added to the server's
+authorized_keys file.
+
+  sudo waymon auth pending
+  sudo waymon auth approve SHA256:abc...
+  sudo waymon auth deny SHA256:abc...
+  sudo waymon auth deny --all
+
+The commands talk to the server over its IPC socket and must be run as root
+or as the user running the server.`,
+}
+
+var authPendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List client keys waiting for approval",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := ipc.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create IPC client: %w", err)
+		}
+
+		resp, err := client.SendAuthList()
+		if err != nil {
+			return fmt.Errorf("failed to list pending keys: %w", err)
+		}
+
+		displayPendingAuths(resp.Pending)
+		return nil
+	},
+}
+
+var authApproveCmd = &cobra.Command{
+	Use:   "approve <fingerprint>",
+	Short: "Approve a client key waiting for approval",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendAuthDecision(args[0], true, false)
+	},
+}
+
+var authDenyCmd = &cobra.Command{
+	Use:   "deny [fingerprint]",
+	Short: "Deny a client key waiting for approval",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if authDenyAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fingerprint := ""
+		if len(args) > 0 {
+			fingerprint = args[0]
+		}
+		return sendAuthDecision(fingerprint, false, authDenyAll)
+	},
+}
+
+var serverPairCmd = &cobra.Command{
+	Use:   "pair",
+	Short: "Show a one-time code to enroll a new client",
+	Long: `Ask the running waymon server for a one-time pairing code.
+
+Run 'waymon client --pair CODE' on the new client before the code expires.
+The client key is added to the server's authorized_keys and the server host
+key is pinned on the client, without comparing fingerprints. A code works
+once, a new code replaces the previous one, and three wrong attempts revoke it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := ipc.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create IPC client: %w", err)
+		}
+
+		resp, err := client.SendPair(int32(pairTimeout / time.Second)) //nolint:gosec // timeouts are short
+		if err != nil {
+			return fmt.Errorf("failed to create pairing code: %w", err)
+		}
+
+		expiresAt := time.UnixMilli(resp.ExpiresAtMs)
+		fmt.Printf("Pairing code: %s\n", resp.Code)
+		fmt.Printf("Valid until %s, for one client\n\n", expiresAt.Format(time.TimeOnly))
+		fmt.Println("On the new client run:")
+		fmt.Printf("  waymon client --host <this-server>:%d --pair %s\n\n", config.Get().Server.Port, resp.Code)
+		fmt.Printf("Server host key: %s\n", resp.HostKeyFingerprint)
+		return nil
+	},
+}
+
+func init() {
+	authDenyCmd.Flags().BoolVar(&authDenyAll, "all", false, "Deny every key waiting for approval")
+
+	authCmd.AddCommand(authPendingCmd)
+	authCmd.AddCommand(authApproveCmd)
+	authCmd.AddCommand(authDenyCmd)
+	rootCmd.AddCommand(authCmd)
+
+	serverPairCmd.Flags().DurationVar(&pairTimeout, "timeout", network.DefaultPairingTimeout, "How long the code stays valid")
+	serverCmd.AddCommand(serverPairCmd)
+}
+
+func sendAuthDecision(fingerprint string, approve, all bool) error {
+	client, err := ipc.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create IPC client: %w", err)
+	}
+
+	resp, err := client.SendAuthDecision(fingerprint, approve, all)
+	if err != nil {
+		return fmt.Errorf("failed to send decision: %w", err)
+	}
+
+	switch {
+	case all:
+		fmt.Printf("✓ Denied %d pending key(s)\n", resp.Resolved)
+	case approve:
+		fmt.Printf("✓ Approved %s\n", fingerprint)
+	default:
+		fmt.Printf("✓ Denied %s\n", fingerprint)
+	}
+
+	if len(resp.Pending) > 0 {
+		fmt.Println()
+		displayPendingAuths(resp.Pending)
+	}
+	return nil
+}
+
+func displayPendingAuths(pending []*pb.PendingAuth) {
+	if len(pending) == 0 {
+		fmt.Println("No keys waiting for approval")
+		return
+	}
+
+	fmt.Println("Keys waiting for approval:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Fingerprint\tAddress\tUser\tExpires in")
+	for _, p := range pending {
+		remaining := time.Until(time.UnixMilli(p.ExpiresAtMs)).Round(time.Second)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Fingerprint, p.Address, p.User, remaining)
+	}
+	_ = w.Flush()
+}
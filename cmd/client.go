@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/bnema/waymon/internal/client"
 	"github.com/bnema/waymon/internal/config"
 	"github.com/bnema/waymon/internal/display"
 	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/network"
 	"github.com/bnema/waymon/internal/ui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,19 +21,24 @@ import (
 var (
 	serverAddr string
 	hostName   string
+	pairCode   string
 )
 
 var clientCmd = &cobra.Command{
 	Use:   "client",
 	Short: "Run Waymon in client mode",
 	Long: `Run Waymon in client mode to receive mouse/keyboard events from a server.
-The client will inject received input events locally using uinput.`,
+The client will inject received input events locally using uinput.
+
+Without a server address the client starts by browsing the local network for
+waymon servers, press [d] in the TUI to browse again.`,
 	RunE: runClient,
 }
 
 func init() {
 	clientCmd.Flags().StringVarP(&serverAddr, "host", "H", "", "Server address (host:port)")
 	clientCmd.Flags().StringVarP(&hostName, "name", "n", "", "Host name from config")
+	clientCmd.Flags().StringVar(&pairCode, "pair", "", "Enroll with a code shown by 'waymon server pair' before connecting")
 
 	// Bind flags to viper
 	if err := viper.BindPFlag("client.server_address", clientCmd.Flags().Lookup("host")); err != nil {
@@ -85,11 +93,21 @@ func runClient(cmd *cobra.Command, args []string) error {
 		serverAddr = cfg.Client.ServerAddress
 	}
 
-	// Validate we have a server address
-	if serverAddr == "" {
+	// Pairing needs an address, otherwise the TUI browses the LAN for servers
+	if serverAddr == "" && pairCode != "" {
 		return fmt.Errorf("no server address specified (use --host or configure a default)")
 	}
 
+	// Enroll the client key and pin the server host key before connecting
+	if pairCode != "" {
+		sshClient := network.NewSSHClient(cfg.Client.SSHPrivateKey)
+		fingerprint, err := sshClient.Pair(serverAddr, pairCode, network.NewClientKnownHosts(nil))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Paired with %s (host key %s)\n", serverAddr, fingerprint)
+	}
+
 	// Note: Edge detection no longer needed in redesigned architecture
 
 	// Initialize display detection
@@ -118,6 +136,7 @@ func runClient(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create input receiver: %w", err)
 	}
+	inputReceiver.SetSoftwareVersion(Version)
 	defer func() {
 		if err := inputReceiver.Disconnect(); err != nil {
 			logger.Errorf("Failed to disconnect input receiver: %v", err)
@@ -137,56 +156,38 @@ func runClient(cmd *cobra.Command, args []string) error {
 		logger.Infof("Connection status: %s", status)
 	})
 
-	// Start connection logic in background
-	go func() {
-		// Wait for UI to initialize and set up callbacks
-		time.Sleep(1 * time.Second)
-
-		// Connection retry logic
-		backoff := 1 * time.Second
-		maxBackoff := 60 * time.Second
-		attempt := 1
-
-		logger.Info("Starting connection to server with automatic retry")
-
-		for {
-			select {
-			case <-ctx.Done():
-				logger.Info("Connection attempts cancelled")
-				return
-			default:
-			}
-
-			logger.Infof("Connection attempt %d to %s", attempt, serverAddr)
-
-			// Try to connect
-			err := inputReceiver.Connect(ctx, privateKeyPath)
-
-			if err == nil {
-				// Connection successful
-				logger.Infof("Successfully connected to server at %s", serverAddr)
-				logger.Info("Client ready to receive input from server")
-				return
-			}
-
-			// Connection failed, log error
-			logger.Errorf("Connection attempt %d failed: %v", attempt, err)
-
-			// Wait with exponential backoff
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(backoff):
-			}
-
-			// Increase backoff
-			backoff *= 2
-			if backoff > maxBackoff {
-				backoff = maxBackoff
-			}
-			attempt++
+	// Connect in the background, retrying until it works. Connecting to
+	// another server, e.g. one discovered from the TUI, ends previous attempts.
+	var (
+		attemptsMu     sync.Mutex
+		cancelAttempts context.CancelFunc = func() {}
+	)
+	connect := func(address, fingerprint string) {
+		attemptsMu.Lock()
+		defer attemptsMu.Unlock()
+
+		// The context of the previous attempts also runs the live connection
+		if inputReceiver.IsConnected() {
+			logger.Errorf("Cannot connect to %s: already connected", address)
+			return
 		}
-	}()
+		cancelAttempts()
+		if err := inputReceiver.SetServerAddress(address, fingerprint); err != nil {
+			logger.Errorf("Cannot connect to %s: %v", address, err)
+			return
+		}
+		var attemptCtx context.Context
+		attemptCtx, cancelAttempts = context.WithCancel(ctx)
+		go connectWithRetry(attemptCtx, inputReceiver, privateKeyPath, address)
+	}
+
+	if serverAddr != "" {
+		go func() {
+			// Wait for UI to initialize and set up callbacks
+			time.Sleep(1 * time.Second)
+			connect(serverAddr, "")
+		}()
+	}
 
 	// Ensure cleanup happens on any exit path
 	defer func() {
@@ -200,9 +201,64 @@ func runClient(cmd *cobra.Command, args []string) error {
 	}()
 
 	// Run the client UI with the new refactored approach
-	if err := ui.RunClientUI(ctx, serverAddr, inputReceiver, Version); err != nil {
+	if err := ui.RunClientUI(ctx, serverAddr, inputReceiver, connect, Version); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// connectWithRetry connects the input receiver to address with exponential
+// backoff until it succeeds or ctx is done
+func connectWithRetry(ctx context.Context, inputReceiver *client.InputReceiver, privateKeyPath, address string) {
+	backoff := 1 * time.Second
+	maxBackoff := 60 * time.Second
+	attempt := 1
+
+	logger.Info("Starting connection to server with automatic retry")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Connection attempts cancelled")
+			return
+		default:
+		}
+
+		logger.Infof("Connection attempt %d to %s", attempt, address)
+
+		// Try to connect
+		err := inputReceiver.Connect(ctx, privateKeyPath)
+
+		if err == nil {
+			// Connection successful
+			logger.Infof("Successfully connected to server at %s", address)
+			logger.Info("Client ready to receive input from server")
+			return
+		}
+
+		// Connection failed, log error
+		logger.Errorf("Connection attempt %d failed: %v", attempt, err)
+
+		// Retrying cannot help until the user decides to trust the server,
+		// or one side is upgraded
+		if errors.Is(err, network.ErrHostKeyChanged) || errors.Is(err, network.ErrHostKeyRejected) ||
+			errors.Is(err, network.ErrProtocolMismatch) {
+			return
+		}
+
+		// Wait with exponential backoff
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		// Increase backoff
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		attempt++
+	}
+}
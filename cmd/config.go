@@ -3,10 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/bnema/waymon/internal/config"
 	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/network"
 	"github.com/spf13/cobra"
 )
 
@@ -30,9 +32,48 @@ var configShowCmd = &cobra.Command{
 		logger.Infof("  Bind Address: %s", cfg.Server.BindAddress)
 		logger.Infof("  Name: %s", cfg.Server.Name)
 		logger.Infof("  Max Clients: %d", cfg.Server.MaxClients)
+		logger.Infof("  Advertise on LAN: %v", cfg.Server.Advertise)
 		logger.Infof("  SSH Host Key: %s", cfg.Server.SSHHostKeyPath)
 		logger.Infof("  SSH Authorized Keys: %s", cfg.Server.SSHAuthKeysPath)
 		logger.Infof("  SSH Whitelist Only: %v", cfg.Server.SSHWhitelistOnly)
+		logger.Infof("  Approval Timeout: %d seconds", cfg.Server.ApprovalTimeout)
+		logger.Infof("  Client State: %s", config.GetClientStatePath())
+		if cfg.Server.SSHTrustedCAKeysPath != "" {
+			logger.Infof("  SSH Trusted CA Keys: %s", cfg.Server.SSHTrustedCAKeysPath)
+			if len(cfg.Server.SSHCertPrincipals) > 0 {
+				logger.Infof("  SSH Certificate Principals: %s", strings.Join(cfg.Server.SSHCertPrincipals, ", "))
+			}
+		}
+		logger.Infof("  Edge Switching: %v", cfg.Server.EdgeSwitching)
+		logger.Infof("  Switch Position: %s", cfg.Server.SwitchPosition)
+		logger.Infof("  Forward Keymap: %v", cfg.Server.ForwardKeymap)
+		if cfg.Server.XKBLayout != "" {
+			logger.Infof("  XKB Layout: %s", cfg.Server.XKBLayout)
+		}
+		logger.Infof("  Clipboard Sync: %v", cfg.Server.ClipboardSync)
+		logger.Infof("  Primary Selection Sync: %v", cfg.Server.PrimarySelectionSync)
+		if cfg.Server.MotionCoalescing == "interval" {
+			logger.Infof("  Motion Coalescing: interval, %d ms", cfg.Server.CoalesceInterval)
+		} else {
+			logger.Infof("  Motion Coalescing: %s", cfg.Server.MotionCoalescing)
+		}
+		logger.Infof("  Touchpad Speed: %g", cfg.Server.TouchpadSpeed)
+		logger.Infof("  Tap To Click: %v", cfg.Server.TapToClick)
+		if cfg.Server.HeartbeatInterval > 0 {
+			logger.Infof("  Heartbeat: every %d ms, %d misses", cfg.Server.HeartbeatInterval, cfg.Server.HeartbeatMisses)
+		} else {
+			logger.Info("  Heartbeat: disabled")
+		}
+		if len(cfg.Server.Bindings) > 0 {
+			logger.Info("  Bindings:")
+			for _, b := range cfg.Server.Bindings {
+				target := b.Client
+				if target == "" && b.Index > 0 {
+					target = fmt.Sprintf("#%d", b.Index)
+				}
+				logger.Infof("    - %s: %s %s", b.Keys, b.Action, target)
+			}
+		}
 		if len(cfg.Server.SSHWhitelist) > 0 {
 			logger.Info("  SSH Whitelist:")
 			for _, fp := range cfg.Server.SSHWhitelist {
@@ -46,6 +87,22 @@ var configShowCmd = &cobra.Command{
 		logger.Infof("  Reconnect Delay: %d seconds", cfg.Client.ReconnectDelay)
 		logger.Infof("  Edge Threshold: %d pixels", cfg.Client.EdgeThreshold)
 		logger.Infof("  Hotkey: %s+%s", cfg.Client.HotkeyModifier, cfg.Client.HotkeyKey)
+		logger.Infof("  Clipboard Sync: %v", cfg.Client.ClipboardSync)
+		logger.Infof("  Primary Selection Sync: %v", cfg.Client.PrimarySelectionSync)
+		logger.Infof("  Heartbeat Misses: %d", cfg.Client.HeartbeatMisses)
+		logger.Infof("  Scroll Speed: %g", cfg.Client.ScrollSpeed)
+		logger.Infof("  Natural Scroll: %v", cfg.Client.NaturalScroll)
+		if len(cfg.Client.GestureBindings) > 0 {
+			logger.Info("  Gesture Bindings:")
+			for _, b := range cfg.Client.GestureBindings {
+				logger.Infof("    - %s: %s", b.Gesture, b.Keys)
+			}
+		}
+		if cfg.Client.TabletMonitor != "" {
+			logger.Infof("  Tablet Monitor: %s", cfg.Client.TabletMonitor)
+		}
+		logger.Infof("  Known Hosts: %s", config.GetKnownHostsPath())
+		logger.Infof("  System Known Hosts: %v", cfg.Client.SystemKnownHosts)
 
 
 		if len(cfg.Hosts) > 0 {
@@ -271,6 +328,65 @@ var configSSHClearCmd = &cobra.Command{
 	},
 }
 
+var configKnownHostsCmd = &cobra.Command{
+	Use:   "known-hosts",
+	Short: "Manage trusted server host keys",
+}
+
+var configKnownHostsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted server host keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.GetKnownHostsPath()
+		entries, err := network.ListKnownHosts(path)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) == 0 {
+			logger.Infof("No trusted servers in %s", path)
+			return nil
+		}
+
+		logger.Infof("Trusted servers (%s):", path)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(w, "Host\tKey Type\tFingerprint"); err != nil {
+			logger.Errorf("Failed to write header: %v", err)
+		}
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Join(entry.Hosts, ","), entry.KeyType, entry.Fingerprint); err != nil {
+				logger.Errorf("Failed to write host key info: %v", err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			logger.Errorf("Failed to flush writer: %v", err)
+		}
+
+		return nil
+	},
+}
+
+var configKnownHostsRemoveCmd = &cobra.Command{
+	Use:   "remove <host>",
+	Short: "Forget the host key of a server",
+	Long:  `Forget the host key of a server, e.g. after its key was regenerated. The next connection will ask to trust the new key.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host := args[0]
+
+		removed, err := network.RemoveKnownHost(config.GetKnownHostsPath(), host)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return fmt.Errorf("no known host key for '%s'", host)
+		}
+
+		logger.Infof("Removed %d host key(s) for %s", removed, host)
+		return nil
+	},
+}
+
 func init() {
 	// Add subcommands
 	configCmd.AddCommand(configShowCmd)
@@ -278,6 +394,7 @@ func init() {
 	configCmd.AddCommand(configHostCmd)
 	configCmd.AddCommand(configInitCmd)
 	configCmd.AddCommand(configSSHCmd)
+	configCmd.AddCommand(configKnownHostsCmd)
 
 	// Add host subcommands
 	configHostCmd.AddCommand(configHostAddCmd)
@@ -289,6 +406,10 @@ func init() {
 	configSSHCmd.AddCommand(configSSHRemoveCmd)
 	configSSHCmd.AddCommand(configSSHClearCmd)
 
+	// Add known hosts subcommands
+	configKnownHostsCmd.AddCommand(configKnownHostsListCmd)
+	configKnownHostsCmd.AddCommand(configKnownHostsRemoveCmd)
+
 	// Add flags
 	configInitCmd.Flags().Bool("force", false, "Force overwrite existing configuration")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bnema/waymon/internal/discovery"
+	"github.com/bnema/waymon/internal/protocol"
+	"github.com/spf13/cobra"
+)
+
+var discoverTimeout time.Duration
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find waymon servers on the local network",
+	Long: `Find waymon servers announcing themselves on the local network over mDNS.
+
+Each server lists its name, address, protocol version and the fingerprint of
+its SSH host key. When connecting to a discovered server from the client TUI,
+the server must present the announced key, which is then pinned in known_hosts
+once trusted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		services, err := discovery.Browse(context.Background(), discoverTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to browse the local network: %w", err)
+		}
+		if len(services) == 0 {
+			fmt.Println("No waymon servers found on the local network")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "Name\tAddress\tProtocol\tHost key")
+		for _, s := range services {
+			version := fmt.Sprintf("v%d", s.ProtocolVersion)
+			if !protocol.Compatible(s.ProtocolVersion) {
+				version += " (incompatible)"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Address(), version, s.Fingerprint)
+		}
+		_ = w.Flush()
+
+		fmt.Printf("\nConnect with: waymon client --host %s\n", services[0].Address())
+		return nil
+	},
+}
+
+func init() {
+	discoverCmd.Flags().DurationVar(&discoverTimeout, "timeout", discovery.DefaultBrowseTimeout, "How long to wait for servers to answer")
+	rootCmd.AddCommand(discoverCmd)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/bnema/waymon/internal/config"
 	"github.com/bnema/waymon/internal/ipc"
 	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/network"
 	"github.com/bnema/waymon/internal/protocol"
 	"github.com/bnema/waymon/internal/server"
 	"github.com/bnema/waymon/internal/ui"
@@ -62,14 +63,15 @@ func initializeServer(ctx context.Context, srv *server.Server, cfg *config.Confi
 	// NOW set up client connection callbacks AFTER the SSH server is created
 	if sshSrv := srv.GetNetworkServer(); sshSrv != nil {
 		logger.Debug("Setting up SSH server callbacks")
+		sshSrv.SetSoftwareVersion(Version)
 
 		// Set up client connection handler (only once!)
-		sshSrv.OnClientConnected = func(addr, publicKey string) {
+		sshSrv.OnClientConnected = func(clientID, addr, publicKey string) {
 			// Register client with ClientManager
 			if cm := srv.GetClientManager(); cm != nil {
-				// Use address as both ID and name initially
+				// Use address as name initially
 				// The client will send its actual configuration later
-				cm.RegisterClient(addr, addr, addr)
+				cm.RegisterClient(clientID, addr, addr)
 				logger.Infof("Client connected and registered: %s", addr)
 			}
 
@@ -85,10 +87,10 @@ func initializeServer(ctx context.Context, srv *server.Server, cfg *config.Confi
 		}
 
 		// Set up client disconnection handler
-		sshSrv.OnClientDisconnected = func(addr string) {
+		sshSrv.OnClientDisconnected = func(clientID, addr string) {
 			// Unregister client from ClientManager
 			if cm := srv.GetClientManager(); cm != nil {
-				cm.UnregisterClient(addr)
+				cm.UnregisterClient(clientID, addr)
 				logger.Infof("Client disconnected and unregistered: %s", addr)
 			}
 
@@ -103,21 +105,30 @@ func initializeServer(ctx context.Context, srv *server.Server, cfg *config.Confi
 			}
 		}
 
-		// Set up authentication handler
-		sshSrv.OnAuthRequest = func(addr, publicKey, fingerprint string) bool {
-			if model != nil {
-				// For now, log the auth request and auto-approve
-				// TODO: Implement interactive approval in the refactored UI
-				model.AddLogEntry(ui.LogEntry{
-					Timestamp: time.Now(),
-					Level:     "WARN",
-					Message:   fmt.Sprintf("SSH auth request from %s (fingerprint: %s) - auto-approved", addr, fingerprint),
-				})
-				return true
-			} else {
-				// In non-TUI mode, auto-approve (you might want to change this)
-				logger.Warnf("Auto-approving SSH connection from %s (fingerprint: %s) - running in no-TUI mode", addr, fingerprint)
-				return true
+		// Unknown keys wait for approval in the TUI or with 'waymon auth approve'.
+		// Without the TUI the SSH server logs the request.
+		sshSrv.OnAuthPending = func(request network.PendingAuth) {
+			if model == nil || model.GetProgram() == nil {
+				return
+			}
+			response := make(chan bool, 1)
+			model.GetProgram().Send(ui.SSHAuthRequestMsg{
+				ClientAddr:   request.Address,
+				PublicKey:    request.PublicKey,
+				Fingerprint:  request.Fingerprint,
+				ResponseChan: response,
+			})
+			select {
+			case approved := <-response:
+				if err := sshSrv.ResolveAuth(request.Fingerprint, approved); err != nil {
+					logger.Debugf("SSH key decided in the TUI was already settled: %v", err)
+				}
+			case <-time.After(time.Until(request.ExpiresAt)):
+			}
+		}
+		sshSrv.OnAuthResolved = func(fingerprint string, approved bool) {
+			if model != nil && model.GetProgram() != nil {
+				model.GetProgram().Send(ui.SSHAuthResolvedMsg{Fingerprint: fingerprint, Approved: approved})
 			}
 		}
 
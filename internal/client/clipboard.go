@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bnema/waymon/internal/clipboard"
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// startClipboard starts sharing the client clipboard, and the primary selection
+// when enabled, with the server unless disabled or unsupported by the
+// compositor. Must be called with the lock held.
+func (ir *InputReceiver) startClipboard(ctx context.Context) {
+	cfg := config.Get()
+	if ir.clipboard != nil || !cfg.Client.ClipboardSync {
+		return
+	}
+
+	clip, err := clipboard.New(ctx, cfg.Client.ClipboardMaxSize, cfg.Client.PrimarySelectionSync)
+	if err != nil {
+		logger.Warnf("[CLIENT-RECEIVER] Clipboard sharing unavailable: %v", err)
+		return
+	}
+	ir.clipboard = clip
+	ir.clipboardSent = 0
+	ir.primarySent = 0
+	logger.Info("[CLIENT-RECEIVER] Clipboard sharing enabled")
+}
+
+// stopClipboard stops sharing the client clipboard. Must be called with the lock held.
+func (ir *InputReceiver) stopClipboard() {
+	if ir.clipboard == nil {
+		return
+	}
+	if err := ir.clipboard.Close(); err != nil {
+		logger.Errorf("[CLIENT-RECEIVER] Failed to close clipboard: %v", err)
+	}
+	ir.clipboard = nil
+}
+
+// offerClipboard announces the client clipboard and primary selection to the
+// server if they changed since they were last sent or taken from the server,
+// so what was copied here can be pasted there once control returns. Must be
+// called with the lock held.
+func (ir *InputReceiver) offerClipboard() {
+	if ir.clipboard == nil || ir.sshConnection == nil {
+		return
+	}
+
+	for _, primary := range []bool{false, true} {
+		offer := ir.clipboard.Offer(primary)
+		sent := ir.sentSerial(primary)
+		if offer == nil || offer.Serial == *sent {
+			continue
+		}
+
+		if err := ir.sshConnection.SendInputEvent(&protocol.InputEvent{
+			Event:     &protocol.InputEvent_ClipboardOffer{ClipboardOffer: offer},
+			Timestamp: time.Now().UnixNano(),
+			SourceId:  ir.clientID,
+		}); err != nil {
+			logger.Warnf("[CLIENT-RECEIVER] Failed to offer clipboard: %v", err)
+			return
+		}
+		*sent = offer.Serial
+		logger.Debugf("[CLIENT-RECEIVER] Offered clipboard %d (primary: %v): %v", offer.Serial, primary, offer.MimeTypes)
+	}
+}
+
+// sentSerial returns the serial last exchanged with the server for the
+// clipboard or the primary selection. Must be called with the lock held.
+func (ir *InputReceiver) sentSerial(primary bool) *uint64 {
+	if primary {
+		return &ir.primarySent
+	}
+	return &ir.clipboardSent
+}
+
+// handleClipboardEvent processes clipboard messages from the server and
+// reports whether the event was one
+func (ir *InputReceiver) handleClipboardEvent(event *protocol.InputEvent) bool {
+	switch event.Event.(type) {
+	case *protocol.InputEvent_ClipboardOffer, *protocol.InputEvent_ClipboardRequest, *protocol.InputEvent_ClipboardData:
+	default:
+		return false
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	clip := ir.clipboard
+	if clip == nil {
+		return true
+	}
+
+	switch e := event.Event.(type) {
+	case *protocol.InputEvent_ClipboardOffer:
+		if e.ClipboardOffer.Primary && !clip.Primary() {
+			logger.Debug("[CLIENT-RECEIVER] Ignoring primary selection from server, not shared")
+			return true
+		}
+		serial, err := clip.Adopt(e.ClipboardOffer, "server", func(req *protocol.ClipboardRequest) error {
+			return ir.sendToServer(&protocol.InputEvent{
+				Event: &protocol.InputEvent_ClipboardRequest{ClipboardRequest: req},
+			})
+		})
+		if err != nil {
+			logger.Warnf("[CLIENT-RECEIVER] Failed to take clipboard from server: %v", err)
+			return true
+		}
+		*ir.sentSerial(e.ClipboardOffer.Primary) = serial
+		logger.Infof("[CLIENT-RECEIVER] Clipboard taken from server (primary: %v)", e.ClipboardOffer.Primary)
+
+	case *protocol.InputEvent_ClipboardRequest:
+		go func() {
+			reply := clip.HandleRequest(e.ClipboardRequest)
+			if reply.Error != "" {
+				logger.Warnf("[CLIENT-RECEIVER] Clipboard request failed: %s", reply.Error)
+			}
+			if err := ir.sendToServer(&protocol.InputEvent{
+				Event: &protocol.InputEvent_ClipboardData{ClipboardData: reply},
+			}); err != nil {
+				logger.Warnf("[CLIENT-RECEIVER] Failed to send clipboard: %v", err)
+			}
+		}()
+
+	case *protocol.InputEvent_ClipboardData:
+		clip.HandleData("server", e.ClipboardData)
+	}
+	return true
+}
+
+// sendToServer sends an event to the server from outside the lock
+func (ir *InputReceiver) sendToServer(event *protocol.InputEvent) error {
+	ir.mu.RLock()
+	sshConnection := ir.sshConnection
+	ir.mu.RUnlock()
+
+	if sshConnection == nil {
+		return fmt.Errorf("not connected")
+	}
+	event.Timestamp = time.Now().UnixNano()
+	event.SourceId = ir.clientID
+	return sshConnection.SendInputEvent(event)
+}
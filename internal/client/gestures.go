@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/input"
+	"github.com/bnema/waymon/internal/logger"
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// parseGestureBindings validates the configured gesture bindings, skipping
+// invalid ones, and returns the chord of each gesture
+func parseGestureBindings(entries []config.GestureBinding) map[string]input.Chord {
+	bindings := make(map[string]input.Chord, len(entries))
+	for _, entry := range entries {
+		chord, err := parseGestureBinding(entry)
+		if err != nil {
+			logger.Warnf("[CLIENT-RECEIVER] Ignoring gesture binding: %v", err)
+			continue
+		}
+		bindings[entry.Gesture] = chord
+	}
+	return bindings
+}
+
+// parseGestureBinding validates a configured gesture binding
+func parseGestureBinding(entry config.GestureBinding) (input.Chord, error) {
+	if !slices.Contains(input.GestureNames(), entry.Gesture) {
+		return input.Chord{}, fmt.Errorf("unknown gesture %q", entry.Gesture)
+	}
+	chord, err := input.ParseChord(entry.Keys)
+	if err != nil {
+		return input.Chord{}, fmt.Errorf("gesture %s: %w", entry.Gesture, err)
+	}
+	if chord.Code >= evdev.BTN_MISC && chord.Code < evdev.KEY_OK {
+		return input.Chord{}, fmt.Errorf("gesture %s: %q ends with a mouse button, not a key", entry.Gesture, entry.Keys)
+	}
+	return chord, nil
+}
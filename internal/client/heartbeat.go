@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// heartbeatCheckInterval is how often the client checks that pings still arrive
+const heartbeatCheckInterval = 500 * time.Millisecond
+
+// handlePing answers a server ping and reports whether the event was one. The
+// watchdog only arms once the server has pinged, so servers without a
+// heartbeat are left alone.
+func (ir *InputReceiver) handlePing(event *protocol.InputEvent) bool {
+	control := event.GetControl()
+	if control == nil || control.Type != protocol.ControlEvent_PING {
+		return false
+	}
+
+	ir.mu.Lock()
+	ir.lastPing = time.Now()
+	ir.pingInterval = time.Duration(control.IntervalMs) * time.Millisecond
+	ir.mu.Unlock()
+
+	go func() {
+		if err := ir.sendToServer(&protocol.InputEvent{
+			Event: &protocol.InputEvent_Control{
+				Control: &protocol.ControlEvent{
+					Type:     protocol.ControlEvent_PONG,
+					Sequence: control.Sequence,
+				},
+			},
+		}); err != nil {
+			logger.Debugf("[CLIENT-RECEIVER] Failed to answer ping %d: %v", control.Sequence, err)
+		}
+	}()
+	return true
+}
+
+// startHeartbeatWatch watches for missed server pings. Must be called with
+// the lock held.
+func (ir *InputReceiver) startHeartbeatWatch(ctx context.Context) {
+	if ir.heartbeatCancel != nil {
+		ir.heartbeatCancel()
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	ir.heartbeatCancel = cancel
+	ir.lastPing = time.Time{}
+
+	go func() {
+		ticker := time.NewTicker(heartbeatCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-ticker.C:
+				ir.checkHeartbeat()
+			}
+		}
+	}()
+}
+
+// checkHeartbeat drops out of the controlled state and releases injected
+// input when the server missed too many pings, then drops the connection so
+// reconnection takes over
+func (ir *InputReceiver) checkHeartbeat() {
+	misses := config.Get().Client.HeartbeatMisses
+	if misses < 1 {
+		misses = 1
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if !ir.connected || ir.lastPing.IsZero() || ir.pingInterval <= 0 {
+		return
+	}
+	timeout := ir.pingInterval * time.Duration(misses)
+	if time.Since(ir.lastPing) <= timeout {
+		return
+	}
+
+	logger.Warnf("[CLIENT-RECEIVER] No ping from server for %v - releasing control", timeout)
+	ir.lastPing = time.Time{}
+	wasControlled := ir.controlStatus.BeingControlled
+	ir.connected = false
+	ir.controlStatus = ControlStatus{}
+	ir.releaseInjected()
+
+	// Like a server shutdown, leave the rest to reconnection
+	if ir.sshConnection != nil {
+		if err := ir.sshConnection.Disconnect(); err != nil {
+			logger.Errorf("Failed to disconnect SSH connection: %v", err)
+		}
+		ir.sshConnection = nil
+	}
+	if ir.onReconnectStatus != nil {
+		go ir.onReconnectStatus("Server stopped responding - will reconnect shortly...")
+	}
+
+	if wasControlled && ir.onStatusChange != nil {
+		statusCopy := ir.controlStatus
+		go ir.onStatusChange(statusCopy)
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// latencySampleInterval is how often an injected event is echoed to the server
+const latencySampleInterval = time.Second
+
+// jitterMeter estimates interarrival jitter as in RFC 3550: how much the
+// spacing of events on arrival differs from their spacing when captured.
+// Only differences of server timestamps are used, so the clocks of the two
+// machines need not agree.
+type jitterMeter struct {
+	lastSent    int64 // Server timestamp of the previous event, in nanoseconds
+	lastArrival time.Time
+	jitter      float64 // In nanoseconds
+}
+
+// observe records the arrival of an event captured at sent
+func (j *jitterMeter) observe(sent int64, arrival time.Time) {
+	if j.lastSent != 0 {
+		d := float64(arrival.Sub(j.lastArrival)) - float64(sent-j.lastSent)
+		if d < 0 {
+			d = -d
+		}
+		j.jitter += (d - j.jitter) / 16
+	}
+	j.lastSent = sent
+	j.lastArrival = arrival
+}
+
+// value returns the current jitter estimate
+func (j *jitterMeter) value() time.Duration {
+	return time.Duration(j.jitter)
+}
+
+// sampleLatency measures an injected input event and, at most once per
+// latencySampleInterval, echoes it back to the server
+func (ir *InputReceiver) sampleLatency(event *protocol.InputEvent, arrival time.Time, injectTime time.Duration) {
+	if event.Timestamp == 0 {
+		return
+	}
+
+	ir.mu.Lock()
+	ir.jitter.observe(event.Timestamp, arrival)
+	if arrival.Sub(ir.lastSample) < latencySampleInterval {
+		ir.mu.Unlock()
+		return
+	}
+	ir.lastSample = arrival
+	sample := &protocol.LatencySample{
+		EventTimestamp: event.Timestamp,
+		InjectUs:       injectTime.Microseconds(),
+		JitterUs:       ir.jitter.value().Microseconds(),
+	}
+	ir.mu.Unlock()
+
+	go func() {
+		if err := ir.sendToServer(&protocol.InputEvent{
+			Event: &protocol.InputEvent_LatencySample{LatencySample: sample},
+		}); err != nil {
+			logger.Debugf("[CLIENT-RECEIVER] Failed to send latency sample: %v", err)
+		}
+	}()
+}
@@ -2,11 +2,14 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/bnema/waymon/internal/clipboard"
+	"github.com/bnema/waymon/internal/config"
 	"github.com/bnema/waymon/internal/display"
 	"github.com/bnema/waymon/internal/input"
 	"github.com/bnema/waymon/internal/logger"
@@ -32,7 +35,8 @@ type InputReceiver struct {
 	inputBackend   input.InputBackend
 	controlStatus  ControlStatus
 	onStatusChange func(ControlStatus)
-	clientID       string // The client identifier (hostname)
+	clientID       string              // The client identifier (hostname)
+	pressed        *input.PressedState // Keys and buttons injected and not yet released
 
 	// Connection callbacks
 	onConnected    func()
@@ -43,8 +47,29 @@ type InputReceiver struct {
 	reconnectCtx        context.Context
 	reconnectCancel     context.CancelFunc
 	privateKeyPath      string
-	onReconnectStatus   func(status string) // Callback for reconnection status updates
-	reconnectInProgress bool                // Prevent multiple concurrent reconnection attempts
+	onReconnectStatus   func(status string)   // Callback for reconnection status updates
+	hostKeyPrompt       network.HostKeyPrompt // Asks whether to trust a new server key
+	expectedHostKey     string                // Fingerprint announced by a discovered server
+	softwareVersion     string                // Waymon release told to the server
+	reconnectInProgress bool                  // Prevent multiple concurrent reconnection attempts
+
+	// Output layout last reported to the server and used for absolute positioning
+	monitors          []*protocol.Monitor
+	outputWatchCancel context.CancelFunc
+
+	// Clipboard shared with the server, and the selection serials last sent or taken
+	clipboard     *clipboard.Clipboard
+	clipboardSent uint64
+	primarySent   uint64
+
+	// Heartbeat from the server, the watchdog arms on the first ping
+	lastPing        time.Time
+	pingInterval    time.Duration
+	heartbeatCancel context.CancelFunc
+
+	// Latency measured on injected events and last echoed to the server
+	jitter     jitterMeter
+	lastSample time.Time
 
 	// Hotkey handling state - disabled for now
 	// lastHotkeyPress  time.Time
@@ -66,6 +91,12 @@ func NewInputReceiver(serverAddress string) (*InputReceiver, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create input backend: %w", err)
 	}
+	if injector, ok := backend.(*input.WaylandVirtualInput); ok {
+		cfg := config.Get().Client
+		injector.SetScrollOptions(cfg.ScrollSpeed, cfg.NaturalScroll)
+		injector.SetGestureBindings(parseGestureBindings(cfg.GestureBindings))
+		injector.SetTabletMonitor(cfg.TabletMonitor)
+	}
 
 	// Get hostname for client ID
 	hostname, err := os.Hostname()
@@ -78,6 +109,7 @@ func NewInputReceiver(serverAddress string) (*InputReceiver, error) {
 		inputBackend:  backend,
 		connected:     false,
 		clientID:      hostname,
+		pressed:       input.NewPressedState(),
 		// removed health check timeout
 		// hotkeyDebounceMs: 500, // 500ms debounce for hotkey presses - disabled
 	}, nil
@@ -101,7 +133,7 @@ func (ir *InputReceiver) Connect(ctx context.Context, privateKeyPath string) err
 	}
 
 	// Create SSH connection to server
-	sshConnection := network.NewSSHClient(privateKeyPath)
+	sshConnection := ir.newSSHClient(privateKeyPath)
 
 	// Connect to server
 	if err := sshConnection.Connect(ctx, ir.serverAddress); err != nil {
@@ -127,6 +159,15 @@ func (ir *InputReceiver) Connect(ctx context.Context, privateKeyPath string) err
 	// Enable reconnection by default
 	ir.enableReconnection(ctx)
 
+	// Follow output changes so the server and the virtual pointer stay in sync
+	ir.startOutputWatch(ctx)
+
+	// Share the clipboard with the server as control moves
+	ir.startClipboard(ctx)
+
+	// Release control if the server stops pinging
+	ir.startHeartbeatWatch(ctx)
+
 	// Note: Input events are received automatically by SSH client
 
 	logger.Infof("Connected to server: %s", ir.serverAddress)
@@ -154,6 +195,14 @@ func (ir *InputReceiver) Disconnect() error {
 		ir.reconnectCancel()
 		ir.reconnectCancel = nil
 	}
+	if ir.outputWatchCancel != nil {
+		ir.outputWatchCancel()
+		ir.outputWatchCancel = nil
+	}
+	if ir.heartbeatCancel != nil {
+		ir.heartbeatCancel()
+		ir.heartbeatCancel = nil
+	}
 
 	// Disconnect SSH connection
 	if ir.sshConnection != nil {
@@ -163,6 +212,10 @@ func (ir *InputReceiver) Disconnect() error {
 		ir.sshConnection = nil
 	}
 
+	// Release held input while the virtual devices still exist
+	ir.releaseInjected()
+	ir.stopClipboard()
+
 	// Stop input backend
 	if err := ir.inputBackend.Stop(); err != nil {
 		logger.Errorf("Failed to stop input backend: %v", err)
@@ -212,9 +265,14 @@ func (ir *InputReceiver) OnStatusChange(callback func(ControlStatus)) {
 
 // processInputEvent processes a received input event
 func (ir *InputReceiver) processInputEvent(event *protocol.InputEvent) {
+	arrival := time.Now()
 	logger.Debugf("[CLIENT-RECEIVER] Processing input event: type=%T, timestamp=%d, sourceId=%s",
 		event.Event, event.Timestamp, event.SourceId)
 
+	if ir.handlePing(event) || ir.handleClipboardEvent(event) {
+		return
+	}
+
 	// Handle control events first
 	if controlEvent := event.GetControl(); controlEvent != nil {
 		logger.Debugf("[CLIENT-RECEIVER] Event is control event: type=%v", controlEvent.Type)
@@ -244,10 +302,29 @@ func (ir *InputReceiver) processInputEvent(event *protocol.InputEvent) {
 	if err := ir.injectEvent(event); err != nil {
 		logger.Errorf("[CLIENT-RECEIVER] Failed to inject input event: %v", err)
 	} else {
+		ir.pressed.Observe(event)
+		ir.sampleLatency(event, arrival, time.Since(arrival))
 		logger.Debugf("[CLIENT-RECEIVER] Successfully injected event")
 	}
 }
 
+// releaseInjected releases every key and button injected and still held down,
+// and lifts a tablet tool still in proximity, so nothing stays pressed once the
+// server stops sending input
+func (ir *InputReceiver) releaseInjected() {
+	if !ir.pressed.Any() {
+		return
+	}
+
+	events := ir.pressed.Release(ir.clientID)
+	for _, event := range events {
+		if err := ir.injectEvent(event); err != nil {
+			logger.Warnf("[CLIENT-RECEIVER] Failed to release held input: %v", err)
+		}
+	}
+	logger.Infof("[CLIENT-RECEIVER] Released %d held keys/buttons/tools", len(events))
+}
+
 // handleControlEvent processes control events from the server
 func (ir *InputReceiver) handleControlEvent(control *protocol.ControlEvent) {
 	logger.Debugf("[CLIENT-RECEIVER] Handling control event: type=%v, targetId=%s", control.Type, control.TargetId)
@@ -263,6 +340,13 @@ func (ir *InputReceiver) handleControlEvent(control *protocol.ControlEvent) {
 		ir.controlStatus.ConnectedAt = time.Now().Unix()
 		logger.Infof("[CLIENT-RECEIVER] Control granted to server: %s", control.TargetId)
 
+		// Match the server's Caps Lock and Num Lock
+		if backend, ok := ir.inputBackend.(*input.WaylandVirtualInput); ok {
+			if err := backend.SyncLockedModifiers(control.LockedModifiers); err != nil {
+				logger.Warnf("[CLIENT-RECEIVER] Failed to sync lock state: %v", err)
+			}
+		}
+
 		// Show notification to user
 		logger.Infof("🖥️  %s is now controlling your system", control.TargetId)
 
@@ -271,6 +355,8 @@ func (ir *InputReceiver) handleControlEvent(control *protocol.ControlEvent) {
 		previousController := ir.controlStatus.ControllerName
 		ir.controlStatus.BeingControlled = false
 		ir.controlStatus.ControllerName = ""
+		ir.releaseInjected()
+		ir.offerClipboard()
 		logger.Info("[CLIENT-RECEIVER] Control released by server")
 
 		// Show notification to user
@@ -284,8 +370,20 @@ func (ir *InputReceiver) handleControlEvent(control *protocol.ControlEvent) {
 		// Server switched to local control (we're no longer being controlled)
 		ir.controlStatus.BeingControlled = false
 		ir.controlStatus.ControllerName = ""
+		ir.releaseInjected()
+		ir.offerClipboard()
 		logger.Info("[CLIENT-RECEIVER] Server switched to local control")
 
+	case protocol.ControlEvent_KEYMAP:
+		// Server sent its keyboard layout for the keys it forwards
+		if backend, ok := ir.inputBackend.(*input.WaylandVirtualInput); ok && control.Keymap != nil {
+			if err := backend.SetKeymap(control.Keymap.XkbKeymap); err != nil {
+				logger.Warnf("[CLIENT-RECEIVER] Failed to install server keymap: %v", err)
+			} else {
+				logger.Infof("[CLIENT-RECEIVER] Using server keymap: %s", control.Keymap.Name)
+			}
+		}
+
 	case protocol.ControlEvent_SERVER_SHUTDOWN:
 		// Server is shutting down gracefully
 		logger.Info("[CLIENT-RECEIVER] Server is shutting down - will attempt to reconnect")
@@ -293,6 +391,7 @@ func (ir *InputReceiver) handleControlEvent(control *protocol.ControlEvent) {
 		ir.connected = false
 		// Clear control status
 		ir.controlStatus = ControlStatus{}
+		ir.releaseInjected()
 		// Don't call Disconnect() here as it will cleanup input injector and disable reconnection
 		// Just disconnect the SSH connection
 		if ir.sshConnection != nil {
@@ -426,7 +525,7 @@ func (ir *InputReceiver) isControlSwitchHotkey(keyEvent *protocol.KeyboardEvent)
 
 // parseHotkeyKey converts a key name to its key code
 func (ir *InputReceiver) parseHotkeyKey(keyName string) uint32 {
-	// Key codes from wayland-virtual-input-go
+	// Key codes from libwldevices-go
 	keyMap := map[string]uint32{
 		"a": 30, "b": 48, "c": 46, "d": 32, "e": 18, "f": 33, "g": 34, "h": 35,
 		"i": 23, "j": 36, "k": 37, "l": 38, "m": 50, "n": 49, "o": 24, "p": 25,
@@ -465,13 +564,30 @@ func (ir *InputReceiver) parseHotkeyModifiers(modifierString string) uint32 {
 }
 */
 
-// sendClientConfiguration sends the client's monitor and capability information to the server
+// sendClientConfiguration detects the client's monitors, applies them as the
+// output layout and sends them with the capability information to the server
 func (ir *InputReceiver) sendClientConfiguration() error {
+	protocolMonitors, err := detectMonitors()
+	if err != nil {
+		return err
+	}
+	ir.applyOutputLayout(protocolMonitors)
+	return ir.sendConfiguration(protocolMonitors)
+}
+
+// applyOutputLayout records the monitors and hands them to the injection backend
+func (ir *InputReceiver) applyOutputLayout(monitors []*protocol.Monitor) {
+	ir.monitors = monitors
+	if backend, ok := ir.inputBackend.(*input.WaylandVirtualInput); ok {
+		backend.SetOutputLayout(monitors)
+	}
+}
 
-	// Get display information
+// detectMonitors reads the current output layout
+func detectMonitors() ([]*protocol.Monitor, error) {
 	disp, err := display.New()
 	if err != nil {
-		return fmt.Errorf("failed to initialize display for config: %w", err)
+		return nil, fmt.Errorf("failed to initialize display for config: %w", err)
 	}
 	defer func() {
 		if err := disp.Close(); err != nil {
@@ -496,13 +612,18 @@ func (ir *InputReceiver) sendClientConfiguration() error {
 		}
 	}
 
+	return protocolMonitors, nil
+}
+
+// sendConfiguration sends the given monitors and the client's capabilities to the server
+func (ir *InputReceiver) sendConfiguration(protocolMonitors []*protocol.Monitor) error {
 	// Create client capabilities
 	capabilities := &protocol.ClientCapabilities{
 		CanReceiveKeyboard: true,
 		CanReceiveMouse:    true,
 		CanReceiveScroll:   true,
 		WaylandCompositor:  getWaylandCompositor(),
-		UinputVersion:      "wayland-virtual-input-v0.1.3", // Using Wayland virtual input
+		UinputVersion:      "libwldevices-go", // Using Wayland virtual input
 	}
 
 	// Create client configuration
@@ -540,6 +661,65 @@ func (ir *InputReceiver) sendClientConfiguration() error {
 	return nil
 }
 
+// startOutputWatch starts polling for output changes, replacing any previous watch
+func (ir *InputReceiver) startOutputWatch(ctx context.Context) {
+	if ir.outputWatchCancel != nil {
+		ir.outputWatchCancel()
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	ir.outputWatchCancel = cancel
+	go ir.watchOutputs(watchCtx)
+}
+
+// watchOutputs re-detects the monitors periodically and, when the layout changed,
+// updates the absolute pointer extent and resends the configuration to the server
+func (ir *InputReceiver) watchOutputs(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			monitors, err := detectMonitors()
+			if err != nil {
+				logger.Debugf("[CLIENT-RECEIVER] Failed to detect monitors: %v", err)
+				continue
+			}
+
+			ir.mu.Lock()
+			if monitorsEqual(ir.monitors, monitors) {
+				ir.mu.Unlock()
+				continue
+			}
+			logger.Infof("[CLIENT-RECEIVER] Output layout changed: %d monitors", len(monitors))
+			ir.applyOutputLayout(monitors)
+			if ir.connected {
+				if err := ir.sendConfiguration(monitors); err != nil {
+					logger.Warnf("Failed to send updated client configuration: %v", err)
+				}
+			}
+			ir.mu.Unlock()
+		}
+	}
+}
+
+// monitorsEqual reports whether two monitor lists describe the same layout
+func monitorsEqual(a, b []*protocol.Monitor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].X != b[i].X || a[i].Y != b[i].Y ||
+			a[i].Width != b[i].Width || a[i].Height != b[i].Height ||
+			a[i].Primary != b[i].Primary || a[i].Scale != b[i].Scale {
+			return false
+		}
+	}
+	return true
+}
+
 // getWaylandCompositor attempts to detect the Wayland compositor
 func getWaylandCompositor() string {
 	// Check common environment variables
@@ -571,6 +751,74 @@ func (ir *InputReceiver) SetOnReconnectStatus(callback func(status string)) {
 	ir.onReconnectStatus = callback
 }
 
+// SetHostKeyPrompt sets the callback asking whether to trust the key of a
+// server connected to for the first time. Without one such servers are refused.
+func (ir *InputReceiver) SetHostKeyPrompt(prompt network.HostKeyPrompt) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.hostKeyPrompt = prompt
+}
+
+// SetServerAddress changes the server to connect to. A non-empty fingerprint,
+// such as the one a server announced on the LAN, is required from the server
+// if its key is not in known_hosts yet.
+func (ir *InputReceiver) SetServerAddress(address, fingerprint string) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if ir.connected {
+		return fmt.Errorf("already connected to %s", ir.serverAddress)
+	}
+	ir.serverAddress = address
+	ir.expectedHostKey = fingerprint
+	return nil
+}
+
+// SetSoftwareVersion sets the waymon release told to the server when a
+// session opens
+func (ir *InputReceiver) SetSoftwareVersion(version string) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.softwareVersion = version
+}
+
+// newSSHClient creates the SSH client for a connection attempt. Must be
+// called with the lock held.
+func (ir *InputReceiver) newSSHClient(privateKeyPath string) *network.SSHClient {
+	sshConnection := network.NewSSHClient(privateKeyPath)
+	sshConnection.SetHostKeyCallback(ir.knownHosts().HostKeyCallback())
+	sshConnection.SetFeatures(clientFeatures())
+	sshConnection.SetSoftwareVersion(ir.softwareVersion)
+	sshConnection.SetClientID(ir.clientID)
+	return sshConnection
+}
+
+// clientFeatures returns the features offered to the server, leaving out
+// those turned off in the configuration or unavailable on this machine
+func clientFeatures() []string {
+	var features []string
+	for _, feature := range protocol.Features() {
+		if feature == protocol.FeatureClipboard && !config.Get().Client.ClipboardSync {
+			continue
+		}
+		// Tablets are injected through a virtual device, which needs uinput
+		if feature == protocol.FeatureTablet && !input.UinputAvailable() {
+			continue
+		}
+		features = append(features, feature)
+	}
+	return features
+}
+
+// knownHosts creates the server host key verifier for a connection attempt
+func (ir *InputReceiver) knownHosts() *network.KnownHosts {
+	knownHosts := network.NewClientKnownHosts(ir.hostKeyPrompt)
+	if ir.expectedHostKey != "" {
+		knownHosts.ExpectFingerprint(ir.expectedHostKey)
+	}
+	return knownHosts
+}
+
 // SetOnConnected sets a callback for when connection is established
 func (ir *InputReceiver) SetOnConnected(callback func()) {
 	ir.mu.Lock()
@@ -596,7 +844,7 @@ func (ir *InputReceiver) monitorConnection() {
 			return
 		case <-ticker.C:
 			ir.mu.RLock()
-			connected := ir.connected
+			connected := ir.connected && ir.sshConnection != nil && ir.sshConnection.IsConnected()
 			enabled := ir.reconnectEnabled
 			ir.mu.RUnlock()
 
@@ -606,6 +854,10 @@ func (ir *InputReceiver) monitorConnection() {
 
 			if !connected {
 				ir.mu.Lock()
+				// Nothing will release what the server was holding down
+				ir.connected = false
+				ir.controlStatus = ControlStatus{}
+				ir.releaseInjected()
 				inProgress := ir.reconnectInProgress
 				if !inProgress {
 					ir.reconnectInProgress = true
@@ -675,6 +927,18 @@ func (ir *InputReceiver) attemptReconnection() {
 			cancel()
 			logger.Warnf("Reconnection attempt %d failed: %v", attempt, err)
 
+			// Retrying cannot fix a server key that is no longer trusted
+			if errors.Is(err, network.ErrHostKeyChanged) || errors.Is(err, network.ErrHostKeyRejected) {
+				logger.Errorf("Giving up reconnecting to %s: %v", ir.serverAddress, err)
+				ir.notifyReconnectStatus("Server host key not trusted - not reconnecting")
+				return
+			}
+			if errors.Is(err, network.ErrProtocolMismatch) {
+				logger.Errorf("Giving up reconnecting to %s: %v", ir.serverAddress, err)
+				ir.notifyReconnectStatus("Server speaks an incompatible protocol - not reconnecting")
+				return
+			}
+
 			// Wait with exponential backoff
 			ir.notifyReconnectStatus(fmt.Sprintf("Reconnection failed, retrying in %v...", backoff))
 
@@ -714,7 +978,7 @@ func (ir *InputReceiver) reconnectToServer(ctx context.Context) error {
 	}
 
 	// Create new SSH connection
-	sshConnection := network.NewSSHClient(ir.privateKeyPath)
+	sshConnection := ir.newSSHClient(ir.privateKeyPath)
 
 	// Connect to server
 	if err := sshConnection.Connect(ctx, ir.serverAddress); err != nil {
@@ -723,6 +987,7 @@ func (ir *InputReceiver) reconnectToServer(ctx context.Context) error {
 
 	ir.sshConnection = sshConnection
 	ir.connected = true
+	ir.lastPing = time.Time{} // Re-armed by the new session's first ping
 
 	// Set up input event handler
 	logger.Debug("[CLIENT-RECEIVER] Setting up SSH input event handler")
@@ -774,16 +1039,28 @@ func (ir *InputReceiver) injectEvent(event *protocol.InputEvent) error {
 
 	case *protocol.InputEvent_MouseScroll:
 		logger.Debugf("[CLIENT-RECEIVER] Injecting mouse scroll event")
-		return backend.InjectMouseScroll(e.MouseScroll.Dx, e.MouseScroll.Dy)
+		return backend.InjectMouseScroll(e.MouseScroll)
 
 	case *protocol.InputEvent_Keyboard:
 		logger.Debugf("[CLIENT-RECEIVER] Injecting keyboard event")
 		return backend.InjectKeyEvent(e.Keyboard.Key, e.Keyboard.Pressed)
 
+	case *protocol.InputEvent_Frame:
+		logger.Debugf("[CLIENT-RECEIVER] Injecting frame of %d events", len(e.Frame.Events))
+		return backend.InjectFrame(e.Frame.Events)
+
+	case *protocol.InputEvent_Gesture:
+		logger.Debugf("[CLIENT-RECEIVER] Injecting %s gesture %s", e.Gesture.Type, e.Gesture.Phase)
+		return backend.InjectGesture(e.Gesture)
+
+	case *protocol.InputEvent_TabletTool:
+		logger.Debugf("[CLIENT-RECEIVER] Injecting tablet %s event", e.TabletTool.Tool)
+		return backend.InjectTabletTool(e.TabletTool)
+
 	case *protocol.InputEvent_MousePosition:
 		logger.Debugf("[CLIENT-RECEIVER] Received mouse position event")
 		// Use absolute positioning if supported by the backend
-		if err := backend.InjectMousePosition(uint32(e.MousePosition.X), uint32(e.MousePosition.Y)); err != nil {
+		if err := backend.InjectMousePosition(e.MousePosition.X, e.MousePosition.Y); err != nil {
 			logger.Warnf("[CLIENT-RECEIVER] Failed to inject absolute mouse position: %v", err)
 			// Fall back to relative movement calculation if absolute positioning fails
 			// This is just a placeholder - proper implementation would track current position
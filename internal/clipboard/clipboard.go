@@ -0,0 +1,333 @@
+// Package clipboard shares the Wayland clipboard and primary selection between
+// the server and its clients. A selection is announced when control moves
+// between machines and its contents only cross the network when something is
+// pasted.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnema/libwldevices-go/data_control"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/network"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// ownerMimeType marks selections set by waymon, so a selection adopted from
+// another machine is not mistaken for a local copy and offered back
+const ownerMimeType = "application/x-waymon-selection"
+
+// requestTimeout bounds a paste waiting on the other machine
+const requestTimeout = 5 * time.Second
+
+// FetchFunc sends a request for clipboard contents to the machine that offered them
+type FetchFunc func(req *protocol.ClipboardRequest) error
+
+// Clipboard follows the local selections and serves them to, or fills them
+// from, the other end of a waymon connection
+type Clipboard struct {
+	manager *data_control.DataControlManager
+	maxSize int64
+	primary bool // Whether the primary selection is shared
+
+	mu         sync.Mutex
+	clipboard  selection
+	primarySel selection
+	requests   map[uint64]*pendingRequest
+	nextID     uint64
+}
+
+// pendingRequest is a request for contents waiting on the machine it was sent to
+type pendingRequest struct {
+	source string // Only replies from this machine are accepted
+	reply  chan *protocol.ClipboardData
+}
+
+// selection is the state of the clipboard or the primary selection
+type selection struct {
+	serial  uint64              // Changes with every new selection
+	local   *data_control.Offer // Selection owned by a local application
+	adopted *adoptedOffer       // Selection offered by another machine
+}
+
+// adoptedOffer is a remote selection set as the local one
+type adoptedOffer struct {
+	offer  *protocol.ClipboardOffer
+	source string // Machine the selection came from
+	fetch  FetchFunc
+}
+
+// New starts watching the clipboard, and the primary selection when primary is
+// set and the compositor supports it. Contents larger than maxSize bytes are
+// not transferred.
+func New(ctx context.Context, maxSize int, primary bool) (*Clipboard, error) {
+	if maxSize <= 0 || maxSize > network.MaxClipboardSize {
+		maxSize = network.MaxClipboardSize
+	}
+
+	useSudoWaylandSession()
+
+	c := &Clipboard{
+		maxSize:  int64(maxSize),
+		requests: make(map[uint64]*pendingRequest),
+	}
+	manager, err := data_control.NewDataControlManager(ctx, data_control.Handlers{
+		OnSelection:        c.handleSelection,
+		OnPrimarySelection: c.handlePrimarySelection,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch clipboard: %w", err)
+	}
+	c.manager = manager
+
+	if primary && !manager.HasPrimarySelection() {
+		logger.Warn("[CLIPBOARD] Compositor has no primary selection support, only sharing the clipboard")
+		primary = false
+	}
+	c.primary = primary
+	return c, nil
+}
+
+// Close stops watching the clipboard
+func (c *Clipboard) Close() error {
+	return c.manager.Close()
+}
+
+// Primary reports whether the primary selection is shared
+func (c *Clipboard) Primary() bool {
+	return c.primary
+}
+
+// Offer returns the current clipboard, or primary selection when primary is
+// set, for announcing to another machine. It is nil when the selection is
+// empty or not shared.
+func (c *Clipboard) Offer(primary bool) *protocol.ClipboardOffer {
+	if primary && !c.primary {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sel := c.selection(primary)
+	switch {
+	case sel.adopted != nil:
+		return &protocol.ClipboardOffer{Serial: sel.serial, MimeTypes: sel.adopted.offer.MimeTypes, Primary: primary}
+	case sel.local != nil && len(sel.local.MimeTypes) > 0:
+		return &protocol.ClipboardOffer{Serial: sel.serial, MimeTypes: sel.local.MimeTypes, Primary: primary}
+	}
+	return nil
+}
+
+// Adopt makes a selection offered by the machine source the local one. Pastes
+// are answered through fetch, and only by source. It returns the serial the
+// selection now has locally, so it is not offered back to where it came from.
+func (c *Clipboard) Adopt(offer *protocol.ClipboardOffer, source string, fetch FetchFunc) (uint64, error) {
+	if len(offer.MimeTypes) == 0 {
+		return 0, fmt.Errorf("offer has no MIME types")
+	}
+	if offer.Primary && !c.primary {
+		return 0, fmt.Errorf("primary selection is not shared")
+	}
+
+	adopted := &adoptedOffer{offer: offer, source: source, fetch: fetch}
+	mimeTypes := append(slices.Clone(offer.MimeTypes), ownerMimeType)
+	send := func(mimeType string, w io.Writer) error {
+		data, err := c.fetch(adopted, mimeType)
+		if err != nil {
+			logger.Warnf("[CLIPBOARD] Paste of %s failed: %v", mimeType, err)
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	var err error
+	if offer.Primary {
+		err = c.manager.SetPrimarySelection(mimeTypes, send)
+	} else {
+		err = c.manager.SetSelection(mimeTypes, send)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sel := c.selection(offer.Primary)
+	sel.serial++
+	sel.local = nil
+	sel.adopted = adopted
+	logger.Debugf("[CLIPBOARD] Adopted remote selection %d as %d (primary: %v): %v", offer.Serial, sel.serial, offer.Primary, offer.MimeTypes)
+	return sel.serial, nil
+}
+
+// HandleRequest reads the contents asked for by another machine. It blocks
+// until the contents are available and should run in its own goroutine.
+func (c *Clipboard) HandleRequest(req *protocol.ClipboardRequest) *protocol.ClipboardData {
+	reply := &protocol.ClipboardData{RequestId: req.RequestId}
+
+	c.mu.Lock()
+	sel := c.selection(req.Primary)
+	serial, local, adopted := sel.serial, sel.local, sel.adopted
+	c.mu.Unlock()
+
+	var data []byte
+	var err error
+	switch {
+	case req.Primary && !c.primary:
+		err = fmt.Errorf("primary selection is not shared")
+	case req.Serial != serial:
+		err = fmt.Errorf("clipboard changed")
+	case adopted != nil:
+		// Relay from the machine the selection came from
+		data, err = c.fetch(adopted, req.MimeType)
+	case local != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		data, err = local.Receive(ctx, req.MimeType, c.maxSize)
+		cancel()
+		if errors.Is(err, data_control.ErrTooLarge) {
+			err = fmt.Errorf("clipboard content exceeds %d bytes", c.maxSize)
+		}
+	default:
+		err = fmt.Errorf("clipboard is empty")
+	}
+
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Data = data
+	}
+	return reply
+}
+
+// HandleData delivers contents received from the machine source for an
+// earlier request. Contents from any other machine than the one asked are
+// dropped.
+func (c *Clipboard) HandleData(source string, data *protocol.ClipboardData) {
+	c.mu.Lock()
+	pending, ok := c.requests[data.RequestId]
+	if ok && pending.source == source {
+		delete(c.requests, data.RequestId)
+	}
+	c.mu.Unlock()
+
+	switch {
+	case !ok:
+		logger.Debugf("[CLIPBOARD] Dropping contents for unknown request %d", data.RequestId)
+	case pending.source != source:
+		logger.Warnf("[CLIPBOARD] Dropping contents for request %d from %s, it was sent to %s", data.RequestId, source, pending.source)
+	default:
+		pending.reply <- data
+	}
+}
+
+// fetch requests the contents of an adopted selection and waits for them
+func (c *Clipboard) fetch(adopted *adoptedOffer, mimeType string) ([]byte, error) {
+	ch := make(chan *protocol.ClipboardData, 1)
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.requests[id] = &pendingRequest{source: adopted.source, reply: ch}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.requests, id)
+		c.mu.Unlock()
+	}()
+
+	req := &protocol.ClipboardRequest{
+		RequestId: id,
+		Serial:    adopted.offer.Serial,
+		MimeType:  mimeType,
+		Primary:   adopted.offer.Primary,
+	}
+	if err := adopted.fetch(req); err != nil {
+		return nil, fmt.Errorf("failed to request clipboard: %w", err)
+	}
+
+	select {
+	case data := <-ch:
+		if data.Error != "" {
+			return nil, errors.New(data.Error)
+		}
+		if int64(len(data.Data)) > c.maxSize {
+			return nil, fmt.Errorf("clipboard content exceeds %d bytes", c.maxSize)
+		}
+		return data.Data, nil
+	case <-time.After(requestTimeout):
+		return nil, fmt.Errorf("timed out waiting for clipboard contents")
+	}
+}
+
+// selection returns the clipboard or primary selection state. Must be called
+// with the lock held.
+func (c *Clipboard) selection(primary bool) *selection {
+	if primary {
+		return &c.primarySel
+	}
+	return &c.clipboard
+}
+
+func (c *Clipboard) handleSelection(offer *data_control.Offer) {
+	c.updateLocal(false, offer)
+}
+
+func (c *Clipboard) handlePrimarySelection(offer *data_control.Offer) {
+	c.updateLocal(true, offer)
+}
+
+// updateLocal follows selection changes made by local applications
+func (c *Clipboard) updateLocal(primary bool, offer *data_control.Offer) {
+	if offer != nil && offer.HasMimeType(ownerMimeType) {
+		// Our own adopted selection coming back from the compositor
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sel := c.selection(primary)
+	sel.serial++
+	sel.local = offer
+	sel.adopted = nil
+	if offer != nil {
+		logger.Debugf("[CLIPBOARD] Local selection %d (primary: %v): %v", sel.serial, primary, offer.MimeTypes)
+	}
+}
+
+// useSudoWaylandSession points WAYLAND_DISPLAY at the session of the user that
+// started waymon through sudo, where root's environment has no runtime directory
+func useSudoWaylandSession() {
+	sudoUID := os.Getenv("SUDO_UID")
+	if sudoUID == "" || os.Getenv("XDG_RUNTIME_DIR") != "" || filepath.IsAbs(os.Getenv("WAYLAND_DISPLAY")) {
+		return
+	}
+
+	runtimeDir := "/run/user/" + sudoUID
+	socket := os.Getenv("WAYLAND_DISPLAY")
+	if socket == "" {
+		entries, err := os.ReadDir(runtimeDir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "wayland-") && !strings.HasSuffix(entry.Name(), ".lock") {
+				socket = entry.Name()
+				break
+			}
+		}
+	}
+	if socket != "" {
+		_ = os.Setenv("WAYLAND_DISPLAY", filepath.Join(runtimeDir, socket))
+	}
+}
@@ -0,0 +1,106 @@
+package clipboard
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClipboard creates a clipboard whose clipboard, or primary selection
+// when primary is set, was adopted from a machine that answers requests with reply
+func newTestClipboard(primary bool, reply func(req *protocol.ClipboardRequest) *protocol.ClipboardData) *Clipboard {
+	c := &Clipboard{
+		maxSize:  8,
+		primary:  true,
+		requests: make(map[uint64]*pendingRequest),
+	}
+	sel := c.selection(primary)
+	sel.serial = 3
+	sel.adopted = &adoptedOffer{
+		offer:  &protocol.ClipboardOffer{Serial: 42, MimeTypes: []string{"text/plain"}, Primary: primary},
+		source: "laptop",
+		fetch: func(req *protocol.ClipboardRequest) error {
+			go c.HandleData("laptop", reply(req))
+			return nil
+		},
+	}
+	return c
+}
+
+func TestClipboardRelaysAdoptedSelection(t *testing.T) {
+	var asked *protocol.ClipboardRequest
+	c := newTestClipboard(false, func(req *protocol.ClipboardRequest) *protocol.ClipboardData {
+		asked = req
+		return &protocol.ClipboardData{RequestId: req.RequestId, Data: []byte("hello")}
+	})
+
+	offer := c.Offer(false)
+	if assert.NotNil(t, offer) {
+		assert.Equal(t, uint64(3), offer.Serial, "offers carry the local serial")
+		assert.Equal(t, []string{"text/plain"}, offer.MimeTypes)
+	}
+
+	reply := c.HandleRequest(&protocol.ClipboardRequest{RequestId: 7, Serial: 3, MimeType: "text/plain"})
+	assert.Equal(t, uint64(7), reply.RequestId)
+	assert.Empty(t, reply.Error)
+	assert.Equal(t, []byte("hello"), reply.Data)
+	if assert.NotNil(t, asked) {
+		assert.Equal(t, uint64(42), asked.Serial, "requests carry the origin serial")
+	}
+	assert.Empty(t, c.requests)
+}
+
+func TestClipboardRejectsStaleAndOversized(t *testing.T) {
+	c := newTestClipboard(false, func(req *protocol.ClipboardRequest) *protocol.ClipboardData {
+		return &protocol.ClipboardData{RequestId: req.RequestId, Data: []byte("far too large")}
+	})
+
+	reply := c.HandleRequest(&protocol.ClipboardRequest{RequestId: 1, Serial: 2, MimeType: "text/plain"})
+	assert.Equal(t, "clipboard changed", reply.Error)
+
+	reply = c.HandleRequest(&protocol.ClipboardRequest{RequestId: 2, Serial: 3, MimeType: "text/plain"})
+	assert.Contains(t, reply.Error, "exceeds 8 bytes")
+	assert.Nil(t, reply.Data)
+}
+
+func TestClipboardKeepsPrimarySelectionApart(t *testing.T) {
+	var asked *protocol.ClipboardRequest
+	c := newTestClipboard(true, func(req *protocol.ClipboardRequest) *protocol.ClipboardData {
+		asked = req
+		return &protocol.ClipboardData{RequestId: req.RequestId, Data: []byte("middle")}
+	})
+
+	assert.Nil(t, c.Offer(false), "the clipboard is still empty")
+	offer := c.Offer(true)
+	if assert.NotNil(t, offer) {
+		assert.True(t, offer.Primary)
+	}
+
+	reply := c.HandleRequest(&protocol.ClipboardRequest{RequestId: 1, Serial: 3, MimeType: "text/plain"})
+	assert.Equal(t, "clipboard changed", reply.Error)
+
+	reply = c.HandleRequest(&protocol.ClipboardRequest{RequestId: 2, Serial: 3, MimeType: "text/plain", Primary: true})
+	assert.Empty(t, reply.Error)
+	assert.Equal(t, []byte("middle"), reply.Data)
+	if assert.NotNil(t, asked) {
+		assert.True(t, asked.Primary, "requests ask the origin for its primary selection")
+	}
+
+	c.primary = false
+	assert.Nil(t, c.Offer(true), "the primary selection is not offered when not shared")
+}
+
+func TestClipboardDropsContentsFromOtherMachines(t *testing.T) {
+	var c *Clipboard
+	c = newTestClipboard(false, func(req *protocol.ClipboardRequest) *protocol.ClipboardData {
+		// Another client answers first, with contents of its own
+		c.HandleData("desktop", &protocol.ClipboardData{RequestId: req.RequestId, Data: []byte("evil")})
+		return &protocol.ClipboardData{RequestId: req.RequestId, Data: []byte("hello")}
+	})
+
+	reply := c.HandleRequest(&protocol.ClipboardRequest{RequestId: 1, Serial: 3, MimeType: "text/plain"})
+	assert.Empty(t, reply.Error)
+	assert.Equal(t, []byte("hello"), reply.Data)
+	assert.Empty(t, c.requests)
+}
@@ -32,12 +32,53 @@ type ServerConfig struct {
 	BindAddress string `mapstructure:"bind_address"`
 	Name        string `mapstructure:"name"`
 	MaxClients  int    `mapstructure:"max_clients"`
+	Advertise   bool   `mapstructure:"advertise"` // Announce the server on the LAN over mDNS
 
 	// SSH configuration
 	SSHHostKeyPath   string   `mapstructure:"ssh_host_key_path"`
 	SSHAuthKeysPath  string   `mapstructure:"ssh_authorized_keys_path"`
 	SSHWhitelist     []string `mapstructure:"ssh_whitelist"`      // List of allowed SSH key fingerprints
 	SSHWhitelistOnly bool     `mapstructure:"ssh_whitelist_only"` // Only allow whitelisted keys
+	ApprovalTimeout  int      `mapstructure:"approval_timeout"`   // Seconds an unknown key waits for approval
+
+	// SSH user certificates
+	SSHTrustedCAKeysPath string   `mapstructure:"ssh_trusted_ca_keys_path"` // CA keys whose certificates are accepted, empty disables
+	SSHCertPrincipals    []string `mapstructure:"ssh_cert_principals"`      // Principals accepted, empty = the SSH user name
+
+	// Screen layout
+	EdgeSwitching  bool   `mapstructure:"edge_switching"`  // Switch to a client when the cursor crosses a mapped edge
+	SwitchPosition string `mapstructure:"switch_position"` // Cursor placement on hotkey switches: "center" or "last"
+
+	// Keyboard layout forwarded to clients
+	ForwardKeymap bool   `mapstructure:"forward_keymap"` // Send the server's keymap to clients
+	XKBRules      string `mapstructure:"xkb_rules"`      // Empty layout = read the keymap from the compositor
+	XKBModel      string `mapstructure:"xkb_model"`
+	XKBLayout     string `mapstructure:"xkb_layout"`
+	XKBVariant    string `mapstructure:"xkb_variant"`
+	XKBOptions    string `mapstructure:"xkb_options"`
+
+	// Clipboard shared with clients
+	ClipboardSync        bool `mapstructure:"clipboard_sync"`         // Share the clipboard when control moves
+	PrimarySelectionSync bool `mapstructure:"primary_selection_sync"` // Share the middle-click selection too
+	ClipboardMaxSize     int  `mapstructure:"clipboard_max_size"`     // Largest clipboard content transferred, in bytes
+
+	// Hotkeys matched on the captured input
+	Bindings []Binding `mapstructure:"bindings"`
+
+	// How captured mouse motion is batched before being sent
+	MotionCoalescing string `mapstructure:"motion_coalescing"` // "frame" sends every hardware frame, "interval" merges motion
+	CoalesceInterval int    `mapstructure:"coalesce_interval"` // Milliseconds motion is merged over with "interval"
+
+	// Touchpads used to control clients
+	TouchpadSpeed float64 `mapstructure:"touchpad_speed"` // Multiplier of pointer motion and scrolling
+	TapToClick    bool    `mapstructure:"tap_to_click"`   // Tapping with one, two or three fingers clicks
+
+	// Name, slot and cursor of each client, kept across reconnects
+	ClientStatePath string `mapstructure:"client_state_path"` // Empty = clients.json next to the config file
+
+	// Heartbeat used to detect clients that stopped answering
+	HeartbeatInterval int `mapstructure:"heartbeat_interval"` // Milliseconds between pings, 0 disables
+	HeartbeatMisses   int `mapstructure:"heartbeat_misses"`   // Unanswered pings before control returns to the server
 }
 
 // ClientConfig contains client-specific settings
@@ -51,8 +92,28 @@ type ClientConfig struct {
 	HotkeyModifier string        `mapstructure:"hotkey_modifier"`
 	HotkeyKey      string        `mapstructure:"hotkey_key"`
 
+	// Clipboard shared with the server
+	ClipboardSync        bool `mapstructure:"clipboard_sync"`
+	PrimarySelectionSync bool `mapstructure:"primary_selection_sync"`
+	ClipboardMaxSize     int  `mapstructure:"clipboard_max_size"` // In bytes
+
+	// Missed server pings before a controlled client releases itself
+	HeartbeatMisses int `mapstructure:"heartbeat_misses"`
+
+	// Scrolling injected on this machine
+	ScrollSpeed   float64 `mapstructure:"scroll_speed"`   // Multiplier of the scroll distance
+	NaturalScroll bool    `mapstructure:"natural_scroll"` // Content follows the wheel, like touchpads
+
+	// Key chords pressed for touchpad gestures, which cannot be injected as such
+	GestureBindings []GestureBinding `mapstructure:"gesture_bindings"`
+
+	// Monitor the area of a graphics tablet is mapped onto, empty for the primary one
+	TabletMonitor string `mapstructure:"tablet_monitor"`
+
 	// SSH configuration
-	SSHPrivateKey string `mapstructure:"ssh_private_key"`
+	SSHPrivateKey    string `mapstructure:"ssh_private_key"`
+	KnownHostsPath   string `mapstructure:"known_hosts_path"`   // Empty = ~/.config/waymon/known_hosts
+	SystemKnownHosts bool   `mapstructure:"system_known_hosts"` // Also trust server keys in ~/.ssh/known_hosts
 }
 
 
@@ -74,9 +135,29 @@ type DeviceInfo struct {
 
 // HostConfig represents a known host for quick connections
 type HostConfig struct {
-	Name     string `mapstructure:"name"`
-	Address  string `mapstructure:"address"`
-	Position string `mapstructure:"position"` // left, right, top, bottom
+	Name               string `mapstructure:"name"`
+	Address            string `mapstructure:"address"`
+	Position           string `mapstructure:"position"`        // left, right, top, bottom
+	SwitchPosition     string `mapstructure:"switch_position"` // Overrides server.switch_position for this host
+	XKBLayout          string `mapstructure:"xkb_layout"`      // Keymap layout for this host instead of the server's
+	XKBVariant         string `mapstructure:"xkb_variant"`
+	XKBOptions         string `mapstructure:"xkb_options"`
+	NoClipboard        bool   `mapstructure:"no_clipboard"`         // Do not share the clipboard with this host
+	NoPrimarySelection bool   `mapstructure:"no_primary_selection"` // Do not share the primary selection with this host
+}
+
+// Binding maps a key chord or mouse button on the server to an action
+type Binding struct {
+	Keys   string `mapstructure:"keys"`   // Chord such as "ctrl+alt+right" or "super+btn_side"
+	Action string `mapstructure:"action"` // "switch", "next", "previous", "local" or "lock"
+	Client string `mapstructure:"client"` // For "switch": client name, address or [[hosts]] name
+	Index  int    `mapstructure:"index"`  // For "switch": position in the client list, from 1
+}
+
+// GestureBinding maps a touchpad gesture of the server to a key chord on the client
+type GestureBinding struct {
+	Gesture string `mapstructure:"gesture"` // Such as "swipe_left_3" or "pinch_in_4"
+	Keys    string `mapstructure:"keys"`    // Chord such as "super+right"
 }
 
 // EdgeMapping defines which monitor edge connects to which host
@@ -95,10 +176,29 @@ var (
 			BindAddress:      "0.0.0.0",
 			Name:             getHostname(),
 			MaxClients:       1,
+			Advertise:        true,
 			SSHHostKeyPath:   "/etc/waymon/host_key",
 			SSHAuthKeysPath:  "/etc/waymon/authorized_keys",
 			SSHWhitelist:     []string{},
 			SSHWhitelistOnly: true,
+			ApprovalTimeout:  120,
+			EdgeSwitching:    true,
+			SwitchPosition:   "center",
+			ForwardKeymap:    true,
+			ClipboardSync:    true,
+			ClipboardMaxSize: 4 << 20,
+
+			SSHCertPrincipals:    []string{},
+			PrimarySelectionSync: true,
+
+			HeartbeatInterval: 1000,
+			HeartbeatMisses:   3,
+
+			MotionCoalescing: "frame",
+			CoalesceInterval: 8,
+
+			TouchpadSpeed: 1.0,
+			TapToClick:    true,
 		},
 		Client: ClientConfig{
 			ServerAddress:  "",
@@ -109,6 +209,14 @@ var (
 			HotkeyModifier: "ctrl+alt",
 			HotkeyKey:      "s",
 			SSHPrivateKey:  "",
+
+			ClipboardSync:        true,
+			PrimarySelectionSync: true,
+			ClipboardMaxSize:     4 << 20,
+
+			HeartbeatMisses: 3,
+
+			ScrollSpeed: 1.0,
 		},
 		Logging: LoggingConfig{
 			FileLogging: true,  // Enable file logging by default
@@ -159,10 +267,33 @@ func Init() error {
 	viper.SetDefault("server.bind_address", DefaultConfig.Server.BindAddress)
 	viper.SetDefault("server.name", DefaultConfig.Server.Name)
 	viper.SetDefault("server.max_clients", DefaultConfig.Server.MaxClients)
+	viper.SetDefault("server.advertise", DefaultConfig.Server.Advertise)
 	viper.SetDefault("server.ssh_host_key_path", DefaultConfig.Server.SSHHostKeyPath)
 	viper.SetDefault("server.ssh_authorized_keys_path", DefaultConfig.Server.SSHAuthKeysPath)
 	viper.SetDefault("server.ssh_whitelist", DefaultConfig.Server.SSHWhitelist)
 	viper.SetDefault("server.ssh_whitelist_only", DefaultConfig.Server.SSHWhitelistOnly)
+	viper.SetDefault("server.approval_timeout", DefaultConfig.Server.ApprovalTimeout)
+	viper.SetDefault("server.client_state_path", DefaultConfig.Server.ClientStatePath)
+	viper.SetDefault("server.ssh_trusted_ca_keys_path", DefaultConfig.Server.SSHTrustedCAKeysPath)
+	viper.SetDefault("server.ssh_cert_principals", DefaultConfig.Server.SSHCertPrincipals)
+	viper.SetDefault("server.edge_switching", DefaultConfig.Server.EdgeSwitching)
+	viper.SetDefault("server.switch_position", DefaultConfig.Server.SwitchPosition)
+	viper.SetDefault("server.forward_keymap", DefaultConfig.Server.ForwardKeymap)
+	viper.SetDefault("server.xkb_rules", DefaultConfig.Server.XKBRules)
+	viper.SetDefault("server.xkb_model", DefaultConfig.Server.XKBModel)
+	viper.SetDefault("server.xkb_layout", DefaultConfig.Server.XKBLayout)
+	viper.SetDefault("server.xkb_variant", DefaultConfig.Server.XKBVariant)
+	viper.SetDefault("server.xkb_options", DefaultConfig.Server.XKBOptions)
+	viper.SetDefault("server.clipboard_sync", DefaultConfig.Server.ClipboardSync)
+	viper.SetDefault("server.primary_selection_sync", DefaultConfig.Server.PrimarySelectionSync)
+	viper.SetDefault("server.clipboard_max_size", DefaultConfig.Server.ClipboardMaxSize)
+	viper.SetDefault("server.bindings", DefaultConfig.Server.Bindings)
+	viper.SetDefault("server.motion_coalescing", DefaultConfig.Server.MotionCoalescing)
+	viper.SetDefault("server.coalesce_interval", DefaultConfig.Server.CoalesceInterval)
+	viper.SetDefault("server.touchpad_speed", DefaultConfig.Server.TouchpadSpeed)
+	viper.SetDefault("server.tap_to_click", DefaultConfig.Server.TapToClick)
+	viper.SetDefault("server.heartbeat_interval", DefaultConfig.Server.HeartbeatInterval)
+	viper.SetDefault("server.heartbeat_misses", DefaultConfig.Server.HeartbeatMisses)
 
 	viper.SetDefault("client.server_address", DefaultConfig.Client.ServerAddress)
 	viper.SetDefault("client.auto_connect", DefaultConfig.Client.AutoConnect)
@@ -172,7 +303,17 @@ func Init() error {
 	viper.SetDefault("client.edge_mappings", DefaultConfig.Client.EdgeMappings)
 	viper.SetDefault("client.hotkey_modifier", DefaultConfig.Client.HotkeyModifier)
 	viper.SetDefault("client.hotkey_key", DefaultConfig.Client.HotkeyKey)
+	viper.SetDefault("client.clipboard_sync", DefaultConfig.Client.ClipboardSync)
+	viper.SetDefault("client.primary_selection_sync", DefaultConfig.Client.PrimarySelectionSync)
+	viper.SetDefault("client.clipboard_max_size", DefaultConfig.Client.ClipboardMaxSize)
+	viper.SetDefault("client.heartbeat_misses", DefaultConfig.Client.HeartbeatMisses)
+	viper.SetDefault("client.scroll_speed", DefaultConfig.Client.ScrollSpeed)
+	viper.SetDefault("client.natural_scroll", DefaultConfig.Client.NaturalScroll)
+	viper.SetDefault("client.gesture_bindings", DefaultConfig.Client.GestureBindings)
+	viper.SetDefault("client.tablet_monitor", DefaultConfig.Client.TabletMonitor)
 	viper.SetDefault("client.ssh_private_key", DefaultConfig.Client.SSHPrivateKey)
+	viper.SetDefault("client.known_hosts_path", DefaultConfig.Client.KnownHostsPath)
+	viper.SetDefault("client.system_known_hosts", DefaultConfig.Client.SystemKnownHosts)
 
 
 	viper.SetDefault("logging.file_logging", DefaultConfig.Logging.FileLogging)
@@ -259,6 +400,27 @@ func GetConfigPath() string {
 	return filepath.Join(home, ".config", "waymon", "waymon.toml")
 }
 
+// GetKnownHostsPath returns the file the client records trusted server keys in
+func GetKnownHostsPath() string {
+	if path := Get().Client.KnownHostsPath; path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "/etc/waymon/known_hosts"
+	}
+	return filepath.Join(home, ".config", "waymon", "known_hosts")
+}
+
+// GetClientStatePath returns the file the server remembers its clients in
+func GetClientStatePath() string {
+	if path := Get().Server.ClientStatePath; path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(GetConfigPath()), "clients.json")
+}
+
 // AddHost adds a new host to the configuration
 func AddHost(host HostConfig) error {
 	cfg := Get()
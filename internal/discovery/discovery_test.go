@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// TestMessageRoundTrip tests encoding and decoding every supported record type
+func TestMessageRoundTrip(t *testing.T) {
+	m := &message{
+		id:        7,
+		response:  true,
+		questions: []question{{name: serviceName, qtype: typePTR, unicast: true}},
+		answers: []record{
+			{name: serviceName, rtype: typePTR, ttl: 120, target: "desk." + serviceName},
+			{name: "desk." + serviceName, rtype: typeSRV, cacheFlush: true, ttl: 120, port: 52525, target: "desk.local."},
+			{name: "desk." + serviceName, rtype: typeTXT, ttl: 120, txt: []string{"name=desk", "fp=SHA256:abc"}},
+		},
+		extra: []record{
+			{name: "desk.local.", rtype: typeA, ttl: 120, ip: net.IPv4(192, 168, 1, 10).To4()},
+			{name: "desk.local.", rtype: typeAAAA, ttl: 120, ip: net.ParseIP("fe80::1")},
+		},
+	}
+
+	packet, err := m.pack()
+	if err != nil {
+		t.Fatalf("pack() failed: %v", err)
+	}
+	got, err := unpackMessage(packet)
+	if err != nil {
+		t.Fatalf("unpackMessage() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, m)
+	}
+
+	// Truncated messages are refused, not read past their end
+	for i := 0; i < len(packet); i++ {
+		if _, err := unpackMessage(packet[:i]); err == nil {
+			t.Fatalf("Message truncated to %d bytes accepted", i)
+		}
+	}
+}
+
+// TestReadCompressedName tests following compression pointers
+func TestReadCompressedName(t *testing.T) {
+	// "local." at 0, "desk.local." at 7 pointing back to it, and a pointer loop at 14
+	data := []byte{5, 'l', 'o', 'c', 'a', 'l', 0, 4, 'd', 'e', 's', 'k', 0xC0, 0, 0xC0, 14}
+
+	name, next, err := readName(data, 7)
+	if err != nil || name != "desk.local." || next != 14 {
+		t.Errorf("readName() = %q, %d, %v, want desk.local., 14", name, next, err)
+	}
+	if _, _, err := readName(data, 14); err == nil {
+		t.Errorf("Pointer loop not detected")
+	}
+}
+
+// TestAdvertiserAnswer tests that a browse query finds the advertised service
+func TestAdvertiserAnswer(t *testing.T) {
+	a := NewAdvertiser(Service{
+		Name:            "Living room",
+		Host:            "htpc.local.",
+		Port:            52525,
+		ProtocolVersion: 1,
+		Fingerprint:     "SHA256:abc",
+	})
+	a.addrs = func() []net.IP { return []net.IP{net.IPv4(192, 168, 1, 10).To4()} }
+
+	if resp := a.answer(&message{questions: []question{{name: "_ssh._tcp.local.", qtype: typePTR}}}); resp != nil {
+		t.Errorf("Answered a query for another service: %+v", resp)
+	}
+
+	resp := a.answer(&message{questions: []question{{name: "_WAYMON._tcp.local.", qtype: typePTR}}})
+	if resp == nil {
+		t.Fatalf("Browse query not answered")
+	}
+	c := newCollector()
+	c.add(resp)
+	services := c.services()
+	if len(services) != 1 {
+		t.Fatalf("Found %d services, want 1", len(services))
+	}
+
+	want := Service{
+		Instance:        "Living room",
+		Name:            "Living room",
+		Host:            "htpc.local.",
+		Port:            52525,
+		Addrs:           []net.IP{net.IPv4(192, 168, 1, 10).To4()},
+		ProtocolVersion: 1,
+		Fingerprint:     "SHA256:abc",
+	}
+	if !reflect.DeepEqual(services[0], want) {
+		t.Errorf("Service = %+v, want %+v", services[0], want)
+	}
+	if addr := services[0].Address(); addr != "192.168.1.10:52525" {
+		t.Errorf("Address() = %q", addr)
+	}
+
+	// A goodbye withdraws the service
+	c.add(a.records(0))
+	if services := c.services(); len(services) != 0 {
+		t.Errorf("Service still listed after goodbye: %+v", services)
+	}
+}
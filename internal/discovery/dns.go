@@ -0,0 +1,326 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Just enough of the DNS wire format (RFC 1035) for multicast DNS service
+// discovery: PTR, SRV, TXT, A and AAAA records. Names are written without
+// compression but compressed names are read.
+
+// DNS record types
+const (
+	typeA    uint16 = 1
+	typePTR  uint16 = 12
+	typeTXT  uint16 = 16
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+	typeANY  uint16 = 255
+)
+
+const (
+	classIN       uint16 = 1
+	classTopBit   uint16 = 1 << 15 // Unicast response in questions, cache flush in records
+	flagResponse  uint16 = 1 << 15
+	flagAuthority uint16 = 1 << 10
+	headerSize           = 12
+	maxPointers          = 16 // Compression pointers followed in one name
+)
+
+var errTruncated = errors.New("truncated DNS message")
+
+// message is a DNS message
+type message struct {
+	id        uint16
+	response  bool
+	questions []question
+	answers   []record
+	extra     []record // Additional section
+}
+
+// question is an entry of the question section
+type question struct {
+	name    string
+	qtype   uint16
+	unicast bool // The querier asks for a unicast response
+}
+
+// record is a resource record of one of the supported types
+type record struct {
+	name       string
+	rtype      uint16
+	cacheFlush bool
+	ttl        uint32
+
+	target string   // PTR and SRV
+	port   uint16   // SRV
+	txt    []string // TXT
+	ip     net.IP   // A and AAAA
+}
+
+// pack encodes the message
+func (m *message) pack() ([]byte, error) {
+	buf := make([]byte, headerSize, 512)
+	binary.BigEndian.PutUint16(buf[0:], m.id)
+	if m.response {
+		binary.BigEndian.PutUint16(buf[2:], flagResponse|flagAuthority)
+	}
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(m.questions))) //nolint:gosec // a few entries
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(m.answers)))   //nolint:gosec // a few entries
+	binary.BigEndian.PutUint16(buf[10:], uint16(len(m.extra)))    //nolint:gosec // a few entries
+
+	var err error
+	for _, q := range m.questions {
+		if buf, err = appendName(buf, q.name); err != nil {
+			return nil, err
+		}
+		class := classIN
+		if q.unicast {
+			class |= classTopBit
+		}
+		buf = binary.BigEndian.AppendUint16(buf, q.qtype)
+		buf = binary.BigEndian.AppendUint16(buf, class)
+	}
+	for _, section := range [][]record{m.answers, m.extra} {
+		for _, r := range section {
+			if buf, err = r.appendTo(buf); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf, nil
+}
+
+// appendTo encodes the record at the end of buf
+func (r *record) appendTo(buf []byte) ([]byte, error) {
+	buf, err := appendName(buf, r.name)
+	if err != nil {
+		return nil, err
+	}
+	class := classIN
+	if r.cacheFlush {
+		class |= classTopBit
+	}
+	buf = binary.BigEndian.AppendUint16(buf, r.rtype)
+	buf = binary.BigEndian.AppendUint16(buf, class)
+	buf = binary.BigEndian.AppendUint32(buf, r.ttl)
+
+	// Data length, filled in below
+	lengthAt := len(buf)
+	buf = append(buf, 0, 0)
+
+	switch r.rtype {
+	case typePTR:
+		buf, err = appendName(buf, r.target)
+	case typeSRV:
+		buf = append(buf, 0, 0, 0, 0) // Priority and weight
+		buf = binary.BigEndian.AppendUint16(buf, r.port)
+		buf, err = appendName(buf, r.target)
+	case typeTXT:
+		if len(r.txt) == 0 {
+			buf = append(buf, 0) // A TXT record holds at least one string
+		}
+		for _, s := range r.txt {
+			if len(s) > 255 {
+				return nil, fmt.Errorf("TXT string too long: %q", s)
+			}
+			buf = append(buf, byte(len(s)))
+			buf = append(buf, s...)
+		}
+	case typeA:
+		ip := r.ip.To4()
+		if ip == nil {
+			return nil, fmt.Errorf("not an IPv4 address: %s", r.ip)
+		}
+		buf = append(buf, ip...)
+	case typeAAAA:
+		buf = append(buf, r.ip.To16()...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %d", r.rtype)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	binary.BigEndian.PutUint16(buf[lengthAt:], uint16(len(buf)-lengthAt-2)) //nolint:gosec // bounded by the label limits
+	return buf, nil
+}
+
+// appendName encodes a dot separated name at the end of buf
+func appendName(buf []byte, name string) ([]byte, error) {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			return nil, fmt.Errorf("empty label in %q", name)
+		}
+		if len(label) > 63 {
+			return nil, fmt.Errorf("label too long in %q", name)
+		}
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	return append(buf, 0), nil
+}
+
+// unpackMessage decodes a message, skipping records of unsupported types
+func unpackMessage(data []byte) (*message, error) {
+	if len(data) < headerSize {
+		return nil, errTruncated
+	}
+	m := &message{
+		id:       binary.BigEndian.Uint16(data[0:]),
+		response: binary.BigEndian.Uint16(data[2:])&flagResponse != 0,
+	}
+	counts := [4]int{}
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint16(data[4+2*i:]))
+	}
+
+	off := headerSize
+	for range counts[0] {
+		name, next, err := readName(data, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(data) {
+			return nil, errTruncated
+		}
+		class := binary.BigEndian.Uint16(data[next+2:])
+		m.questions = append(m.questions, question{
+			name:    name,
+			qtype:   binary.BigEndian.Uint16(data[next:]),
+			unicast: class&classTopBit != 0,
+		})
+		off = next + 4
+	}
+
+	// Authority records are read and dropped
+	for section := 1; section < 4; section++ {
+		for range counts[section] {
+			r, next, err := readRecord(data, off)
+			if err != nil {
+				return nil, err
+			}
+			off = next
+			if r == nil {
+				continue
+			}
+			switch section {
+			case 1:
+				m.answers = append(m.answers, *r)
+			case 3:
+				m.extra = append(m.extra, *r)
+			}
+		}
+	}
+	return m, nil
+}
+
+// readRecord decodes the record at off. Records of unsupported types are
+// skipped and returned as nil.
+func readRecord(data []byte, off int) (*record, int, error) {
+	name, off, err := readName(data, off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if off+10 > len(data) {
+		return nil, 0, errTruncated
+	}
+	r := &record{
+		name:       name,
+		rtype:      binary.BigEndian.Uint16(data[off:]),
+		cacheFlush: binary.BigEndian.Uint16(data[off+2:])&classTopBit != 0,
+		ttl:        binary.BigEndian.Uint32(data[off+4:]),
+	}
+	length := int(binary.BigEndian.Uint16(data[off+8:]))
+	start := off + 10
+	end := start + length
+	if end > len(data) {
+		return nil, 0, errTruncated
+	}
+	rdata := data[start:end]
+
+	switch r.rtype {
+	case typePTR:
+		if r.target, _, err = readName(data, start); err != nil {
+			return nil, 0, err
+		}
+	case typeSRV:
+		if length < 7 {
+			return nil, 0, errTruncated
+		}
+		r.port = binary.BigEndian.Uint16(rdata[4:])
+		if r.target, _, err = readName(data, start+6); err != nil {
+			return nil, 0, err
+		}
+	case typeTXT:
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return nil, 0, errTruncated
+			}
+			if n > 0 {
+				r.txt = append(r.txt, string(rdata[i+1:i+1+n]))
+			}
+			i += 1 + n
+		}
+	case typeA:
+		if length != net.IPv4len {
+			return nil, 0, fmt.Errorf("invalid A record length %d", length)
+		}
+		r.ip = net.IP(append([]byte(nil), rdata...))
+	case typeAAAA:
+		if length != net.IPv6len {
+			return nil, 0, fmt.Errorf("invalid AAAA record length %d", length)
+		}
+		r.ip = net.IP(append([]byte(nil), rdata...))
+	default:
+		return nil, end, nil
+	}
+	return r, end, nil
+}
+
+// readName decodes the possibly compressed name at off and returns it with
+// the offset following it
+func readName(data []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1 // Offset after the name, set at the first pointer
+	for pointers := 0; ; {
+		if off >= len(data) {
+			return "", 0, errTruncated
+		}
+		length := int(data[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(data) {
+				return "", 0, errTruncated
+			}
+			if pointers++; pointers > maxPointers {
+				return "", 0, fmt.Errorf("too many compression pointers")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(data[off:]) & 0x3FFF)
+
+		case length > 63:
+			return "", 0, fmt.Errorf("invalid label length %d", length)
+
+		default:
+			if off+1+length > len(data) {
+				return "", 0, errTruncated
+			}
+			labels = append(labels, string(data[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
@@ -0,0 +1,519 @@
+// Package discovery announces waymon servers on the local network and finds
+// them, using multicast DNS service discovery (RFC 6762 and RFC 6763)
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+)
+
+// ServiceType is the DNS-SD service type waymon servers are announced as
+const ServiceType = "_waymon._tcp"
+
+// DefaultBrowseTimeout is how long to wait for servers to answer a browse
+const DefaultBrowseTimeout = 3 * time.Second
+
+const (
+	serviceName         = ServiceType + ".local."
+	servicesEnumeration = "_services._dns-sd._udp.local."
+	recordTTL           = 120 // Seconds
+	legacyUnicastTTL    = 10  // Cap for answers to one-shot queries, RFC 6762 section 6.7
+	mdnsPort            = 5353
+	maxPacketSize       = 9000
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+
+// Service is a waymon server announced on the local network
+type Service struct {
+	Instance        string // DNS-SD instance name, e.g. "desk"
+	Name            string // Server name
+	Host            string // mDNS host name, e.g. "desk.local."
+	Port            int
+	Addrs           []net.IP
+	ProtocolVersion int
+	Fingerprint     string // SHA256 fingerprint of the SSH host key
+}
+
+// Address returns the host:port to connect to, preferring an address on a
+// network this machine is attached to
+func (s Service) Address() string {
+	port := strconv.Itoa(s.Port)
+	if len(s.Addrs) == 0 {
+		return net.JoinHostPort(strings.TrimSuffix(s.Host, "."), port)
+	}
+
+	networks := localNetworks()
+	for _, ip := range s.Addrs {
+		for _, n := range networks {
+			if n.Contains(ip) {
+				return net.JoinHostPort(ip.String(), port)
+			}
+		}
+	}
+	return net.JoinHostPort(s.Addrs[0].String(), port)
+}
+
+// Advertiser answers mDNS queries for a waymon server
+type Advertiser struct {
+	service Service
+	addrs   func() []net.IP // Addresses announced for the host
+
+	conn     *net.UDPConn
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewAdvertiser creates an advertiser for service. The instance and host
+// names default to the server name and the machine host name.
+func NewAdvertiser(service Service) *Advertiser {
+	if service.Instance == "" {
+		service.Instance = service.Name
+	}
+	service.Instance = label(service.Instance)
+	if service.Host == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = service.Instance
+		}
+		service.Host = label(strings.SplitN(hostname, ".", 2)[0]) + ".local."
+	}
+
+	return &Advertiser{
+		service: service,
+		addrs:   localIPv4s,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start joins the mDNS group, announces the service and answers queries
+// until ctx is done or Stop is called
+func (a *Advertiser) Start(ctx context.Context) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+	a.conn = conn
+
+	a.wg.Add(2)
+	go a.serve()
+	go a.announce()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.Stop()
+		case <-a.stop:
+		}
+	}()
+
+	logger.Infof("[DISCOVERY] Announcing %s.%s on port %d", a.service.Instance, serviceName, a.service.Port)
+	return nil
+}
+
+// Stop withdraws the announcement and stops answering queries
+func (a *Advertiser) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stop)
+		if a.conn == nil {
+			return
+		}
+
+		// Tell caches the service is gone
+		if err := a.send(a.records(0), mdnsGroup); err != nil {
+			logger.Debugf("[DISCOVERY] Failed to send goodbye: %v", err)
+		}
+		_ = a.conn.Close()
+		a.wg.Wait()
+	})
+}
+
+// announce sends the records unsolicited twice, one second apart, as
+// RFC 6762 section 8.3 asks
+func (a *Advertiser) announce() {
+	defer a.wg.Done()
+
+	for i := 0; i < 2; i++ {
+		if err := a.send(a.records(recordTTL), mdnsGroup); err != nil {
+			logger.Warnf("[DISCOVERY] Failed to announce service: %v", err)
+		}
+		select {
+		case <-a.stop:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// serve answers queries until the connection is closed
+func (a *Advertiser) serve() {
+	defer a.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, src, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.stop:
+			default:
+				logger.Errorf("[DISCOVERY] Failed to read mDNS packet: %v", err)
+			}
+			return
+		}
+
+		query, err := unpackMessage(buf[:n])
+		if err != nil || query.response {
+			continue
+		}
+		resp := a.answer(query)
+		if resp == nil {
+			continue
+		}
+
+		dst := mdnsGroup
+		switch {
+		case src.Port != mdnsPort:
+			// One-shot querier: reply to it alone, echoing the query
+			resp.id = query.id
+			resp.questions = query.questions
+			for _, section := range [][]record{resp.answers, resp.extra} {
+				for i := range section {
+					section[i].cacheFlush = false
+					section[i].ttl = min(section[i].ttl, legacyUnicastTTL)
+				}
+			}
+			dst = src
+		case unicastRequested(query):
+			dst = src
+		}
+		if err := a.send(resp, dst); err != nil {
+			logger.Debugf("[DISCOVERY] Failed to answer %s: %v", src, err)
+		}
+	}
+}
+
+// answer returns the response to a query, or nil if it is not about the
+// service
+func (a *Advertiser) answer(query *message) *message {
+	resp := &message{response: true}
+	inAnswers := make(map[uint16]bool)
+	add := func(r ...record) {
+		for _, rec := range r {
+			if rec.name == a.instanceName() || rec.name == a.service.Host {
+				inAnswers[rec.rtype] = true
+			}
+		}
+		resp.answers = append(resp.answers, r...)
+	}
+
+	for _, q := range query.questions {
+		matches := func(t uint16) bool { return q.qtype == t || q.qtype == typeANY }
+		switch {
+		case strings.EqualFold(q.name, serviceName) && matches(typePTR):
+			add(a.ptrRecord(recordTTL))
+		case strings.EqualFold(q.name, servicesEnumeration) && matches(typePTR):
+			add(record{name: servicesEnumeration, rtype: typePTR, ttl: recordTTL, target: serviceName})
+		case strings.EqualFold(q.name, a.instanceName()):
+			if matches(typeSRV) {
+				add(a.srvRecord(recordTTL))
+			}
+			if matches(typeTXT) {
+				add(a.txtRecord(recordTTL))
+			}
+		case strings.EqualFold(q.name, a.service.Host) && matches(typeA):
+			add(a.addrRecords(recordTTL)...)
+		}
+	}
+	if len(resp.answers) == 0 {
+		return nil
+	}
+
+	// Save the querier a round trip when it will need the rest
+	for _, r := range resp.answers {
+		if r.rtype != typePTR || r.target != a.instanceName() {
+			continue
+		}
+		if !inAnswers[typeSRV] {
+			resp.extra = append(resp.extra, a.srvRecord(recordTTL))
+		}
+		if !inAnswers[typeTXT] {
+			resp.extra = append(resp.extra, a.txtRecord(recordTTL))
+		}
+	}
+	if (inAnswers[typeSRV] || len(resp.extra) > 0) && !inAnswers[typeA] {
+		resp.extra = append(resp.extra, a.addrRecords(recordTTL)...)
+	}
+	return resp
+}
+
+// records returns every record of the service
+func (a *Advertiser) records(ttl uint32) *message {
+	resp := &message{response: true}
+	resp.answers = append(resp.answers, a.ptrRecord(ttl), a.srvRecord(ttl), a.txtRecord(ttl))
+	resp.answers = append(resp.answers, a.addrRecords(ttl)...)
+	return resp
+}
+
+func (a *Advertiser) instanceName() string {
+	return a.service.Instance + "." + serviceName
+}
+
+func (a *Advertiser) ptrRecord(ttl uint32) record {
+	return record{name: serviceName, rtype: typePTR, ttl: ttl, target: a.instanceName()}
+}
+
+func (a *Advertiser) srvRecord(ttl uint32) record {
+	return record{
+		name:       a.instanceName(),
+		rtype:      typeSRV,
+		cacheFlush: true,
+		ttl:        ttl,
+		port:       uint16(a.service.Port), //nolint:gosec // ports fit
+		target:     a.service.Host,
+	}
+}
+
+func (a *Advertiser) txtRecord(ttl uint32) record {
+	return record{
+		name:       a.instanceName(),
+		rtype:      typeTXT,
+		cacheFlush: true,
+		ttl:        ttl,
+		txt: []string{
+			"txtvers=1",
+			"name=" + a.service.Name,
+			"proto=" + strconv.Itoa(a.service.ProtocolVersion),
+			"fp=" + a.service.Fingerprint,
+		},
+	}
+}
+
+func (a *Advertiser) addrRecords(ttl uint32) []record {
+	var records []record
+	for _, ip := range a.addrs() {
+		records = append(records, record{name: a.service.Host, rtype: typeA, cacheFlush: true, ttl: ttl, ip: ip})
+	}
+	return records
+}
+
+// send writes a message to dst
+func (a *Advertiser) send(m *message, dst *net.UDPAddr) error {
+	packet, err := m.pack()
+	if err != nil {
+		return err
+	}
+	_, err = a.conn.WriteToUDP(packet, dst)
+	return err
+}
+
+// Browse queries the local network for waymon servers until timeout and
+// returns those that answered, sorted by name
+func Browse(ctx context.Context, timeout time.Duration) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	query := &message{questions: []question{{name: serviceName, qtype: typePTR}}}
+	packet, err := query.pack()
+	if err != nil {
+		return nil, err
+	}
+
+	results := newCollector()
+	buf := make([]byte, maxPacketSize)
+	deadline := time.Now().Add(timeout)
+
+	// Queries are repeated every second as datagrams can be lost
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		if _, err := conn.WriteToUDP(packet, mdnsGroup); err != nil {
+			return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+		}
+
+		round := time.Now().Add(time.Second)
+		if round.After(deadline) {
+			round = deadline
+		}
+		if err := conn.SetReadDeadline(round); err != nil {
+			return nil, err
+		}
+		for {
+			n, _, err := conn.ReadFromUDP(buf)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read mDNS response: %w", err)
+			}
+			if resp, err := unpackMessage(buf[:n]); err == nil && resp.response {
+				results.add(resp)
+			}
+		}
+	}
+	return results.services(), nil
+}
+
+// collector assembles services from the records of mDNS responses
+type collector struct {
+	instances map[string]*Service // Instance name -> service
+	hosts     map[string][]net.IP
+}
+
+func newCollector() *collector {
+	return &collector{
+		instances: make(map[string]*Service),
+		hosts:     make(map[string][]net.IP),
+	}
+}
+
+// add records the answers of a response. Instances are learned from PTR
+// records, which are read first, and completed by the other records.
+func (c *collector) add(resp *message) {
+	records := append(append([]record(nil), resp.answers...), resp.extra...)
+	for _, r := range records {
+		if r.rtype != typePTR || !strings.EqualFold(r.name, serviceName) {
+			continue
+		}
+		instance := strings.ToLower(r.target)
+		if r.ttl == 0 {
+			delete(c.instances, instance) // Goodbye
+			continue
+		}
+		if _, ok := c.instances[instance]; !ok {
+			c.instances[instance] = &Service{Instance: strings.TrimSuffix(r.target, "."+serviceName)}
+		}
+	}
+
+	for _, r := range records {
+		name := strings.ToLower(r.name)
+		service := c.instances[name]
+		switch {
+		case r.ttl == 0:
+		case r.rtype == typeSRV && service != nil:
+			service.Host = strings.ToLower(r.target)
+			service.Port = int(r.port)
+		case r.rtype == typeTXT && service != nil:
+			service.parseTXT(r.txt)
+		case r.rtype == typeA || r.rtype == typeAAAA:
+			if !containsIP(c.hosts[name], r.ip) {
+				c.hosts[name] = append(c.hosts[name], r.ip)
+			}
+		}
+	}
+}
+
+// services returns the services whose address is known
+func (c *collector) services() []Service {
+	var services []Service
+	for _, service := range c.instances {
+		if service.Port == 0 {
+			continue
+		}
+		s := *service
+		s.Addrs = c.hosts[s.Host]
+		if s.Name == "" {
+			s.Name = s.Instance
+		}
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+// parseTXT reads the name, protocol version and host key fingerprint
+func (s *Service) parseTXT(txt []string) {
+	for _, entry := range txt {
+		key, value, _ := strings.Cut(entry, "=")
+		switch strings.ToLower(key) {
+		case "name":
+			s.Name = value
+		case "proto":
+			s.ProtocolVersion, _ = strconv.Atoi(value)
+		case "fp":
+			s.Fingerprint = value
+		}
+	}
+}
+
+// unicastRequested reports whether every question of a query asks for a
+// unicast response
+func unicastRequested(query *message) bool {
+	for _, q := range query.questions {
+		if !q.unicast {
+			return false
+		}
+	}
+	return len(query.questions) > 0
+}
+
+// label turns a name into a single DNS label
+func label(name string) string {
+	name = strings.ReplaceAll(strings.TrimSpace(name), ".", "-")
+	if name == "" {
+		name = "waymon"
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+// localIPv4s returns the IPv4 addresses of the interfaces that can reach the
+// local network
+func localIPv4s() []net.IP {
+	var ips []net.IP
+	for _, n := range localNetworks() {
+		ips = append(ips, n.IP)
+	}
+	return ips
+}
+
+// localNetworks returns the IPv4 networks of the interfaces that are up and
+// support multicast, loopback excluded
+func localNetworks() []*net.IPNet {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var networks []*net.IPNet
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				networks = append(networks, &net.IPNet{IP: ipNet.IP.To4(), Mask: ipNet.Mask[len(ipNet.Mask)-net.IPv4len:]})
+			}
+		}
+	}
+	return networks
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, known := range ips {
+		if known.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
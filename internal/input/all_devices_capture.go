@@ -2,16 +2,19 @@ package input
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/bnema/waymon/internal/logger"
 	"github.com/bnema/waymon/internal/protocol"
 	evdev "github.com/gvalkov/golang-evdev"
+	"golang.org/x/sys/unix"
 )
 
 // AllDevicesCapture captures input events from all available input devices
@@ -21,11 +24,15 @@ type AllDevicesCapture struct {
 	ignoredDevices map[string]bool // devices that are not suitable for capture
 	eventChan      chan *protocol.InputEvent
 	onInputEvent   func(*protocol.InputEvent)
+	onLocalEvent   func(*protocol.InputEvent) // Events seen while controlling the local system
+	onDropped      func(target string)        // Events lost because the event channel was full
 	currentTarget  string
 	capturing      bool
 	ctx            context.Context
 	cancel         context.CancelFunc
 	deviceMonitor  *DeviceMonitor
+	poller         *evdevPoller   // Reads every captured device
+	modifiers      *ModifierState // Modifier and lock state of all captured keyboards
 
 	// Safety mechanisms
 	grabTimeout      time.Duration // Auto-release timeout
@@ -35,15 +42,38 @@ type AllDevicesCapture struct {
 	noGrab           bool          // Disable exclusive grab (for safer testing)
 	ctrlPressed      bool          // Track if Ctrl key is pressed
 	emergencyHandler func()        // Optional callback for emergency release
+
+	// Configured hotkeys, matched before events are forwarded
+	bindings       *Bindings
+	bindingHandler func(index int)
+
+	// How relative motion is batched before being forwarded
+	coalescePolicy   CoalescePolicy
+	coalesceInterval time.Duration
+
+	// How touchpads move the pointer
+	touchpadSpeed float64
+	tapToClick    bool
 }
 
 // deviceHandler manages a single input device
 type deviceHandler struct {
 	path    string
 	device  *evdev.InputDevice
-	cancel  context.CancelFunc
+	fd      int // Polled file descriptor of the device
 	name    string
 	grabbed bool // Track if device is currently grabbed
+
+	// Hardware frame being read, sent on SYN_REPORT. Only the poller touches it.
+	frame      []*protocol.InputEvent
+	accX, accY int32 // Relative motion of the frame
+	wheel      wheelFrame
+	touchpad   *touchpad // Multitouch state, for touchpads only
+	tablet     *tablet   // Tool state, for graphics tablets only
+	dropping   bool      // Skipping a frame the kernel dropped events of
+
+	// Keys and buttons forwarded as pressed, checked again after dropped events
+	held map[uint32]bool
 }
 
 // NewAllDevicesCapture creates a new all-devices input capture
@@ -53,8 +83,11 @@ func NewAllDevicesCapture() *AllDevicesCapture {
 		ignoredDevices: make(map[string]bool),
 		eventChan:      make(chan *protocol.InputEvent, 1000), // Increased buffer to handle bursts
 		capturing:      false,
+		modifiers:      NewModifierState(),
 		grabTimeout:    30 * time.Second, // Default 30 second safety timeout
 		emergencyKey:   evdev.KEY_ESC,    // ESC key for emergency release (requires Ctrl)
+		touchpadSpeed:  1,
+		tapToClick:     true,
 	}
 }
 
@@ -67,6 +100,13 @@ func (a *AllDevicesCapture) Start(ctx context.Context) error {
 		return fmt.Errorf("already capturing")
 	}
 
+	poller, err := newEvdevPoller()
+	if err != nil {
+		return fmt.Errorf("failed to start input polling: %w", err)
+	}
+	a.poller = poller
+	go poller.run(a.handleDeviceEvents)
+
 	// Create cancellable context
 	a.ctx, a.cancel = context.WithCancel(ctx)
 
@@ -82,6 +122,11 @@ func (a *AllDevicesCapture) Start(ctx context.Context) error {
 	// Discover and start capturing from existing devices
 	if err := a.discoverAndStartDevices(); err != nil {
 		a.cancel()
+		for _, handler := range a.devices {
+			a.stopDeviceHandler(handler)
+		}
+		a.devices = make(map[string]*deviceHandler)
+		poller.stop()
 		return fmt.Errorf("failed to discover devices: %w", err)
 	}
 
@@ -111,6 +156,7 @@ func (a *AllDevicesCapture) Stop() error {
 
 	// Clear devices map
 	a.devices = make(map[string]*deviceHandler)
+	a.poller.stop()
 
 	// Clear ignored devices (fresh start)
 	a.ignoredDevices = make(map[string]bool)
@@ -184,20 +230,22 @@ func (a *AllDevicesCapture) SetTarget(clientID string) error {
 
 			// Set up safety timeout
 			a.lastActivity = time.Now()
-			a.grabTimer = time.AfterFunc(a.grabTimeout, func() {
-				logger.Warnf("Safety timeout reached - auto-releasing devices")
-				a.mu.Lock()
-				if a.currentTarget != "" {
-					a.currentTarget = ""
-					for _, handler := range a.devices {
-						if handler.device != nil && handler.grabbed {
-							handler.device.Release()
-							handler.grabbed = false
+			if a.grabTimeout > 0 {
+				a.grabTimer = time.AfterFunc(a.grabTimeout, func() {
+					logger.Warnf("Safety timeout reached - auto-releasing devices")
+					a.mu.Lock()
+					if a.currentTarget != "" {
+						a.currentTarget = ""
+						for _, handler := range a.devices {
+							if handler.device != nil && handler.grabbed {
+								handler.device.Release()
+								handler.grabbed = false
+							}
 						}
 					}
-				}
-				a.mu.Unlock()
-			})
+					a.mu.Unlock()
+				})
+			}
 
 			logger.Infof("Set input capture target to client: %s (timeout: %v)", clientID, a.grabTimeout)
 		} else {
@@ -215,6 +263,22 @@ func (a *AllDevicesCapture) OnInputEvent(callback func(*protocol.InputEvent)) {
 	logger.Info("All-devices input event callback set")
 }
 
+// OnLocalInputEvent sets the callback for events captured while no target is set.
+// These events still reach the local compositor; the callback only observes them.
+func (a *AllDevicesCapture) OnLocalInputEvent(callback func(*protocol.InputEvent)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onLocalEvent = callback
+}
+
+// OnDroppedEvent sets the callback for events dropped because the event
+// channel was full, with the target they were captured for
+func (a *AllDevicesCapture) OnDroppedEvent(callback func(target string)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onDropped = callback
+}
+
 // discoverAndStartDevices finds all input devices and starts capturing from them
 func (a *AllDevicesCapture) discoverAndStartDevices() error {
 	eventDir := "/dev/input"
@@ -283,18 +347,43 @@ func (a *AllDevicesCapture) addDevice(path string) error {
 	handler := &deviceHandler{
 		path:   path,
 		device: device,
+		fd:     int(device.File.Fd()),
 		name:   device.Name,
 	}
 
-	// Start capturing from this device
-	handlerCtx, handlerCancel := context.WithCancel(a.ctx)
-	handler.cancel = handlerCancel
+	// Touchpads report fingers, turned into motion, scrolling and gestures,
+	// and tablets the position, pressure and tilt of their tools
+	if isTouchpad(device) {
+		pad, err := openTouchpad(device, a.touchpadSpeed, a.tapToClick)
+		if err != nil {
+			device.File.Close()
+			return fmt.Errorf("failed to read touchpad %s: %w", path, err)
+		}
+		handler.touchpad = pad
+	} else if isTablet(device) {
+		tool, err := openTablet(device)
+		if err != nil {
+			device.File.Close()
+			return fmt.Errorf("failed to read tablet %s: %w", path, err)
+		}
+		handler.tablet = tool
+	}
 
-	// Add to devices map
-	a.devices[path] = handler
+	// Pick up Caps Lock and Num Lock from a keyboard's LEDs
+	if hasCapabilityType(device, evdev.EV_LED) {
+		if locked, err := readLockLEDs(device); err != nil {
+			logger.Debugf("Cannot read LEDs of %s: %v", path, err)
+		} else {
+			a.modifiers.SetLocked(locked)
+		}
+	}
 
-	// Start capture goroutine for this device
-	go a.captureFromDevice(handlerCtx, handler)
+	// Start reading the device
+	if err := a.poller.add(handler); err != nil {
+		device.File.Close()
+		return fmt.Errorf("failed to capture from device %s: %w", path, err)
+	}
+	a.devices[path] = handler
 
 	logger.Infof("Added input device: %s (%s)", handler.name, path)
 	return nil
@@ -317,11 +406,11 @@ func (a *AllDevicesCapture) removeDevice(path string) {
 
 // stopDeviceHandler stops a device handler
 func (a *AllDevicesCapture) stopDeviceHandler(handler *deviceHandler) {
-	if handler.cancel != nil {
-		handler.cancel()
+	// The poller must let go of the fd before it is closed and reused
+	a.poller.remove(handler)
+	if err := handler.device.File.Close(); err != nil {
+		logger.Debugf("Failed to close device %s: %v", handler.path, err)
 	}
-	// evdev devices are automatically closed when the process exits
-	// No explicit Close() method needed
 }
 
 // isValidInputDevice checks if a device has input capabilities we care about
@@ -381,7 +470,8 @@ func (a *AllDevicesCapture) isValidInputDevice(device *evdev.InputDevice) bool {
 		}
 	}
 
-	return false
+	// Touchpads without buttons only report touches, tablets their tools
+	return isTouchpad(device) || isTablet(device)
 }
 
 // monitorDeviceChanges monitors for device addition/removal
@@ -470,132 +560,282 @@ func (a *AllDevicesCapture) checkForDeviceChanges() {
 	}
 }
 
-// captureFromDevice captures events from a specific device
-func (a *AllDevicesCapture) captureFromDevice(ctx context.Context, handler *deviceHandler) {
+// handleDeviceEvents handles the events read from a device in one wakeup of
+// the poller
+func (a *AllDevicesCapture) handleDeviceEvents(handler *deviceHandler, events []evdev.InputEvent, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Errorf("Device capture panic for %s: %v", handler.path, r)
 		}
 	}()
 
-	logger.Debugf("Starting capture from device: %s (%s)", handler.name, handler.path)
+	if err != nil {
+		if !errors.Is(err, unix.ENODEV) {
+			logger.Errorf("Error reading events from %s: %v", handler.path, err)
+		}
+		a.mu.Lock()
+		if a.devices[handler.path] == handler {
+			a.stopDeviceHandler(handler)
+			delete(a.devices, handler.path)
+			logger.Infof("Removed input device: %s", handler.path)
+		}
+		a.mu.Unlock()
+		return
+	}
 
-	// Variables to accumulate relative movements
-	var accX, accY int32
-	ticker := time.NewTicker(16 * time.Millisecond) // ~60 FPS
-	defer ticker.Stop()
+	for _, event := range events {
+		// After SYN_DROPPED the frame is incomplete, skip it up to the next report
+		// and release what the skipped events may have released
+		if handler.dropping {
+			if event.Type == evdev.EV_SYN && event.Code == evdev.SYN_REPORT {
+				handler.dropping = false
+				a.releaseDropped(handler)
+			}
+			continue
+		}
 
-	for {
-		select {
-		case <-ctx.Done():
-			logger.Debugf("Device capture context cancelled for %s", handler.path)
-			return
-		case <-ticker.C:
-			// Send accumulated movement if any
-			if accX != 0 || accY != 0 {
-				a.sendEvent(&protocol.InputEvent{
-					Event: &protocol.InputEvent_MouseMove{
-						MouseMove: &protocol.MouseMoveEvent{
-							Dx: float64(accX),
-							Dy: float64(accY),
-						},
-					},
-					Timestamp: time.Now().UnixNano(),
-					SourceId:  fmt.Sprintf("all-devices-%s", filepath.Base(handler.path)),
-				})
-				accX, accY = 0, 0
+		switch event.Type {
+		case evdev.EV_ABS:
+			if handler.touchpad != nil {
+				handler.touchpad.abs(event.Code, event.Value)
+			} else if handler.tablet != nil {
+				handler.tablet.abs(event.Code, event.Value)
 			}
-		default:
-			// Read events with a small timeout
-			events, err := handler.device.Read()
-			if err != nil {
-				if !strings.Contains(err.Error(), "resource temporarily unavailable") {
-					logger.Errorf("Error reading events from %s: %v", handler.path, err)
-				}
-				time.Sleep(5 * time.Millisecond)
+		case evdev.EV_REL:
+			switch event.Code {
+			case evdev.REL_X:
+				handler.accX += event.Value
+			case evdev.REL_Y:
+				handler.accY += event.Value
+			default:
+				handler.wheel.add(event.Code, event.Value)
+			}
+		case evdev.EV_KEY:
+			if handler.touchpad != nil && handler.touchpad.key(event.Code, event.Value) {
+				continue
+			}
+			if handler.tablet != nil && handler.tablet.key(event.Code, event.Value) {
 				continue
 			}
 
-			for _, event := range events {
-				switch event.Type {
-				case evdev.EV_REL:
-					switch event.Code {
-					case evdev.REL_X:
-						accX += event.Value
-					case evdev.REL_Y:
-						accY += event.Value
-					case evdev.REL_WHEEL:
-						a.sendScrollEvent(0, float64(event.Value))
-					case evdev.REL_HWHEEL:
-						a.sendScrollEvent(float64(event.Value), 0)
-					}
-				case evdev.EV_KEY:
-					// Track Ctrl key state
-					if event.Code == evdev.KEY_LEFTCTRL || event.Code == evdev.KEY_RIGHTCTRL {
-						a.mu.Lock()
-						a.ctrlPressed = (event.Value == 1)
-						a.mu.Unlock()
-						logger.Debugf("Ctrl key state changed: pressed=%v", event.Value == 1)
+			// Track Ctrl key state
+			if event.Code == evdev.KEY_LEFTCTRL || event.Code == evdev.KEY_RIGHTCTRL {
+				a.mu.Lock()
+				a.ctrlPressed = (event.Value == 1)
+				a.mu.Unlock()
+				logger.Debugf("Ctrl key state changed: pressed=%v", event.Value == 1)
+			}
+
+			// Check for emergency release key combination (Ctrl+ESC)
+			a.mu.RLock()
+			emergencyKey := a.emergencyKey
+			currentTarget := a.currentTarget
+			noGrab := a.noGrab
+			ctrlPressed := a.ctrlPressed
+			a.mu.RUnlock()
+
+			// Debug emergency key detection
+			if event.Code == emergencyKey {
+				logger.Debugf("ESC key detected: value=%d, ctrlPressed=%v, currentTarget=%s, noGrab=%v",
+					event.Value, ctrlPressed, currentTarget, noGrab)
+			}
+
+			// Only check emergency key if we're grabbing devices and Ctrl is pressed
+			if !noGrab && event.Code == emergencyKey && event.Value == 1 && currentTarget != "" && ctrlPressed {
+				logger.Warnf("Emergency release triggered - Ctrl+ESC pressed")
+				// Use goroutine to avoid deadlock
+				go func() {
+					// First try to release at our level
+					if err := a.SetTarget(""); err != nil {
+						logger.Errorf("Failed to release on emergency: %v", err)
 					}
 
-					// Check for emergency release key combination (Ctrl+ESC)
-					a.mu.RLock()
-					emergencyKey := a.emergencyKey
-					currentTarget := a.currentTarget
-					noGrab := a.noGrab
-					ctrlPressed := a.ctrlPressed
-					a.mu.RUnlock()
-
-					// Debug emergency key detection
-					if event.Code == emergencyKey {
-						logger.Debugf("ESC key detected: value=%d, ctrlPressed=%v, currentTarget=%s, noGrab=%v", 
-							event.Value, ctrlPressed, currentTarget, noGrab)
+					// Also notify the handler if set (ClientManager)
+					if a.emergencyHandler != nil {
+						logger.Debug("Notifying emergency handler")
+						a.emergencyHandler()
 					}
-					
-					// Only check emergency key if we're grabbing devices and Ctrl is pressed
-					if !noGrab && event.Code == emergencyKey && event.Value == 1 && currentTarget != "" && ctrlPressed {
-						logger.Warnf("Emergency release triggered - Ctrl+ESC pressed")
-						// Use goroutine to avoid deadlock
-						go func() {
-							// First try to release at our level
-							if err := a.SetTarget(""); err != nil {
-								logger.Errorf("Failed to release on emergency: %v", err)
-							}
-							
-							// Also notify the handler if set (ClientManager)
-							if a.emergencyHandler != nil {
-								logger.Debug("Notifying emergency handler")
-								a.emergencyHandler()
-							}
-						}()
-						continue
+				}()
+				continue
+			}
+
+			a.mu.RLock()
+			bindings, bindingHandler := a.bindings, a.bindingHandler
+			a.mu.RUnlock()
+			if bindings != nil {
+				if index, swallow := bindings.Match(event.Code, event.Value, a.modifiers.Depressed()); swallow {
+					if index >= 0 && bindingHandler != nil {
+						logger.Debugf("Binding %d triggered by code %d", index, event.Code)
+						// The handler may switch targets, which takes the lock
+						go bindingHandler(index)
 					}
+					continue
+				}
+			}
 
-					if event.Code >= evdev.BTN_LEFT && event.Code <= evdev.BTN_TASK {
-						a.sendMouseButtonEvent(event.Code, event.Value)
-					} else {
-						a.sendKeyboardEvent(event.Code, event.Value)
+			a.forwardKey(handler, event.Code, event.Value)
+		case evdev.EV_LED:
+			// While the compositor sees the keyboard it owns the lock state
+			a.mu.RLock()
+			local := a.currentTarget == ""
+			a.mu.RUnlock()
+			if local && (event.Code == evdev.LED_CAPSL || event.Code == evdev.LED_NUML) {
+				lock := ModLock
+				if event.Code == evdev.LED_NUML {
+					lock = ModNumLock
+				}
+				locked := a.modifiers.Locked() &^ lock
+				if event.Value != 0 {
+					locked |= lock
+				}
+				a.modifiers.SetLocked(locked)
+			}
+		case evdev.EV_SYN:
+			switch event.Code {
+			case evdev.SYN_REPORT:
+				at := time.Unix(event.Time.Unix())
+				if handler.touchpad != nil {
+					handler.frame = append(handler.touchpad.report(at, deviceSource(handler)), handler.frame...)
+				}
+				if handler.tablet != nil {
+					if tool := handler.tablet.report(at, deviceSource(handler)); tool != nil {
+						handler.frame = append([]*protocol.InputEvent{tool}, handler.frame...)
 					}
-				case evdev.EV_SYN:
-					// Synchronization event - ignore
-				case evdev.EV_MSC:
-					// Miscellaneous events - ignore
+				}
+				a.sendFrame(handler)
+			case evdev.SYN_DROPPED:
+				logger.Debugf("Events dropped by the kernel on %s, skipping frame", handler.path)
+				handler.frame, handler.accX, handler.accY = handler.frame[:0], 0, 0
+				handler.wheel = wheelFrame{}
+				handler.dropping = true
+				if handler.touchpad != nil {
+					handler.touchpad.dropped()
 				}
 			}
+		case evdev.EV_MSC:
+			// Miscellaneous events - ignore
 		}
 	}
 }
 
+// forwardKey adds a key or button event to the frame of its device,
+// remembering what is held down
+func (a *AllDevicesCapture) forwardKey(handler *deviceHandler, code uint16, value int32) {
+	if code >= evdev.BTN_LEFT && code <= evdev.BTN_TASK {
+		button := mouseButtonEvent(code, value)
+		if button == nil {
+			return
+		}
+		handler.frame = append(handler.frame, button)
+	} else {
+		handler.frame = append(handler.frame, a.keyboardEvent(code, value))
+	}
+
+	switch value {
+	case 0:
+		delete(handler.held, uint32(code))
+	case 1:
+		if handler.held == nil {
+			handler.held = make(map[uint32]bool)
+		}
+		handler.held[uint32(code)] = true
+	}
+}
+
+// releaseDropped sends a release for the keys and buttons held down that the
+// device no longer reports down, as their release may have been dropped with
+// the skipped events. When the state cannot be read, everything held is
+// released.
+func (a *AllDevicesCapture) releaseDropped(handler *deviceHandler) {
+	if len(handler.held) == 0 {
+		return
+	}
+
+	state, err := readKeyState(handler.fd)
+	if err != nil {
+		logger.Warnf("Failed to read key state of %s, releasing held keys: %v", handler.path, err)
+	}
+	for _, code := range sortedCodes(handler.held) {
+		if err == nil && state.down(uint16(code)) {
+			continue
+		}
+		logger.Debugf("Releasing code %d on %s after dropped events", code, handler.path)
+		if code == evdev.KEY_LEFTCTRL || code == evdev.KEY_RIGHTCTRL {
+			a.mu.Lock()
+			a.ctrlPressed = false
+			a.mu.Unlock()
+		}
+		a.forwardKey(handler, uint16(code), 0)
+	}
+	a.sendFrame(handler)
+}
+
+// keyState is the bitmask of the keys and buttons down on a device
+type keyState [evdev.KEY_MAX/8 + 1]byte
+
+// readKeyState reads which keys and buttons are down on a device
+func readKeyState(fd int) (keyState, error) {
+	var state keyState
+	request := uintptr(2<<30 | len(state)<<16 | 'E'<<8 | 0x18) // EVIOCGKEY(len)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(&state[0]))); errno != 0 {
+		return keyState{}, errno
+	}
+	return state, nil
+}
+
+// down reports whether a key or button is down
+func (s *keyState) down(code uint16) bool {
+	return int(code/8) < len(s) && s[code/8]&(1<<(code%8)) != 0
+}
+
+// sendFrame sends the events of a device's hardware frame together, its
+// motion and wheel first as the device reported them before its buttons and
+// keys
+func (a *AllDevicesCapture) sendFrame(handler *deviceHandler) {
+	events := handler.frame
+	if scroll := handler.wheel.event(); scroll != nil {
+		events = append([]*protocol.InputEvent{scroll}, events...)
+	}
+	if handler.accX != 0 || handler.accY != 0 {
+		events = append([]*protocol.InputEvent{{
+			Event: &protocol.InputEvent_MouseMove{
+				MouseMove: &protocol.MouseMoveEvent{
+					Dx: float64(handler.accX),
+					Dy: float64(handler.accY),
+				},
+			},
+			Timestamp: time.Now().UnixNano(),
+			SourceId:  deviceSource(handler),
+		}}, events...)
+	}
+	handler.frame, handler.accX, handler.accY = nil, 0, 0
+
+	switch len(events) {
+	case 0:
+	case 1:
+		a.sendEvent(events[0])
+	default:
+		a.sendEvent(protocol.NewFrame(events, time.Now().UnixNano(), deviceSource(handler)))
+	}
+}
+
+// deviceSource is the source ID of events read from a device
+func deviceSource(handler *deviceHandler) string {
+	return fmt.Sprintf("all-devices-%s", filepath.Base(handler.path))
+}
+
 // sendEvent sends an event to the event channel
 func (a *AllDevicesCapture) sendEvent(event *protocol.InputEvent) {
 	// Update activity timestamp and reset timer if we have an active grab
 	a.mu.Lock()
-	if a.currentTarget != "" {
+	target := a.currentTarget
+	if target != "" {
 		a.lastActivity = time.Now()
 		if a.grabTimer != nil {
 			a.grabTimer.Reset(a.grabTimeout)
 		}
 	}
+	onDropped := a.onDropped
 	a.mu.Unlock()
 
 	select {
@@ -603,11 +843,14 @@ func (a *AllDevicesCapture) sendEvent(event *protocol.InputEvent) {
 	default:
 		// Channel full, drop event
 		logger.Warnf("Event channel full, dropping event")
+		if onDropped != nil {
+			onDropped(target)
+		}
 	}
 }
 
-// sendMouseButtonEvent sends a mouse button event
-func (a *AllDevicesCapture) sendMouseButtonEvent(code uint16, value int32) {
+// mouseButtonEvent converts an evdev button, nil if it has no protocol number
+func mouseButtonEvent(code uint16, value int32) *protocol.InputEvent {
 	// Convert evdev button codes to protocol button numbers
 	var button uint32
 	switch code {
@@ -627,11 +870,11 @@ func (a *AllDevicesCapture) sendMouseButtonEvent(code uint16, value int32) {
 			button = uint32(code - evdev.BTN_LEFT + 1)
 		} else {
 			logger.Warnf("Unknown button code: %d", code)
-			return
+			return nil
 		}
 	}
-	
-	a.sendEvent(&protocol.InputEvent{
+
+	return &protocol.InputEvent{
 		Event: &protocol.InputEvent_MouseButton{
 			MouseButton: &protocol.MouseButtonEvent{
 				Button:  button,
@@ -640,12 +883,12 @@ func (a *AllDevicesCapture) sendMouseButtonEvent(code uint16, value int32) {
 		},
 		Timestamp: time.Now().UnixNano(),
 		SourceId:  "all-devices-capture",
-	})
+	}
 }
 
-// sendScrollEvent sends a mouse scroll event
-func (a *AllDevicesCapture) sendScrollEvent(dx, dy float64) {
-	a.sendEvent(&protocol.InputEvent{
+// scrollEvent creates a mouse scroll event
+func scrollEvent(dx, dy float64) *protocol.InputEvent {
+	return &protocol.InputEvent{
 		Event: &protocol.InputEvent_MouseScroll{
 			MouseScroll: &protocol.MouseScrollEvent{
 				Dx: dx,
@@ -654,11 +897,11 @@ func (a *AllDevicesCapture) sendScrollEvent(dx, dy float64) {
 		},
 		Timestamp: time.Now().UnixNano(),
 		SourceId:  "all-devices-capture",
-	})
+	}
 }
 
-// sendKeyboardEvent sends a keyboard event
-func (a *AllDevicesCapture) sendKeyboardEvent(code uint16, value int32) {
+// keyboardEvent converts an evdev key, tracking the modifiers it changes
+func (a *AllDevicesCapture) keyboardEvent(code uint16, value int32) *protocol.InputEvent {
 	// Log modifier key state changes for debugging
 	switch code {
 	case evdev.KEY_LEFTSHIFT, evdev.KEY_RIGHTSHIFT:
@@ -670,26 +913,48 @@ func (a *AllDevicesCapture) sendKeyboardEvent(code uint16, value int32) {
 	case evdev.KEY_LEFTCTRL, evdev.KEY_RIGHTCTRL:
 		// Already logged above in the main event handler
 	}
-	
+
 	// value can be 0 (release), 1 (press), 2 (autorepeat)
 	// We treat autorepeat as a press
-	a.sendEvent(&protocol.InputEvent{
+	a.modifiers.Update(uint32(code), value > 0)
+	return &protocol.InputEvent{
 		Event: &protocol.InputEvent_Keyboard{
 			Keyboard: &protocol.KeyboardEvent{
-				Key:     uint32(code),
-				Pressed: value > 0,
+				Key:       uint32(code),
+				Pressed:   value > 0,
+				Modifiers: a.modifiers.Mask(),
 			},
 		},
 		Timestamp: time.Now().UnixNano(),
 		SourceId:  "all-devices-capture",
-	})
+	}
 }
 
-// SetGrabTimeout sets the safety timeout for device grabbing
+// LockedModifiers returns the server's current Caps Lock and Num Lock state
+// as an XKB modifier mask
+func (a *AllDevicesCapture) LockedModifiers() uint32 {
+	return a.modifiers.Locked()
+}
+
+// hasCapabilityType reports whether a device reports events of the given type
+func hasCapabilityType(device *evdev.InputDevice, evType int) bool {
+	for capType := range device.Capabilities {
+		if capType.Type == evType {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGrabTimeout sets the safety timeout for device grabbing, 0 disables it
 func (a *AllDevicesCapture) SetGrabTimeout(timeout time.Duration) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.grabTimeout = timeout
+	if timeout <= 0 {
+		logger.Info("Device grab timeout disabled")
+		return
+	}
 	logger.Infof("Set device grab timeout to %v", timeout)
 }
 
@@ -720,6 +985,40 @@ func (a *AllDevicesCapture) SetEmergencyHandler(handler func()) {
 	a.emergencyHandler = handler
 }
 
+// SetMotionCoalescing sets how relative motion is batched before being
+// forwarded. It applies from the next Start.
+func (a *AllDevicesCapture) SetMotionCoalescing(policy CoalescePolicy, interval time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.coalescePolicy = policy
+	a.coalesceInterval = interval
+	if policy == CoalesceInterval {
+		logger.Infof("Relative motion merged over %v", interval)
+	}
+}
+
+// SetTouchpadOptions sets the multiplier of touchpad pointer motion and
+// scrolling, and whether tapping clicks. It applies to touchpads added after.
+func (a *AllDevicesCapture) SetTouchpadOptions(speed float64, tapToClick bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if speed <= 0 {
+		speed = 1
+	}
+	a.touchpadSpeed = speed
+	a.tapToClick = tapToClick
+}
+
+// SetBindings sets the hotkeys matched on the captured stream. handler is
+// called with the index of the chord that was pressed; matched keys and
+// buttons are not forwarded to the client.
+func (a *AllDevicesCapture) SetBindings(chords []Chord, handler func(index int)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bindings = NewBindings(chords)
+	a.bindingHandler = handler
+}
+
 // processEvents processes events from the event channel
 func (a *AllDevicesCapture) processEvents() {
 	defer func() {
@@ -728,29 +1027,57 @@ func (a *AllDevicesCapture) processEvents() {
 		}
 	}()
 
+	a.mu.RLock()
+	coalescer := &motionCoalescer{}
+	if a.coalescePolicy == CoalesceInterval {
+		coalescer.interval = a.coalesceInterval
+	}
+	eventChan := a.eventChan
+	a.mu.RUnlock()
+
+	// Runs while motion is held back
+	var flushTimer <-chan time.Time
 	for {
 		select {
 		case <-a.ctx.Done():
 			return
-		case event, ok := <-a.eventChan:
+		case <-flushTimer:
+			flushTimer = nil
+			if event := coalescer.flush(); event != nil {
+				a.forwardEvent(event)
+			}
+		case event, ok := <-eventChan:
 			if !ok {
 				return
 			}
+			for _, event := range coalescer.add(event) {
+				a.forwardEvent(event)
+			}
+			if coalescer.holding() && flushTimer == nil {
+				flushTimer = time.After(coalescer.interval)
+			}
+		}
+	}
+}
 
-			a.mu.RLock()
-			target := a.currentTarget
-			callback := a.onInputEvent
-			a.mu.RUnlock()
+// forwardEvent hands a captured event to the callback for the current target
+func (a *AllDevicesCapture) forwardEvent(event *protocol.InputEvent) {
+	a.mu.RLock()
+	target := a.currentTarget
+	callback := a.onInputEvent
+	localCallback := a.onLocalEvent
+	a.mu.RUnlock()
 
-			// Only forward events if we have a target and callback
-			if target != "" && callback != nil {
-				logger.Debugf("Forwarding %T event to callback (target: %s)", event.Event, target)
-				callback(event)
-			} else if target == "" {
-				// Controlling local system, don't forward
-			} else if callback == nil {
-				logger.Warnf("No callback set for input events!")
-			}
+	// Only forward events if we have a target and callback
+	if target != "" && callback != nil {
+		logger.Debugf("Forwarding %T event to callback (target: %s)", event.Event, target)
+		callback(event)
+	} else if target == "" {
+		// Controlling local system, don't forward but let observers track the cursor
+		if localCallback != nil {
+			localCallback(event)
 		}
+	} else if callback == nil {
+		logger.Warnf("No callback set for input events!")
 	}
 }
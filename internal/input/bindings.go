@@ -0,0 +1,154 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// Chord is a key or mouse button pressed while exactly a set of modifiers is held
+type Chord struct {
+	Modifiers uint32 // XKB modifier mask that must be held, see ModShift
+	Code      uint16 // evdev key or button code that completes the chord
+}
+
+// chordModifiers maps modifier names accepted in chords to their masks
+var chordModifiers = map[string]uint32{
+	"shift":   ModShift,
+	"ctrl":    ModControl,
+	"control": ModControl,
+	"alt":     ModAlt,
+	"altgr":   ModAltGr,
+	"super":   ModSuper,
+	"meta":    ModSuper,
+	"logo":    ModSuper,
+}
+
+// chordAliases maps friendly names to evdev codes. Mouse buttons are listed
+// here because evdev gives BTN_LEFT and BTN_MOUSE the same code.
+var chordAliases = map[string]uint16{
+	"escape":      evdev.KEY_ESC,
+	"return":      evdev.KEY_ENTER,
+	"del":         evdev.KEY_DELETE,
+	"ins":         evdev.KEY_INSERT,
+	"pgup":        evdev.KEY_PAGEUP,
+	"pgdn":        evdev.KEY_PAGEDOWN,
+	"btn_left":    evdev.BTN_LEFT,
+	"btn_right":   evdev.BTN_RIGHT,
+	"btn_middle":  evdev.BTN_MIDDLE,
+	"btn_side":    evdev.BTN_SIDE,
+	"btn_extra":   evdev.BTN_EXTRA,
+	"btn_forward": evdev.BTN_FORWARD,
+	"btn_back":    evdev.BTN_BACK,
+	"btn_task":    evdev.BTN_TASK,
+}
+
+// ParseChord parses a chord such as "ctrl+alt+right" or "super+btn_side".
+// Every part but the last is a modifier; the last is an evdev key name
+// without its KEY_ prefix, or a mouse button such as btn_side.
+func ParseChord(s string) (Chord, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	var chord Chord
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return Chord{}, fmt.Errorf("invalid chord %q", s)
+		}
+
+		if i < len(parts)-1 {
+			modifier, ok := chordModifiers[part]
+			if !ok {
+				return Chord{}, fmt.Errorf("unknown modifier %q in chord %q", part, s)
+			}
+			chord.Modifiers |= modifier
+			continue
+		}
+
+		if _, ok := chordModifiers[part]; ok {
+			return Chord{}, fmt.Errorf("chord %q needs a key or button after its modifiers", s)
+		}
+		code, ok := chordCode(part)
+		if !ok {
+			return Chord{}, fmt.Errorf("unknown key %q in chord %q", part, s)
+		}
+		if modifierForKey(uint32(code)) != 0 || lockForKey(uint32(code)) != 0 {
+			return Chord{}, fmt.Errorf("chord %q cannot end with a modifier key", s)
+		}
+		chord.Code = code
+	}
+	return chord, nil
+}
+
+// chordCode looks up the evdev code of a key or button name
+func chordCode(name string) (uint16, bool) {
+	if code, ok := chordAliases[name]; ok {
+		return code, true
+	}
+	want := "KEY_" + strings.ToUpper(name)
+	if strings.HasPrefix(name, "btn_") {
+		want = strings.ToUpper(name)
+	}
+	for code, keyName := range evdev.KEY {
+		if keyName == want {
+			return uint16(code), true //nolint:gosec // evdev codes fit in uint16
+		}
+	}
+	for code, btnName := range evdev.BTN {
+		if btnName == want {
+			return uint16(code), true //nolint:gosec // evdev codes fit in uint16
+		}
+	}
+	return 0, false
+}
+
+// Bindings matches chords on a raw key and button stream. Events completing a
+// chord are swallowed up to and including their release, so none of them
+// reach the machine being controlled.
+type Bindings struct {
+	mu        sync.Mutex
+	chords    map[Chord]int   // Index of the binding each chord triggers
+	swallowed map[uint16]bool // Codes whose press matched a chord and are still down
+}
+
+// NewBindings creates a matcher for chords, identified by their index
+func NewBindings(chords []Chord) *Bindings {
+	b := &Bindings{
+		chords:    make(map[Chord]int, len(chords)),
+		swallowed: make(map[uint16]bool),
+	}
+	for i, chord := range chords {
+		if _, exists := b.chords[chord]; !exists {
+			b.chords[chord] = i
+		}
+	}
+	return b
+}
+
+// Match checks a key or button event against the chords with the given
+// modifiers held. It returns the index of the chord a press completed, or -1,
+// and whether the event must be swallowed. Autorepeats and the release of a
+// matched press are swallowed without matching again.
+func (b *Bindings) Match(code uint16, value int32, held uint32) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch value {
+	case 0: // release
+		if b.swallowed[code] {
+			delete(b.swallowed, code)
+			return -1, true
+		}
+		return -1, false
+	case 1: // press
+		index, ok := b.chords[Chord{Modifiers: held, Code: code}]
+		if !ok {
+			return -1, false
+		}
+		b.swallowed[code] = true
+		return index, true
+	default: // autorepeat
+		return -1, b.swallowed[code]
+	}
+}
@@ -0,0 +1,60 @@
+package input
+
+import (
+	"testing"
+
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChord(t *testing.T) {
+	chord, err := ParseChord("Ctrl+Alt+Right")
+	assert.NoError(t, err)
+	assert.Equal(t, Chord{Modifiers: ModControl | ModAlt, Code: evdev.KEY_RIGHT}, chord)
+
+	chord, err = ParseChord("super+btn_side")
+	assert.NoError(t, err)
+	assert.Equal(t, Chord{Modifiers: ModSuper, Code: evdev.BTN_SIDE}, chord)
+
+	chord, err = ParseChord("btn_left")
+	assert.NoError(t, err, "BTN_LEFT shares its code with BTN_MOUSE")
+	assert.Equal(t, Chord{Code: evdev.BTN_LEFT}, chord)
+
+	chord, err = ParseChord("ctrl+shift+f1")
+	assert.NoError(t, err)
+	assert.Equal(t, Chord{Modifiers: ModControl | ModShift, Code: evdev.KEY_F1}, chord)
+
+	for _, invalid := range []string{"", "ctrl+alt", "ctrl++a", "hyper+a", "ctrl+nosuchkey", "ctrl+leftshift"} {
+		_, err := ParseChord(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBindingsMatch(t *testing.T) {
+	b := NewBindings([]Chord{
+		{Modifiers: ModControl | ModAlt, Code: evdev.KEY_RIGHT},
+		{Modifiers: ModSuper, Code: evdev.BTN_SIDE},
+	})
+
+	index, swallow := b.Match(evdev.KEY_RIGHT, 1, ModControl)
+	assert.Equal(t, -1, index, "modifiers must match exactly")
+	assert.False(t, swallow)
+	_, swallow = b.Match(evdev.KEY_RIGHT, 0, ModControl)
+	assert.False(t, swallow, "unmatched releases are forwarded")
+
+	index, swallow = b.Match(evdev.KEY_RIGHT, 1, ModControl|ModAlt)
+	assert.Equal(t, 0, index)
+	assert.True(t, swallow)
+	index, swallow = b.Match(evdev.KEY_RIGHT, 2, ModControl|ModAlt)
+	assert.Equal(t, -1, index, "autorepeat does not trigger again")
+	assert.True(t, swallow)
+	index, swallow = b.Match(evdev.KEY_RIGHT, 0, 0)
+	assert.Equal(t, -1, index)
+	assert.True(t, swallow, "release of a matched key is swallowed once modifiers are gone")
+	_, swallow = b.Match(evdev.KEY_RIGHT, 0, 0)
+	assert.False(t, swallow)
+
+	index, swallow = b.Match(evdev.BTN_SIDE, 1, ModSuper)
+	assert.Equal(t, 1, index)
+	assert.True(t, swallow)
+}
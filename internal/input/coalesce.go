@@ -0,0 +1,87 @@
+package input
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// CoalescePolicy decides how captured relative motion is batched before it is
+// forwarded
+type CoalescePolicy int
+
+const (
+	// CoalesceFrames forwards every hardware frame as the device reported it
+	CoalesceFrames CoalescePolicy = iota
+	// CoalesceInterval merges frames carrying only motion over an interval,
+	// trading smoothness for fewer messages on high rate mice
+	CoalesceInterval
+)
+
+// ParseCoalescePolicy parses a policy name from the configuration
+func ParseCoalescePolicy(name string) (CoalescePolicy, error) {
+	switch name {
+	case "", "frame":
+		return CoalesceFrames, nil
+	case "interval":
+		return CoalesceInterval, nil
+	default:
+		return CoalesceFrames, fmt.Errorf("unknown motion coalescing %q, want frame or interval", name)
+	}
+}
+
+// String returns the configuration name of the policy
+func (p CoalescePolicy) String() string {
+	if p == CoalesceInterval {
+		return "interval"
+	}
+	return "frame"
+}
+
+// motionCoalescer merges motion-only events of one device until the interval
+// since the first of them has passed. Any other event first flushes the motion
+// held back, so nothing overtakes the motion that preceded it.
+type motionCoalescer struct {
+	interval time.Duration // 0 forwards everything right away
+	pending  *protocol.InputEvent
+}
+
+// add takes the next captured event and returns those to forward now. While
+// motion is held back, flush must be called once the interval has passed.
+func (c *motionCoalescer) add(event *protocol.InputEvent) (forward []*protocol.InputEvent) {
+	move := event.GetMouseMove()
+	if c.interval <= 0 || move == nil {
+		if c.pending != nil {
+			forward = append(forward, c.pending)
+			c.pending = nil
+		}
+		return append(forward, event)
+	}
+
+	if c.pending != nil && c.pending.SourceId == event.SourceId {
+		merged := c.pending.GetMouseMove()
+		merged.Dx += move.Dx
+		merged.Dy += move.Dy
+		return nil
+	}
+
+	// Motion from another device is not merged with the first one's
+	if c.pending != nil {
+		forward = append(forward, c.pending)
+	}
+	c.pending = event
+	return forward
+}
+
+// holding reports whether motion is held back
+func (c *motionCoalescer) holding() bool {
+	return c.pending != nil
+}
+
+// flush returns the motion held back, if any
+func (c *motionCoalescer) flush() *protocol.InputEvent {
+	event := c.pending
+	c.pending = nil
+	return event
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func moveEvent(source string, dx, dy float64) *protocol.InputEvent {
+	return &protocol.InputEvent{
+		Event:    &protocol.InputEvent_MouseMove{MouseMove: &protocol.MouseMoveEvent{Dx: dx, Dy: dy}},
+		SourceId: source,
+	}
+}
+
+func TestParseCoalescePolicy(t *testing.T) {
+	for name, want := range map[string]CoalescePolicy{"": CoalesceFrames, "frame": CoalesceFrames, "interval": CoalesceInterval} {
+		policy, err := ParseCoalescePolicy(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, policy, name)
+	}
+	_, err := ParseCoalescePolicy("ticker")
+	assert.Error(t, err)
+}
+
+func TestMotionCoalescerFrames(t *testing.T) {
+	c := &motionCoalescer{}
+	for i := 0; i < 3; i++ {
+		assert.Len(t, c.add(moveEvent("mouse", 1, 0)), 1, "Motion held back without an interval")
+	}
+	assert.False(t, c.holding())
+}
+
+func TestMotionCoalescerInterval(t *testing.T) {
+	c := &motionCoalescer{interval: 8 * time.Millisecond}
+
+	assert.Empty(t, c.add(moveEvent("mouse", 1, 2)))
+	assert.Empty(t, c.add(moveEvent("mouse", 3, 4)))
+	assert.True(t, c.holding())
+
+	// A button flushes the motion before it
+	forward := c.add(buttonEvent(1, true))
+	require.Len(t, forward, 2)
+	assert.Equal(t, &protocol.MouseMoveEvent{Dx: 4, Dy: 6}, forward[0].GetMouseMove())
+	assert.NotNil(t, forward[1].GetMouseButton())
+	assert.False(t, c.holding())
+
+	// Motion of another device is not merged
+	assert.Empty(t, c.add(moveEvent("mouse", 1, 1)))
+	forward = c.add(moveEvent("trackpad", 2, 2))
+	require.Len(t, forward, 1)
+	assert.Equal(t, "mouse", forward[0].SourceId)
+
+	flushed := c.flush()
+	require.NotNil(t, flushed)
+	assert.Equal(t, "trackpad", flushed.SourceId)
+	assert.Nil(t, c.flush())
+}
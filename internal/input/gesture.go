@@ -0,0 +1,102 @@
+package input
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// pinchThreshold is how far the finger spread must change for a pinch to
+// count as pinching in or out
+const pinchThreshold = 0.15
+
+// GestureNames lists the gestures that can be bound to keys, such as
+// swipe_left_3 or pinch_in_4
+func GestureNames() []string {
+	var names []string
+	for _, fingers := range []int{3, 4} {
+		for _, direction := range []string{"swipe_left", "swipe_right", "swipe_up", "swipe_down", "pinch_in", "pinch_out"} {
+			names = append(names, fmt.Sprintf("%s_%d", direction, fingers))
+		}
+	}
+	return names
+}
+
+// gestureTracker follows the gestures received from the controlling machine
+// and names each one once it ends
+type gestureTracker struct {
+	active  bool
+	kind    protocol.GestureType
+	fingers uint32
+	dx, dy  float64 // Travel since the begin
+	scale   float64
+}
+
+// add takes the next gesture event and returns the name of the gesture it
+// completed, or "" while the gesture goes on or when it was cancelled or too
+// small to tell its direction
+func (g *gestureTracker) add(gesture *protocol.GestureEvent) string {
+	switch gesture.Phase {
+	case protocol.GesturePhase_GESTURE_BEGIN:
+		*g = gestureTracker{active: true, kind: gesture.Type, fingers: gesture.Fingers, scale: 1}
+	case protocol.GesturePhase_GESTURE_UPDATE:
+		g.dx += gesture.Dx
+		g.dy += gesture.Dy
+		if gesture.Scale > 0 {
+			g.scale = gesture.Scale
+		}
+	case protocol.GesturePhase_GESTURE_END:
+		if !g.active || gesture.Cancelled {
+			*g = gestureTracker{}
+			return ""
+		}
+		name := g.name()
+		*g = gestureTracker{}
+		return name
+	}
+	return ""
+}
+
+// name names the gesture followed so far
+func (g *gestureTracker) name() string {
+	var direction string
+	switch {
+	case g.kind == protocol.GestureType_GESTURE_PINCH && g.scale < 1-pinchThreshold:
+		direction = "pinch_in"
+	case g.kind == protocol.GestureType_GESTURE_PINCH && g.scale > 1+pinchThreshold:
+		direction = "pinch_out"
+	case g.kind == protocol.GestureType_GESTURE_PINCH || (g.dx == 0 && g.dy == 0):
+		return ""
+	case math.Abs(g.dx) >= math.Abs(g.dy) && g.dx < 0:
+		direction = "swipe_left"
+	case math.Abs(g.dx) >= math.Abs(g.dy):
+		direction = "swipe_right"
+	case g.dy < 0:
+		direction = "swipe_up"
+	default:
+		direction = "swipe_down"
+	}
+	return fmt.Sprintf("%s_%d", direction, g.fingers)
+}
+
+// Keys returns the keys to press for the chord, its modifiers first
+func (c Chord) Keys() []uint32 {
+	var keys []uint32
+	for _, modifier := range []struct {
+		mask uint32
+		key  uint32
+	}{
+		{ModControl, evdev.KEY_LEFTCTRL},
+		{ModShift, evdev.KEY_LEFTSHIFT},
+		{ModAlt, evdev.KEY_LEFTALT},
+		{ModAltGr, evdev.KEY_RIGHTALT},
+		{ModSuper, evdev.KEY_LEFTMETA},
+	} {
+		if c.Modifiers&modifier.mask != 0 {
+			keys = append(keys, modifier.key)
+		}
+	}
+	return append(keys, uint32(c.Code))
+}
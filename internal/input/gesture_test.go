@@ -0,0 +1,50 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGestureTracker(t *testing.T) {
+	gesture := func(kind protocol.GestureType, phase protocol.GesturePhase, dx, dy, scale float64) *protocol.GestureEvent {
+		return &protocol.GestureEvent{Type: kind, Phase: phase, Fingers: 3, Dx: dx, Dy: dy, Scale: scale}
+	}
+	swipe, pinch := protocol.GestureType_GESTURE_SWIPE, protocol.GestureType_GESTURE_PINCH
+	begin, update, end := protocol.GesturePhase_GESTURE_BEGIN, protocol.GesturePhase_GESTURE_UPDATE, protocol.GesturePhase_GESTURE_END
+
+	var g gestureTracker
+	assert.Empty(t, g.add(gesture(swipe, begin, 0, 0, 1)))
+	assert.Empty(t, g.add(gesture(swipe, update, -20, 5, 1)))
+	assert.Empty(t, g.add(gesture(swipe, update, -20, -5, 1)))
+	assert.Equal(t, "swipe_left_3", g.add(gesture(swipe, end, 0, 0, 1)))
+
+	g.add(gesture(swipe, begin, 0, 0, 1))
+	g.add(gesture(swipe, update, 5, 30, 1))
+	assert.Equal(t, "swipe_down_3", g.add(gesture(swipe, end, 0, 0, 1)))
+
+	g.add(gesture(pinch, begin, 0, 0, 1))
+	g.add(gesture(pinch, update, 0, 0, 0.6))
+	assert.Equal(t, "pinch_in_3", g.add(gesture(pinch, end, 0, 0, 1)))
+
+	// Cancelled gestures and pinches that barely changed trigger nothing
+	g.add(gesture(swipe, begin, 0, 0, 1))
+	g.add(gesture(swipe, update, 30, 0, 1))
+	cancelled := gesture(swipe, end, 0, 0, 1)
+	cancelled.Cancelled = true
+	assert.Empty(t, g.add(cancelled))
+	g.add(gesture(pinch, begin, 0, 0, 1))
+	g.add(gesture(pinch, update, 0, 0, 1.05))
+	assert.Empty(t, g.add(gesture(pinch, end, 0, 0, 1)))
+
+	assert.Contains(t, GestureNames(), "swipe_up_4")
+	assert.Contains(t, GestureNames(), "pinch_out_3")
+}
+
+func TestChordKeys(t *testing.T) {
+	chord, err := ParseChord("super+ctrl+right")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{evdev.KEY_LEFTCTRL, evdev.KEY_LEFTMETA, evdev.KEY_RIGHT}, chord.Keys())
+}
@@ -0,0 +1,150 @@
+package input
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// XKB modifier masks used by the standard keymaps, including the default
+// keymap of the virtual keyboard
+const (
+	ModShift   uint32 = 1 << 0 // Shift
+	ModLock    uint32 = 1 << 1 // Caps Lock
+	ModControl uint32 = 1 << 2 // Control
+	ModAlt     uint32 = 1 << 3 // Mod1
+	ModNumLock uint32 = 1 << 4 // Mod2
+	ModSuper   uint32 = 1 << 6 // Mod4
+	ModAltGr   uint32 = 1 << 7 // Mod5, ISO_Level3_Shift
+)
+
+// ModifierState follows the depressed and locked XKB modifiers of a key
+// stream. Latched modifiers only come from sticky keys handled inside a
+// compositor, so a raw evdev stream never produces them.
+type ModifierState struct {
+	mu     sync.Mutex
+	held   map[uint32]bool // Modifier and lock keys currently down
+	locked uint32
+}
+
+// NewModifierState creates a modifier state with nothing held or locked
+func NewModifierState() *ModifierState {
+	return &ModifierState{held: make(map[uint32]bool)}
+}
+
+// Update applies a key press or release and reports whether the modifier
+// state changed. A press of a key already held is an autorepeat and does not
+// toggle a lock again.
+func (m *ModifierState) Update(key uint32, pressed bool) bool {
+	modifier := modifierForKey(key)
+	lock := lockForKey(key)
+	if modifier == 0 && lock == 0 {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, lockedBefore := m.depressed(), m.locked
+	if pressed {
+		if !m.held[key] && lock != 0 {
+			m.locked ^= lock
+		}
+		m.held[key] = true
+	} else {
+		delete(m.held, key)
+	}
+	return m.depressed() != before || m.locked != lockedBefore
+}
+
+// Depressed returns the mask of modifiers held down
+func (m *ModifierState) Depressed() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.depressed()
+}
+
+// Locked returns the mask of active locks
+func (m *ModifierState) Locked() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.locked
+}
+
+// Mask returns the effective modifier mask
+func (m *ModifierState) Mask() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.depressed() | m.locked
+}
+
+// SetLocked replaces the lock state and reports whether it changed
+func (m *ModifierState) SetLocked(locked uint32) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := m.locked != locked
+	m.locked = locked
+	return changed
+}
+
+// depressed computes the held modifier mask. Must be called with the lock held.
+func (m *ModifierState) depressed() uint32 {
+	var mask uint32
+	for key := range m.held {
+		mask |= modifierForKey(key)
+	}
+	return mask
+}
+
+// modifierForKey returns the modifier a key sets while held
+func modifierForKey(key uint32) uint32 {
+	switch key {
+	case evdev.KEY_LEFTSHIFT, evdev.KEY_RIGHTSHIFT:
+		return ModShift
+	case evdev.KEY_LEFTCTRL, evdev.KEY_RIGHTCTRL:
+		return ModControl
+	case evdev.KEY_LEFTALT:
+		return ModAlt
+	case evdev.KEY_RIGHTALT:
+		return ModAltGr
+	case evdev.KEY_LEFTMETA, evdev.KEY_RIGHTMETA:
+		return ModSuper
+	}
+	return 0
+}
+
+// lockForKey returns the lock a key toggles when pressed
+func lockForKey(key uint32) uint32 {
+	switch key {
+	case evdev.KEY_CAPSLOCK:
+		return ModLock
+	case evdev.KEY_NUMLOCK:
+		return ModNumLock
+	}
+	return 0
+}
+
+// readLockLEDs returns the lock modifiers shown by a keyboard's LEDs, which
+// the compositor keeps in line with its own lock state
+func readLockLEDs(device *evdev.InputDevice) (uint32, error) {
+	var leds [(evdev.LED_MAX + 8) / 8]byte
+	request := uintptr(2<<30 | len(leds)<<16 | 'E'<<8 | 0x19) // EVIOCGLED(len)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, device.File.Fd(), request, uintptr(unsafe.Pointer(&leds[0]))); errno != 0 {
+		return 0, errno
+	}
+	return locksFromLEDs(leds[0]&(1<<evdev.LED_CAPSL) != 0, leds[0]&(1<<evdev.LED_NUML) != 0), nil
+}
+
+// locksFromLEDs converts Caps Lock and Num Lock LED states to a lock mask
+func locksFromLEDs(capsLock, numLock bool) uint32 {
+	var locked uint32
+	if capsLock {
+		locked |= ModLock
+	}
+	if numLock {
+		locked |= ModNumLock
+	}
+	return locked
+}
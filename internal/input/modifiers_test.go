@@ -0,0 +1,44 @@
+package input
+
+import (
+	"testing"
+
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModifierStateUpdate(t *testing.T) {
+	m := NewModifierState()
+
+	assert.False(t, m.Update(evdev.KEY_A, true), "plain keys do not change modifiers")
+
+	assert.True(t, m.Update(evdev.KEY_LEFTSHIFT, true))
+	assert.False(t, m.Update(evdev.KEY_RIGHTSHIFT, true), "shift is already held")
+	assert.Equal(t, ModShift, m.Depressed())
+	assert.False(t, m.Update(evdev.KEY_LEFTSHIFT, false), "other shift is still held")
+	assert.True(t, m.Update(evdev.KEY_RIGHTSHIFT, false))
+	assert.Zero(t, m.Depressed())
+
+	assert.True(t, m.Update(evdev.KEY_CAPSLOCK, true))
+	assert.False(t, m.Update(evdev.KEY_CAPSLOCK, true), "autorepeat does not toggle again")
+	assert.False(t, m.Update(evdev.KEY_CAPSLOCK, false))
+	assert.Equal(t, ModLock, m.Locked())
+
+	m.Update(evdev.KEY_LEFTCTRL, true)
+	m.Update(evdev.KEY_RIGHTALT, true)
+	assert.Equal(t, ModControl|ModAltGr|ModLock, m.Mask())
+
+	assert.True(t, m.Update(evdev.KEY_CAPSLOCK, true))
+	assert.Equal(t, ModControl|ModAltGr, m.Mask())
+}
+
+func TestModifierStateSetLocked(t *testing.T) {
+	m := NewModifierState()
+
+	assert.True(t, m.SetLocked(locksFromLEDs(false, true)))
+	assert.False(t, m.SetLocked(ModNumLock))
+	assert.Equal(t, ModNumLock, m.Mask())
+
+	assert.True(t, m.Update(evdev.KEY_NUMLOCK, true))
+	assert.Zero(t, m.Locked())
+}
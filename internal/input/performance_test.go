@@ -2,14 +2,18 @@ package input
 
 import (
 	"context"
+	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 )
 
 // TestMouseMovementLatency tests that mouse movement events are sent immediately
@@ -203,6 +207,60 @@ func TestMouseButtonLatency(t *testing.T) {
 	}
 }
 
+// TestHardwareFrames tests that every hardware frame of a device is sent, its
+// motion ahead of the buttons reported with it
+func TestHardwareFrames(t *testing.T) {
+	capture := NewAllDevicesCapture()
+	handler := &deviceHandler{path: "/dev/input/event5", fd: -1, name: "mouse"}
+
+	capture.handleDeviceEvents(handler, []evdev.InputEvent{
+		{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 2},
+		{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+		{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 3},
+		{Type: evdev.EV_REL, Code: evdev.REL_Y, Value: -1},
+		{Type: evdev.EV_KEY, Code: evdev.BTN_LEFT, Value: 1},
+	}, nil)
+	// The frame ends in the next read
+	capture.handleDeviceEvents(handler, []evdev.InputEvent{
+		{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+		// Events the kernel dropped part of are skipped up to the next report
+		{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 50},
+		{Type: evdev.EV_SYN, Code: evdev.SYN_DROPPED},
+		{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 60},
+		{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+		{Type: evdev.EV_KEY, Code: evdev.BTN_LEFT, Value: 0},
+		{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+	}, nil)
+
+	require.Len(t, capture.eventChan, 4)
+	first := <-capture.eventChan
+	assert.Equal(t, &protocol.MouseMoveEvent{Dx: 2}, first.GetMouseMove())
+
+	second := (<-capture.eventChan).GetFrame()
+	require.NotNil(t, second)
+	require.Len(t, second.Events, 2)
+	assert.Equal(t, &protocol.MouseMoveEvent{Dx: 3, Dy: -1}, second.Events[0].GetMouseMove())
+	assert.Equal(t, &protocol.MouseButtonEvent{Button: 1, Pressed: true}, second.Events[1].GetMouseButton())
+
+	// The button held when events were dropped is released, the device state
+	// being unreadable
+	third := <-capture.eventChan
+	assert.Equal(t, &protocol.MouseButtonEvent{Button: 1}, third.GetMouseButton())
+	fourth := <-capture.eventChan
+	assert.Equal(t, &protocol.MouseButtonEvent{Button: 1}, fourth.GetMouseButton())
+	assert.Empty(t, handler.held)
+}
+
+// TestKeyState tests reading the keys down from the device key bitmask
+func TestKeyState(t *testing.T) {
+	var state keyState
+	state[evdev.BTN_LEFT/8] |= 1 << (evdev.BTN_LEFT % 8)
+	assert.True(t, state.down(evdev.BTN_LEFT))
+	assert.False(t, state.down(evdev.BTN_RIGHT))
+	assert.False(t, state.down(evdev.KEY_A))
+	assert.False(t, state.down(0xffff))
+}
+
 // TestEventAggregatorPerformance tests the event aggregator performance
 func TestEventAggregatorPerformance(t *testing.T) {
 	aggregator := NewEventAggregator()
@@ -352,3 +410,176 @@ func BenchmarkEventProcessing(b *testing.B) {
 
 	b.Logf("Processed %d events", atomic.LoadInt32(&eventCount))
 }
+
+// fakeDevice is a pipe standing in for an evdev device: events written to it
+// are read by the poller as they would be from the kernel
+type fakeDevice struct {
+	handler *deviceHandler
+	w       *os.File
+}
+
+func newFakeDevice(t testing.TB, name string) *fakeDevice {
+	var fds [2]int
+	require.NoError(t, unix.Pipe2(fds[:], unix.O_CLOEXEC))
+	r := os.NewFile(uintptr(fds[0]), name)
+	w := os.NewFile(uintptr(fds[1]), name)
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+	return &fakeDevice{handler: &deviceHandler{path: "/dev/input/" + name, fd: fds[0], name: name}, w: w}
+}
+
+func (d *fakeDevice) write(t testing.TB, events ...evdev.InputEvent) {
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&events[0])), len(events)*eventSize)
+	_, err := d.w.Write(raw)
+	require.NoError(t, err)
+}
+
+// startPoller runs a poller until the test ends
+func startPoller(t testing.TB, handle deviceEvents) *evdevPoller {
+	poller, err := newEvdevPoller()
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		poller.run(handle)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		poller.stop()
+		<-done
+	})
+	return poller
+}
+
+// TestEvdevPollerWakeLatency tests that events are read as soon as they are
+// written, from every device of the one loop
+func TestEvdevPollerWakeLatency(t *testing.T) {
+	type batch struct {
+		device string
+		events []evdev.InputEvent
+	}
+	received := make(chan batch, 10)
+	poller := startPoller(t, func(handler *deviceHandler, events []evdev.InputEvent, err error) {
+		assert.NoError(t, err)
+		received <- batch{handler.name, append([]evdev.InputEvent(nil), events...)}
+	})
+
+	mouse, keyboard := newFakeDevice(t, "event0"), newFakeDevice(t, "event1")
+	require.NoError(t, poller.add(mouse.handler))
+	require.NoError(t, poller.add(keyboard.handler))
+
+	for _, device := range []*fakeDevice{mouse, keyboard, mouse} {
+		start := time.Now()
+		device.write(t,
+			evdev.InputEvent{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 3},
+			evdev.InputEvent{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+		)
+		select {
+		case got := <-received:
+			assert.Less(t, time.Since(start), 5*time.Millisecond, "Events read too late")
+			assert.Equal(t, device.handler.name, got.device)
+			require.Len(t, got.events, 2)
+			assert.Equal(t, int32(3), got.events[0].Value)
+		case <-time.After(time.Second):
+			t.Fatalf("Events from %s not read", device.handler.name)
+		}
+	}
+}
+
+// TestEvdevPollerRemove tests that a removed device is no longer read and that
+// a device going away is reported once
+func TestEvdevPollerRemove(t *testing.T) {
+	var reads, failures atomic.Int32
+	poller := startPoller(t, func(handler *deviceHandler, events []evdev.InputEvent, err error) {
+		if err != nil {
+			failures.Add(1)
+		} else {
+			reads.Add(1)
+		}
+	})
+
+	removed, unplugged := newFakeDevice(t, "event0"), newFakeDevice(t, "event1")
+	require.NoError(t, poller.add(removed.handler))
+	require.NoError(t, poller.add(unplugged.handler))
+
+	poller.remove(removed.handler)
+	poller.remove(removed.handler)
+	removed.write(t, evdev.InputEvent{Type: evdev.EV_KEY, Code: evdev.KEY_A, Value: 1})
+
+	// A pipe's writer closing hangs it up like an unplugged device
+	require.NoError(t, unplugged.w.Close())
+	require.Eventually(t, func() bool { return failures.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), failures.Load(), "Hang up reported more than once")
+	assert.Equal(t, int32(0), reads.Load(), "Removed device still read")
+}
+
+// TestEvdevPollerStop tests that stopping wakes an idle loop right away
+func TestEvdevPollerStop(t *testing.T) {
+	poller, err := newEvdevPoller()
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		poller.run(func(*deviceHandler, []evdev.InputEvent, error) {})
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	poller.stop()
+	select {
+	case <-done:
+		assert.Less(t, time.Since(start), 5*time.Millisecond, "Loop stopped too late")
+	case <-time.After(time.Second):
+		t.Fatal("Loop not stopped")
+	}
+	assert.Error(t, poller.add(newFakeDevice(t, "event0").handler), "Device added to a stopped poller")
+}
+
+// BenchmarkEvdevPollerWake benchmarks the time from a device having an event
+// to the event being handled
+func BenchmarkEvdevPollerWake(b *testing.B) {
+	received := make(chan struct{})
+	poller := startPoller(b, func(*deviceHandler, []evdev.InputEvent, error) {
+		received <- struct{}{}
+	})
+	device := newFakeDevice(b, "event0")
+	require.NoError(b, poller.add(device.handler))
+	event := evdev.InputEvent{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 1}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		device.write(b, event)
+		<-received
+	}
+}
+
+// BenchmarkEvdevPollerDevices benchmarks reading a burst of events spread over
+// many devices
+func BenchmarkEvdevPollerDevices(b *testing.B) {
+	const deviceCount = 16
+	var handled atomic.Int64
+	poller := startPoller(b, func(_ *deviceHandler, events []evdev.InputEvent, _ error) {
+		handled.Add(int64(len(events)))
+	})
+	devices := make([]*fakeDevice, deviceCount)
+	for i := range devices {
+		devices[i] = newFakeDevice(b, "event"+string(rune('a'+i)))
+		require.NoError(b, poller.add(devices[i].handler))
+	}
+	frame := []evdev.InputEvent{
+		{Type: evdev.EV_REL, Code: evdev.REL_X, Value: 1},
+		{Type: evdev.EV_REL, Code: evdev.REL_Y, Value: 1},
+		{Type: evdev.EV_SYN, Code: evdev.SYN_REPORT},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		devices[i%deviceCount].write(b, frame...)
+	}
+	want := int64(b.N * len(frame))
+	for handled.Load() < want {
+		time.Sleep(time.Millisecond)
+	}
+}
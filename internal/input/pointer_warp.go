@@ -0,0 +1,87 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bnema/libwldevices-go/virtual_pointer"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// PointerWarper moves the server's own pointer to a position, which the
+// relative motion of its evdev devices cannot do. It is a virtual pointer
+// sending absolute motion over the server's outputs.
+type PointerWarper struct {
+	mu      sync.Mutex
+	manager *virtual_pointer.VirtualPointerManager
+	pointer *virtual_pointer.VirtualPointer
+
+	// Bounding box of the outputs, the extent of absolute motion
+	layoutX, layoutY          int32
+	layoutWidth, layoutHeight uint32
+}
+
+// NewPointerWarper creates a virtual pointer over the given outputs
+func NewPointerWarper(ctx context.Context, monitors []*protocol.Monitor) (*PointerWarper, error) {
+	x, y, width, height, ok := outputExtent(monitors)
+	if !ok {
+		return nil, fmt.Errorf("no outputs to warp the pointer over")
+	}
+
+	manager, err := virtual_pointer.NewVirtualPointerManager(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create virtual pointer manager: %w", err)
+	}
+	pointer, err := manager.CreatePointer()
+	if err != nil {
+		_ = manager.Close()
+		return nil, fmt.Errorf("failed to create virtual pointer: %w", err)
+	}
+
+	return &PointerWarper{
+		manager:      manager,
+		pointer:      pointer,
+		layoutX:      x,
+		layoutY:      y,
+		layoutWidth:  width,
+		layoutHeight: height,
+	}, nil
+}
+
+// Warp moves the pointer to a position in output layout coordinates
+func (p *PointerWarper) Warp(x, y float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pointer == nil {
+		return fmt.Errorf("pointer warper closed")
+	}
+
+	relX := uint32(max(0, min(int64(x)-int64(p.layoutX), int64(p.layoutWidth)-1)))  //nolint:gosec // clamped to extent
+	relY := uint32(max(0, min(int64(y)-int64(p.layoutY), int64(p.layoutHeight)-1))) //nolint:gosec // clamped to extent
+	if err := p.pointer.MotionAbsolute(time.Now(), relX, relY, p.layoutWidth, p.layoutHeight); err != nil {
+		return fmt.Errorf("failed to warp pointer: %w", err)
+	}
+	if err := p.pointer.Frame(); err != nil {
+		return fmt.Errorf("failed to frame pointer warp: %w", err)
+	}
+	return nil
+}
+
+// Close removes the virtual pointer
+func (p *PointerWarper) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pointer == nil {
+		return nil
+	}
+	err := p.pointer.Close()
+	if closeErr := p.manager.Close(); err == nil {
+		err = closeErr
+	}
+	p.pointer, p.manager = nil, nil
+	return err
+}
@@ -0,0 +1,185 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/bnema/waymon/internal/logger"
+	evdev "github.com/gvalkov/golang-evdev"
+	"golang.org/x/sys/unix"
+)
+
+// eventSize is the size of the kernel's struct input_event
+const eventSize = int(unsafe.Sizeof(evdev.InputEvent{}))
+
+// pollBatch is how many input events are read from a device per wakeup
+const pollBatch = 64
+
+// deviceEvents handles the events read from a device in one wakeup. err is
+// set instead when the device can no longer be read, e.g. it was unplugged;
+// the device is then no longer polled.
+type deviceEvents func(handler *deviceHandler, events []evdev.InputEvent, err error)
+
+// evdevPoller waits on every captured device with a single epoll instance, so
+// events are read as soon as the kernel queues them and idle devices cost
+// nothing. An eventfd in the same set wakes the loop to stop it.
+type evdevPoller struct {
+	epfd   int
+	wakefd int
+
+	mu      sync.Mutex
+	devices map[int32]*deviceHandler // By file descriptor
+	closed  bool
+}
+
+// newEvdevPoller creates the epoll instance and the eventfd stopping it
+func newEvdevPoller() (*evdevPoller, error) {
+	epfd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create epoll instance: %w", err)
+	}
+	wakefd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		_ = unix.Close(epfd)
+		return nil, fmt.Errorf("failed to create eventfd: %w", err)
+	}
+	if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, wakefd, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: int32(wakefd)}); err != nil {
+		_ = unix.Close(wakefd)
+		_ = unix.Close(epfd)
+		return nil, fmt.Errorf("failed to watch eventfd: %w", err)
+	}
+
+	return &evdevPoller{
+		epfd:    epfd,
+		wakefd:  wakefd,
+		devices: make(map[int32]*deviceHandler),
+	}, nil
+}
+
+// add starts polling a device. The handler's fd must stay open until remove
+// returns.
+func (p *evdevPoller) add(handler *deviceHandler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return errors.New("poller stopped")
+	}
+	if err := unix.SetNonblock(handler.fd, true); err != nil {
+		return fmt.Errorf("failed to make %s non-blocking: %w", handler.path, err)
+	}
+	fd := int32(handler.fd) //nolint:gosec // file descriptors fit in 32 bits
+	if err := unix.EpollCtl(p.epfd, unix.EPOLL_CTL_ADD, handler.fd, &unix.EpollEvent{Events: unix.EPOLLIN, Fd: fd}); err != nil {
+		return fmt.Errorf("failed to poll %s: %w", handler.path, err)
+	}
+	p.devices[fd] = handler
+	return nil
+}
+
+// remove stops polling a device. Once it returns the loop no longer reads
+// the device's fd, which may then be closed.
+func (p *evdevPoller) remove(handler *deviceHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fd := int32(handler.fd) //nolint:gosec // file descriptors fit in 32 bits
+	if p.devices[fd] != handler {
+		return
+	}
+	delete(p.devices, fd)
+	if !p.closed {
+		_ = unix.EpollCtl(p.epfd, unix.EPOLL_CTL_DEL, handler.fd, nil)
+	}
+}
+
+// run reads devices as they become readable and hands their events over until
+// stop is called, then releases the epoll instance and the eventfd
+func (p *evdevPoller) run(handle deviceEvents) {
+	defer p.close()
+
+	ready := make([]unix.EpollEvent, 16)
+	buf := make([]evdev.InputEvent, pollBatch)
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), len(buf)*eventSize)
+
+	for {
+		n, err := unix.EpollWait(p.epfd, ready, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			logger.Errorf("Input device polling failed: %v", err)
+			return
+		}
+
+		for _, ev := range ready[:n] {
+			if int(ev.Fd) == p.wakefd {
+				return
+			}
+
+			handler, count, err := p.read(ev.Fd, raw)
+			switch {
+			case handler == nil:
+				// Removed since epoll reported it
+			case errors.Is(err, unix.EAGAIN):
+				// Nothing queued after all
+			case err != nil:
+				p.remove(handler)
+				handle(handler, nil, err)
+			case count > 0:
+				handle(handler, buf[:count], nil)
+			case ev.Events&(unix.EPOLLHUP|unix.EPOLLERR) != 0:
+				p.remove(handler)
+				handle(handler, nil, errors.New("device hung up"))
+			}
+		}
+	}
+}
+
+// read reads the events queued on a device. The lock keeps remove, and so
+// closing the fd, from happening during the read. Only one read is made per
+// wakeup: it cannot block even when the fd was switched back to blocking mode,
+// as evdev.InputDevice.Grab does through File.Fd.
+func (p *evdevPoller) read(fd int32, raw []byte) (*deviceHandler, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handler, exists := p.devices[fd]
+	if !exists {
+		return nil, 0, nil
+	}
+	n, err := unix.Read(int(fd), raw)
+	if err != nil {
+		return handler, 0, err
+	}
+	if n == 0 {
+		return handler, 0, errors.New("end of file")
+	}
+	return handler, n / eventSize, nil
+}
+
+// stop wakes the loop so that it returns
+func (p *evdevPoller) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	var one [8]byte
+	one[0] = 1 // Native endian counter, only its being non-zero matters
+	if _, err := unix.Write(p.wakefd, one[:]); err != nil {
+		logger.Errorf("Failed to wake input device polling: %v", err)
+	}
+}
+
+// close releases the epoll instance and the eventfd
+func (p *evdevPoller) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	_ = unix.Close(p.epfd)
+	_ = unix.Close(p.wakefd)
+}
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// PressedState tracks which keys and mouse buttons are held down on a target,
+// and which tablet tool is in proximity, so they can be released when input
+// stops flowing to it. Without this a key or button held while control moves
+// away stays pressed on the target, and a pen keeps drawing.
+type PressedState struct {
+	mu      sync.Mutex
+	keys    map[uint32]bool
+	buttons map[uint32]bool
+	tool    *protocol.TabletToolEvent // Last state of a tool in proximity
+}
+
+// NewPressedState creates an empty pressed state
+func NewPressedState() *PressedState {
+	return &PressedState{
+		keys:    make(map[uint32]bool),
+		buttons: make(map[uint32]bool),
+	}
+}
+
+// Observe records the key and button presses and releases and the tablet tool
+// states of an event or frame; other events are ignored
+func (p *PressedState) Observe(event *protocol.InputEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, event := range protocol.Unframe(event) {
+		switch e := event.Event.(type) {
+		case *protocol.InputEvent_Keyboard:
+			if e.Keyboard.Pressed {
+				p.keys[e.Keyboard.Key] = true
+			} else {
+				delete(p.keys, e.Keyboard.Key)
+			}
+		case *protocol.InputEvent_MouseButton:
+			if e.MouseButton.Pressed {
+				p.buttons[e.MouseButton.Button] = true
+			} else {
+				delete(p.buttons, e.MouseButton.Button)
+			}
+		case *protocol.InputEvent_TabletTool:
+			if e.TabletTool.Proximity {
+				p.tool = e.TabletTool
+			} else {
+				p.tool = nil
+			}
+		}
+	}
+}
+
+// Any reports whether anything is currently held down
+func (p *PressedState) Any() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys) > 0 || len(p.buttons) > 0 || p.tool != nil
+}
+
+// Release returns release events for everything held down and clears the state.
+// A tablet tool leaves proximity first, lifting its tip, and buttons are
+// released before keys so a modifier held during a drag still applies to the
+// drop.
+func (p *PressedState) Release(sourceID string) []*protocol.InputEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	events := make([]*protocol.InputEvent, 0, len(p.keys)+len(p.buttons)+1)
+	if p.tool != nil {
+		events = append(events, &protocol.InputEvent{
+			Event: &protocol.InputEvent_TabletTool{
+				TabletTool: &protocol.TabletToolEvent{Tool: p.tool.Tool, Proximity: false},
+			},
+			Timestamp: now,
+			SourceId:  sourceID,
+		})
+	}
+	for _, button := range sortedCodes(p.buttons) {
+		events = append(events, &protocol.InputEvent{
+			Event: &protocol.InputEvent_MouseButton{
+				MouseButton: &protocol.MouseButtonEvent{Button: button, Pressed: false},
+			},
+			Timestamp: now,
+			SourceId:  sourceID,
+		})
+	}
+	for _, key := range sortedCodes(p.keys) {
+		events = append(events, &protocol.InputEvent{
+			Event: &protocol.InputEvent_Keyboard{
+				Keyboard: &protocol.KeyboardEvent{Key: key, Pressed: false},
+			},
+			Timestamp: now,
+			SourceId:  sourceID,
+		})
+	}
+
+	clear(p.keys)
+	clear(p.buttons)
+	p.tool = nil
+	return events
+}
+
+// sortedCodes returns the codes of a set in ascending order
+func sortedCodes(set map[uint32]bool) []uint32 {
+	codes := make([]uint32, 0, len(set))
+	for code := range set {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
@@ -0,0 +1,87 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func keyEvent(key uint32, pressed bool) *protocol.InputEvent {
+	return &protocol.InputEvent{Event: &protocol.InputEvent_Keyboard{
+		Keyboard: &protocol.KeyboardEvent{Key: key, Pressed: pressed},
+	}}
+}
+
+func buttonEvent(button uint32, pressed bool) *protocol.InputEvent {
+	return &protocol.InputEvent{Event: &protocol.InputEvent_MouseButton{
+		MouseButton: &protocol.MouseButtonEvent{Button: button, Pressed: pressed},
+	}}
+}
+
+func TestPressedStateRelease(t *testing.T) {
+	p := NewPressedState()
+	assert.False(t, p.Any())
+
+	p.Observe(keyEvent(29, true))  // KEY_LEFTCTRL
+	p.Observe(keyEvent(30, true))  // KEY_A
+	p.Observe(keyEvent(30, false)) // released normally
+	p.Observe(buttonEvent(272, true))
+	p.Observe(&protocol.InputEvent{Event: &protocol.InputEvent_MouseMove{
+		MouseMove: &protocol.MouseMoveEvent{Dx: 5},
+	}})
+	assert.True(t, p.Any())
+
+	events := p.Release("server")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, uint32(272), events[0].GetMouseButton().Button)
+		assert.False(t, events[0].GetMouseButton().Pressed)
+		assert.Equal(t, uint32(29), events[1].GetKeyboard().Key)
+		assert.False(t, events[1].GetKeyboard().Pressed)
+		assert.Equal(t, "server", events[1].SourceId)
+	}
+
+	assert.False(t, p.Any())
+	assert.Empty(t, p.Release("server"))
+}
+
+func TestPressedStateFrame(t *testing.T) {
+	p := NewPressedState()
+	p.Observe(protocol.NewFrame([]*protocol.InputEvent{
+		{Event: &protocol.InputEvent_MouseMove{MouseMove: &protocol.MouseMoveEvent{Dx: 5}}},
+		buttonEvent(1, true),
+		buttonEvent(3, true),
+	}, 0, "server"))
+	p.Observe(buttonEvent(3, false))
+
+	events := p.Release("server")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, uint32(1), events[0].GetMouseButton().Button)
+	}
+}
+
+func TestPressedStateTablet(t *testing.T) {
+	tool := func(proximity, tip bool) *protocol.InputEvent {
+		return &protocol.InputEvent{Event: &protocol.InputEvent_TabletTool{
+			TabletTool: &protocol.TabletToolEvent{Tool: protocol.TabletTool_TABLET_TOOL_ERASER, Proximity: proximity, Tip: tip},
+		}}
+	}
+
+	// A tool that left proximity needs no release
+	p := NewPressedState()
+	p.Observe(tool(true, true))
+	p.Observe(tool(false, false))
+	assert.False(t, p.Any())
+
+	// One still drawing is taken out of proximity, before buttons and keys
+	p.Observe(tool(true, true))
+	p.Observe(keyEvent(29, true))
+	assert.True(t, p.Any())
+	events := p.Release("server")
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, &protocol.TabletToolEvent{Tool: protocol.TabletTool_TABLET_TOOL_ERASER}, events[0].GetTabletTool())
+		assert.Equal(t, "server", events[0].SourceId)
+		assert.Equal(t, uint32(29), events[1].GetKeyboard().Key)
+	}
+	assert.False(t, p.Any())
+}
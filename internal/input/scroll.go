@@ -0,0 +1,143 @@
+package input
+
+import (
+	"math"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// High-resolution wheel codes, reported in 120ths of a notch alongside
+// REL_WHEEL and REL_HWHEEL by wheels that support them
+const (
+	relWheelHiRes  = 0x0b
+	relHWheelHiRes = 0x0c
+)
+
+// notch is one wheel notch in value120 units
+const notch = 120
+
+// wheelStep is the scroll distance of one wheel notch, the value libinput
+// reports so that clients scroll as they do for a real mouse
+const wheelStep = 15.0
+
+// wheelFrame collects the wheel movement of one hardware frame
+type wheelFrame struct {
+	notches  [2]int32 // REL_HWHEEL, REL_WHEEL
+	hiRes    [2]int32 // REL_HWHEEL_HI_RES, REL_WHEEL_HI_RES
+	hasHiRes [2]bool
+}
+
+// add takes a relative event, reporting whether it was wheel movement
+func (w *wheelFrame) add(code uint16, value int32) bool {
+	switch code {
+	case evdev.REL_HWHEEL:
+		w.notches[0] += value
+	case evdev.REL_WHEEL:
+		w.notches[1] += value
+	case relHWheelHiRes:
+		w.hiRes[0] += value
+		w.hasHiRes[0] = true
+	case relWheelHiRes:
+		w.hiRes[1] += value
+		w.hasHiRes[1] = true
+	default:
+		return false
+	}
+	return true
+}
+
+// event returns the scroll event of the frame and resets it, nil if the wheel
+// did not move. A wheel reporting high-resolution movement also reports each
+// whole notch, which is then left out.
+func (w *wheelFrame) event() *protocol.InputEvent {
+	var v120 [2]int32
+	for axis := range v120 {
+		if w.hasHiRes[axis] {
+			v120[axis] = w.hiRes[axis]
+		} else {
+			v120[axis] = w.notches[axis] * notch
+		}
+	}
+	*w = wheelFrame{}
+	if v120 == [2]int32{} {
+		return nil
+	}
+
+	event := scrollEvent(float64(v120[0])/notch, float64(v120[1])/notch)
+	event.GetMouseScroll().Value120X = v120[0]
+	event.GetMouseScroll().Value120Y = v120[1]
+	return event
+}
+
+// scrollAxis is what is injected on one axis for a scroll event, in Wayland
+// terms: positive values scroll down and right
+type scrollAxis struct {
+	horizontal bool
+	value      float64
+	discrete   int32 // Whole notches completed by this movement
+	stop       bool
+}
+
+// scrollState converts received scroll events into what is injected. It keeps
+// the part of a notch high-resolution wheels moved beyond the last whole one.
+type scrollState struct {
+	speed     float64 // Multiplier of the scroll distance
+	natural   bool    // Content follows the wheel or fingers
+	remainder [2]int32
+}
+
+// axes returns what to inject for a scroll event
+func (s *scrollState) axes(scroll *protocol.MouseScrollEvent) []scrollAxis {
+	speed := s.speed
+	if speed <= 0 {
+		speed = 1
+	}
+	sign := [2]float64{1, -1} // evdev scrolls up on positive values, Wayland down
+	if s.natural {
+		sign = [2]float64{-1, 1}
+	}
+
+	var axes []scrollAxis
+	if scroll.Type != protocol.ScrollType_SCROLL_WHEEL {
+		for axis, delta := range [2]float64{scroll.Dx, scroll.Dy} {
+			if delta != 0 || scroll.Stop {
+				axes = append(axes, scrollAxis{
+					horizontal: axis == 0,
+					value:      delta * speed * sign[axis],
+					stop:       scroll.Stop,
+				})
+			}
+		}
+		return axes
+	}
+
+	// Senders without high-resolution data only fill in whole notches
+	v120 := [2]int32{scroll.Value120X, scroll.Value120Y}
+	for axis, delta := range [2]float64{scroll.Dx, scroll.Dy} {
+		if v120[axis] == 0 && delta != 0 {
+			v120[axis] = int32(math.Round(delta * notch))
+		}
+	}
+
+	for axis := range v120 {
+		moved := int32(math.Round(float64(v120[axis]) * speed * sign[axis]))
+		if moved == 0 {
+			continue
+		}
+		// A notch started in the other direction is abandoned
+		if (moved > 0) != (s.remainder[axis] > 0) {
+			s.remainder[axis] = 0
+		}
+		s.remainder[axis] += moved
+		discrete := s.remainder[axis] / notch
+		s.remainder[axis] -= discrete * notch
+
+		axes = append(axes, scrollAxis{
+			horizontal: axis == 0,
+			value:      float64(moved) / notch * wheelStep,
+			discrete:   discrete,
+		})
+	}
+	return axes
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWheelFrame(t *testing.T) {
+	var w wheelFrame
+	assert.Nil(t, w.event())
+
+	// A plain wheel only reports notches
+	assert.True(t, w.add(evdev.REL_WHEEL, -1))
+	assert.False(t, w.add(evdev.REL_X, 4))
+	scroll := w.event().GetMouseScroll()
+	assert.Equal(t, &protocol.MouseScrollEvent{Dy: -1, Value120Y: -120}, scroll)
+
+	// A high-resolution wheel reports the notch it completes as well
+	w.add(relWheelHiRes, 60)
+	w.add(evdev.REL_WHEEL, 1)
+	w.add(relHWheelHiRes, -30)
+	scroll = w.event().GetMouseScroll()
+	assert.Equal(t, &protocol.MouseScrollEvent{Dx: -0.25, Dy: 0.5, Value120X: -30, Value120Y: 60}, scroll)
+	assert.Nil(t, w.event())
+}
+
+func TestScrollStateWheel(t *testing.T) {
+	s := &scrollState{speed: 1}
+
+	// Half notches add up to a discrete step on the second one
+	axes := s.axes(&protocol.MouseScrollEvent{Dy: 0.5, Value120Y: 60})
+	require.Len(t, axes, 1)
+	assert.Equal(t, scrollAxis{value: -7.5}, axes[0])
+	axes = s.axes(&protocol.MouseScrollEvent{Dy: 0.5, Value120Y: 60})
+	assert.Equal(t, []scrollAxis{{value: -7.5, discrete: -1}}, axes)
+
+	// Reversing drops the notch in progress
+	s.axes(&protocol.MouseScrollEvent{Value120Y: 60})
+	axes = s.axes(&protocol.MouseScrollEvent{Value120Y: -60})
+	assert.Equal(t, []scrollAxis{{value: 7.5}}, axes)
+
+	// Senders without high-resolution data send notches only
+	axes = s.axes(&protocol.MouseScrollEvent{Dx: 1})
+	assert.Equal(t, []scrollAxis{{horizontal: true, value: 15, discrete: 1}}, axes)
+}
+
+func TestScrollStateOptions(t *testing.T) {
+	s := &scrollState{speed: 2, natural: true}
+	axes := s.axes(&protocol.MouseScrollEvent{Dy: 1, Value120Y: 120})
+	assert.Equal(t, []scrollAxis{{value: 30, discrete: 2}}, axes)
+
+	axes = s.axes(&protocol.MouseScrollEvent{Type: protocol.ScrollType_SCROLL_FINGER, Dx: 3})
+	assert.Equal(t, []scrollAxis{{horizontal: true, value: -6}}, axes)
+
+	axes = s.axes(&protocol.MouseScrollEvent{Type: protocol.ScrollType_SCROLL_FINGER, Stop: true})
+	assert.Equal(t, []scrollAxis{{horizontal: true, stop: true}, {stop: true}}, axes)
+}
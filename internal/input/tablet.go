@@ -0,0 +1,173 @@
+package input
+
+import (
+	"math"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// btnStylus3 is the third stylus button, missing from the evdev package
+const btnStylus3 = 0x149
+
+// tabletAxes are the ranges of the axes of a tablet. Axes the tablet lacks
+// have an empty range.
+type tabletAxes struct {
+	x, y, pressure, tiltX, tiltY absInfo
+}
+
+// toolState is the state of a tablet tool, positions and pressure from 0 to 1
+// and tilt in degrees
+type toolState struct {
+	tool         protocol.TabletTool
+	proximity    bool
+	tip          bool
+	buttons      uint32
+	x, y         float64
+	pressure     float64
+	tiltX, tiltY float64
+}
+
+// tablet follows the tool of a graphics tablet and reports its state in
+// each frame that changed it. It only sees one device and is only used by the
+// poller.
+type tablet struct {
+	axes       tabletAxes
+	state      toolState
+	changed    bool
+	wasInRange bool // The last report had the tool in proximity
+}
+
+// openTablet reads the axes of a tablet device
+func openTablet(device *evdev.InputDevice) (*tablet, error) {
+	var axes tabletAxes
+	var err error
+	if axes.x, err = readAbsInfo(device, evdev.ABS_X); err != nil {
+		return nil, err
+	}
+	if axes.y, err = readAbsInfo(device, evdev.ABS_Y); err != nil {
+		return nil, err
+	}
+
+	// Pressure and tilt are optional
+	axes.pressure, _ = readAbsInfo(device, evdev.ABS_PRESSURE)
+	axes.tiltX, _ = readAbsInfo(device, evdev.ABS_TILT_X)
+	axes.tiltY, _ = readAbsInfo(device, evdev.ABS_TILT_Y)
+	return &tablet{axes: axes}, nil
+}
+
+// abs takes an axis event
+func (t *tablet) abs(code uint16, value int32) {
+	switch code {
+	case evdev.ABS_X:
+		t.state.x = normalizeAbs(t.axes.x, value)
+	case evdev.ABS_Y:
+		t.state.y = normalizeAbs(t.axes.y, value)
+	case evdev.ABS_PRESSURE:
+		t.state.pressure = normalizeAbs(t.axes.pressure, value)
+	case evdev.ABS_TILT_X:
+		t.state.tiltX = tiltDegrees(t.axes.tiltX, value)
+	case evdev.ABS_TILT_Y:
+		t.state.tiltY = tiltDegrees(t.axes.tiltY, value)
+	default:
+		return
+	}
+	t.changed = true
+}
+
+// key takes a key event, reporting whether it belonged to the tool
+func (t *tablet) key(code uint16, value int32) bool {
+	var button uint32
+	switch code {
+	case evdev.BTN_TOOL_PEN, evdev.BTN_TOOL_BRUSH, evdev.BTN_TOOL_PENCIL, evdev.BTN_TOOL_AIRBRUSH:
+		t.state.proximity = value != 0
+		t.state.tool = protocol.TabletTool_TABLET_TOOL_PEN
+	case evdev.BTN_TOOL_RUBBER:
+		t.state.proximity = value != 0
+		t.state.tool = protocol.TabletTool_TABLET_TOOL_ERASER
+	case evdev.BTN_TOUCH:
+		t.state.tip = value != 0
+	case evdev.BTN_STYLUS:
+		button = 1
+	case evdev.BTN_STYLUS2:
+		button = 2
+	case btnStylus3:
+		button = 4
+	default:
+		return false
+	}
+
+	if value != 0 {
+		t.state.buttons |= button
+	} else {
+		t.state.buttons &^= button
+	}
+	t.changed = true
+	return true
+}
+
+// report ends a hardware frame and returns the tool state, nil if nothing
+// changed or the tool stayed out of range
+func (t *tablet) report(at time.Time, source string) *protocol.InputEvent {
+	changed := t.changed
+	t.changed = false
+	if !changed || (!t.state.proximity && !t.wasInRange) {
+		return nil
+	}
+	t.wasInRange = t.state.proximity
+
+	event := &protocol.TabletToolEvent{Tool: t.state.tool}
+	if t.state.proximity {
+		event = &protocol.TabletToolEvent{
+			Tool:      t.state.tool,
+			Proximity: true,
+			Tip:       t.state.tip,
+			X:         t.state.x,
+			Y:         t.state.y,
+			Pressure:  t.state.pressure,
+			TiltX:     t.state.tiltX,
+			TiltY:     t.state.tiltY,
+			Buttons:   t.state.buttons,
+		}
+	}
+	return &protocol.InputEvent{
+		Event:     &protocol.InputEvent_TabletTool{TabletTool: event},
+		Timestamp: at.UnixNano(),
+		SourceId:  source,
+	}
+}
+
+// normalizeAbs maps an axis value onto 0 to 1
+func normalizeAbs(info absInfo, value int32) float64 {
+	if info.maximum <= info.minimum {
+		return 0
+	}
+	return max(0, min(1, float64(value-info.minimum)/float64(info.maximum-info.minimum)))
+}
+
+// tiltDegrees converts a tilt axis value to degrees. The resolution is in
+// units per radian; tablets without one report degrees.
+func tiltDegrees(info absInfo, value int32) float64 {
+	degrees := float64(value)
+	if info.resolution > 0 {
+		degrees = float64(value) / float64(info.resolution) * 180 / math.Pi
+	}
+	return max(-90, min(90, degrees))
+}
+
+// isTablet reports whether a device is a graphics tablet or a pen display
+func isTablet(device *evdev.InputDevice) bool {
+	var position, pen bool
+	for capType, caps := range device.Capabilities {
+		for _, c := range caps {
+			switch {
+			case capType.Type == evdev.EV_ABS && c.Code == evdev.ABS_X:
+				position = true
+			case capType.Type == evdev.EV_KEY && c.Code == evdev.BTN_TOOL_PEN:
+				pen = true
+			}
+		}
+	}
+	return position && pen
+}
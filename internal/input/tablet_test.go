@@ -0,0 +1,87 @@
+package input
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabletReport(t *testing.T) {
+	pen := &tablet{axes: tabletAxes{
+		x:        absInfo{maximum: 1000},
+		y:        absInfo{maximum: 500},
+		pressure: absInfo{maximum: 2048},
+		tiltX:    absInfo{minimum: -64, maximum: 63},
+	}}
+	at := time.Unix(0, 0)
+
+	// Nothing is reported before the pen comes in range
+	pen.abs(evdev.ABS_X, 100)
+	assert.Nil(t, pen.report(at, "pen"))
+
+	assert.True(t, pen.key(evdev.BTN_TOOL_PEN, 1))
+	assert.True(t, pen.key(evdev.BTN_TOUCH, 1))
+	assert.True(t, pen.key(evdev.BTN_STYLUS2, 1))
+	assert.False(t, pen.key(evdev.BTN_LEFT, 1))
+	pen.abs(evdev.ABS_X, 250)
+	pen.abs(evdev.ABS_Y, 500)
+	pen.abs(evdev.ABS_PRESSURE, 1024)
+	pen.abs(evdev.ABS_TILT_X, -30)
+	event := pen.report(at, "pen")
+	require.NotNil(t, event)
+	assert.Equal(t, "pen", event.SourceId)
+	tool := event.GetTabletTool()
+	assert.Equal(t, protocol.TabletTool_TABLET_TOOL_PEN, tool.Tool)
+	assert.True(t, tool.Proximity)
+	assert.True(t, tool.Tip)
+	assert.Equal(t, uint32(2), tool.Buttons)
+	assert.InDelta(t, 0.25, tool.X, 1e-9)
+	assert.InDelta(t, 1, tool.Y, 1e-9)
+	assert.InDelta(t, 0.5, tool.Pressure, 1e-9)
+	assert.InDelta(t, -30, tool.TiltX, 1e-9)
+
+	// Unchanged frames are not reported
+	assert.Nil(t, pen.report(at, "pen"))
+
+	// Leaving proximity is reported once
+	pen.key(evdev.BTN_TOUCH, 0)
+	pen.key(evdev.BTN_TOOL_PEN, 0)
+	event = pen.report(at, "pen")
+	require.NotNil(t, event)
+	assert.Equal(t, &protocol.TabletToolEvent{Tool: protocol.TabletTool_TABLET_TOOL_PEN}, event.GetTabletTool())
+	pen.abs(evdev.ABS_X, 300)
+	assert.Nil(t, pen.report(at, "pen"))
+
+	// The eraser end is its own tool
+	pen.key(evdev.BTN_TOOL_RUBBER, 1)
+	assert.Equal(t, protocol.TabletTool_TABLET_TOOL_ERASER, pen.report(at, "pen").GetTabletTool().Tool)
+}
+
+func TestTiltDegrees(t *testing.T) {
+	assert.Equal(t, 45.0, tiltDegrees(absInfo{minimum: -90, maximum: 90}, 45))
+	assert.InDelta(t, 30, tiltDegrees(absInfo{resolution: 1000}, int32(math.Round(1000*math.Pi/6))), 0.1)
+	assert.Equal(t, -90.0, tiltDegrees(absInfo{}, -127))
+}
+
+func TestTabletPosition(t *testing.T) {
+	left := &protocol.Monitor{Name: "DP-1", X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true}
+	right := &protocol.Monitor{Name: "DP-2", X: 1920, Y: 0, Width: 1920, Height: 1080}
+	monitors := []*protocol.Monitor{right, left}
+
+	assert.Same(t, left, tabletMonitor(monitors, ""))
+	assert.Same(t, right, tabletMonitor(monitors, "DP-2"))
+	assert.Same(t, left, tabletMonitor(monitors, "HDMI-A-1"))
+	assert.Nil(t, tabletMonitor(nil, ""))
+
+	// The tablet spans the layout, so the right monitor is its right half
+	x, y := tabletPosition(&protocol.TabletToolEvent{X: 0, Y: 1}, right, 0, 0, 3840, 1080)
+	assert.Equal(t, int32(math.Round(tabletRange/2.0)), x)
+	assert.Equal(t, int32(tabletRange), y)
+	x, _ = tabletPosition(&protocol.TabletToolEvent{X: 1}, right, 0, 0, 3840, 1080)
+	assert.Equal(t, int32(tabletRange), x)
+}
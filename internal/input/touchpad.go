@@ -0,0 +1,404 @@
+package input
+
+import (
+	"math"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+// inputPropDirect is the input property of devices whose touches map to the
+// screen, such as touchscreens
+const inputPropDirect = 0x01
+
+// Touchpad behaviour, distances in millimetres of finger travel
+const (
+	maxTouchSlots    = 10
+	touchpadScale    = 4.0 // Pointer units per millimetre before acceleration
+	tapTimeout       = 180 * time.Millisecond
+	tapMotion        = 1.5 // Distance a finger may move and still tap
+	gestureThreshold = 2.0 // Travel or change of spread that starts a swipe or pinch
+	touchpadWidth    = 100 // Assumed width of touchpads not reporting a resolution
+)
+
+// touchMode is what the fingers on a touchpad currently do
+type touchMode int
+
+const (
+	touchNone    touchMode = iota // No finger, or a gesture not recognised yet
+	touchPointer                  // One finger moves the pointer
+	touchScroll                   // Two fingers scroll
+	touchGesture                  // Three or four fingers swipe or pinch
+	touchIgnore                   // Fingers lifted one by one, ignored until the last is up
+)
+
+// touchSlot is one tracked contact of a multitouch touchpad
+type touchSlot struct {
+	active         bool
+	fresh          bool // Landed in the frame being read
+	x, y           int32
+	startX, startY int32
+}
+
+// touchpad turns the multitouch stream of a touchpad into pointer motion,
+// finger scrolling, taps and gestures. It only sees one device and is only
+// used by the poller.
+type touchpad struct {
+	resX, resY float64 // Units per millimetre
+	speed      float64 // Multiplier of pointer motion and scrolling
+	tap        bool    // Tapping clicks
+
+	slots [maxTouchSlots]touchSlot
+	slot  int
+	tools int  // Fingers counted by BTN_TOOL_*, touchpads may track fewer slots
+	stale bool // Events were dropped, the next frame only sets the reference
+
+	// Touch sequence, from the first finger down to the last one up
+	mode         touchMode
+	fingers      int
+	maxFingers   int
+	moved        bool // A finger went too far for a tap
+	touchStart   time.Time
+	lastX, lastY float64 // Center of the fingers in the previous frame
+	lastTime     time.Time
+	startSpread  float64 // Spread of the fingers when their number last changed
+	travelX      float64 // Travel before a gesture was recognised
+	travelY      float64
+	gesture      protocol.GestureType
+}
+
+// newTouchpad creates the state of a touchpad with the given resolutions
+func newTouchpad(resX, resY, speed float64, tap bool) *touchpad {
+	return &touchpad{resX: resX, resY: resY, speed: speed, tap: tap}
+}
+
+// openTouchpad reads the geometry of a touchpad device
+func openTouchpad(device *evdev.InputDevice, speed float64, tap bool) (*touchpad, error) {
+	var res [2]float64
+	for i, code := range []uint16{evdev.ABS_MT_POSITION_X, evdev.ABS_MT_POSITION_Y} {
+		info, err := readAbsInfo(device, code)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = float64(info.resolution)
+		if res[i] <= 0 {
+			res[i] = max(1, float64(info.maximum-info.minimum)/touchpadWidth)
+		}
+	}
+	return newTouchpad(res[0], res[1], speed, tap), nil
+}
+
+// abs takes a multitouch axis event
+func (t *touchpad) abs(code uint16, value int32) {
+	if code == evdev.ABS_MT_SLOT {
+		t.slot = int(value)
+		return
+	}
+	if t.slot < 0 || t.slot >= maxTouchSlots {
+		return
+	}
+
+	slot := &t.slots[t.slot]
+	switch code {
+	case evdev.ABS_MT_TRACKING_ID:
+		slot.active = value >= 0
+		slot.fresh = slot.active
+	case evdev.ABS_MT_POSITION_X:
+		slot.x = value
+	case evdev.ABS_MT_POSITION_Y:
+		slot.y = value
+	}
+}
+
+// key takes a key event, reporting whether it was a touch event of the
+// touchpad rather than a button
+func (t *touchpad) key(code uint16, value int32) bool {
+	fingers := 0
+	switch code {
+	case evdev.BTN_TOUCH:
+		return true
+	case evdev.BTN_TOOL_FINGER:
+		fingers = 1
+	case evdev.BTN_TOOL_DOUBLETAP:
+		fingers = 2
+	case evdev.BTN_TOOL_TRIPLETAP:
+		fingers = 3
+	case evdev.BTN_TOOL_QUADTAP:
+		fingers = 4
+	case evdev.BTN_TOOL_QUINTTAP:
+		fingers = 5
+	default:
+		return false
+	}
+
+	// The tool being released may come after the next one is pressed
+	if value != 0 {
+		t.tools = fingers
+	} else if t.tools == fingers {
+		t.tools = 0
+	}
+	return true
+}
+
+// dropped makes the next frame start over from the positions it reports
+func (t *touchpad) dropped() {
+	t.stale = true
+}
+
+// report ends a hardware frame and returns the events it produces
+func (t *touchpad) report(at time.Time, source string) []*protocol.InputEvent {
+	fingers, x, y, spread := t.contacts()
+
+	if t.stale || fingers != t.fingers {
+		var events []*protocol.InputEvent
+		if fingers != t.fingers {
+			events = t.changeFingers(fingers, at, source)
+		}
+		t.stale = false
+		t.lastX, t.lastY, t.lastTime = x, y, at
+		t.startSpread, t.travelX, t.travelY = spread, 0, 0
+		return events
+	}
+	if fingers == 0 || t.mode == touchIgnore {
+		return nil
+	}
+
+	dx, dy := x-t.lastX, y-t.lastY
+	elapsed := at.Sub(t.lastTime)
+	t.lastX, t.lastY, t.lastTime = x, y, at
+
+	switch fingers {
+	case 1:
+		if dx == 0 && dy == 0 {
+			return nil
+		}
+		t.mode = touchPointer
+		factor := t.speed
+		if elapsed > 0 {
+			factor *= touchpadAccel(math.Hypot(dx, dy) / elapsed.Seconds())
+		}
+		return []*protocol.InputEvent{{
+			Event: &protocol.InputEvent_MouseMove{
+				MouseMove: &protocol.MouseMoveEvent{
+					Dx: dx * touchpadScale * factor,
+					Dy: dy * touchpadScale * factor,
+				},
+			},
+			Timestamp: at.UnixNano(),
+			SourceId:  source,
+		}}
+	case 2:
+		if dx == 0 && dy == 0 {
+			return nil
+		}
+		t.mode = touchScroll
+		// Fingers moving down scroll down, which evdev reports as negative
+		return []*protocol.InputEvent{fingerScrollEvent(dx*touchpadScale*t.speed, -dy*touchpadScale*t.speed, false, source)}
+	case 3, 4:
+		return t.gestureEvents(dx, dy, spread, at, source)
+	}
+	return nil
+}
+
+// gestureEvents follows three or four fingers moving together, telling a
+// swipe from a pinch once they travelled far enough
+func (t *touchpad) gestureEvents(dx, dy, spread float64, at time.Time, source string) []*protocol.InputEvent {
+	var events []*protocol.InputEvent
+	if t.mode != touchGesture {
+		t.travelX += dx
+		t.travelY += dy
+		swipe := math.Hypot(t.travelX, t.travelY)
+		pinch := math.Abs(spread - t.startSpread)
+		if max(swipe, pinch) < gestureThreshold {
+			return nil
+		}
+
+		t.mode = touchGesture
+		t.gesture = protocol.GestureType_GESTURE_SWIPE
+		if pinch > swipe {
+			t.gesture = protocol.GestureType_GESTURE_PINCH
+		}
+		events = append(events, t.gestureEvent(protocol.GesturePhase_GESTURE_BEGIN, at, source))
+		dx, dy = t.travelX, t.travelY
+	}
+
+	update := t.gestureEvent(protocol.GesturePhase_GESTURE_UPDATE, at, source)
+	update.GetGesture().Dx = dx * touchpadScale * t.speed
+	update.GetGesture().Dy = dy * touchpadScale * t.speed
+	if t.startSpread > 0 {
+		update.GetGesture().Scale = spread / t.startSpread
+	}
+	return append(events, update)
+}
+
+// changeFingers ends what the previous fingers did when a finger lands or
+// lifts, and clicks once the last finger of a tap is up
+func (t *touchpad) changeFingers(fingers int, at time.Time, source string) []*protocol.InputEvent {
+	var events []*protocol.InputEvent
+	switch t.mode {
+	case touchScroll:
+		events = append(events, fingerScrollEvent(0, 0, true, source))
+	case touchGesture:
+		end := t.gestureEvent(protocol.GesturePhase_GESTURE_END, at, source)
+		end.GetGesture().Cancelled = fingers > t.fingers
+		events = append(events, end)
+	}
+
+	switch {
+	case t.fingers == 0:
+		t.touchStart, t.maxFingers, t.moved = at, 0, false
+		t.mode = touchNone
+	case fingers == 0:
+		events = append(events, t.tapEvents(at, source)...)
+		t.mode = touchNone
+	case fingers < t.fingers:
+		t.mode = touchIgnore
+	case t.mode != touchIgnore:
+		t.mode = touchNone
+	}
+	t.fingers = fingers
+	t.maxFingers = max(t.maxFingers, fingers)
+	return events
+}
+
+// tapEvents returns the click of a tap that just ended: one finger clicks the
+// left button, two the right one and three the middle one
+func (t *touchpad) tapEvents(at time.Time, source string) []*protocol.InputEvent {
+	if !t.tap || t.moved || at.Sub(t.touchStart) > tapTimeout {
+		return nil
+	}
+
+	var button uint16
+	switch t.maxFingers {
+	case 1:
+		button = evdev.BTN_LEFT
+	case 2:
+		button = evdev.BTN_RIGHT
+	case 3:
+		button = evdev.BTN_MIDDLE
+	default:
+		return nil
+	}
+	press, release := mouseButtonEvent(button, 1), mouseButtonEvent(button, 0)
+	press.SourceId, release.SourceId = source, source
+	return []*protocol.InputEvent{press, release}
+}
+
+// gestureEvent creates a gesture event of the fingers down
+func (t *touchpad) gestureEvent(phase protocol.GesturePhase, at time.Time, source string) *protocol.InputEvent {
+	return &protocol.InputEvent{
+		Event: &protocol.InputEvent_Gesture{
+			Gesture: &protocol.GestureEvent{
+				Type:    t.gesture,
+				Phase:   phase,
+				Fingers: uint32(t.fingers), //nolint:gosec // at most five fingers
+				Scale:   1,
+			},
+		},
+		Timestamp: at.UnixNano(),
+		SourceId:  source,
+	}
+}
+
+// fingerScrollEvent creates a scroll event of two fingers, stop ending the
+// scroll when they lift
+func fingerScrollEvent(dx, dy float64, stop bool, source string) *protocol.InputEvent {
+	event := scrollEvent(dx, dy)
+	event.GetMouseScroll().Type = protocol.ScrollType_SCROLL_FINGER
+	event.GetMouseScroll().Stop = stop
+	event.SourceId = source
+	return event
+}
+
+// contacts returns the number of fingers down, their center and their mean
+// distance from it in millimetres. Fresh contacts start their tap distance
+// here, and contacts that moved too far rule out a tap.
+func (t *touchpad) contacts() (fingers int, x, y, spread float64) {
+	var points [][2]float64
+	for i := range t.slots {
+		slot := &t.slots[i]
+		if !slot.active {
+			continue
+		}
+		if slot.fresh {
+			slot.startX, slot.startY, slot.fresh = slot.x, slot.y, false
+		}
+		if math.Hypot(float64(slot.x-slot.startX)/t.resX, float64(slot.y-slot.startY)/t.resY) > tapMotion {
+			t.moved = true
+		}
+
+		point := [2]float64{float64(slot.x) / t.resX, float64(slot.y) / t.resY}
+		points = append(points, point)
+		x += point[0]
+		y += point[1]
+	}
+	if len(points) == 0 {
+		return t.tools, 0, 0, 0
+	}
+
+	x /= float64(len(points))
+	y /= float64(len(points))
+	for _, point := range points {
+		spread += math.Hypot(point[0]-x, point[1]-y)
+	}
+	return max(len(points), t.tools), x, y, spread / float64(len(points))
+}
+
+// touchpadAccel returns the factor finger travel is multiplied by at a speed
+// in millimetres per second: slow movements stay precise and quick ones cross
+// the screen
+func touchpadAccel(speed float64) float64 {
+	const (
+		threshold = 30.0 // Speed below which motion is not accelerated
+		ramp      = 0.02 // Factor gained per mm/s above the threshold
+		maxFactor = 3.0
+	)
+	if math.IsNaN(speed) || speed <= threshold {
+		return 1
+	}
+	return min(maxFactor, 1+(speed-threshold)*ramp)
+}
+
+// absInfo is the kernel's struct input_absinfo
+type absInfo struct {
+	value, minimum, maximum, fuzz, flat, resolution int32
+}
+
+// readAbsInfo returns the range and resolution of an absolute axis
+func readAbsInfo(device *evdev.InputDevice, code uint16) (absInfo, error) {
+	var info absInfo
+	request := uintptr(2<<30 | int(unsafe.Sizeof(info))<<16 | 'E'<<8 | (0x40 + int(code))) // EVIOCGABS(code)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, device.File.Fd(), request, uintptr(unsafe.Pointer(&info))); errno != 0 {
+		return absInfo{}, errno
+	}
+	return info, nil
+}
+
+// isTouchpad reports whether a device is a multitouch touchpad rather than a
+// touchscreen
+func isTouchpad(device *evdev.InputDevice) bool {
+	var multitouch, finger bool
+	for capType, caps := range device.Capabilities {
+		for _, c := range caps {
+			switch {
+			case capType.Type == evdev.EV_ABS && c.Code == evdev.ABS_MT_POSITION_X:
+				multitouch = true
+			case capType.Type == evdev.EV_KEY && c.Code == evdev.BTN_TOOL_FINGER:
+				finger = true
+			}
+		}
+	}
+	if !multitouch || !finger {
+		return false
+	}
+
+	var props [4]byte
+	request := uintptr(2<<30 | len(props)<<16 | 'E'<<8 | 0x09) // EVIOCGPROP(len)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, device.File.Fd(), request, uintptr(unsafe.Pointer(&props[0]))); errno != 0 {
+		return true
+	}
+	return props[0]&(1<<inputPropDirect) == 0
+}
@@ -0,0 +1,156 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// touchFrame reports the fingers down, by slot, in units of 0.1 mm
+func touchFrame(pad *touchpad, at time.Time, fingers map[int][2]int32) []*protocol.InputEvent {
+	for slot := range 5 {
+		position, down := fingers[slot]
+		pad.abs(evdev.ABS_MT_SLOT, int32(slot))
+		switch {
+		case down && !pad.slots[slot].active:
+			pad.abs(evdev.ABS_MT_TRACKING_ID, int32(100+slot))
+		case !down && pad.slots[slot].active:
+			pad.abs(evdev.ABS_MT_TRACKING_ID, -1)
+		}
+		if down {
+			pad.abs(evdev.ABS_MT_POSITION_X, position[0])
+			pad.abs(evdev.ABS_MT_POSITION_Y, position[1])
+		}
+	}
+	return pad.report(at, "pad")
+}
+
+func TestTouchpadPointer(t *testing.T) {
+	pad := newTouchpad(10, 10, 1, true)
+	start := time.Unix(0, 0)
+
+	assert.Empty(t, touchFrame(pad, start, map[int][2]int32{0: {100, 100}}))
+
+	// Slow movement is not accelerated
+	events := touchFrame(pad, start.Add(time.Second), map[int][2]int32{0: {110, 100}})
+	require.Len(t, events, 1)
+	assert.InDelta(t, touchpadScale, events[0].GetMouseMove().Dx, 1e-9)
+	assert.Equal(t, "pad", events[0].SourceId)
+
+	// The same distance moved quickly goes further
+	events = touchFrame(pad, start.Add(time.Second+5*time.Millisecond), map[int][2]int32{0: {120, 100}})
+	require.Len(t, events, 1)
+	assert.InDelta(t, 3*touchpadScale, events[0].GetMouseMove().Dx, 1e-9)
+
+	// The finger moved too far for a tap
+	assert.Empty(t, touchFrame(pad, start.Add(2*time.Second), nil))
+}
+
+func TestTouchpadTap(t *testing.T) {
+	for fingers, button := range map[int]uint32{1: 1, 2: 2, 3: 3} {
+		pad := newTouchpad(10, 10, 1, true)
+		start := time.Unix(0, 0)
+
+		down := make(map[int][2]int32)
+		for slot := range fingers {
+			down[slot] = [2]int32{100 + int32(slot)*100, 100}
+		}
+		touchFrame(pad, start, down)
+		events := touchFrame(pad, start.Add(100*time.Millisecond), nil)
+		require.Len(t, events, 2, "%d fingers", fingers)
+		assert.Equal(t, &protocol.MouseButtonEvent{Button: button, Pressed: true}, events[0].GetMouseButton())
+		assert.Equal(t, &protocol.MouseButtonEvent{Button: button}, events[1].GetMouseButton())
+	}
+
+	// A long touch does not click, nor does any touch with tapping disabled
+	pad := newTouchpad(10, 10, 1, true)
+	touchFrame(pad, time.Unix(0, 0), map[int][2]int32{0: {100, 100}})
+	assert.Empty(t, touchFrame(pad, time.Unix(1, 0), nil))
+	pad = newTouchpad(10, 10, 1, false)
+	touchFrame(pad, time.Unix(0, 0), map[int][2]int32{0: {100, 100}})
+	assert.Empty(t, touchFrame(pad, time.Unix(0, 0).Add(50*time.Millisecond), nil))
+}
+
+func TestTouchpadScroll(t *testing.T) {
+	pad := newTouchpad(10, 10, 1, true)
+	start := time.Unix(0, 0)
+
+	touchFrame(pad, start, map[int][2]int32{0: {100, 100}})
+	assert.Empty(t, touchFrame(pad, start.Add(10*time.Millisecond), map[int][2]int32{0: {100, 100}, 1: {300, 100}}))
+
+	// Fingers moving down scroll down
+	events := touchFrame(pad, start.Add(20*time.Millisecond), map[int][2]int32{0: {100, 120}, 1: {300, 120}})
+	require.Len(t, events, 1)
+	scroll := events[0].GetMouseScroll()
+	assert.Equal(t, protocol.ScrollType_SCROLL_FINGER, scroll.Type)
+	assert.InDelta(t, -2*touchpadScale, scroll.Dy, 1e-9)
+
+	// Lifting a finger stops the scroll, the other one no longer moves the pointer
+	events = touchFrame(pad, start.Add(30*time.Millisecond), map[int][2]int32{0: {100, 120}})
+	require.Len(t, events, 1)
+	assert.True(t, events[0].GetMouseScroll().Stop)
+	assert.Empty(t, touchFrame(pad, start.Add(40*time.Millisecond), map[int][2]int32{0: {100, 160}}))
+}
+
+func TestTouchpadGestures(t *testing.T) {
+	start := time.Unix(0, 0)
+	three := func(dx, dy, spread int32) map[int][2]int32 {
+		return map[int][2]int32{0: {400 + dx - spread, 400 + dy}, 1: {400 + dx + spread, 400 + dy}, 2: {400 + dx, 400 + dy + spread}}
+	}
+
+	// A swipe begins once the fingers travelled far enough
+	pad := newTouchpad(10, 10, 1, true)
+	touchFrame(pad, start, three(0, 0, 100))
+	assert.Empty(t, touchFrame(pad, start.Add(10*time.Millisecond), three(-10, 0, 100)))
+	events := touchFrame(pad, start.Add(20*time.Millisecond), three(-30, 0, 100))
+	require.Len(t, events, 2)
+	assert.Equal(t, protocol.GesturePhase_GESTURE_BEGIN, events[0].GetGesture().Phase)
+	assert.Equal(t, protocol.GestureType_GESTURE_SWIPE, events[0].GetGesture().Type)
+	assert.Equal(t, uint32(3), events[0].GetGesture().Fingers)
+	assert.InDelta(t, -3*touchpadScale, events[1].GetGesture().Dx, 1e-9)
+
+	// Lifting the fingers ends it
+	events = touchFrame(pad, start.Add(30*time.Millisecond), nil)
+	require.Len(t, events, 1)
+	assert.Equal(t, protocol.GesturePhase_GESTURE_END, events[0].GetGesture().Phase)
+	assert.False(t, events[0].GetGesture().Cancelled)
+
+	// Spreading the fingers is a pinch, cancelled by a fourth finger
+	pad = newTouchpad(10, 10, 1, true)
+	touchFrame(pad, start, three(0, 0, 100))
+	events = touchFrame(pad, start.Add(10*time.Millisecond), three(0, 0, 150))
+	require.Len(t, events, 2)
+	assert.Equal(t, protocol.GestureType_GESTURE_PINCH, events[0].GetGesture().Type)
+	assert.InDelta(t, 1.5, events[1].GetGesture().Scale, 1e-9)
+	four := three(0, 0, 150)
+	four[3] = [2]int32{600, 600}
+	events = touchFrame(pad, start.Add(20*time.Millisecond), four)
+	require.Len(t, events, 1)
+	assert.True(t, events[0].GetGesture().Cancelled)
+}
+
+func TestTouchpadToolFingers(t *testing.T) {
+	pad := newTouchpad(10, 10, 1, true)
+
+	// Touchpads tracking two slots count further fingers with BTN_TOOL_*
+	assert.True(t, pad.key(evdev.BTN_TOUCH, 1))
+	assert.True(t, pad.key(evdev.BTN_TOOL_TRIPLETAP, 1))
+	assert.True(t, pad.key(evdev.BTN_TOOL_DOUBLETAP, 0))
+	assert.False(t, pad.key(evdev.BTN_LEFT, 1))
+	fingers, _, _, _ := pad.contacts()
+	assert.Equal(t, 3, fingers)
+
+	pad.key(evdev.BTN_TOOL_TRIPLETAP, 0)
+	fingers, _, _, _ = pad.contacts()
+	assert.Equal(t, 0, fingers)
+}
+
+func TestTouchpadAccel(t *testing.T) {
+	assert.Equal(t, 1.0, touchpadAccel(10))
+	assert.InDelta(t, 2.0, touchpadAccel(80), 1e-9)
+	assert.Equal(t, 3.0, touchpadAccel(1000))
+}
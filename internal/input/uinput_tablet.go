@@ -0,0 +1,212 @@
+package input
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/bnema/waymon/internal/protocol"
+	evdev "github.com/gvalkov/golang-evdev"
+	"golang.org/x/sys/unix"
+)
+
+// uinputPath is the device creating virtual input devices
+const uinputPath = "/dev/uinput"
+
+// uinput requests, from linux/uinput.h
+const (
+	uiDevCreate  = 'U'<<8 | 1
+	uiDevDestroy = 'U'<<8 | 2
+	uiSetEvBit   = 1<<30 | 4<<16 | 'U'<<8 | 100
+	uiSetKeyBit  = 1<<30 | 4<<16 | 'U'<<8 | 101
+	uiSetAbsBit  = 1<<30 | 4<<16 | 'U'<<8 | 103
+	uiSetPropBit = 1<<30 | 4<<16 | 'U'<<8 | 110
+)
+
+// Axes of the virtual tablet. Its area spans the whole output layout, which
+// compositors map tablets onto by default.
+const (
+	tabletRange       = 32767
+	tabletResolution  = 100 // Units per millimetre
+	tabletPressureMax = 8191
+	tabletTiltScale   = 100 // Units per degree
+)
+
+// inputPropPointer is the input property of devices that move a pointer on
+// the screen rather than touching it, such as graphics tablets
+const inputPropPointer = 0x00
+
+// uinputSetup is the kernel's struct uinput_setup
+type uinputSetup struct {
+	busType, vendor, product, version uint16
+	name                              [80]byte
+	ffEffectsMax                      uint32
+}
+
+// uinputAbsSetup is the kernel's struct uinput_abs_setup
+type uinputAbsSetup struct {
+	code uint16
+	_    uint16
+	info absInfo
+}
+
+// uinputTablet is a virtual graphics tablet. The compositor reads it as it
+// would a real one, so pressure and tilt reach applications unchanged.
+type uinputTablet struct {
+	fd   int
+	tool int32 // Tool code in proximity, 0 when out of range
+}
+
+// UinputAvailable reports whether virtual devices can be created, which
+// needs write access to /dev/uinput
+func UinputAvailable() bool {
+	return unix.Access(uinputPath, unix.W_OK) == nil
+}
+
+// newUinputTablet creates the virtual tablet
+func newUinputTablet() (*uinputTablet, error) {
+	fd, err := unix.Open(uinputPath, unix.O_WRONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", uinputPath, err)
+	}
+	if err := setupUinputTablet(fd); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	return &uinputTablet{fd: fd}, nil
+}
+
+// setupUinputTablet declares the events of the tablet and creates it
+func setupUinputTablet(fd int) error {
+	bits := []struct {
+		request uint
+		values  []int
+	}{
+		{uiSetEvBit, []int{evdev.EV_SYN, evdev.EV_KEY, evdev.EV_ABS}},
+		{uiSetKeyBit, []int{evdev.BTN_TOOL_PEN, evdev.BTN_TOOL_RUBBER, evdev.BTN_TOUCH, evdev.BTN_STYLUS, evdev.BTN_STYLUS2, btnStylus3}},
+		{uiSetAbsBit, []int{evdev.ABS_X, evdev.ABS_Y, evdev.ABS_PRESSURE, evdev.ABS_TILT_X, evdev.ABS_TILT_Y}},
+		{uiSetPropBit, []int{inputPropPointer}},
+	}
+	for _, set := range bits {
+		for _, value := range set.values {
+			if err := unix.IoctlSetInt(fd, set.request, value); err != nil {
+				return fmt.Errorf("failed to declare virtual tablet events: %w", err)
+			}
+		}
+	}
+
+	axes := []uinputAbsSetup{
+		{code: evdev.ABS_X, info: absInfo{maximum: tabletRange, resolution: tabletResolution}},
+		{code: evdev.ABS_Y, info: absInfo{maximum: tabletRange, resolution: tabletResolution}},
+		{code: evdev.ABS_PRESSURE, info: absInfo{maximum: tabletPressureMax}},
+		// Tilt resolution is in units per radian
+		{code: evdev.ABS_TILT_X, info: absInfo{minimum: -90 * tabletTiltScale, maximum: 90 * tabletTiltScale, resolution: int32(math.Round(tabletTiltScale * 180 / math.Pi))}},
+		{code: evdev.ABS_TILT_Y, info: absInfo{minimum: -90 * tabletTiltScale, maximum: 90 * tabletTiltScale, resolution: int32(math.Round(tabletTiltScale * 180 / math.Pi))}},
+	}
+	for i := range axes {
+		request := uintptr(1<<30 | int(unsafe.Sizeof(axes[i]))<<16 | 'U'<<8 | 4) // UI_ABS_SETUP
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(&axes[i]))); errno != 0 {
+			return fmt.Errorf("failed to set up virtual tablet axis %d: %w", axes[i].code, errno)
+		}
+	}
+
+	setup := uinputSetup{busType: evdev.BUS_VIRTUAL, vendor: 0x1, product: 0x1, version: 1}
+	copy(setup.name[:], "Waymon virtual tablet")
+	request := uintptr(1<<30 | int(unsafe.Sizeof(setup))<<16 | 'U'<<8 | 3) // UI_DEV_SETUP
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(&setup))); errno != 0 {
+		return fmt.Errorf("failed to set up virtual tablet: %w", errno)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), uiDevCreate, 0); errno != 0 {
+		return fmt.Errorf("failed to create virtual tablet: %w", errno)
+	}
+	return nil
+}
+
+// send reports a tool state with the tool at x, y in tablet units. The
+// kernel drops values that did not change, so the whole state is sent.
+func (u *uinputTablet) send(tool *protocol.TabletToolEvent, x, y int32) error {
+	var events []evdev.InputEvent
+	add := func(evType, code uint16, value int32) {
+		events = append(events, evdev.InputEvent{Type: evType, Code: code, Value: value})
+	}
+
+	code := int32(evdev.BTN_TOOL_PEN)
+	if tool.Tool == protocol.TabletTool_TABLET_TOOL_ERASER {
+		code = evdev.BTN_TOOL_RUBBER
+	}
+	// A new tool only comes in once the previous one left
+	if u.tool != 0 && (u.tool != code || !tool.Proximity) {
+		add(evdev.EV_KEY, evdev.BTN_TOUCH, 0)
+		add(evdev.EV_ABS, evdev.ABS_PRESSURE, 0)
+		add(evdev.EV_KEY, uint16(u.tool), 0)
+		add(evdev.EV_SYN, evdev.SYN_REPORT, 0)
+		u.tool = 0
+	}
+	if tool.Proximity {
+		add(evdev.EV_ABS, evdev.ABS_X, x)
+		add(evdev.EV_ABS, evdev.ABS_Y, y)
+		add(evdev.EV_ABS, evdev.ABS_PRESSURE, int32(math.Round(tool.Pressure*tabletPressureMax)))
+		add(evdev.EV_ABS, evdev.ABS_TILT_X, int32(math.Round(tool.TiltX*tabletTiltScale)))
+		add(evdev.EV_ABS, evdev.ABS_TILT_Y, int32(math.Round(tool.TiltY*tabletTiltScale)))
+		add(evdev.EV_KEY, uint16(code), 1)
+		add(evdev.EV_KEY, evdev.BTN_TOUCH, boolValue(tool.Tip))
+		add(evdev.EV_KEY, evdev.BTN_STYLUS, boolValue(tool.Buttons&1 != 0))
+		add(evdev.EV_KEY, evdev.BTN_STYLUS2, boolValue(tool.Buttons&2 != 0))
+		add(evdev.EV_KEY, btnStylus3, boolValue(tool.Buttons&4 != 0))
+		add(evdev.EV_SYN, evdev.SYN_REPORT, 0)
+		u.tool = code
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&events[0])), len(events)*eventSize)
+	if _, err := unix.Write(u.fd, raw); err != nil {
+		return fmt.Errorf("failed to write virtual tablet events: %w", err)
+	}
+	return nil
+}
+
+// close removes the virtual tablet
+func (u *uinputTablet) close() error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(u.fd), uiDevDestroy, 0)
+	if err := unix.Close(u.fd); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// tabletPosition maps a tool position onto a monitor, in units of a virtual
+// tablet spanning the output layout box at layoutX, layoutY
+func tabletPosition(tool *protocol.TabletToolEvent, monitor *protocol.Monitor, layoutX, layoutY int32, layoutWidth, layoutHeight uint32) (int32, int32) {
+	x := float64(monitor.X-layoutX) + tool.X*float64(monitor.Width)
+	y := float64(monitor.Y-layoutY) + tool.Y*float64(monitor.Height)
+	return int32(math.Round(max(0, min(1, x/float64(layoutWidth))) * tabletRange)),
+		int32(math.Round(max(0, min(1, y/float64(layoutHeight))) * tabletRange))
+}
+
+// tabletMonitor picks the monitor the tablet area is mapped onto: the one
+// named, else the primary one
+func tabletMonitor(monitors []*protocol.Monitor, name string) *protocol.Monitor {
+	var primary *protocol.Monitor
+	for _, monitor := range monitors {
+		if name != "" && monitor.Name == name {
+			return monitor
+		}
+		if primary == nil || monitor.Primary && !primary.Primary {
+			primary = monitor
+		}
+	}
+	return primary
+}
+
+// boolValue is the evdev value of a key state
+func boolValue(pressed bool) int32 {
+	if pressed {
+		return 1
+	}
+	return 0
+}
@@ -6,10 +6,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bnema/wayland-virtual-input-go/keyboard_shortcuts_inhibitor"
-	"github.com/bnema/wayland-virtual-input-go/pointer_constraints"
-	"github.com/bnema/wayland-virtual-input-go/virtual_keyboard"
-	"github.com/bnema/wayland-virtual-input-go/virtual_pointer"
+	"github.com/bnema/libwldevices-go/keyboard_shortcuts_inhibitor"
+	"github.com/bnema/libwldevices-go/pointer_constraints"
+	"github.com/bnema/libwldevices-go/virtual_keyboard"
+	"github.com/bnema/libwldevices-go/virtual_pointer"
 	"github.com/bnema/waymon/internal/logger"
 	"github.com/bnema/waymon/internal/protocol"
 	"github.com/rajveermalviya/go-wayland/wayland/client"
@@ -34,13 +34,36 @@ type WaylandVirtualInput struct {
 	compositor *client.Compositor //nolint:unused // part of wayland infrastructure, may be used in future
 
 	// Pointer constraints for exclusive capture
-	constraintsMgr pointer_constraints.PointerConstraintsManager
-	lockedPointer  pointer_constraints.LockedPointer
+	constraintsMgr *pointer_constraints.PointerConstraintsManager
+	lockedPointer  *pointer_constraints.LockedPointer
 
 	// Keyboard shortcuts inhibitor for exclusive keyboard capture
 	shortcutsInhibitorMgr keyboard_shortcuts_inhibitor.KeyboardShortcutsInhibitorManager
 	shortcutsInhibitor    keyboard_shortcuts_inhibitor.KeyboardShortcutsInhibitor
 
+	// Output layout bounding box, used as the extent for absolute motion
+	layoutX      int32
+	layoutY      int32
+	layoutWidth  uint32
+	layoutHeight uint32
+	outputs      []*protocol.Monitor
+
+	// Virtual graphics tablet, absent without access to /dev/uinput, and the
+	// monitor the server's tablet area is mapped onto
+	tablet        *uinputTablet
+	tabletMonitor string
+
+	// Modifier state of the injected key stream, mirrored to the compositor
+	modifiers *ModifierState
+
+	// Scroll speed and direction, and the wheel notch in progress
+	scrolling scrollState
+
+	// Touchpad gestures, which no Wayland protocol lets us inject, are
+	// replayed as the key chords bound to them
+	gestures        gestureTracker
+	gestureBindings map[string]Chord
+
 	onInputEvent  func(*protocol.InputEvent)
 	currentTarget string
 	capturing     bool
@@ -50,7 +73,12 @@ type WaylandVirtualInput struct {
 
 // NewWaylandVirtualInput creates a new Wayland virtual input backend
 func NewWaylandVirtualInput() (*WaylandVirtualInput, error) {
-	w := &WaylandVirtualInput{}
+	w := &WaylandVirtualInput{
+		layoutWidth:  1920, // Until the real output layout is known
+		layoutHeight: 1080,
+		modifiers:    NewModifierState(),
+		scrolling:    scrollState{speed: 1},
+	}
 
 	// Connect to Wayland display
 	display, err := client.Connect("")
@@ -150,6 +178,19 @@ func (w *WaylandVirtualInput) Start(ctx context.Context) error {
 		}
 	}
 
+	// No Wayland protocol creates tablets, so the tablet is a uinput device.
+	// It is created up front for the compositor to have picked it up by the
+	// time the pen comes near.
+	if UinputAvailable() {
+		tablet, err := newUinputTablet()
+		if err != nil {
+			logger.Warnf("Failed to create virtual tablet: %v", err)
+		} else {
+			w.tablet = tablet
+			logger.Info("Virtual tablet created successfully")
+		}
+	}
+
 	logger.Info("Wayland virtual input backend started")
 
 	// Monitor context for shutdown
@@ -250,6 +291,13 @@ func (w *WaylandVirtualInput) Stop() error {
 		w.virtualKbd = nil
 	}
 
+	if w.tablet != nil {
+		if err := w.tablet.close(); err != nil {
+			logger.Errorf("Failed to close virtual tablet: %v", err)
+		}
+		w.tablet = nil
+	}
+
 	// Disable exclusive capture first
 	if err := w.disableExclusiveCapture(); err != nil {
 		logger.Errorf("Failed to disable exclusive capture: %v", err)
@@ -335,6 +383,21 @@ func (w *WaylandVirtualInput) InjectMouseMove(dx, dy float64) error {
 
 	logger.Debugf("[WAYLAND-INPUT] InjectMouseMove called: capturing=%v, virtualPtr=%v", w.capturing, w.virtualPtr != nil)
 
+	if err := w.motion(dx, dy); err != nil {
+		return err
+	}
+
+	// Frame the event
+	if err := w.virtualPtr.Frame(); err != nil {
+		return fmt.Errorf("failed to frame mouse motion: %w", err)
+	}
+
+	logger.Debugf("[WAYLAND-INPUT] Successfully injected mouse move")
+	return nil
+}
+
+// motion sends relative motion without ending the frame. Must be called with the lock held.
+func (w *WaylandVirtualInput) motion(dx, dy float64) error {
 	if !w.capturing || w.virtualPtr == nil {
 		return fmt.Errorf("virtual pointer not available (capturing=%v, virtualPtr=%v)", w.capturing, w.virtualPtr != nil)
 	}
@@ -343,30 +406,61 @@ func (w *WaylandVirtualInput) InjectMouseMove(dx, dy float64) error {
 	if err := w.virtualPtr.Motion(time.Now(), dx, dy); err != nil {
 		return fmt.Errorf("failed to inject mouse motion: %w", err)
 	}
+	return nil
+}
 
-	// Frame the event
-	if err := w.virtualPtr.Frame(); err != nil {
-		return fmt.Errorf("failed to frame mouse motion: %w", err)
+// SetOutputLayout sets the outputs that absolute positions are mapped onto.
+// The compositor scales absolute motion over the bounding box of all outputs,
+// so the extent is that box and positions are made relative to its origin.
+func (w *WaylandVirtualInput) SetOutputLayout(monitors []*protocol.Monitor) {
+	if len(monitors) == 0 {
+		return
 	}
 
-	logger.Debugf("[WAYLAND-INPUT] Successfully injected mouse move")
-	return nil
-}
+	x, y, width, height, ok := outputExtent(monitors)
+	if !ok {
+		logger.Warnf("[WAYLAND-INPUT] Ignoring empty output layout")
+		return
+	}
 
-// InjectMousePosition injects an absolute mouse position event
-func (w *WaylandVirtualInput) InjectMousePosition(x, y uint32) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	w.layoutX, w.layoutY = x, y
+	w.layoutWidth, w.layoutHeight = width, height
+	w.outputs = monitors
+	logger.Infof("[WAYLAND-INPUT] Absolute pointer extent set to %dx%d at %d,%d",
+		w.layoutWidth, w.layoutHeight, w.layoutX, w.layoutY)
+}
 
-	logger.Debugf("[WAYLAND-INPUT] InjectMousePosition called: capturing=%v, virtualPtr=%v", w.capturing, w.virtualPtr != nil)
+// outputExtent returns the bounding box of the outputs, false if it is empty
+func outputExtent(monitors []*protocol.Monitor) (x, y int32, width, height uint32, ok bool) {
+	if len(monitors) == 0 {
+		return 0, 0, 0, 0, false
+	}
 
-	if !w.capturing || w.virtualPtr == nil {
-		return fmt.Errorf("virtual pointer not available (capturing=%v, virtualPtr=%v)", w.capturing, w.virtualPtr != nil)
+	minX, minY := monitors[0].X, monitors[0].Y
+	maxX, maxY := monitors[0].X+monitors[0].Width, monitors[0].Y+monitors[0].Height
+	for _, monitor := range monitors[1:] {
+		minX = min(minX, monitor.X)
+		minY = min(minY, monitor.Y)
+		maxX = max(maxX, monitor.X+monitor.Width)
+		maxY = max(maxY, monitor.Y+monitor.Height)
+	}
+	if maxX <= minX || maxY <= minY {
+		return 0, 0, 0, 0, false
 	}
+	return minX, minY, uint32(maxX - minX), uint32(maxY - minY), true //nolint:gosec // checked positive above
+}
 
-	// Use absolute motion for positioning
-	if err := w.virtualPtr.MotionAbsolute(time.Now(), x, y, 1920, 1080); err != nil {
-		return fmt.Errorf("failed to inject absolute mouse position: %w", err)
+// InjectMousePosition injects an absolute mouse position event in output layout coordinates
+func (w *WaylandVirtualInput) InjectMousePosition(x, y int32) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	logger.Debugf("[WAYLAND-INPUT] InjectMousePosition called: capturing=%v, virtualPtr=%v", w.capturing, w.virtualPtr != nil)
+
+	if err := w.motionAbsolute(x, y); err != nil {
+		return err
 	}
 
 	// Frame the event
@@ -378,6 +472,24 @@ func (w *WaylandVirtualInput) InjectMousePosition(x, y uint32) error {
 	return nil
 }
 
+// motionAbsolute sends an absolute position without ending the frame. Must be
+// called with the lock held.
+func (w *WaylandVirtualInput) motionAbsolute(x, y int32) error {
+	if !w.capturing || w.virtualPtr == nil {
+		return fmt.Errorf("virtual pointer not available (capturing=%v, virtualPtr=%v)", w.capturing, w.virtualPtr != nil)
+	}
+
+	// Make the position relative to the layout origin and keep it inside the extent
+	relX := uint32(max(0, min(int64(x)-int64(w.layoutX), int64(w.layoutWidth)-1)))  //nolint:gosec // clamped to extent
+	relY := uint32(max(0, min(int64(y)-int64(w.layoutY), int64(w.layoutHeight)-1))) //nolint:gosec // clamped to extent
+
+	// Use absolute motion for positioning
+	if err := w.virtualPtr.MotionAbsolute(time.Now(), relX, relY, w.layoutWidth, w.layoutHeight); err != nil {
+		return fmt.Errorf("failed to inject absolute mouse position: %w", err)
+	}
+	return nil
+}
+
 // InjectMouseButton injects a mouse button event (for server mode)
 func (w *WaylandVirtualInput) InjectMouseButton(button uint32, pressed bool) error {
 	w.mu.Lock()
@@ -385,6 +497,17 @@ func (w *WaylandVirtualInput) InjectMouseButton(button uint32, pressed bool) err
 
 	logger.Debugf("[WAYLAND-INPUT] InjectMouseButton called: capturing=%v, virtualPtr=%v", w.capturing, w.virtualPtr != nil)
 
+	if err := w.button(button, pressed); err != nil {
+		return err
+	}
+
+	// Frame the event
+	return w.virtualPtr.Frame()
+}
+
+// button sends a button press or release without ending the frame. Must be
+// called with the lock held.
+func (w *WaylandVirtualInput) button(button uint32, pressed bool) error {
 	if !w.capturing || w.virtualPtr == nil {
 		return fmt.Errorf("virtual pointer not available (capturing=%v, virtualPtr=%v)", w.capturing, w.virtualPtr != nil)
 	}
@@ -418,50 +541,158 @@ func (w *WaylandVirtualInput) InjectMouseButton(button uint32, pressed bool) err
 	if err := w.virtualPtr.Button(time.Now(), linuxButton, state); err != nil {
 		return fmt.Errorf("failed to inject mouse button: %w", err)
 	}
+	return nil
+}
+
+// InjectMouseScroll injects a mouse scroll event (for server mode)
+func (w *WaylandVirtualInput) InjectMouseScroll(scroll *protocol.MouseScrollEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.scroll(scroll); err != nil {
+		return err
+	}
 
 	// Frame the event
 	return w.virtualPtr.Frame()
 }
 
-// InjectMouseScroll injects a mouse scroll event (for server mode)
-func (w *WaylandVirtualInput) InjectMouseScroll(dx, dy float64) error {
+// SetScrollOptions sets the multiplier of the scroll distance and whether
+// scrolling is inverted so that content follows the wheel or fingers
+func (w *WaylandVirtualInput) SetScrollOptions(speed float64, natural bool) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	w.scrolling.speed = speed
+	w.scrolling.natural = natural
+}
 
+// scroll sends scrolling without ending the frame. Wheels send whole notches
+// as discrete steps, finger and continuous scrolling end with a stop. Must be
+// called with the lock held.
+func (w *WaylandVirtualInput) scroll(scroll *protocol.MouseScrollEvent) error {
 	if !w.capturing || w.virtualPtr == nil {
 		return fmt.Errorf("virtual pointer not available")
 	}
 
-	// Set axis source to wheel
-	if err := w.virtualPtr.AxisSource(virtual_pointer.AxisSourceWheel); err != nil {
+	source := virtual_pointer.AxisSourceWheel
+	switch scroll.Type {
+	case protocol.ScrollType_SCROLL_FINGER:
+		source = virtual_pointer.AxisSourceFinger
+	case protocol.ScrollType_SCROLL_CONTINUOUS:
+		source = virtual_pointer.AxisSourceContinuous
+	}
+	if err := w.virtualPtr.AxisSource(source); err != nil {
 		return fmt.Errorf("failed to set axis source: %w", err)
 	}
 
 	now := time.Now()
+	for _, axis := range w.scrolling.axes(scroll) {
+		direction := virtual_pointer.AxisVertical
+		if axis.horizontal {
+			direction = virtual_pointer.AxisHorizontal
+		}
 
-	// Inject vertical scroll if dy != 0
-	if dy != 0 {
-		if err := w.virtualPtr.Axis(now, virtual_pointer.AxisVertical, -dy); err != nil {
-			return fmt.Errorf("failed to inject vertical scroll: %w", err)
+		var err error
+		switch {
+		case axis.stop:
+			err = w.virtualPtr.AxisStop(now, direction)
+		case axis.discrete != 0:
+			err = w.virtualPtr.AxisDiscrete(now, direction, axis.value, axis.discrete)
+		default:
+			err = w.virtualPtr.Axis(now, direction, axis.value)
 		}
+		if err != nil {
+			return fmt.Errorf("failed to inject scroll: %w", err)
+		}
+	}
+	return nil
+}
+
+// InjectGesture follows a touchpad gesture and, once it ends, presses the
+// chord bound to it
+func (w *WaylandVirtualInput) InjectGesture(gesture *protocol.GestureEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.gesture(gesture)
+}
+
+// SetGestureBindings sets the chords pressed for gestures, keyed by gesture
+// name such as swipe_left_3
+func (w *WaylandVirtualInput) SetGestureBindings(bindings map[string]Chord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gestureBindings = bindings
+}
+
+// gesture presses and releases the chord bound to a gesture that ended. Must
+// be called with the lock held.
+func (w *WaylandVirtualInput) gesture(gesture *protocol.GestureEvent) error {
+	name := w.gestures.add(gesture)
+	if name == "" {
+		return nil
+	}
+	chord, ok := w.gestureBindings[name]
+	if !ok {
+		logger.Debugf("[WAYLAND-INPUT] No binding for gesture %s", name)
+		return nil
 	}
 
-	// Inject horizontal scroll if dx != 0
-	if dx != 0 {
-		if err := w.virtualPtr.Axis(now, virtual_pointer.AxisHorizontal, dx); err != nil {
-			return fmt.Errorf("failed to inject horizontal scroll: %w", err)
+	logger.Debugf("[WAYLAND-INPUT] Gesture %s", name)
+	keys := chord.Keys()
+	for _, key := range keys {
+		if err := w.key(key, true); err != nil {
+			return fmt.Errorf("failed to press binding of gesture %s: %w", name, err)
+		}
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := w.key(keys[i], false); err != nil {
+			return fmt.Errorf("failed to release binding of gesture %s: %w", name, err)
 		}
 	}
+	return nil
+}
+
+// InjectTabletTool moves the virtual tablet's tool to the state received,
+// with the server's tablet area mapped onto the chosen monitor
+func (w *WaylandVirtualInput) InjectTabletTool(tool *protocol.TabletToolEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tabletTool(tool)
+}
+
+// SetTabletMonitor sets the name of the monitor the tablet area is mapped
+// onto, empty for the primary monitor
+func (w *WaylandVirtualInput) SetTabletMonitor(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tabletMonitor = name
+}
+
+// tabletTool sends a tool state to the virtual tablet. Must be called with
+// the lock held.
+func (w *WaylandVirtualInput) tabletTool(tool *protocol.TabletToolEvent) error {
+	if !w.capturing || w.tablet == nil {
+		return fmt.Errorf("virtual tablet not available")
+	}
 
-	// Frame the event
-	return w.virtualPtr.Frame()
+	monitor := tabletMonitor(w.outputs, w.tabletMonitor)
+	if monitor == nil {
+		// Until the outputs are known the area covers the whole layout
+		monitor = &protocol.Monitor{X: w.layoutX, Y: w.layoutY, Width: int32(w.layoutWidth), Height: int32(w.layoutHeight)} //nolint:gosec // layout sizes fit in int32
+	}
+	x, y := tabletPosition(tool, monitor, w.layoutX, w.layoutY, w.layoutWidth, w.layoutHeight)
+	return w.tablet.send(tool, x, y)
 }
 
 // InjectKeyEvent injects a keyboard event (for server mode)
 func (w *WaylandVirtualInput) InjectKeyEvent(key uint32, pressed bool) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return w.key(key, pressed)
+}
 
+// key sends a key press or release. Must be called with the lock held.
+func (w *WaylandVirtualInput) key(key uint32, pressed bool) error {
 	if !w.capturing || w.virtualKbd == nil {
 		return fmt.Errorf("virtual keyboard not available")
 	}
@@ -475,7 +706,109 @@ func (w *WaylandVirtualInput) InjectKeyEvent(key uint32, pressed bool) error {
 	}
 
 	// Inject key event
-	return w.virtualKbd.Key(time.Now(), key, state)
+	if err := w.virtualKbd.Key(time.Now(), key, state); err != nil {
+		return err
+	}
+
+	// Compositors differ in whether they derive modifiers from virtual key
+	// events, so state them explicitly
+	if w.modifiers.Update(key, pressed) {
+		return w.sendModifiers()
+	}
+	return nil
+}
+
+// InjectFrame injects the events of one hardware frame and ends the pointer
+// frame once after them, so the compositor handles them as the device
+// reported them
+func (w *WaylandVirtualInput) InjectFrame(events []*protocol.InputEvent) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	pointer := false
+	for _, event := range events {
+		switch e := event.Event.(type) {
+		case *protocol.InputEvent_MouseMove:
+			err = w.motion(e.MouseMove.Dx, e.MouseMove.Dy)
+		case *protocol.InputEvent_MousePosition:
+			err = w.motionAbsolute(e.MousePosition.X, e.MousePosition.Y)
+		case *protocol.InputEvent_MouseButton:
+			err = w.button(e.MouseButton.Button, e.MouseButton.Pressed)
+		case *protocol.InputEvent_MouseScroll:
+			err = w.scroll(e.MouseScroll)
+		case *protocol.InputEvent_Keyboard:
+			if err := w.key(e.Keyboard.Key, e.Keyboard.Pressed); err != nil {
+				return err
+			}
+			continue
+		case *protocol.InputEvent_Gesture:
+			if err := w.gesture(e.Gesture); err != nil {
+				return err
+			}
+			continue
+		case *protocol.InputEvent_TabletTool:
+			if err := w.tabletTool(e.TabletTool); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf("unsupported event type in frame: %T", event.Event)
+		}
+		if err != nil {
+			return err
+		}
+		pointer = true
+	}
+
+	if !pointer {
+		return nil
+	}
+	if err := w.virtualPtr.Frame(); err != nil {
+		return fmt.Errorf("failed to frame pointer events: %w", err)
+	}
+	return nil
+}
+
+// SyncLockedModifiers adopts the lock state of the controlling machine, so
+// Caps Lock and Num Lock match when control is granted
+func (w *WaylandVirtualInput) SyncLockedModifiers(locked uint32) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.capturing || w.virtualKbd == nil {
+		return fmt.Errorf("virtual keyboard not available")
+	}
+
+	w.modifiers.SetLocked(locked)
+	return w.sendModifiers()
+}
+
+// SetKeymap replaces the virtual keyboard's keymap, so injected key codes are
+// interpreted with the controlling machine's layout
+func (w *WaylandVirtualInput) SetKeymap(keymap string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.capturing || w.virtualKbd == nil {
+		return fmt.Errorf("virtual keyboard not available")
+	}
+
+	if err := w.virtualKbd.SetKeymap(keymap); err != nil {
+		return fmt.Errorf("failed to set keymap: %w", err)
+	}
+	// A new keymap resets the compositor's modifier state for this keyboard
+	return w.sendModifiers()
+}
+
+// sendModifiers sends the tracked modifier state. Must be called with the lock held.
+func (w *WaylandVirtualInput) sendModifiers() error {
+	depressed, locked := w.modifiers.Depressed(), w.modifiers.Locked()
+	logger.Debugf("[WAYLAND-INPUT] Modifiers: depressed=0x%x locked=0x%x", depressed, locked)
+	if err := w.virtualKbd.Modifiers(depressed, 0, locked, 0); err != nil {
+		return fmt.Errorf("failed to send modifiers: %w", err)
+	}
+	return nil
 }
 
 // Input event handlers for capture
@@ -132,6 +132,67 @@ func (c *Client) SendStatus() (*pb.StatusResponse, error) {
 	}
 }
 
+// SendAuthList asks the running server for the keys waiting for approval
+func (c *Client) SendAuthList() (*pb.AuthResponse, error) {
+	msg, err := NewAuthListMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth list message: %w", err)
+	}
+	return c.sendAuthMessage(msg)
+}
+
+// SendAuthDecision approves or denies a key waiting for approval on the
+// running server, or denies all of them
+func (c *Client) SendAuthDecision(fingerprint string, approve, all bool) (*pb.AuthResponse, error) {
+	msg, err := NewAuthDecisionMessage(fingerprint, approve, all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth decision message: %w", err)
+	}
+	return c.sendAuthMessage(msg)
+}
+
+// SendPair asks the running server for a pairing code valid for
+// timeoutSeconds, or the server default when 0
+func (c *Client) SendPair(timeoutSeconds int32) (*pb.PairResponse, error) {
+	msg, err := NewPairMessage(timeoutSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pair message: %w", err)
+	}
+
+	response, err := c.sendMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.Type {
+	case pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR_RESPONSE:
+		return GetPairResponse(response)
+	case pb.IPCMessageType_IPC_MESSAGE_TYPE_ERROR:
+		errResp, _ := GetErrorResponse(response)
+		return nil, fmt.Errorf("server error: %s", errResp.Error)
+	default:
+		return nil, fmt.Errorf("unexpected response type: %s", response.Type)
+	}
+}
+
+// sendAuthMessage sends a key approval message and returns the response
+func (c *Client) sendAuthMessage(msg *pb.IPCMessage) (*pb.AuthResponse, error) {
+	response, err := c.sendMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.Type {
+	case pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_RESPONSE:
+		return GetAuthResponse(response)
+	case pb.IPCMessageType_IPC_MESSAGE_TYPE_ERROR:
+		errResp, _ := GetErrorResponse(response)
+		return nil, fmt.Errorf("server error: %s", errResp.Error)
+	default:
+		return nil, fmt.Errorf("unexpected response type: %s", response.Type)
+	}
+}
+
 // IsWaymonRunning checks if a waymon instance is currently running
 func (c *Client) IsWaymonRunning() bool {
 	_, err := c.SendStatus()
@@ -87,6 +87,74 @@ func NewErrorMessage(errMsg string) (*pb.IPCMessage, error) {
 	}, nil
 }
 
+// NewAuthListMessage creates a query for the keys waiting for approval
+func NewAuthListMessage() (*pb.IPCMessage, error) {
+	return &pb.IPCMessage{
+		Type: pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_LIST,
+		Payload: &pb.IPCMessage_AuthListQuery{
+			AuthListQuery: &pb.AuthListQuery{},
+		},
+	}, nil
+}
+
+// NewAuthDecisionMessage creates a message approving or denying a pending key,
+// or denying all of them
+func NewAuthDecisionMessage(fingerprint string, approve, all bool) (*pb.IPCMessage, error) {
+	if all && approve {
+		return nil, fmt.Errorf("pending keys can only be denied all at once")
+	}
+	return &pb.IPCMessage{
+		Type: pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_DECISION,
+		Payload: &pb.IPCMessage_AuthDecision{
+			AuthDecision: &pb.AuthDecision{
+				Fingerprint: fingerprint,
+				Approve:     approve,
+				All:         all,
+			},
+		},
+	}, nil
+}
+
+// NewAuthResponseMessage creates a response listing the keys waiting for approval
+func NewAuthResponseMessage(pending []*pb.PendingAuth, resolved int32) (*pb.IPCMessage, error) {
+	return &pb.IPCMessage{
+		Type: pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_RESPONSE,
+		Payload: &pb.IPCMessage_AuthResponse{
+			AuthResponse: &pb.AuthResponse{
+				Pending:  pending,
+				Resolved: resolved,
+			},
+		},
+	}, nil
+}
+
+// NewPairMessage creates a request for a pairing code valid for timeoutSeconds,
+// or the server default when 0
+func NewPairMessage(timeoutSeconds int32) (*pb.IPCMessage, error) {
+	return &pb.IPCMessage{
+		Type: pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR,
+		Payload: &pb.IPCMessage_PairRequest{
+			PairRequest: &pb.PairRequest{
+				TimeoutSeconds: timeoutSeconds,
+			},
+		},
+	}, nil
+}
+
+// NewPairResponseMessage creates a response carrying a pairing code
+func NewPairResponseMessage(code string, expiresAtMs int64, hostKeyFingerprint string) (*pb.IPCMessage, error) {
+	return &pb.IPCMessage{
+		Type: pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR_RESPONSE,
+		Payload: &pb.IPCMessage_PairResponse{
+			PairResponse: &pb.PairResponse{
+				Code:               code,
+				ExpiresAtMs:        expiresAtMs,
+				HostKeyFingerprint: hostKeyFingerprint,
+			},
+		},
+	}, nil
+}
+
 // GetSwitchCommand extracts switch command from message
 func GetSwitchCommand(msg *pb.IPCMessage) (*pb.SwitchCommand, error) {
 	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_SWITCH {
@@ -142,3 +210,73 @@ func GetErrorResponse(msg *pb.IPCMessage) (*pb.ErrorResponse, error) {
 
 	return errResp.ErrorResponse, nil
 }
+
+// GetAuthListQuery extracts the pending keys query from message
+func GetAuthListQuery(msg *pb.IPCMessage) (*pb.AuthListQuery, error) {
+	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_LIST {
+		return nil, fmt.Errorf("message is not an auth list query")
+	}
+
+	query, ok := msg.Payload.(*pb.IPCMessage_AuthListQuery)
+	if !ok {
+		return nil, fmt.Errorf("invalid auth list query payload")
+	}
+
+	return query.AuthListQuery, nil
+}
+
+// GetAuthDecision extracts an approval decision from message
+func GetAuthDecision(msg *pb.IPCMessage) (*pb.AuthDecision, error) {
+	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_DECISION {
+		return nil, fmt.Errorf("message is not an auth decision")
+	}
+
+	decision, ok := msg.Payload.(*pb.IPCMessage_AuthDecision)
+	if !ok {
+		return nil, fmt.Errorf("invalid auth decision payload")
+	}
+
+	return decision.AuthDecision, nil
+}
+
+// GetAuthResponse extracts the pending keys response from message
+func GetAuthResponse(msg *pb.IPCMessage) (*pb.AuthResponse, error) {
+	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_RESPONSE {
+		return nil, fmt.Errorf("message is not an auth response")
+	}
+
+	resp, ok := msg.Payload.(*pb.IPCMessage_AuthResponse)
+	if !ok {
+		return nil, fmt.Errorf("invalid auth response payload")
+	}
+
+	return resp.AuthResponse, nil
+}
+
+// GetPairRequest extracts a pairing code request from message
+func GetPairRequest(msg *pb.IPCMessage) (*pb.PairRequest, error) {
+	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR {
+		return nil, fmt.Errorf("message is not a pair request")
+	}
+
+	req, ok := msg.Payload.(*pb.IPCMessage_PairRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid pair request payload")
+	}
+
+	return req.PairRequest, nil
+}
+
+// GetPairResponse extracts a pairing code from message
+func GetPairResponse(msg *pb.IPCMessage) (*pb.PairResponse, error) {
+	if msg.Type != pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR_RESPONSE {
+		return nil, fmt.Errorf("message is not a pair response")
+	}
+
+	resp, ok := msg.Payload.(*pb.IPCMessage_PairResponse)
+	if !ok {
+		return nil, fmt.Errorf("invalid pair response payload")
+	}
+
+	return resp.PairResponse, nil
+}
@@ -10,6 +10,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"sync"
+	"syscall"
 
 	"github.com/bnema/waymon/internal/logger"
 	pb "github.com/bnema/waymon/internal/proto"
@@ -33,6 +34,15 @@ type MessageHandler interface {
 	HandleStatusQuery(query *pb.StatusQuery) (*pb.IPCMessage, error)
 }
 
+// AuthHandler is implemented by message handlers that manage client keys
+// waiting for approval and pairing codes. Only root and the user running the
+// server may use it.
+type AuthHandler interface {
+	HandleAuthList(query *pb.AuthListQuery) (*pb.IPCMessage, error)
+	HandleAuthDecision(decision *pb.AuthDecision) (*pb.IPCMessage, error)
+	HandlePairRequest(req *pb.PairRequest) (*pb.IPCMessage, error)
+}
+
 // NewSocketServer creates a new socket server
 func NewSocketServer(handler MessageHandler) (*SocketServer, error) {
 	socketPath, err := getSocketPath()
@@ -165,6 +175,15 @@ func (s *SocketServer) handleConnection(ctx context.Context, conn net.Conn) {
 
 	logger.Debug("New IPC connection established")
 
+	// The socket is open to every user when the server runs as root, so
+	// approving keys is reserved to root and the server's own user
+	privileged := false
+	if uid, err := peerUID(conn); err != nil {
+		logger.Warnf("Failed to get IPC peer credentials: %v", err)
+	} else {
+		privileged = uid == 0 || uid == uint32(os.Geteuid()) //nolint:gosec // uids are non-negative
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -176,7 +195,7 @@ func (s *SocketServer) handleConnection(ctx context.Context, conn net.Conn) {
 				return
 			}
 
-			response := s.handleMessage(msg)
+			response := s.handleMessage(msg, privileged)
 			if err := s.writeMessage(conn, response); err != nil {
 				logger.Errorf("Failed to send response: %v", err)
 				return
@@ -186,7 +205,7 @@ func (s *SocketServer) handleConnection(ctx context.Context, conn net.Conn) {
 }
 
 // handleMessage processes a single message and returns a response
-func (s *SocketServer) handleMessage(msg *pb.IPCMessage) *pb.IPCMessage {
+func (s *SocketServer) handleMessage(msg *pb.IPCMessage, privileged bool) *pb.IPCMessage {
 	switch msg.Type {
 	case pb.IPCMessageType_IPC_MESSAGE_TYPE_SWITCH:
 		cmd, err := GetSwitchCommand(msg)
@@ -216,12 +235,74 @@ func (s *SocketServer) handleMessage(msg *pb.IPCMessage) *pb.IPCMessage {
 		}
 		return response
 
+	case pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_LIST, pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_DECISION,
+		pb.IPCMessageType_IPC_MESSAGE_TYPE_PAIR:
+		authHandler, ok := s.handler.(AuthHandler)
+		if !ok {
+			errMsg, _ := NewErrorMessage("This waymon instance does not manage SSH keys")
+			return errMsg
+		}
+		if !privileged {
+			errMsg, _ := NewErrorMessage("Permission denied: run as root or as the user running the server")
+			return errMsg
+		}
+
+		var response *pb.IPCMessage
+		var err error
+		switch msg.Type {
+		case pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_LIST:
+			var query *pb.AuthListQuery
+			if query, err = GetAuthListQuery(msg); err == nil {
+				response, err = authHandler.HandleAuthList(query)
+			}
+		case pb.IPCMessageType_IPC_MESSAGE_TYPE_AUTH_DECISION:
+			var decision *pb.AuthDecision
+			if decision, err = GetAuthDecision(msg); err == nil {
+				response, err = authHandler.HandleAuthDecision(decision)
+			}
+		default:
+			var req *pb.PairRequest
+			if req, err = GetPairRequest(msg); err == nil {
+				response, err = authHandler.HandlePairRequest(req)
+			}
+		}
+		if err != nil {
+			errMsg, _ := NewErrorMessage(err.Error())
+			return errMsg
+		}
+		return response
+
 	default:
 		errMsg, _ := NewErrorMessage(fmt.Sprintf("Unknown message type: %s", msg.Type))
 		return errMsg
 	}
 }
 
+// peerUID returns the user ID of the process at the other end of a Unix
+// socket connection
+func peerUID(conn net.Conn) (uint32, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("not a Unix socket connection")
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, fmt.Errorf("failed to access socket: %w", err)
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, fmt.Errorf("failed to access socket: %w", err)
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+	return cred.Uid, nil
+}
+
 // readMessage reads a protobuf message from the connection
 func (s *SocketServer) readMessage(conn net.Conn) (*pb.IPCMessage, error) {
 	// Read message length (4 bytes, big endian)
@@ -0,0 +1,147 @@
+// Package keymap obtains the XKB keymap the server's keyboard is read with, so
+// clients can interpret forwarded key codes the same way.
+package keymap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bnema/waymon/internal/logger"
+)
+
+// Names selects a keymap by XKB rules, model, layout, variant and options,
+// the same names used by compositor keyboard settings
+type Names struct {
+	Rules   string
+	Model   string
+	Layout  string
+	Variant string
+	Options string
+}
+
+// String returns a short description such as "us,de(nodeadkeys)"
+func (n Names) String() string {
+	layouts := strings.Split(n.Layout, ",")
+	variants := strings.Split(n.Variant, ",")
+	for i := range layouts {
+		if i < len(variants) && variants[i] != "" {
+			layouts[i] += "(" + variants[i] + ")"
+		}
+	}
+	return strings.Join(layouts, ",")
+}
+
+// FromCompositor returns the keymap the compositor currently sends to
+// keyboard clients. It needs xkbcli from libxkbcommon-tools.
+func FromCompositor() (string, error) {
+	keymap, err := runXkbcli("dump-keymap-wayland")
+	if err != nil {
+		return "", fmt.Errorf("failed to dump compositor keymap: %w", err)
+	}
+	return keymap, nil
+}
+
+// Compile builds a keymap from XKB names. Without xkbcli a keymap is
+// assembled from the layouts alone and the model and options are dropped.
+func Compile(names Names) (string, error) {
+	if names.Layout == "" {
+		return "", fmt.Errorf("no layout configured")
+	}
+
+	args := []string{"compile-keymap"}
+	for _, opt := range [][2]string{
+		{"--rules", names.Rules},
+		{"--model", names.Model},
+		{"--layout", names.Layout},
+		{"--variant", names.Variant},
+		{"--options", names.Options},
+	} {
+		if opt[1] != "" {
+			args = append(args, opt[0], opt[1])
+		}
+	}
+
+	keymap, err := runXkbcli(args...)
+	if err == nil {
+		return keymap, nil
+	}
+	logger.Debugf("[KEYMAP] xkbcli compile-keymap failed, using a basic keymap for %s: %v", names, err)
+	return Basic(names), nil
+}
+
+// Basic returns a keymap for the given layouts and variants built from the
+// standard evdev components
+func Basic(names Names) string {
+	symbols := []string{"pc"}
+	variants := strings.Split(names.Variant, ",")
+	for i, layout := range strings.Split(names.Layout, ",") {
+		layout = strings.TrimSpace(layout)
+		if layout == "" {
+			continue
+		}
+		if i < len(variants) && strings.TrimSpace(variants[i]) != "" {
+			layout += "(" + strings.TrimSpace(variants[i]) + ")"
+		}
+		if i > 0 {
+			layout += fmt.Sprintf(":%d", i+1)
+		}
+		symbols = append(symbols, layout)
+	}
+	symbols = append(symbols, "inet(evdev)")
+
+	return fmt.Sprintf(`xkb_keymap {
+	xkb_keycodes  { include "evdev+aliases(qwerty)"	};
+	xkb_types     { include "complete"	};
+	xkb_compat    { include "complete"	};
+	xkb_symbols   { include "%s"	};
+	xkb_geometry  { include "pc(pc105)"	};
+};
+`, strings.Join(symbols, "+"))
+}
+
+// runXkbcli runs an xkbcli subcommand and returns its output
+func runXkbcli(args ...string) (string, error) {
+	cmd := exec.Command("xkbcli", args...)
+	cmd.Env = waylandEnv()
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	if !strings.Contains(string(out), "xkb_keymap") {
+		return "", fmt.Errorf("xkbcli returned no keymap")
+	}
+	return string(out), nil
+}
+
+// waylandEnv returns the environment with the Wayland session of the user
+// that started waymon through sudo, where root's own environment has none
+func waylandEnv() []string {
+	env := os.Environ()
+	sudoUID := os.Getenv("SUDO_UID")
+	if sudoUID == "" || os.Geteuid() != 0 {
+		return env
+	}
+
+	runtimeDir := "/run/user/" + sudoUID
+	env = append(env, "XDG_RUNTIME_DIR="+runtimeDir)
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		if entries, err := os.ReadDir(runtimeDir); err == nil {
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "wayland-") && !strings.HasSuffix(entry.Name(), ".lock") {
+					env = append(env, "WAYLAND_DISPLAY="+entry.Name())
+					break
+				}
+			}
+		}
+	}
+	return env
+}
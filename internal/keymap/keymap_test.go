@@ -0,0 +1,44 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamesString(t *testing.T) {
+	tests := []struct {
+		names    Names
+		expected string
+	}{
+		{Names{Layout: "us"}, "us"},
+		{Names{Layout: "de", Variant: "nodeadkeys"}, "de(nodeadkeys)"},
+		{Names{Layout: "us,fr", Variant: ",azerty"}, "us,fr(azerty)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.names.String(); got != tt.expected {
+			t.Errorf("Names%+v.String() = %q, want %q", tt.names, got, tt.expected)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	tests := []struct {
+		names   Names
+		symbols string
+	}{
+		{Names{Layout: "us"}, `include "pc+us+inet(evdev)"`},
+		{Names{Layout: "de", Variant: "nodeadkeys"}, `include "pc+de(nodeadkeys)+inet(evdev)"`},
+		{Names{Layout: "us, ru", Variant: ",phonetic"}, `include "pc+us+ru(phonetic):2+inet(evdev)"`},
+	}
+
+	for _, tt := range tests {
+		keymap := Basic(tt.names)
+		if !strings.Contains(keymap, tt.symbols) {
+			t.Errorf("Basic(%+v) has no %s:\n%s", tt.names, tt.symbols, keymap)
+		}
+		if !strings.HasPrefix(keymap, "xkb_keymap {") {
+			t.Errorf("Basic(%+v) is not a keymap:\n%s", tt.names, keymap)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// AuthorizedKey is a key listed in an authorized_keys file
+type AuthorizedKey struct {
+	Key         gossh.PublicKey
+	Fingerprint string
+	Comment     string
+	Options     AuthorizedKeyOptions
+	Line        int
+}
+
+// AuthorizedKeyOptions are the restrictions set on an authorized key. Besides
+// the OpenSSH options that make sense for waymon, a few of its own are
+// understood:
+//
+//	waymon-name="laptop"          name shown for the client instead of the one it reports
+//	waymon-no-keyboard            forward the mouse only
+//	waymon-hours="08:00-18:00"    local times the key may be used, comma separated
+type AuthorizedKeyOptions struct {
+	From       []string  // from="..." address patterns, "!" negates
+	ExpiryTime time.Time // expiry-time="YYYYMMDD[HHMM[SS]][Z]", zero if none
+	ClientName string
+	NoKeyboard bool
+	hours      []hourRange
+}
+
+// hourRange is a daily time window in minutes after midnight. It wraps past
+// midnight when end is before start.
+type hourRange struct {
+	start, end int
+	text       string
+}
+
+// OpenSSH options that only affect shells, forwarding or terminals. They
+// change nothing for waymon, so keys restricted with them are still accepted.
+var ignoredKeyOptions = map[string]bool{
+	"restrict":            true,
+	"no-pty":              true,
+	"pty":                 true,
+	"no-port-forwarding":  true,
+	"port-forwarding":     true,
+	"no-agent-forwarding": true,
+	"agent-forwarding":    true,
+	"no-x11-forwarding":   true,
+	"x11-forwarding":      true,
+	"no-user-rc":          true,
+	"user-rc":             true,
+	"no-touch-required":   true,
+	"permitopen":          true,
+	"permitlisten":        true,
+	"environment":         true,
+	"tunnel":              true,
+}
+
+// LoadAuthorizedKeys reads an authorized_keys file. Lines that cannot be
+// parsed, or that use options waymon cannot honor, are skipped with a warning
+// so that one bad entry does not lock every client out. A missing file has no
+// keys.
+func LoadAuthorizedKeys(path string) ([]AuthorizedKey, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the server configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorized keys: %w", err)
+	}
+
+	var keys []AuthorizedKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		key, comment, options, _, err := gossh.ParseAuthorizedKey(line)
+		if err != nil {
+			logger.Warnf("Skipping invalid line %d of %s: %v", lineNum, path, err)
+			continue
+		}
+		opts, err := parseKeyOptions(options)
+		if err != nil {
+			logger.Warnf("Skipping line %d of %s: %v", lineNum, path, err)
+			continue
+		}
+		keys = append(keys, AuthorizedKey{
+			Key:         key,
+			Fingerprint: gossh.FingerprintSHA256(key),
+			Comment:     comment,
+			Options:     opts,
+			Line:        lineNum,
+		})
+	}
+	return keys, scanner.Err()
+}
+
+// AppendAuthorizedKey adds a key to an authorized_keys file in the standard
+// format, so it can be edited with the usual tools afterwards
+func AppendAuthorizedKey(path string, key gossh.PublicKey, comment string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create authorized keys directory: %w", err)
+	}
+
+	// Don't glue the new key to a last line that lacks its newline
+	var prefix string
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 && data[len(data)-1] != '\n' { //nolint:gosec // path comes from the server configuration
+		prefix = "\n"
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec // path comes from the server configuration
+	if err != nil {
+		return fmt.Errorf("failed to open authorized keys: %w", err)
+	}
+
+	line := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		line += " " + comment
+	}
+	if _, err := fmt.Fprintf(f, "%s%s\n", prefix, line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write authorized keys: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write authorized keys: %w", err)
+	}
+	return nil
+}
+
+// findAuthorizedKey returns the entry of keys matching key, if any
+func findAuthorizedKey(keys []AuthorizedKey, key gossh.PublicKey) *AuthorizedKey {
+	wire := key.Marshal()
+	for i := range keys {
+		if bytes.Equal(keys[i].Key.Marshal(), wire) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// parseKeyOptions interprets the options of an authorized_keys line
+func parseKeyOptions(options []string) (AuthorizedKeyOptions, error) {
+	var opts AuthorizedKeyOptions
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		name = strings.ToLower(name)
+		value = strings.Trim(value, `"`)
+
+		switch name {
+		case "from":
+			for _, pattern := range strings.Split(value, ",") {
+				if pattern = strings.TrimSpace(pattern); pattern != "" {
+					opts.From = append(opts.From, pattern)
+				}
+			}
+			if len(opts.From) == 0 {
+				return opts, fmt.Errorf("empty from option")
+			}
+		case "expiry-time":
+			expiry, err := parseExpiryTime(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.ExpiryTime = expiry
+		case "waymon-name":
+			if value == "" {
+				return opts, fmt.Errorf("empty waymon-name option")
+			}
+			opts.ClientName = value
+		case "waymon-no-keyboard":
+			opts.NoKeyboard = true
+		case "waymon-hours":
+			hours, err := parseHours(value)
+			if err != nil {
+				return opts, err
+			}
+			opts.hours = append(opts.hours, hours...)
+		default:
+			if !ignoredKeyOptions[name] {
+				// Accepting a key whose restriction we cannot enforce would
+				// grant more than the file says
+				return opts, fmt.Errorf("unsupported option %q", name)
+			}
+		}
+	}
+	return opts, nil
+}
+
+// parseExpiryTime parses an OpenSSH expiry-time value, in local time unless
+// it ends with Z
+func parseExpiryTime(value string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(value, "Z") || strings.HasSuffix(value, "z") {
+		loc = time.UTC
+		value = value[:len(value)-1]
+	}
+
+	var layout string
+	switch len(value) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, fmt.Errorf("invalid expiry-time %q", value)
+	}
+	expiry, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry-time %q: %w", value, err)
+	}
+	return expiry, nil
+}
+
+// parseHours parses comma separated HH:MM-HH:MM windows
+func parseHours(value string) ([]hourRange, error) {
+	var ranges []hourRange
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid waymon-hours range %q, expected HH:MM-HH:MM", part)
+		}
+		start, err := time.Parse("15:04", strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid waymon-hours range %q: %w", part, err)
+		}
+		end, err := time.Parse("15:04", strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("invalid waymon-hours range %q: %w", part, err)
+		}
+		r := hourRange{
+			start: start.Hour()*60 + start.Minute(),
+			end:   end.Hour()*60 + end.Minute(),
+			text:  part,
+		}
+		if r.start == r.end {
+			return nil, fmt.Errorf("empty waymon-hours range %q", part)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// check returns why the key may not be used from ip at now, or nil if it may
+func (o *AuthorizedKeyOptions) check(ip net.IP, now time.Time) error {
+	if len(o.From) > 0 && !matchFrom(o.From, ip) {
+		return fmt.Errorf("not allowed from %s (from=%q)", ip, strings.Join(o.From, ","))
+	}
+	if !o.ExpiryTime.IsZero() && !now.Before(o.ExpiryTime) {
+		return fmt.Errorf("key expired on %s", o.ExpiryTime.Format(time.RFC3339))
+	}
+	if len(o.hours) > 0 && o.windowEnd(now).IsZero() {
+		texts := make([]string, len(o.hours))
+		for i, r := range o.hours {
+			texts[i] = r.text
+		}
+		return fmt.Errorf("outside allowed hours %s", strings.Join(texts, ","))
+	}
+	return nil
+}
+
+// allowedUntil returns when a session started at now has to end because the
+// key expires or its allowed hours are over, or zero if it never has to
+func (o *AuthorizedKeyOptions) allowedUntil(now time.Time) time.Time {
+	until := o.windowEnd(now)
+	if !o.ExpiryTime.IsZero() && (until.IsZero() || o.ExpiryTime.Before(until)) {
+		until = o.ExpiryTime
+	}
+	return until
+}
+
+// windowEnd returns the end of the allowed hours window containing now, or
+// zero if now is outside all of them or no hours are set
+func (o *AuthorizedKeyOptions) windowEnd(now time.Time) time.Time {
+	minute := now.Hour()*60 + now.Minute()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var end time.Time
+	for _, r := range o.hours {
+		var rangeEnd time.Time
+		switch {
+		case r.start < r.end && minute >= r.start && minute < r.end:
+			rangeEnd = midnight.Add(time.Duration(r.end) * time.Minute)
+		case r.start > r.end && minute >= r.start:
+			rangeEnd = midnight.AddDate(0, 0, 1).Add(time.Duration(r.end) * time.Minute)
+		case r.start > r.end && minute < r.end:
+			rangeEnd = midnight.Add(time.Duration(r.end) * time.Minute)
+		default:
+			continue
+		}
+		if rangeEnd.After(end) {
+			end = rangeEnd
+		}
+	}
+	return end
+}
+
+// matchFrom matches an address against from= patterns the way OpenSSH does:
+// a negated match refuses, otherwise any match allows. Patterns are
+// addresses with * and ? wildcards, or CIDR networks. Host names are not
+// resolved.
+func matchFrom(patterns []string, ip net.IP) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if !matchAddress(pattern, ip) {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchAddress matches an address against one from= pattern
+func matchAddress(pattern string, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if strings.Contains(pattern, "/") {
+		_, network, err := net.ParseCIDR(pattern)
+		return err == nil && network.Contains(ip)
+	}
+	ok, _ := path.Match(pattern, ip.String())
+	return ok
+}
+
+// remoteIP returns the IP address of a network address
+func remoteIP(addr net.Addr) net.IP {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,141 @@
+package network
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// TestAuthorizedKeyOptions tests the restrictions set by authorized_keys options
+func TestAuthorizedKeyOptions(t *testing.T) {
+	noon := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	lan := net.ParseIP("192.168.1.20")
+
+	tests := []struct {
+		name      string
+		options   []string
+		ip        net.IP
+		now       time.Time
+		wantErr   bool // Option cannot be parsed
+		wantDeny  bool
+		wantUntil time.Time
+	}{
+		{name: "no options", ip: lan, now: noon},
+		{name: "ignored ssh options", options: []string{"no-pty", "restrict"}, ip: lan, now: noon},
+		{name: "unsupported option", options: []string{`command="/bin/true"`}, wantErr: true},
+		{name: "from wildcard", options: []string{`from="192.168.1.*"`}, ip: lan, now: noon},
+		{name: "from cidr", options: []string{`from="10.0.0.0/8,192.168.0.0/16"`}, ip: lan, now: noon},
+		{name: "from other network", options: []string{`from="10.0.0.0/8"`}, ip: lan, now: noon, wantDeny: true},
+		{name: "from negated", options: []string{`from="!192.168.1.20,192.168.1.*"`}, ip: lan, now: noon, wantDeny: true},
+		{name: "not expired", options: []string{`expiry-time="20260311Z"`}, ip: lan, now: noon,
+			wantUntil: time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{name: "expired", options: []string{`expiry-time="202603101159Z"`}, ip: lan, now: noon, wantDeny: true},
+		{name: "bad expiry", options: []string{`expiry-time="tomorrow"`}, wantErr: true},
+		{name: "within hours", options: []string{`waymon-hours="08:00-18:00"`}, ip: lan, now: noon,
+			wantUntil: time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)},
+		{name: "outside hours", options: []string{`waymon-hours="08:00-11:00,13:00-18:00"`}, ip: lan, now: noon, wantDeny: true},
+		{name: "overnight hours", options: []string{`waymon-hours="22:00-06:00"`}, ip: lan,
+			now: time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC), wantUntil: time.Date(2026, 3, 11, 6, 0, 0, 0, time.UTC)},
+		{name: "expiry before end of hours", options: []string{`waymon-hours="08:00-18:00"`, `expiry-time="202603101500Z"`}, ip: lan, now: noon,
+			wantUntil: time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)},
+		{name: "bad hours", options: []string{`waymon-hours="9-5"`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseKeyOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKeyOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if err := opts.check(tt.ip, tt.now); (err != nil) != tt.wantDeny {
+				t.Errorf("check() = %v, wantDeny %v", err, tt.wantDeny)
+			}
+			if !tt.wantDeny {
+				if got := opts.allowedUntil(tt.now); !got.Equal(tt.wantUntil) {
+					t.Errorf("allowedUntil() = %v, want %v", got, tt.wantUntil)
+				}
+			}
+		})
+	}
+}
+
+// TestAuthorizedKeysFile tests reading and appending to an authorized_keys file
+func TestAuthorizedKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authorized_keys")
+	restricted := newTestHostKey(t)
+	approved := newTestHostKey(t)
+
+	content := "# Waymon clients\n" +
+		`from="192.168.1.0/24",waymon-name="laptop",waymon-no-keyboard ` +
+		strings.TrimSpace(string(gossh.MarshalAuthorizedKey(restricted))) + " me@laptop\n" +
+		"not a key\n" +
+		`frobnicate ` + strings.TrimSpace(string(gossh.MarshalAuthorizedKey(approved))) // no trailing newline
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write authorized keys: %v", err)
+	}
+
+	if err := AppendAuthorizedKey(path, approved, "me@desktop"); err != nil {
+		t.Fatalf("AppendAuthorizedKey failed: %v", err)
+	}
+
+	keys, err := LoadAuthorizedKeys(path)
+	if err != nil {
+		t.Fatalf("LoadAuthorizedKeys failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Loaded %d keys, want 2 (invalid lines skipped): %+v", len(keys), keys)
+	}
+
+	entry := findAuthorizedKey(keys, restricted)
+	if entry == nil || entry.Line != 2 || entry.Comment != "me@laptop" {
+		t.Fatalf("Restricted key entry = %+v", entry)
+	}
+	if entry.Options.ClientName != "laptop" || !entry.Options.NoKeyboard || len(entry.Options.From) != 1 {
+		t.Errorf("Restricted key options = %+v", entry.Options)
+	}
+
+	entry = findAuthorizedKey(keys, approved)
+	if entry == nil || entry.Line != 5 || entry.Comment != "me@desktop" {
+		t.Errorf("Appended key entry = %+v", entry)
+	}
+
+	if entry := findAuthorizedKey(keys, newTestHostKey(t)); entry != nil {
+		t.Errorf("Unknown key found: %+v", entry)
+	}
+}
+
+// TestKeyOptions tests that a session gets the options of its own key only
+func TestKeyOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authorized_keys")
+	restricted := newTestHostKey(t)
+	content := `from="192.168.1.0/24",waymon-name="laptop" ` + string(gossh.MarshalAuthorizedKey(restricted))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write authorized keys: %v", err)
+	}
+	s := NewSSHServer(0, "", path)
+	lan := net.ParseIP("192.168.1.20")
+	now := time.Now()
+
+	opts, err := s.keyOptions(restricted, "alice", lan, now)
+	if err != nil || opts == nil || opts.ClientName != "laptop" {
+		t.Fatalf("keyOptions(restricted) = %+v, %v", opts, err)
+	}
+	if _, err := s.keyOptions(restricted, "alice", net.ParseIP("10.0.0.1"), now); err == nil {
+		t.Errorf("keyOptions(restricted) from another network succeeded")
+	}
+
+	// A whitelisted or approved key has no entry, whatever keys were queried before
+	opts, err = s.keyOptions(newTestHostKey(t), "alice", lan, now)
+	if err != nil || opts != nil {
+		t.Errorf("keyOptions(other key) = %+v, %v, want no options", opts, err)
+	}
+}
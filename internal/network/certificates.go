@@ -0,0 +1,167 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// sourceAddressOption is the certificate critical option restricting the
+// addresses it may be used from
+const sourceAddressOption = "source-address"
+
+// LoadCAKeys reads the public keys of trusted certificate authorities, one per
+// line as in an authorized_keys file. A missing file trusts no authority.
+func LoadCAKeys(path string) ([]gossh.PublicKey, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the server configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA keys: %w", err)
+	}
+
+	var keys []gossh.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		key, _, _, _, err := gossh.ParseAuthorizedKey(line)
+		if err != nil {
+			logger.Warnf("Skipping invalid line %d of %s: %v", lineNum, path, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+// checkCertificate validates a user certificate presented by user from ip and
+// returns the restrictions it carries. The key ID becomes the client name.
+func (s *SSHServer) checkCertificate(cert *gossh.Certificate, user string, ip net.IP, now time.Time) (*AuthorizedKeyOptions, error) {
+	if cert.CertType != gossh.UserCert {
+		return nil, fmt.Errorf("not a user certificate")
+	}
+	if s.caKeysPath == "" {
+		return nil, fmt.Errorf("no trusted certificate authorities configured")
+	}
+
+	cas, err := LoadCAKeys(s.caKeysPath)
+	if err != nil {
+		return nil, err
+	}
+	signer := cert.SignatureKey.Marshal()
+	if !slices.ContainsFunc(cas, func(ca gossh.PublicKey) bool { return bytes.Equal(ca.Marshal(), signer) }) {
+		return nil, fmt.Errorf("signed by untrusted authority %s", gossh.FingerprintSHA256(cert.SignatureKey))
+	}
+
+	// Like sshd, require a principal and accept the SSH user name unless
+	// principals are configured
+	allowed := s.certPrincipals
+	if len(allowed) == 0 {
+		allowed = []string{user}
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return nil, fmt.Errorf("certificate lists no principals")
+	}
+	i := slices.IndexFunc(cert.ValidPrincipals, func(p string) bool { return slices.Contains(allowed, p) })
+	if i < 0 {
+		return nil, fmt.Errorf("principals %q not accepted, want one of %q", cert.ValidPrincipals, allowed)
+	}
+
+	// Validity window, signature and critical options. Unknown critical
+	// options, such as force-command, refuse the certificate.
+	checker := &gossh.CertChecker{
+		SupportedCriticalOptions: []string{sourceAddressOption},
+		Clock:                    func() time.Time { return now },
+	}
+	if err := checker.CheckCert(cert.ValidPrincipals[i], cert); err != nil {
+		return nil, err
+	}
+
+	opts := &AuthorizedKeyOptions{ClientName: cert.KeyId}
+	if addresses, ok := cert.CriticalOptions[sourceAddressOption]; ok {
+		for _, address := range strings.Split(addresses, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				opts.From = append(opts.From, address)
+			}
+		}
+		if len(opts.From) == 0 {
+			return nil, fmt.Errorf("empty %s option", sourceAddressOption)
+		}
+	}
+	if cert.ValidBefore != gossh.CertTimeInfinity && cert.ValidBefore <= math.MaxInt64 {
+		opts.ExpiryTime = time.Unix(int64(cert.ValidBefore), 0)
+	}
+	if err := opts.check(ip, now); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// identityKey returns the key a client is known by: the certified key for a
+// certificate, so a renewed certificate keeps the client's identity
+func identityKey(key gossh.PublicKey) gossh.PublicKey {
+	if cert, ok := key.(*gossh.Certificate); ok {
+		return cert.Key
+	}
+	return key
+}
+
+// loadCertificate returns signer paired with the OpenSSH certificate stored
+// next to its private key as <key>-cert.pub, or nil if there is none
+func loadCertificate(keyPath string, signer gossh.Signer) (gossh.Signer, error) {
+	certPath := keyPath + "-cert.pub"
+	data, err := os.ReadFile(filepath.Clean(certPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	key, _, _, _, err := gossh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", certPath, err)
+	}
+	cert, ok := key.(*gossh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a certificate", certPath)
+	}
+	certSigner, err := gossh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate %s does not match its key: %w", certPath, err)
+	}
+	return certSigner, nil
+}
+
+// certificatesFirst orders signers so that certificates are offered before
+// plain keys, which would otherwise need approval on the server
+func certificatesFirst(signers []gossh.Signer) []gossh.Signer {
+	ordered := make([]gossh.Signer, 0, len(signers))
+	for _, signer := range signers {
+		if _, ok := signer.PublicKey().(*gossh.Certificate); ok {
+			ordered = append(ordered, signer)
+		}
+	}
+	for _, signer := range signers {
+		if _, ok := signer.PublicKey().(*gossh.Certificate); !ok {
+			ordered = append(ordered, signer)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,165 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newTestSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+// TestCheckCertificate tests validation of user certificates
+func TestCheckCertificate(t *testing.T) {
+	ca := newTestSigner(t)
+	otherCA := newTestSigner(t)
+	user := newTestSigner(t)
+	now := time.Unix(1_800_000_000, 0)
+	lan := net.ParseIP("192.168.1.20")
+
+	caPath := filepath.Join(t.TempDir(), "ca.pub")
+	if err := os.WriteFile(caPath, gossh.MarshalAuthorizedKey(ca.PublicKey()), 0600); err != nil {
+		t.Fatalf("Failed to write CA keys: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(cert *gossh.Certificate)
+		signer     gossh.Signer
+		principals []string // Configured on the server
+		wantErr    bool
+	}{
+		{name: "valid for the user name"},
+		{name: "configured principal", principals: []string{"waymon"},
+			modify: func(c *gossh.Certificate) { c.ValidPrincipals = []string{"someone", "waymon"} }},
+		{name: "user name not listed", modify: func(c *gossh.Certificate) { c.ValidPrincipals = []string{"root"} }, wantErr: true},
+		{name: "principal not configured", principals: []string{"waymon"}, wantErr: true},
+		{name: "no principals", modify: func(c *gossh.Certificate) { c.ValidPrincipals = nil }, wantErr: true},
+		{name: "untrusted authority", signer: otherCA, wantErr: true},
+		{name: "host certificate", modify: func(c *gossh.Certificate) { c.CertType = gossh.HostCert }, wantErr: true},
+		{name: "expired", modify: func(c *gossh.Certificate) { c.ValidBefore = uint64(now.Add(-time.Minute).Unix()) }, wantErr: true},
+		{name: "not yet valid", modify: func(c *gossh.Certificate) { c.ValidAfter = uint64(now.Add(time.Minute).Unix()) }, wantErr: true},
+		{name: "force-command", modify: func(c *gossh.Certificate) {
+			c.CriticalOptions = map[string]string{"force-command": "/bin/true"}
+		}, wantErr: true},
+		{name: "source-address allowed", modify: func(c *gossh.Certificate) {
+			c.CriticalOptions = map[string]string{sourceAddressOption: "10.0.0.0/8,192.168.1.0/24"}
+		}},
+		{name: "source-address refused", modify: func(c *gossh.Certificate) {
+			c.CriticalOptions = map[string]string{sourceAddressOption: "10.0.0.0/8"}
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &gossh.Certificate{
+				Key:             user.PublicKey(),
+				KeyId:           "alice-laptop",
+				CertType:        gossh.UserCert,
+				ValidPrincipals: []string{"alice"},
+				ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+				ValidBefore:     uint64(now.Add(time.Hour).Unix()),
+			}
+			if tt.modify != nil {
+				tt.modify(cert)
+			}
+			signer := ca
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+			if err := cert.SignCert(rand.Reader, signer); err != nil {
+				t.Fatalf("Failed to sign certificate: %v", err)
+			}
+
+			s := NewSSHServer(0, "", "")
+			s.SetTrustedCAKeys(caPath, tt.principals)
+			opts, err := s.checkCertificate(cert, "alice", lan, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkCertificate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.ClientName != "alice-laptop" {
+				t.Errorf("ClientName = %q, want the key ID", opts.ClientName)
+			}
+			if !opts.ExpiryTime.Equal(time.Unix(int64(cert.ValidBefore), 0)) {
+				t.Errorf("ExpiryTime = %v, want the end of the validity window", opts.ExpiryTime)
+			}
+		})
+	}
+}
+
+// TestIdentityKey tests that renewed certificates keep the identity of their key
+func TestIdentityKey(t *testing.T) {
+	ca := newTestSigner(t)
+	user := newTestSigner(t)
+	issue := func(serial uint64) *gossh.Certificate {
+		cert := &gossh.Certificate{Key: user.PublicKey(), Serial: serial, KeyId: "alice-laptop", CertType: gossh.UserCert}
+		if err := cert.SignCert(rand.Reader, ca); err != nil {
+			t.Fatalf("Failed to sign certificate: %v", err)
+		}
+		return cert
+	}
+
+	want := gossh.FingerprintSHA256(user.PublicKey())
+	for _, key := range []gossh.PublicKey{user.PublicKey(), issue(1), issue(2)} {
+		if got := gossh.FingerprintSHA256(identityKey(key)); got != want {
+			t.Errorf("identityKey(%T) fingerprint = %s, want %s", key, got, want)
+		}
+	}
+}
+
+// TestLoadCertificate tests pairing a private key with the certificate next to it
+func TestLoadCertificate(t *testing.T) {
+	ca := newTestSigner(t)
+	user := newTestSigner(t)
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+
+	if signer, err := loadCertificate(keyPath, user); err != nil || signer != nil {
+		t.Fatalf("Without a certificate: %v, %v, want nil, nil", signer, err)
+	}
+
+	cert := &gossh.Certificate{
+		Key:             user.PublicKey(),
+		CertType:        gossh.UserCert,
+		ValidPrincipals: []string{"alice"},
+		ValidBefore:     gossh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("Failed to sign certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath+"-cert.pub", gossh.MarshalAuthorizedKey(cert), 0600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+
+	certSigner, err := loadCertificate(keyPath, user)
+	if err != nil || certSigner == nil {
+		t.Fatalf("loadCertificate() = %v, %v", certSigner, err)
+	}
+	ordered := certificatesFirst([]gossh.Signer{user, certSigner})
+	if _, ok := ordered[0].PublicKey().(*gossh.Certificate); !ok {
+		t.Errorf("Certificate not offered first")
+	}
+
+	// A certificate for another key is an error
+	if _, err := loadCertificate(keyPath, newTestSigner(t)); err == nil {
+		t.Errorf("Certificate for another key accepted")
+	}
+}
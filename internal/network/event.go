@@ -11,3 +11,11 @@ type MouseEvent struct {
 
 // EventHandler is a callback for handling mouse events
 type EventHandler func(event *MouseEvent) error
+
+// maxMessageSize bounds the length prefix of a framed message. Keymaps and
+// clipboard contents are far larger than input events; any length below 16 MiB
+// keeps a zero high byte, so it can never be mistaken for a text line from the server.
+const maxMessageSize = 16<<20 - 1
+
+// MaxClipboardSize is the largest clipboard content that fits in a message
+const MaxClipboardSize = maxMessageSize - 4096
@@ -0,0 +1,170 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// Every session opens with a Hello from the client. The server answers with
+// the protocol version and features agreed for the session, or with an error
+// before closing it when the two cannot talk.
+
+// helloTimeout is how long each side waits for the other's Hello
+const helloTimeout = 10 * time.Second
+
+// ErrProtocolMismatch is returned when the server and the client do not speak
+// a common protocol version
+var ErrProtocolMismatch = errors.New("incompatible protocol version")
+
+// newHello creates the Hello of this side
+func newHello(features []string, softwareVersion string) *protocol.Hello {
+	return &protocol.Hello{
+		ProtocolVersion:    protocol.Version,
+		MinProtocolVersion: protocol.MinVersion,
+		Features:           features,
+		SoftwareVersion:    softwareVersion,
+	}
+}
+
+// helloEvent wraps a Hello for sending
+func helloEvent(hello *protocol.Hello, sourceID string) *protocol.InputEvent {
+	return &protocol.InputEvent{
+		Event:     &protocol.InputEvent_Hello{Hello: hello},
+		Timestamp: time.Now().UnixNano(),
+		SourceId:  sourceID,
+	}
+}
+
+// acceptHello is the server side of the exchange: it reads the client Hello
+// from conn and answers it. It returns the client Hello and what was agreed.
+// conn is closed when the client does not speak first within timeout.
+func acceptHello(conn io.ReadWriteCloser, local *protocol.Hello, timeout time.Duration) (client, agreed *protocol.Hello, err error) {
+	event, err := readMessageWithin(conn, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no hello from the client: %w", err)
+	}
+	client = event.GetHello()
+	if client == nil {
+		// Clients from before the exchange send their configuration first
+		return nil, nil, fmt.Errorf("%w: the client does not support protocol v%d or newer", ErrProtocolMismatch, local.MinProtocolVersion)
+	}
+
+	agreed, err = protocol.Negotiate(local, client)
+	if err != nil {
+		refusal := &protocol.Hello{
+			ProtocolVersion:    local.ProtocolVersion,
+			MinProtocolVersion: local.MinProtocolVersion,
+			SoftwareVersion:    local.SoftwareVersion,
+			Error:              err.Error(),
+		}
+		if err := writeInputMessage(conn, helloEvent(refusal, "server")); err != nil {
+			return client, nil, fmt.Errorf("failed to send hello: %w", err)
+		}
+		return client, nil, fmt.Errorf("%w: %v", ErrProtocolMismatch, err)
+	}
+
+	if err := writeInputMessage(conn, helloEvent(agreed, "server")); err != nil {
+		return client, nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+	return client, agreed, nil
+}
+
+// sendHello is the client side of the exchange: it sends the client Hello on
+// conn and returns the server answer. conn is closed when the server does not
+// answer within timeout.
+func sendHello(conn io.ReadWriteCloser, local *protocol.Hello, sourceID string, timeout time.Duration) (*protocol.Hello, error) {
+	if err := writeInputMessage(conn, helloEvent(local, sourceID)); err != nil {
+		return nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	event, err := readMessageWithin(conn, timeout)
+	if err != nil {
+		// Servers from before the exchange never answer
+		return nil, fmt.Errorf("%w: no hello from the server, it may run an older waymon: %v", ErrProtocolMismatch, err)
+	}
+	agreed := event.GetHello()
+	switch {
+	case agreed == nil:
+		return nil, fmt.Errorf("%w: the server did not answer the hello", ErrProtocolMismatch)
+	case agreed.Error != "":
+		return nil, fmt.Errorf("%w: the server refused the session: %s", ErrProtocolMismatch, agreed.Error)
+	case agreed.ProtocolVersion < local.MinProtocolVersion || agreed.ProtocolVersion > local.ProtocolVersion:
+		return nil, fmt.Errorf("%w: the server chose protocol v%d", ErrProtocolMismatch, agreed.ProtocolVersion)
+	}
+	return agreed, nil
+}
+
+// readMessageWithin reads one message, closing conn to end the read if it
+// takes longer than timeout
+func readMessageWithin(conn io.ReadCloser, timeout time.Duration) (*protocol.InputEvent, error) {
+	timer := time.AfterFunc(timeout, func() {
+		_ = conn.Close()
+	})
+	event, err := readMessage(conn)
+	if !timer.Stop() {
+		return nil, fmt.Errorf("timed out after %v", timeout)
+	}
+	return event, err
+}
+
+// readMessage reads one length prefixed message
+func readMessage(r io.Reader) (*protocol.InputEvent, error) {
+	var lengthBuf [4]byte
+	if _, err := io.ReadFull(r, lengthBuf[:]); err != nil {
+		return nil, err
+	}
+	length := int(lengthBuf[0])<<24 | int(lengthBuf[1])<<16 | int(lengthBuf[2])<<8 | int(lengthBuf[3])
+	if length <= 0 || length > maxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	var event protocol.InputEvent
+	if err := proto.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return &event, nil
+}
+
+// supportsFeature reports whether an event may be sent in a session that
+// agreed on features
+func supportsFeature(features []string, event *protocol.InputEvent) bool {
+	feature := protocol.FeatureOf(event)
+	return feature == "" || slices.Contains(features, feature)
+}
+
+// sessionEvents returns what is sent of an event in a session that agreed on
+// features. The events of a frame are filtered one by one, and sent separately
+// when the session has no frames.
+func sessionEvents(features []string, event *protocol.InputEvent) []*protocol.InputEvent {
+	frame := event.GetFrame()
+	if frame == nil {
+		if !supportsFeature(features, event) {
+			return nil
+		}
+		return []*protocol.InputEvent{event}
+	}
+
+	events := make([]*protocol.InputEvent, 0, len(frame.Events))
+	for _, e := range frame.Events {
+		if supportsFeature(features, e) {
+			events = append(events, e)
+		}
+	}
+	if len(events) < 2 || !slices.Contains(features, protocol.FeatureFrames) {
+		return events
+	}
+	if len(events) < len(frame.Events) {
+		return []*protocol.InputEvent{protocol.NewFrame(events, event.Timestamp, event.SourceId)}
+	}
+	return []*protocol.InputEvent{event}
+}
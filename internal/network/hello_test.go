@@ -0,0 +1,138 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// helloResult is what one side of a hello exchange ended with
+type helloResult struct {
+	agreed *protocol.Hello
+	err    error
+}
+
+// exchangeHellos runs both sides of the exchange over a pipe
+func exchangeHellos(t *testing.T, server, client *protocol.Hello) (serverResult, clientResult helloResult) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	done := make(chan helloResult, 1)
+	go func() {
+		_, agreed, err := acceptHello(serverConn, server, time.Second)
+		if err != nil {
+			// The client is not answered further
+			_ = serverConn.Close()
+		}
+		done <- helloResult{agreed, err}
+	}()
+
+	agreed, err := sendHello(clientConn, client, "laptop", time.Second)
+	return <-done, helloResult{agreed, err}
+}
+
+// TestHelloExchange tests agreeing on the version and the common features
+func TestHelloExchange(t *testing.T) {
+	server := newHello(protocol.Features(), "v1.2.0")
+	client := newHello([]string{protocol.FeatureKeyboard, protocol.FeatureMouse, "future_feature"}, "v1.3.0")
+
+	serverResult, clientResult := exchangeHellos(t, server, client)
+	if serverResult.err != nil || clientResult.err != nil {
+		t.Fatalf("Exchange failed: server %v, client %v", serverResult.err, clientResult.err)
+	}
+
+	want := []string{protocol.FeatureKeyboard, protocol.FeatureMouse}
+	for side, agreed := range map[string]*protocol.Hello{"server": serverResult.agreed, "client": clientResult.agreed} {
+		if agreed.ProtocolVersion != protocol.Version {
+			t.Errorf("%s agreed on protocol v%d, want v%d", side, agreed.ProtocolVersion, protocol.Version)
+		}
+		if !slices.Equal(agreed.Features, want) {
+			t.Errorf("%s agreed on features %v, want %v", side, agreed.Features, want)
+		}
+	}
+	if clientResult.agreed.SoftwareVersion != "v1.2.0" {
+		t.Errorf("Client sees server version %q, want v1.2.0", clientResult.agreed.SoftwareVersion)
+	}
+}
+
+// TestHelloVersionMismatch tests that both sides refuse a session without a
+// common protocol version, the client learning why
+func TestHelloVersionMismatch(t *testing.T) {
+	server := newHello(protocol.Features(), "v1.2.0")
+	client := &protocol.Hello{ProtocolVersion: protocol.Version + 2, MinProtocolVersion: protocol.Version + 1}
+
+	serverResult, clientResult := exchangeHellos(t, server, client)
+	if !errors.Is(serverResult.err, ErrProtocolMismatch) {
+		t.Errorf("Server: got %v, want ErrProtocolMismatch", serverResult.err)
+	}
+	if !errors.Is(clientResult.err, ErrProtocolMismatch) || !strings.Contains(clientResult.err.Error(), "refused") {
+		t.Errorf("Client: got %v, want the server refusal", clientResult.err)
+	}
+}
+
+// TestHelloOlderPeers tests refusing peers from before the exchange
+func TestHelloOlderPeers(t *testing.T) {
+	t.Run("client sends its configuration first", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+		defer clientConn.Close()
+
+		go func() {
+			_ = writeInputMessage(clientConn, &protocol.InputEvent{
+				Event: &protocol.InputEvent_Control{Control: &protocol.ControlEvent{Type: protocol.ControlEvent_CLIENT_CONFIG}},
+			})
+		}()
+		_, _, err := acceptHello(serverConn, newHello(protocol.Features(), ""), time.Second)
+		if !errors.Is(err, ErrProtocolMismatch) {
+			t.Errorf("Got %v, want ErrProtocolMismatch", err)
+		}
+	})
+
+	t.Run("server never answers", func(t *testing.T) {
+		serverConn, clientConn := net.Pipe()
+		defer serverConn.Close()
+
+		go func() {
+			_, _ = readMessage(serverConn)
+		}()
+		_, err := sendHello(clientConn, newHello(protocol.Features(), ""), "laptop", 50*time.Millisecond)
+		if !errors.Is(err, ErrProtocolMismatch) {
+			t.Errorf("Got %v, want ErrProtocolMismatch", err)
+		}
+	})
+}
+
+// TestSessionEvents tests filtering frames by the features of a session
+func TestSessionEvents(t *testing.T) {
+	move := &protocol.InputEvent{Event: &protocol.InputEvent_MouseMove{MouseMove: &protocol.MouseMoveEvent{Dx: 1}}}
+	button := &protocol.InputEvent{Event: &protocol.InputEvent_MouseButton{MouseButton: &protocol.MouseButtonEvent{Button: 1, Pressed: true}}}
+	scroll := &protocol.InputEvent{Event: &protocol.InputEvent_MouseScroll{MouseScroll: &protocol.MouseScrollEvent{Dy: 1}}}
+	frame := protocol.NewFrame([]*protocol.InputEvent{move, button, scroll}, 42, "server")
+
+	if events := sessionEvents(protocol.Features(), frame); len(events) != 1 || events[0] != frame {
+		t.Errorf("Frame not sent as is: %v", events)
+	}
+
+	// Without scrolling the frame is sent without it
+	events := sessionEvents([]string{protocol.FeatureMouse, protocol.FeatureFrames}, frame)
+	if len(events) != 1 || len(events[0].GetFrame().GetEvents()) != 2 || events[0].Timestamp != 42 {
+		t.Errorf("Got %v, want a frame of the motion and the button", events)
+	}
+
+	// Without frames its events are sent one by one
+	events = sessionEvents([]string{protocol.FeatureMouse, protocol.FeatureScroll}, frame)
+	if len(events) != 3 || events[0] != move || events[1] != button || events[2] != scroll {
+		t.Errorf("Got %v, want the events of the frame", events)
+	}
+
+	if events := sessionEvents([]string{protocol.FeatureKeyboard}, move); len(events) != 0 {
+		t.Errorf("Event of a feature not agreed sent: %v", events)
+	}
+}
@@ -0,0 +1,262 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/logger"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	// ErrHostKeyChanged is returned when a server presents a different key
+	// than the one recorded for it
+	ErrHostKeyChanged = errors.New("server host key changed")
+
+	// ErrHostKeyRejected is returned when the key of a new server was not trusted
+	ErrHostKeyRejected = errors.New("server host key not trusted")
+)
+
+// HostKeyPrompt asks whether to trust the key of a server seen for the first time
+type HostKeyPrompt func(host, fingerprint string) bool
+
+// KnownHosts verifies server host keys against known_hosts files, trusting
+// a server's key the first time it is seen if the prompt accepts it
+type KnownHosts struct {
+	path      string        // Waymon known_hosts file, accepted keys are added here
+	fallbacks []string      // Read-only files such as ~/.ssh/known_hosts
+	prompt    HostKeyPrompt // Nil refuses unknown servers
+	expected  string        // Fingerprint a new server must present, e.g. as announced on the LAN
+	mu        sync.Mutex    // Serializes prompts and writes
+}
+
+// KnownHost is an entry of a known_hosts file
+type KnownHost struct {
+	Line        int
+	Hosts       []string
+	KeyType     string
+	Fingerprint string
+}
+
+// NewKnownHosts creates a host key verifier that records accepted keys in path
+// and also trusts keys listed in the fallback files
+func NewKnownHosts(path string, fallbacks []string, prompt HostKeyPrompt) *KnownHosts {
+	return &KnownHosts{
+		path:      path,
+		fallbacks: fallbacks,
+		prompt:    prompt,
+	}
+}
+
+// NewClientKnownHosts creates the host key verifier set up in the client
+// configuration
+func NewClientKnownHosts(prompt HostKeyPrompt) *KnownHosts {
+	var fallbacks []string
+	if config.Get().Client.SystemKnownHosts {
+		if home, err := os.UserHomeDir(); err == nil {
+			fallbacks = append(fallbacks, filepath.Join(home, ".ssh", "known_hosts"))
+		}
+	}
+	return NewKnownHosts(config.GetKnownHostsPath(), fallbacks, prompt)
+}
+
+// ExpectFingerprint makes a server seen for the first time present the key
+// with this fingerprint before the prompt is asked. Keys already recorded in
+// known_hosts still take precedence.
+func (k *KnownHosts) ExpectFingerprint(fingerprint string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.expected = fingerprint
+}
+
+// HostKeyCallback returns the callback to use in the SSH client configuration
+func (k *KnownHosts) HostKeyCallback() ssh.HostKeyCallback {
+	return k.verify
+}
+
+// verify checks a server key, asking about servers that are not known yet
+func (k *KnownHosts) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	files := k.existingFiles()
+	if len(files) > 0 {
+		callback, err := knownhosts.New(files...)
+		if err != nil {
+			return fmt.Errorf("failed to read known hosts: %w", err)
+		}
+
+		err = callback(hostname, remote, key)
+		if err == nil {
+			logger.Debugf("[SSH-CLIENT] Host key of %s is known: %s", hostname, fingerprint)
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			known := keyErr.Want[0]
+			return fmt.Errorf("%w: %s now presents %s, but %s:%d lists %s. "+
+				"Someone may be intercepting the connection. If the server key was replaced on purpose, "+
+				"remove the old entry with 'waymon config known-hosts remove %s'",
+				ErrHostKeyChanged, hostname, fingerprint, known.Filename, known.Line,
+				ssh.FingerprintSHA256(known.Key), hostname)
+		}
+	}
+
+	// First connection to this server
+	if k.expected != "" && fingerprint != k.expected {
+		return fmt.Errorf("%w: %s presents %s, but announced %s", ErrHostKeyRejected, hostname, fingerprint, k.expected)
+	}
+	if k.prompt == nil || !k.prompt(hostname, fingerprint) {
+		return fmt.Errorf("%w: %s (%s)", ErrHostKeyRejected, hostname, fingerprint)
+	}
+	if err := k.add(hostname, key); err != nil {
+		return err
+	}
+	logger.Infof("[SSH-CLIENT] Added %s (%s) to %s", hostname, fingerprint, k.path)
+	return nil
+}
+
+// Pin records key as the trusted key of hostname, replacing the entries for
+// it in the waymon known_hosts file
+func (k *KnownHosts) Pin(hostname string, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, err := RemoveKnownHost(k.path, hostname); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return k.add(hostname, key)
+}
+
+// existingFiles returns the known_hosts files that exist
+func (k *KnownHosts) existingFiles() []string {
+	var files []string
+	for _, path := range append([]string{k.path}, k.fallbacks...) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// add records a trusted key in the waymon known_hosts file
+func (k *KnownHosts) add(hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(k.path), 0700); err != nil {
+		return fmt.Errorf("failed to create known hosts directory: %w", err)
+	}
+
+	f, err := os.OpenFile(k.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec // path comes from the client configuration
+	if err != nil {
+		return fmt.Errorf("failed to open known hosts: %w", err)
+	}
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write known hosts: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write known hosts: %w", err)
+	}
+	return nil
+}
+
+// ListKnownHosts returns the entries of a known_hosts file. A missing file
+// has no entries.
+func ListKnownHosts(path string) ([]KnownHost, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the client configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	var entries []KnownHost
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		_, hosts, key, _, _, err := ssh.ParseKnownHosts(line)
+		if err != nil {
+			logger.Warnf("Skipping invalid line %d of %s: %v", lineNum, path, err)
+			continue
+		}
+		entries = append(entries, KnownHost{
+			Line:        lineNum,
+			Hosts:       hosts,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// RemoveKnownHost removes the entries for host from a known_hosts file and
+// returns how many were removed. Host is an address as given to the client,
+// e.g. "192.168.1.10:52525", or a pattern as listed in the file.
+func RemoveKnownHost(path, host string) (int, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the client configuration
+	if err != nil {
+		return 0, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	normalized := knownhosts.Normalize(host)
+	var kept []string
+	removed := 0
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if matchesKnownHost(line, host, normalized) {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	// Replace the file in one step so an interrupted write cannot lose entries
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(kept, "")), 0600); err != nil {
+		return 0, fmt.Errorf("failed to write known hosts: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to replace known hosts: %w", err)
+	}
+	return removed, nil
+}
+
+// matchesKnownHost reports whether a known_hosts line lists host
+func matchesKnownHost(line, host, normalized string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return false
+	}
+	_, hosts, _, _, _, err := ssh.ParseKnownHosts([]byte(trimmed))
+	if err != nil {
+		return false
+	}
+	for _, h := range hosts {
+		if h == host || h == normalized {
+			return true
+		}
+	}
+	return false
+}
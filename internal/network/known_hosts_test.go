@@ -0,0 +1,130 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+	return key
+}
+
+// TestKnownHostsTrustOnFirstUse tests that an accepted key is remembered and
+// that a different key for the same server is refused
+func TestKnownHostsTrustOnFirstUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "waymon", "known_hosts")
+	host := "192.168.1.10:52525"
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 52525}
+	key := newTestHostKey(t)
+
+	// Unknown server without a prompt
+	strict := NewKnownHosts(path, nil, nil)
+	if err := strict.HostKeyCallback()(host, remote, key); !errors.Is(err, ErrHostKeyRejected) {
+		t.Fatalf("Unknown host without prompt: got %v, want ErrHostKeyRejected", err)
+	}
+
+	prompts := 0
+	trusting := NewKnownHosts(path, nil, func(h, fingerprint string) bool {
+		prompts++
+		if fingerprint != ssh.FingerprintSHA256(key) {
+			t.Errorf("Prompted with fingerprint %s, want %s", fingerprint, ssh.FingerprintSHA256(key))
+		}
+		return true
+	})
+	if err := trusting.HostKeyCallback()(host, remote, key); err != nil {
+		t.Fatalf("First connection failed: %v", err)
+	}
+
+	// Known key, no prompt even in strict mode
+	if err := strict.HostKeyCallback()(host, remote, key); err != nil {
+		t.Errorf("Known host refused: %v", err)
+	}
+
+	// Changed key is refused without asking
+	if err := trusting.HostKeyCallback()(host, remote, newTestHostKey(t)); !errors.Is(err, ErrHostKeyChanged) {
+		t.Errorf("Changed key: got %v, want ErrHostKeyChanged", err)
+	}
+	if prompts != 1 {
+		t.Errorf("Prompted %d times, want 1", prompts)
+	}
+}
+
+// TestKnownHostsExpectFingerprint tests that a new server must present the
+// announced key before the prompt is asked
+func TestKnownHostsExpectFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	host := "192.168.1.10:52525"
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 52525}
+	key := newTestHostKey(t)
+
+	prompts := 0
+	k := NewKnownHosts(path, nil, func(string, string) bool {
+		prompts++
+		return true
+	})
+	k.ExpectFingerprint(ssh.FingerprintSHA256(key))
+
+	if err := k.HostKeyCallback()(host, remote, newTestHostKey(t)); !errors.Is(err, ErrHostKeyRejected) {
+		t.Fatalf("Key other than the announced one: got %v, want ErrHostKeyRejected", err)
+	}
+	if prompts != 0 {
+		t.Errorf("Prompted for a key other than the announced one")
+	}
+	if err := k.HostKeyCallback()(host, remote, key); err != nil {
+		t.Fatalf("Announced key refused: %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("Prompted %d times, want 1", prompts)
+	}
+}
+
+// TestKnownHostsListRemove tests listing and removing known_hosts entries
+func TestKnownHostsListRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	accept := func(string, string) bool { return true }
+	k := NewKnownHosts(path, nil, accept)
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 52525}
+
+	for _, host := range []string{"10.0.0.1:52525", "10.0.0.2:52525"} {
+		if err := k.HostKeyCallback()(host, remote, newTestHostKey(t)); err != nil {
+			t.Fatalf("Failed to add %s: %v", host, err)
+		}
+	}
+
+	entries, err := ListKnownHosts(path)
+	if err != nil {
+		t.Fatalf("ListKnownHosts failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Hosts[0] != "[10.0.0.1]:52525" || entries[0].KeyType != ssh.KeyAlgoED25519 {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+
+	removed, err := RemoveKnownHost(path, "10.0.0.1:52525")
+	if err != nil || removed != 1 {
+		t.Fatalf("RemoveKnownHost = %d, %v, want 1, nil", removed, err)
+	}
+	entries, err = ListKnownHosts(path)
+	if err != nil || len(entries) != 1 || entries[0].Hosts[0] != "[10.0.0.2]:52525" {
+		t.Errorf("After remove: %+v, %v", entries, err)
+	}
+
+	// Missing file has no entries
+	if entries, err := ListKnownHosts(filepath.Join(t.TempDir(), "missing")); err != nil || entries != nil {
+		t.Errorf("Missing file: %+v, %v", entries, err)
+	}
+}
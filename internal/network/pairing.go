@@ -0,0 +1,253 @@
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Pairing enrolls a client key with a short one-time code instead of an
+// approval prompt. The code is proven in a keyboard-interactive exchange
+// bound to both keys and to fresh nonces, so it never crosses the wire:
+//
+//	server: nonce_s
+//	client: client key, nonce_c, HMAC(code, "client", nonce_s, nonce_c, host key, client key)
+//	server: HMAC(code, "server", nonce_s, nonce_c, host key, client key)
+//
+// The server enrolls the key once the client proof checks out, and the
+// client only pins the host key once the server proof does.
+
+const (
+	// DefaultPairingTimeout is how long a pairing code stays valid
+	DefaultPairingTimeout = 5 * time.Minute
+
+	maxPairingAttempts  = 3 // Wrong codes before the code is revoked
+	pairingChallenge    = "waymon-pair"
+	pairingCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O or 1/I
+	pairingCodeLength   = 8
+	pairingNonceSize    = 32
+)
+
+// ErrPairingFailed is returned when pairing with a server did not succeed
+var ErrPairingFailed = errors.New("pairing failed")
+
+// PairingCode is a one-time code enrolling the key of the client that uses it
+type PairingCode struct {
+	Code      string // Shown as "ABCD-EFGH"
+	ExpiresAt time.Time
+}
+
+// pairingCode is the code currently accepted by the server
+type pairingCode struct {
+	code      string // Normalized, without the dash
+	expiresAt time.Time
+	failures  int
+}
+
+// NewPairingCode creates a pairing code valid for timeout, replacing the
+// previous one
+func (s *SSHServer) NewPairingCode(timeout time.Duration) (PairingCode, error) {
+	buf := make([]byte, pairingCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return PairingCode{}, fmt.Errorf("failed to generate pairing code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = pairingCodeAlphabet[int(b)%len(pairingCodeAlphabet)]
+	}
+
+	code := &pairingCode{code: string(buf), expiresAt: time.Now().Add(timeout)}
+	s.authMu.Lock()
+	s.pairing = code
+	s.authMu.Unlock()
+
+	logger.Infof("Pairing code created, valid until %s", code.expiresAt.Format(time.TimeOnly))
+	return PairingCode{
+		Code:      code.code[:pairingCodeLength/2] + "-" + code.code[pairingCodeLength/2:],
+		ExpiresAt: code.expiresAt,
+	}, nil
+}
+
+// pair runs the server side of the pairing exchange with user at addr and
+// enrolls its key if it holds the current pairing code
+func (s *SSHServer) pair(user, addr string, challenge gossh.KeyboardInteractiveChallenge) bool {
+	s.authMu.Lock()
+	active := s.pairing != nil && time.Now().Before(s.pairing.expiresAt)
+	s.authMu.Unlock()
+	if !active {
+		return false
+	}
+
+	hostKey, err := s.hostPublicKey()
+	if err != nil {
+		logger.Errorf("Pairing unavailable: %v", err)
+		return false
+	}
+	serverNonce := make([]byte, pairingNonceSize)
+	if _, err := rand.Read(serverNonce); err != nil {
+		logger.Errorf("Failed to generate pairing nonce: %v", err)
+		return false
+	}
+
+	answers, err := challenge(pairingChallenge, base64.StdEncoding.EncodeToString(serverNonce),
+		[]string{"Public key: ", "Nonce: ", "Proof: "}, []bool{false, false, false})
+	if err != nil || len(answers) != 3 {
+		logger.Debugf("Client did not answer the pairing challenge addr=%s: %v", addr, err)
+		return false
+	}
+	clientKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(answers[0]))
+	if err != nil {
+		logger.Warnf("Pairing refused addr=%s: invalid public key: %v", addr, err)
+		return false
+	}
+	clientNonce, err := base64.StdEncoding.DecodeString(answers[1])
+	if err != nil || len(clientNonce) != pairingNonceSize {
+		logger.Warnf("Pairing refused addr=%s: invalid nonce", addr)
+		return false
+	}
+	proof, err := base64.StdEncoding.DecodeString(answers[2])
+	if err != nil {
+		logger.Warnf("Pairing refused addr=%s: invalid proof", addr)
+		return false
+	}
+
+	code, err := s.redeemPairingCode(func(code string) bool {
+		return hmac.Equal(proof, pairingProof(code, "client", serverNonce, clientNonce, hostKey, clientKey))
+	})
+	if err != nil {
+		logger.Warnf("Pairing refused addr=%s: %v", addr, err)
+		return false
+	}
+
+	fingerprint := gossh.FingerprintSHA256(clientKey)
+	logger.Infof("Client paired key=%s addr=%s user=%s", fingerprint, addr, user)
+	s.rememberApprovedKey(clientKey, fingerprint, fmt.Sprintf("%s@%s", user, hostOf(addr)))
+
+	// The client checks this before trusting the host key
+	serverProof := pairingProof(code, "server", serverNonce, clientNonce, hostKey, clientKey)
+	if _, err := challenge(pairingChallenge, base64.StdEncoding.EncodeToString(serverProof), nil, nil); err != nil {
+		logger.Debugf("Client left before the pairing completed addr=%s: %v", addr, err)
+		return false
+	}
+	return true
+}
+
+// redeemPairingCode consumes the current pairing code if match accepts it.
+// Too many wrong codes revoke it.
+func (s *SSHServer) redeemPairingCode(match func(code string) bool) (string, error) {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	current := s.pairing
+	if current == nil || !time.Now().Before(current.expiresAt) {
+		s.pairing = nil
+		return "", fmt.Errorf("no pairing code active")
+	}
+	if !match(current.code) {
+		current.failures++
+		if current.failures >= maxPairingAttempts {
+			s.pairing = nil
+			return "", fmt.Errorf("wrong code, code revoked after %d attempts", current.failures)
+		}
+		return "", fmt.Errorf("wrong code")
+	}
+
+	// Single use
+	s.pairing = nil
+	return current.code, nil
+}
+
+// pairingProof computes the proof of knowledge of code for one side of the
+// exchange
+func pairingProof(code, side string, serverNonce, clientNonce []byte, hostKey, clientKey gossh.PublicKey) []byte {
+	mac := hmac.New(sha256.New, []byte(code))
+	for _, part := range [][]byte{[]byte(side), serverNonce, clientNonce, hostKey.Marshal(), clientKey.Marshal()} {
+		// Length prefixes keep parts from shifting into each other
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(part))) //nolint:gosec // parts are small
+		mac.Write(length[:])
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+// normalizePairingCode accepts a code typed in lower case or without the dash
+func normalizePairingCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// pairingResponder is the client side of the pairing exchange
+type pairingResponder struct {
+	code        string
+	clientKey   gossh.PublicKey
+	serverKey   gossh.PublicKey
+	serverNonce []byte
+	clientNonce []byte // Set once the code was offered
+	answered    bool   // The server accepted the code and sent its proof
+	verified    bool   // The server proof checks out
+}
+
+func newPairingResponder(code string, clientKey gossh.PublicKey) *pairingResponder {
+	return &pairingResponder{
+		code:      normalizePairingCode(code),
+		clientKey: clientKey,
+	}
+}
+
+// hostKey records the server host key. It is only trusted once the server
+// has proven it knows the code.
+func (p *pairingResponder) hostKey(hostname string, remote net.Addr, key gossh.PublicKey) error {
+	p.serverKey = key
+	return nil
+}
+
+// respond answers the pairing challenges of the server
+func (p *pairingResponder) respond(name, instruction string, questions []string, echos []bool) ([]string, error) {
+	if name != pairingChallenge {
+		return nil, fmt.Errorf("the server did not ask for a pairing code")
+	}
+
+	switch {
+	case p.clientNonce == nil && len(questions) == 3:
+		serverNonce, err := base64.StdEncoding.DecodeString(instruction)
+		if err != nil || len(serverNonce) != pairingNonceSize {
+			return nil, fmt.Errorf("invalid pairing challenge")
+		}
+		clientNonce := make([]byte, pairingNonceSize)
+		if _, err := rand.Read(clientNonce); err != nil {
+			return nil, fmt.Errorf("failed to generate pairing nonce: %w", err)
+		}
+		p.serverNonce = serverNonce
+		p.clientNonce = clientNonce
+
+		proof := pairingProof(p.code, "client", p.serverNonce, p.clientNonce, p.serverKey, p.clientKey)
+		return []string{
+			strings.TrimSpace(string(gossh.MarshalAuthorizedKey(p.clientKey))),
+			base64.StdEncoding.EncodeToString(p.clientNonce),
+			base64.StdEncoding.EncodeToString(proof),
+		}, nil
+
+	case p.clientNonce != nil && len(questions) == 0:
+		p.answered = true
+		proof, err := base64.StdEncoding.DecodeString(instruction)
+		want := pairingProof(p.code, "server", p.serverNonce, p.clientNonce, p.serverKey, p.clientKey)
+		if err != nil || !hmac.Equal(proof, want) {
+			return nil, fmt.Errorf("the server does not know the pairing code")
+		}
+		p.verified = true
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected pairing challenge")
+	}
+}
@@ -0,0 +1,127 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// newPairingServer creates a server with a host key on disk
+func newPairingServer(t *testing.T) (*SSHServer, gossh.PublicKey) {
+	t.Helper()
+	dir := t.TempDir()
+	hostKeyPath := filepath.Join(dir, "host_key")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate host key: %v", err)
+	}
+	hostKey, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to convert host key: %v", err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal host key: %v", err)
+	}
+	if err := os.WriteFile(hostKeyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write host key: %v", err)
+	}
+	return NewSSHServer(0, hostKeyPath, filepath.Join(dir, "authorized_keys")), hostKey
+}
+
+// TestPairing tests enrolling a client key with a pairing code
+func TestPairing(t *testing.T) {
+	s, hostKey := newPairingServer(t)
+	clientKey := newTestSigner(t).PublicKey()
+
+	code, err := s.NewPairingCode(time.Minute)
+	if err != nil {
+		t.Fatalf("NewPairingCode failed: %v", err)
+	}
+	if len(code.Code) != 9 || code.Code[4] != '-' {
+		t.Errorf("Code = %q, want ABCD-EFGH", code.Code)
+	}
+
+	// Codes may be typed in lower case and without the dash
+	client := newPairingResponder(strings.ToLower(strings.ReplaceAll(code.Code, "-", "")), clientKey)
+	_ = client.hostKey("server:52525", nil, hostKey)
+	if !s.pair("alice", "192.168.1.20:40000", client.respond) {
+		t.Fatalf("Pairing with the right code failed")
+	}
+	if !client.verified {
+		t.Errorf("Client did not verify the server")
+	}
+
+	keys, err := LoadAuthorizedKeys(s.authKeysPath)
+	if err != nil || findAuthorizedKey(keys, clientKey) == nil {
+		t.Errorf("Client key not enrolled: %v", err)
+	}
+
+	// Codes work once
+	again := newPairingResponder(code.Code, newTestSigner(t).PublicKey())
+	_ = again.hostKey("server:52525", nil, hostKey)
+	if s.pair("bob", "192.168.1.21:40000", again.respond) {
+		t.Errorf("Code accepted twice")
+	}
+}
+
+// TestPairingRefused tests that wrong, expired and intercepted codes fail
+func TestPairingRefused(t *testing.T) {
+	s, hostKey := newPairingServer(t)
+	clientKey := newTestSigner(t).PublicKey()
+
+	attempt := func(code string, serverKey gossh.PublicKey) bool {
+		client := newPairingResponder(code, clientKey)
+		_ = client.hostKey("server:52525", nil, serverKey)
+		return s.pair("alice", "192.168.1.20:40000", client.respond)
+	}
+
+	// A new code replaces the previous one
+	replaced, _ := s.NewPairingCode(time.Minute)
+	expired, _ := s.NewPairingCode(-time.Second)
+	if attempt(replaced.Code, hostKey) || attempt(expired.Code, hostKey) {
+		t.Errorf("Replaced or expired code accepted")
+	}
+
+	code, _ := s.NewPairingCode(time.Minute)
+
+	// A proof made for another host key, as seen by a client talking to an
+	// interceptor, is refused
+	if attempt(code.Code, newTestSigner(t).PublicKey()) {
+		t.Errorf("Proof for another host key accepted")
+	}
+	if attempt("WRON-GCOD", hostKey) {
+		t.Errorf("Wrong code accepted")
+	}
+	if attempt("WRON-GCOD", hostKey) {
+		t.Errorf("Wrong code accepted")
+	}
+	if attempt(code.Code, hostKey) {
+		t.Errorf("Code still accepted after %d wrong attempts", maxPairingAttempts)
+	}
+}
+
+// TestPairingServerProof tests that the client refuses a server that does
+// not know the code
+func TestPairingServerProof(t *testing.T) {
+	client := newPairingResponder("ABCD-EFGH", newTestSigner(t).PublicKey())
+	_ = client.hostKey("server:52525", nil, newTestSigner(t).PublicKey())
+
+	nonce := base64.StdEncoding.EncodeToString(make([]byte, pairingNonceSize))
+	if _, err := client.respond(pairingChallenge, nonce, []string{"", "", ""}, []bool{false, false, false}); err != nil {
+		t.Fatalf("Failed to answer the challenge: %v", err)
+	}
+	forged := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	if _, err := client.respond(pairingChallenge, forged, nil, nil); err == nil || client.verified {
+		t.Errorf("Invalid server proof accepted")
+	}
+}
@@ -0,0 +1,208 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// DefaultAuthTimeout is how long a key waits for approval before it is denied
+const DefaultAuthTimeout = 2 * time.Minute
+
+// maxPendingAuths is how many keys may wait for approval at once, further keys
+// are refused until one is decided
+const maxPendingAuths = 8
+
+// ErrNoPendingAuth is returned when deciding on a key that is not waiting for
+// approval
+var ErrNoPendingAuth = errors.New("no key waiting for approval")
+
+// PendingAuth is a client key waiting for approval
+type PendingAuth struct {
+	Fingerprint string
+	PublicKey   string // authorized_keys format
+	Address     string
+	User        string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// authRequest is the approval shared by every connection attempt made with
+// the same key while it is pending
+type authRequest struct {
+	info     PendingAuth
+	key      gossh.PublicKey
+	done     chan struct{} // Closed once decided
+	approved bool
+}
+
+// SetAuthTimeout sets how long a key waits for approval before it is denied
+func (s *SSHServer) SetAuthTimeout(timeout time.Duration) {
+	s.authTimeout = timeout
+}
+
+// PendingAuths returns the keys waiting for approval, oldest first
+func (s *SSHServer) PendingAuths() []PendingAuth {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	pending := make([]PendingAuth, 0, len(s.pendingAuth))
+	for _, req := range s.pendingAuth {
+		pending = append(pending, req.info)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].RequestedAt.Before(pending[j].RequestedAt)
+	})
+	return pending
+}
+
+// ResolveAuth approves or denies a key waiting for approval. The "SHA256:"
+// prefix of the fingerprint may be left out.
+func (s *SSHServer) ResolveAuth(fingerprint string, approve bool) error {
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		fingerprint = "SHA256:" + fingerprint
+	}
+
+	s.authMu.Lock()
+	req, exists := s.pendingAuth[fingerprint]
+	s.authMu.Unlock()
+
+	if !exists || !s.decide(req, approve) {
+		return fmt.Errorf("%w: %s", ErrNoPendingAuth, fingerprint)
+	}
+	return nil
+}
+
+// DenyPendingAuths denies every key waiting for approval and returns how many
+// there were
+func (s *SSHServer) DenyPendingAuths() int {
+	s.authMu.Lock()
+	requests := make([]*authRequest, 0, len(s.pendingAuth))
+	for _, req := range s.pendingAuth {
+		requests = append(requests, req)
+	}
+	s.authMu.Unlock()
+
+	denied := 0
+	for _, req := range requests {
+		if s.decide(req, false) {
+			denied++
+		}
+	}
+	return denied
+}
+
+// awaitApproval queues a key for approval and waits until it is approved,
+// denied, or the request times out. Keys that cannot be queued are refused
+// right away.
+func (s *SSHServer) awaitApproval(key gossh.PublicKey, fingerprint, user, addr string) bool {
+	s.authMu.Lock()
+	req, exists := s.pendingAuth[fingerprint]
+	if !exists {
+		if err := s.canQueue(addr); err != nil {
+			s.authMu.Unlock()
+			logger.Warnf("SSH key refused key=%s addr=%s: %v", fingerprint, addr, err)
+			return false
+		}
+
+		now := time.Now()
+		req = &authRequest{
+			info: PendingAuth{
+				Fingerprint: fingerprint,
+				PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))),
+				Address:     addr,
+				User:        user,
+				RequestedAt: now,
+				ExpiresAt:   now.Add(s.authTimeout),
+			},
+			key:  key,
+			done: make(chan struct{}),
+		}
+		s.pendingAuth[fingerprint] = req
+	}
+	s.authMu.Unlock()
+
+	if !exists {
+		logger.Infof("SSH key awaiting approval key=%s addr=%s, run 'waymon auth approve %s' to accept it", fingerprint, addr, fingerprint)
+		if s.OnAuthPending != nil {
+			go s.OnAuthPending(req.info)
+		}
+		if s.OnAuthRequest != nil {
+			go func() {
+				s.decide(req, s.OnAuthRequest(addr, req.info.PublicKey, fingerprint))
+			}()
+		}
+	}
+
+	timer := time.NewTimer(time.Until(req.info.ExpiresAt))
+	defer timer.Stop()
+
+	select {
+	case <-req.done:
+	case <-timer.C:
+		if s.decide(req, false) {
+			logger.Infof("SSH key approval timed out key=%s addr=%s", fingerprint, addr)
+		}
+	case <-s.stop:
+		s.decide(req, false)
+	}
+	<-req.done
+	return req.approved
+}
+
+// canQueue returns why a new key from addr cannot wait for approval, nil if it
+// can. Each remote address gets one pending key so a single peer cannot fill
+// the queue. Must be called with authMu held.
+func (s *SSHServer) canQueue(addr string) error {
+	if len(s.pendingAuth) >= maxPendingAuths {
+		return fmt.Errorf("%d keys are already waiting for approval", len(s.pendingAuth))
+	}
+
+	host := hostOf(addr)
+	for _, req := range s.pendingAuth {
+		if hostOf(req.info.Address) == host {
+			return fmt.Errorf("key %s from %s is already waiting for approval", req.info.Fingerprint, host)
+		}
+	}
+	return nil
+}
+
+// decide settles a pending request and reports whether it was still pending
+func (s *SSHServer) decide(req *authRequest, approve bool) bool {
+	fingerprint := req.info.Fingerprint
+
+	s.authMu.Lock()
+	if s.pendingAuth[fingerprint] != req {
+		s.authMu.Unlock()
+		return false
+	}
+	delete(s.pendingAuth, fingerprint)
+	req.approved = approve
+	s.authMu.Unlock()
+
+	if approve {
+		s.rememberApprovedKey(req.key, fingerprint, fmt.Sprintf("%s@%s", req.info.User, hostOf(req.info.Address)))
+	} else {
+		logger.Infof("SSH key denied key=%s addr=%s", fingerprint, req.info.Address)
+	}
+	close(req.done)
+
+	if s.OnAuthResolved != nil {
+		go s.OnAuthResolved(fingerprint, approve)
+	}
+	return true
+}
+
+// hostOf returns the host part of a host:port address
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// waitPending waits until n keys are waiting for approval
+func waitPending(t *testing.T, s *SSHServer, n int) []PendingAuth {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if pending := s.PendingAuths(); len(pending) == n {
+			return pending
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d pending key(s)", n)
+	return nil
+}
+
+// TestPendingAuthApprove tests approving a waiting key by fingerprint
+func TestPendingAuthApprove(t *testing.T) {
+	authKeysPath := filepath.Join(t.TempDir(), "authorized_keys")
+	s := NewSSHServer(0, "", authKeysPath)
+	key := newTestHostKey(t)
+	fingerprint := gossh.FingerprintSHA256(key)
+
+	result := make(chan bool)
+	go func() { result <- s.awaitApproval(key, fingerprint, "alice", "192.168.1.20:40000") }()
+
+	pending := waitPending(t, s, 1)
+	if pending[0].Fingerprint != fingerprint || pending[0].User != "alice" {
+		t.Errorf("Pending = %+v", pending[0])
+	}
+
+	// The SHA256: prefix is optional
+	if err := s.ResolveAuth(strings.TrimPrefix(fingerprint, "SHA256:"), true); err != nil {
+		t.Fatalf("ResolveAuth failed: %v", err)
+	}
+	if !<-result {
+		t.Errorf("Approved key was refused")
+	}
+
+	data, err := os.ReadFile(authKeysPath)
+	if err != nil || !strings.HasSuffix(strings.TrimSpace(string(data)), "alice@192.168.1.20") {
+		t.Errorf("Approved key not added to authorized_keys: %q, %v", data, err)
+	}
+
+	if err := s.ResolveAuth(fingerprint, false); !errors.Is(err, ErrNoPendingAuth) {
+		t.Errorf("Deciding twice: got %v, want ErrNoPendingAuth", err)
+	}
+}
+
+// TestPendingAuthDenyAndTimeout tests that waiting keys are refused when
+// denied or when nobody answers
+func TestPendingAuthDenyAndTimeout(t *testing.T) {
+	s := NewSSHServer(0, "", filepath.Join(t.TempDir(), "authorized_keys"))
+	key := newTestHostKey(t)
+	fingerprint := gossh.FingerprintSHA256(key)
+
+	// Connection attempts with the same key share one request
+	var wg sync.WaitGroup
+	results := make([]bool, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = s.awaitApproval(key, fingerprint, "bob", "10.0.0.2:40000")
+		}(i)
+	}
+	waitPending(t, s, 1)
+	time.Sleep(10 * time.Millisecond) // Let the second attempt join
+
+	if denied := s.DenyPendingAuths(); denied != 1 {
+		t.Errorf("DenyPendingAuths() = %d, want 1", denied)
+	}
+	wg.Wait()
+	if results[0] || results[1] {
+		t.Errorf("Denied key accepted: %v", results)
+	}
+
+	s.SetAuthTimeout(20 * time.Millisecond)
+	if s.awaitApproval(key, fingerprint, "bob", "10.0.0.2:40000") {
+		t.Errorf("Key accepted after timing out")
+	}
+	if pending := s.PendingAuths(); len(pending) != 0 {
+		t.Errorf("Timed out key still pending: %+v", pending)
+	}
+}
+
+// TestPendingAuthLimits tests that keys beyond one per address or beyond the
+// queue size are refused without waiting
+func TestPendingAuthLimits(t *testing.T) {
+	s := NewSSHServer(0, "", filepath.Join(t.TempDir(), "authorized_keys"))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer s.DenyPendingAuths()
+
+	queue := func(addr string) {
+		key := newTestHostKey(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.awaitApproval(key, gossh.FingerprintSHA256(key), "eve", addr)
+		}()
+	}
+
+	queue("10.0.0.3:40000")
+	waitPending(t, s, 1)
+
+	// Another key from the same host is refused, whatever the port
+	key := newTestHostKey(t)
+	if s.awaitApproval(key, gossh.FingerprintSHA256(key), "eve", "10.0.0.3:40001") {
+		t.Errorf("Second key from the same address accepted")
+	}
+
+	for i := 1; i < maxPendingAuths; i++ {
+		queue(fmt.Sprintf("10.0.1.%d:40000", i))
+	}
+	waitPending(t, s, maxPendingAuths)
+
+	// A full queue refuses new keys
+	key = newTestHostKey(t)
+	if s.awaitApproval(key, gossh.FingerprintSHA256(key), "eve", "10.0.2.1:40000") {
+		t.Errorf("Key accepted with a full queue")
+	}
+	if pending := s.PendingAuths(); len(pending) != maxPendingAuths {
+		t.Errorf("%d keys pending, want %d", len(pending), maxPendingAuths)
+	}
+}
@@ -27,10 +27,20 @@ type SSHClient struct {
 
 	mu        sync.Mutex
 	connected bool
+	writeMu   sync.Mutex // Keeps concurrently sent messages from interleaving
 
 	// SSH key paths
 	privateKeyPath string
 
+	// Server host key verification, the configured known_hosts when unset
+	hostKeyCallback ssh.HostKeyCallback
+
+	// Offered in the hello opening the session, and what the server agreed to
+	features        []string
+	softwareVersion string
+	clientID        string
+	agreed          *protocol.Hello
+
 	// Event handling
 	onInputEvent func(*protocol.InputEvent)
 }
@@ -39,94 +49,77 @@ type SSHClient struct {
 func NewSSHClient(privateKeyPath string) *SSHClient {
 	return &SSHClient{
 		privateKeyPath: privateKeyPath,
+		features:       protocol.Features(),
 	}
 }
 
-// Connect establishes an SSH connection to the server
-func (c *SSHClient) Connect(ctx context.Context, serverAddr string) error {
+// SetFeatures sets the features offered to the server, all by default
+func (c *SSHClient) SetFeatures(features []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.features = features
+}
 
-	if c.connected {
-		return fmt.Errorf("already connected")
-	}
+// SetSoftwareVersion sets the waymon release told to the server
+func (c *SSHClient) SetSoftwareVersion(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.softwareVersion = version
+}
 
-	// Get authentication methods - try SSH agent first, then private key files
-	var authMethods []ssh.AuthMethod
+// SetClientID sets the ID that, with the key, identifies this client to the
+// server across connections
+func (c *SSHClient) SetClientID(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clientID = id
+}
 
-	// Try SSH agent first
-	if sshAuthSock := os.Getenv("SSH_AUTH_SOCK"); sshAuthSock != "" {
-		conn, err := net.Dial("unix", sshAuthSock)
-		if err == nil {
-			agentClient := agent.NewClient(conn)
-			signers, err := agentClient.Signers()
-			if err == nil && len(signers) > 0 {
-				logger.Debugf("Using SSH agent with %d key(s)", len(signers))
-				authMethods = append(authMethods, ssh.PublicKeys(signers...))
-			} else {
-				logger.Debugf("SSH agent available but no keys loaded")
-			}
-			conn.Close()
-		} else {
-			logger.Debugf("Failed to connect to SSH agent: %v", err)
-		}
-	} else {
-		logger.Debug("SSH_AUTH_SOCK not set, SSH agent not available")
+// Features returns the features agreed with the server
+func (c *SSHClient) Features() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.agreed == nil {
+		return nil
 	}
+	return c.agreed.Features
+}
 
-	// If we have a specific private key path configured, use it
-	if c.privateKeyPath != "" {
-		signer, err := c.loadPrivateKey(c.privateKeyPath)
-		if err != nil {
-			// If a specific key is configured but fails to load, that's an error
-			return fmt.Errorf("failed to load configured private key: %w", err)
-		}
-		logger.Debugf("Using configured SSH private key: %s", c.privateKeyPath)
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	} else if len(authMethods) == 0 {
-		// No SSH agent and no configured key - try default locations
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
+// SetHostKeyCallback sets how the server host key is verified
+func (c *SSHClient) SetHostKeyCallback(callback ssh.HostKeyCallback) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hostKeyCallback = callback
+}
 
-		// Try standard SSH key locations in order of preference
-		defaultPaths := []string{
-			filepath.Join(homeDir, ".ssh", "id_ed25519"),
-			filepath.Join(homeDir, ".ssh", "id_rsa"),
-			filepath.Join(homeDir, ".ssh", "id_ecdsa"),
-		}
+// Connect establishes an SSH connection to the server
+func (c *SSHClient) Connect(ctx context.Context, serverAddr string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		for _, path := range defaultPaths {
-			if signer, err := c.loadPrivateKeyIfExists(path); err == nil && signer != nil {
-				logger.Debugf("Using SSH private key: %s", path)
-				authMethods = append(authMethods, ssh.PublicKeys(signer))
-				break
-			}
-		}
+	if c.connected {
+		return fmt.Errorf("already connected")
 	}
 
-	if len(authMethods) == 0 {
-		return fmt.Errorf("no SSH authentication methods available. Please start ssh-agent, configure ssh_private_key, or create a key at ~/.ssh/id_ed25519")
+	signers, closeAgent, err := c.loadSigners()
+	if err != nil {
+		return err
 	}
+	// The agent signs during the handshake, keep it open until then
+	defer closeAgent()
 
-	// Get the username
-	username := os.Getenv("USER")
-	if username == "" {
-		username = "waymon"
+	// Without a prompt, servers that are not in known_hosts yet are refused
+	hostKeyCallback := c.hostKeyCallback
+	if hostKeyCallback == nil {
+		hostKeyCallback = NewClientKnownHosts(nil).HostKeyCallback()
 	}
 
 	// Create SSH client config
 	config := &ssh.ClientConfig{
-		User: username,
-		Auth: authMethods,
-		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			// For now, accept any host key
-			// TODO: Implement proper host key verification
-			logger.Debugf("Accepting host key for %s: %s", hostname, ssh.FingerprintSHA256(key))
-			return nil
-		},
-		Timeout: 10 * time.Second,
+		User:            clientUsername(),
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
 	}
 
 	// Connect to SSH server with TCP keepalive
@@ -206,9 +199,22 @@ func (c *SSHClient) Connect(ctx context.Context, serverAddr string) error {
 		}
 	}()
 
-	// No handshake messages expected from server anymore
-	// Server only sends protocol buffer messages or error text
+	// Agree on the protocol before anything else is sent
+	sessConn := &sessionConn{Reader: reader, Writer: writer, session: session}
+	hello := newHello(c.features, c.softwareVersion)
+	hello.ClientId = c.clientID
+	agreed, err := sendHello(sessConn, hello, "client", helloTimeout)
+	if err != nil {
+		_ = session.Close()
+		if err := client.Close(); err != nil {
+			logger.Errorf("Failed to close SSH client: %v", err)
+		}
+		return err
+	}
+	logger.Infof("[SSH-CLIENT] Server runs waymon %s, protocol v%d with features %v",
+		agreed.SoftwareVersion, agreed.ProtocolVersion, agreed.Features)
 
+	c.agreed = agreed
 	c.client = client
 	c.session = session
 	c.writer = writer
@@ -222,6 +228,59 @@ func (c *SSHClient) Connect(ctx context.Context, serverAddr string) error {
 	return nil
 }
 
+// Pair enrolls the client key on the server with a code shown by 'waymon
+// server pair', then pins the server host key in knownHosts, replacing any
+// key recorded for the server before. It returns the host key fingerprint.
+func (c *SSHClient) Pair(serverAddr, code string, knownHosts *KnownHosts) (string, error) {
+	signers, closeAgent, err := c.loadSigners()
+	if err != nil {
+		return "", err
+	}
+	defer closeAgent()
+
+	// Enroll the key offered first when connecting
+	key := signers[0].PublicKey()
+	if cert, ok := key.(*ssh.Certificate); ok {
+		key = cert.Key
+	}
+
+	pairing := newPairingResponder(code, key)
+	config := &ssh.ClientConfig{
+		User:            clientUsername(),
+		Auth:            []ssh.AuthMethod{ssh.KeyboardInteractive(pairing.respond)},
+		HostKeyCallback: pairing.hostKey,
+		Timeout:         10 * time.Second,
+	}
+
+	client, err := ssh.Dial("tcp", serverAddr, config)
+	if err != nil {
+		switch {
+		case pairing.serverKey == nil || pairing.answered:
+			return "", fmt.Errorf("%w: %v", ErrPairingFailed, err)
+		case pairing.clientNonce == nil:
+			return "", fmt.Errorf("%w: the server did not ask for a code, run 'waymon server pair' on it first", ErrPairingFailed)
+		default:
+			return "", fmt.Errorf("%w: the server refused the code, it may be wrong, used or expired", ErrPairingFailed)
+		}
+	}
+	if err := client.Close(); err != nil {
+		logger.Debugf("[SSH-CLIENT] Failed to close pairing connection: %v", err)
+	}
+
+	// A server that accepts the connection without proving it knows the
+	// code could be anyone
+	if !pairing.verified {
+		return "", fmt.Errorf("%w: the server did not prove it knows the code", ErrPairingFailed)
+	}
+
+	if err := knownHosts.Pin(serverAddr, pairing.serverKey); err != nil {
+		return "", err
+	}
+	fingerprint := ssh.FingerprintSHA256(pairing.serverKey)
+	logger.Infof("[SSH-CLIENT] Paired with %s, enrolled %s and pinned host key %s", serverAddr, ssh.FingerprintSHA256(key), fingerprint)
+	return fingerprint, nil
+}
+
 // Disconnect closes the SSH connection
 func (c *SSHClient) Disconnect() error {
 	c.mu.Lock()
@@ -276,12 +335,19 @@ func (c *SSHClient) SendInputEvent(event *protocol.InputEvent) error {
 	c.mu.Lock()
 	writer := c.writer
 	connected := c.connected
+	agreed := c.agreed
 	c.mu.Unlock()
 
 	if !connected || writer == nil {
 		return fmt.Errorf("not connected")
 	}
+	if !supportsFeature(agreed.Features, event) {
+		logger.Debugf("[SSH-CLIENT] Not sending %T: feature %q not agreed", event.Event, protocol.FeatureOf(event))
+		return nil
+	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	return writeInputMessage(writer, event)
 }
 
@@ -348,7 +414,7 @@ func (c *SSHClient) receiveInputEvents(ctx context.Context) {
 
 			// Check if this looks like text instead of a protocol buffer length
 			// Protocol buffer lengths are typically small and positive
-			if length <= 0 || length > 4096 {
+			if length <= 0 || length > maxMessageSize {
 				// This might be text data - check if the bytes are printable ASCII
 				isText := true
 				for _, b := range lengthBuf {
@@ -445,6 +511,18 @@ func (c *SSHClient) receiveInputEvents(ctx context.Context) {
 	}
 }
 
+// sessionConn reads and writes the data of an SSH session
+type sessionConn struct {
+	io.Reader
+	io.Writer
+	session *ssh.Session
+}
+
+// Close closes the session
+func (c *sessionConn) Close() error {
+	return c.session.Close()
+}
+
 // writeMessage writes a protobuf message with length prefix
 func writeMessage(w io.Writer, msg proto.Message) error {
 	data, err := proto.Marshal(msg)
@@ -472,8 +550,86 @@ func writeMessage(w io.Writer, msg proto.Message) error {
 	return nil
 }
 
-// loadPrivateKey loads and parses a private key from the given path
-func (c *SSHClient) loadPrivateKey(keyPath string) (ssh.Signer, error) {
+// loadSigners returns the keys to authenticate with: those of the SSH agent,
+// then the configured private key or, without agent keys, the first default
+// key found. The returned function closes the agent connection.
+func (c *SSHClient) loadSigners() ([]ssh.Signer, func(), error) {
+	var signers []ssh.Signer
+	closeAgent := func() {}
+
+	// Try SSH agent first
+	if sshAuthSock := os.Getenv("SSH_AUTH_SOCK"); sshAuthSock != "" {
+		conn, err := net.Dial("unix", sshAuthSock)
+		if err == nil {
+			closeAgent = func() { _ = conn.Close() }
+
+			agentClient := agent.NewClient(conn)
+			agentSigners, err := agentClient.Signers()
+			if err == nil && len(agentSigners) > 0 {
+				logger.Debugf("Using SSH agent with %d key(s)", len(agentSigners))
+				signers = append(signers, certificatesFirst(agentSigners)...)
+			} else {
+				logger.Debugf("SSH agent available but no keys loaded")
+			}
+		} else {
+			logger.Debugf("Failed to connect to SSH agent: %v", err)
+		}
+	} else {
+		logger.Debug("SSH_AUTH_SOCK not set, SSH agent not available")
+	}
+
+	// If we have a specific private key path configured, use it
+	if c.privateKeyPath != "" {
+		keySigners, err := c.loadPrivateKey(c.privateKeyPath)
+		if err != nil {
+			// If a specific key is configured but fails to load, that's an error
+			closeAgent()
+			return nil, nil, fmt.Errorf("failed to load configured private key: %w", err)
+		}
+		logger.Debugf("Using configured SSH private key: %s", c.privateKeyPath)
+		signers = append(signers, keySigners...)
+	} else if len(signers) == 0 {
+		// No SSH agent and no configured key - try default locations
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			closeAgent()
+			return nil, nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+
+		// Try standard SSH key locations in order of preference
+		defaultPaths := []string{
+			filepath.Join(homeDir, ".ssh", "id_ed25519"),
+			filepath.Join(homeDir, ".ssh", "id_rsa"),
+			filepath.Join(homeDir, ".ssh", "id_ecdsa"),
+		}
+
+		for _, path := range defaultPaths {
+			if keySigners, err := c.loadPrivateKeyIfExists(path); err == nil && keySigners != nil {
+				logger.Debugf("Using SSH private key: %s", path)
+				signers = append(signers, keySigners...)
+				break
+			}
+		}
+	}
+
+	if len(signers) == 0 {
+		closeAgent()
+		return nil, nil, fmt.Errorf("no SSH authentication methods available. Please start ssh-agent, configure ssh_private_key, or create a key at ~/.ssh/id_ed25519")
+	}
+	return signers, closeAgent, nil
+}
+
+// clientUsername returns the user name to log in to the server with
+func clientUsername() string {
+	if username := os.Getenv("USER"); username != "" {
+		return username
+	}
+	return "waymon"
+}
+
+// loadPrivateKey loads and parses a private key from the given path. A
+// certificate stored next to it is returned first, followed by the plain key.
+func (c *SSHClient) loadPrivateKey(keyPath string) ([]ssh.Signer, error) {
 	// Handle ~ expansion
 	if strings.HasPrefix(keyPath, "~") {
 		homeDir, err := os.UserHomeDir()
@@ -497,11 +653,19 @@ func (c *SSHClient) loadPrivateKey(keyPath string) (ssh.Signer, error) {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	return signer, nil
+	certSigner, err := loadCertificate(keyPath, signer)
+	if err != nil {
+		return nil, err
+	}
+	if certSigner != nil {
+		logger.Debugf("Using SSH certificate %s-cert.pub", keyPath)
+		return []ssh.Signer{certSigner, signer}, nil
+	}
+	return []ssh.Signer{signer}, nil
 }
 
 // loadPrivateKeyIfExists loads a private key if the file exists, returns nil if not
-func (c *SSHClient) loadPrivateKeyIfExists(keyPath string) (ssh.Signer, error) {
+func (c *SSHClient) loadPrivateKeyIfExists(keyPath string) ([]ssh.Signer, error) {
 	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
 		return nil, nil // File doesn't exist, not an error
 	}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/bnema/waymon/internal/protocol"
+	"golang.org/x/crypto/ssh"
 )
 
 // TestSSHClientServerIntegration tests the full client-server communication
@@ -46,9 +47,9 @@ func TestSSHClientServerIntegration(t *testing.T) {
 		t.Logf("Server received event: %T from %s", event.Event, event.SourceId)
 	}
 
-	var connectedClientAddr string
-	server.OnClientConnected = func(addr, publicKey string) {
-		connectedClientAddr = addr
+	var connectedClientID string
+	server.OnClientConnected = func(clientID, addr, publicKey string) {
+		connectedClientID = clientID
 		t.Logf("Client connected: %s with key %s", addr, publicKey)
 	}
 
@@ -69,6 +70,7 @@ func TestSSHClientServerIntegration(t *testing.T) {
 
 	// Create client
 	client := NewSSHClient(clientKeyPath)
+	client.SetHostKeyCallback(ssh.InsecureIgnoreHostKey()) //nolint:gosec // throwaway test server key
 
 	// Track received events on client
 	var clientReceivedEvent *protocol.InputEvent
@@ -141,8 +143,8 @@ func TestSSHClientServerIntegration(t *testing.T) {
 		// Reset received event
 		clientReceivedEvent = nil
 
-		// Server needs to send to a specific client address
-		if connectedClientAddr == "" {
+		// Server needs to send to a specific client
+		if connectedClientID == "" {
 			t.Fatal("No client connected to server")
 		}
 
@@ -157,7 +159,7 @@ func TestSSHClientServerIntegration(t *testing.T) {
 			SourceId:  "server",
 		}
 
-		if err := server.SendEventToClient(connectedClientAddr, serverEvent); err != nil {
+		if err := server.SendEventToClient(connectedClientID, serverEvent); err != nil {
 			t.Errorf("Failed to send event from server: %v", err)
 		}
 
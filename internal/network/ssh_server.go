@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bnema/waymon/internal/config"
@@ -31,8 +34,18 @@ type SSHServer struct {
 	clients map[string]*sshClient // sessionID -> client
 
 	// Authentication
-	pendingAuth map[string]chan bool // fingerprint -> approval channel
-	authMu      sync.Mutex           //nolint:unused // kept for future authentication features
+	pendingAuth map[string]*authRequest // fingerprint -> key waiting for approval
+	authMu      sync.Mutex
+	authTimeout time.Duration
+	pairing     *pairingCode // Current pairing code, nil if none
+
+	// User certificates
+	caKeysPath     string
+	certPrincipals []string
+
+	// Offered in the hello opening each session
+	features        []string
+	softwareVersion string
 
 	// Lifecycle
 	stop     chan struct{}
@@ -41,16 +54,37 @@ type SSHServer struct {
 
 	// Event handlers
 	OnInputEvent         func(event *protocol.InputEvent)
-	OnClientConnected    func(addr string, publicKey string)
-	OnClientDisconnected func(addr string)
-	OnAuthRequest        func(addr, publicKey, fingerprint string) bool // Returns approval
+	OnClientConnected    func(clientID, addr, publicKey string)
+	OnClientDisconnected func(clientID, addr string)
+	OnAuthRequest        func(addr, publicKey, fingerprint string) bool // Optional approver, raced against ResolveAuth
+	OnAuthPending        func(request PendingAuth)                      // A key started waiting for approval
+	OnAuthResolved       func(fingerprint string, approved bool)        // A pending key was approved, denied or timed out
 }
 
 type sshClient struct {
 	session   ssh.Session
+	id        string // Key fingerprint and client ID, stable across connections
 	addr      string
 	publicKey string
-	writer    io.Writer // For sending input events to client
+	writer    io.Writer  // For sending input events to client
+	writeMu   sync.Mutex // Keeps concurrently sent messages from interleaving
+	bytesSent atomic.Uint64
+
+	keyOptions *AuthorizedKeyOptions // From the authorized_keys entry, nil if none
+
+	// Agreed in the hello, events of other features are not sent
+	protocolVersion uint32
+	features        []string
+	softwareVersion string // Waymon release of the client
+}
+
+// clientIdentity returns the ID of a client: the fingerprint of its key and the
+// ID it sends in its hello, so machines sharing a key stay apart
+func clientIdentity(fingerprint, clientID string) string {
+	if clientID == "" {
+		return fingerprint
+	}
+	return fingerprint + "/" + clientID
 }
 
 // NewSSHServer creates a new SSH-based server
@@ -61,7 +95,9 @@ func NewSSHServer(port int, hostKeyPath, authKeysPath string) *SSHServer {
 		authKeysPath: authKeysPath,
 		maxClients:   1, // Default to single client
 		clients:      make(map[string]*sshClient),
-		pendingAuth:  make(map[string]chan bool),
+		pendingAuth:  make(map[string]*authRequest),
+		authTimeout:  DefaultAuthTimeout,
+		features:     protocol.Features(),
 		stop:         make(chan struct{}),
 	}
 }
@@ -71,6 +107,23 @@ func (s *SSHServer) SetAuthHandlers(onAuthRequest func(addr, publicKey, fingerpr
 	s.OnAuthRequest = onAuthRequest
 }
 
+// SetFeatures sets the features offered to clients, all by default
+func (s *SSHServer) SetFeatures(features []string) {
+	s.features = features
+}
+
+// SetSoftwareVersion sets the waymon release told to clients
+func (s *SSHServer) SetSoftwareVersion(version string) {
+	s.softwareVersion = version
+}
+
+// SetTrustedCAKeys accepts user certificates signed by the CA keys in path,
+// for one of principals or, if there are none, for the SSH user name
+func (s *SSHServer) SetTrustedCAKeys(path string, principals []string) {
+	s.caKeysPath = path
+	s.certPrincipals = principals
+}
+
 // Start begins listening for SSH connections
 func (s *SSHServer) Start(ctx context.Context) error {
 	// Create SSH server
@@ -78,6 +131,7 @@ func (s *SSHServer) Start(ctx context.Context) error {
 		wish.WithAddress(fmt.Sprintf(":%d", s.port)),
 		wish.WithHostKeyPath(s.hostKeyPath),
 		wish.WithPublicKeyAuth(s.publicKeyAuth),
+		wish.WithKeyboardInteractiveAuth(s.keyboardInteractiveAuth),
 		wish.WithMiddleware(
 			s.loggingMiddleware(),
 			activeterm.Middleware(),
@@ -90,6 +144,13 @@ func (s *SSHServer) Start(ctx context.Context) error {
 
 	s.sshServer = server
 
+	// Clients are asked to compare this on their first connection
+	if fingerprint, err := s.HostKeyFingerprint(); err == nil {
+		logger.Infof("SSH host key fingerprint: %s", fingerprint)
+	} else {
+		logger.Warnf("Failed to read SSH host key: %v", err)
+	}
+
 	// Start listening
 	s.wg.Add(1)
 	go func() {
@@ -113,31 +174,98 @@ func (s *SSHServer) Start(ctx context.Context) error {
 	return nil
 }
 
-// SendEventToClient sends an input event to a specific client by address
-func (s *SSHServer) SendEventToClient(clientAddr string, event *protocol.InputEvent) error {
-	logger.Debugf("[SSH-SERVER] SendEventToClient called: clientAddr=%s, eventType=%T", clientAddr, event.Event)
+// HostKeyFingerprint returns the SHA256 fingerprint of the server host key
+func (s *SSHServer) HostKeyFingerprint() (string, error) {
+	key, err := s.hostPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return gossh.FingerprintSHA256(key), nil
+}
+
+// hostPublicKey reads the public part of the server host key
+func (s *SSHServer) hostPublicKey() (gossh.PublicKey, error) {
+	data, err := os.ReadFile(s.hostKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host key: %w", err)
+	}
+	signer, err := gossh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host key: %w", err)
+	}
+	return signer.PublicKey(), nil
+}
+
+// SendEventToClient sends an input event to a specific client
+func (s *SSHServer) SendEventToClient(clientID string, event *protocol.InputEvent) error {
+	logger.Debugf("[SSH-SERVER] SendEventToClient called: clientID=%s, eventType=%T", clientID, event.Event)
+
+	// A write stuck on an unresponsive client must not hold up the others
+	client := s.clientByID(clientID)
+	if client == nil {
+		logger.Errorf("[SSH-SERVER] Client not found: %s", clientID)
+		return fmt.Errorf("client not found: %s", clientID)
+	}
+	logger.Debugf("[SSH-SERVER] Found client %s, writing event", clientID)
 
+	// Use the same message format as the client expects
+	if err := s.writeInputEvent(client, event); err != nil {
+		logger.Errorf("[SSH-SERVER] Failed to write event to client %s: %v", clientID, err)
+		return fmt.Errorf("failed to send event to client: %w", err)
+	}
+
+	logger.Debugf("[SSH-SERVER] Successfully sent event to client %s", clientID)
+	return nil
+}
+
+// DisconnectClient closes the session of a client
+func (s *SSHServer) DisconnectClient(clientID string) error {
+	client := s.clientByID(clientID)
+	if client == nil {
+		return fmt.Errorf("client not found: %s", clientID)
+	}
+	return client.session.Close()
+}
+
+// BytesSent returns how many bytes were written to a client's session
+func (s *SSHServer) BytesSent(clientID string) uint64 {
+	client := s.clientByID(clientID)
+	if client == nil {
+		return 0
+	}
+	return client.bytesSent.Load()
+}
+
+// ClientKeyOptions returns the authorized_keys options of the key a client
+// authenticated with, or nil if it was not in the authorized_keys file
+func (s *SSHServer) ClientKeyOptions(clientID string) *AuthorizedKeyOptions {
+	client := s.clientByID(clientID)
+	if client == nil {
+		return nil
+	}
+	return client.keyOptions
+}
+
+// ClientFeatures returns the features agreed with a client
+func (s *SSHServer) ClientFeatures(clientID string) []string {
+	client := s.clientByID(clientID)
+	if client == nil {
+		return nil
+	}
+	return client.features
+}
+
+// clientByID finds a connected client by its ID
+func (s *SSHServer) clientByID(clientID string) *sshClient {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Find client by address
 	for _, client := range s.clients {
-		if client.addr == clientAddr {
-			logger.Debugf("[SSH-SERVER] Found client for address %s, writing event", clientAddr)
-
-			// Use the same message format as the client expects
-			if err := s.writeInputEvent(client.writer, event); err != nil {
-				logger.Errorf("[SSH-SERVER] Failed to write event to client %s: %v", clientAddr, err)
-				return fmt.Errorf("failed to send event to client: %w", err)
-			}
-
-			logger.Debugf("[SSH-SERVER] Successfully sent event to client %s", clientAddr)
-			return nil
+		if client.id == clientID {
+			return client
 		}
 	}
-
-	logger.Errorf("[SSH-SERVER] Client not found for address: %s", clientAddr)
-	return fmt.Errorf("client not found: %s", clientAddr)
+	return nil
 }
 
 // Stop shuts down the SSH server
@@ -184,6 +312,30 @@ func (s *SSHServer) publicKeyAuth(ctx ssh.Context, key ssh.PublicKey) bool {
 
 	logger.Infof("SSH authentication attempt addr=%s user=%s key=%s", addr, ctx.User(), fingerprint)
 
+	// Certificates are accepted on the CA signature alone, never approved by hand
+	if cert, ok := goKey.(*gossh.Certificate); ok {
+		if _, err := s.checkCertificate(cert, ctx.User(), remoteIP(ctx.RemoteAddr()), time.Now()); err != nil {
+			logger.Warnf("SSH certificate refused id=%q serial=%d addr=%s: %v", cert.KeyId, cert.Serial, addr, err)
+			return false
+		}
+		logger.Infof("SSH certificate accepted id=%q serial=%d ca=%s", cert.KeyId, cert.Serial, gossh.FingerprintSHA256(cert.SignatureKey))
+		return true
+	}
+
+	// Keys in the authorized_keys file, subject to their options
+	keys, err := LoadAuthorizedKeys(s.authKeysPath)
+	if err != nil {
+		logger.Errorf("Failed to load authorized keys: %v", err)
+	}
+	if entry := findAuthorizedKey(keys, goKey); entry != nil {
+		if err := entry.Options.check(remoteIP(ctx.RemoteAddr()), time.Now()); err != nil {
+			logger.Warnf("SSH key refused key=%s addr=%s: %v", fingerprint, addr, err)
+			return false
+		}
+		logger.Infof("SSH key is authorized key=%s comment=%q line=%d", fingerprint, entry.Comment, entry.Line)
+		return true
+	}
+
 	// Check if key is already whitelisted
 	if config.IsSSHKeyWhitelisted(fingerprint) {
 		logger.Infof("SSH key is whitelisted key=%s", fingerprint)
@@ -198,25 +350,56 @@ func (s *SSHServer) publicKeyAuth(ctx ssh.Context, key ssh.PublicKey) bool {
 		return true
 	}
 
-	// Key not whitelisted, request approval
-	if s.OnAuthRequest != nil {
-		logger.Infof("Requesting approval for SSH key=%s addr=%s", fingerprint, addr)
-		approved := s.OnAuthRequest(addr, string(gossh.MarshalAuthorizedKey(goKey)), fingerprint)
-		if approved {
-			// Add to whitelist
-			if err := config.AddSSHKeyToWhitelist(fingerprint); err != nil {
-				logger.Errorf("Failed to add key to whitelist: %v", err)
-			}
-			logger.Infof("SSH key approved and added to whitelist key=%s addr=%s", fingerprint, addr)
-			return true
+	// Key not whitelisted: wait for the TUI, 'waymon auth approve' or the timeout
+	return s.awaitApproval(goKey, fingerprint, ctx.User(), addr)
+}
+
+// keyOptions returns the options of the certificate or authorized_keys entry
+// of the key a session authenticated with, nil for keys that are whitelisted,
+// approved or accepted without a whitelist. The options are looked up again for
+// the session rather than kept from authentication, which also sees keys the
+// client only queries without proving it holds them.
+func (s *SSHServer) keyOptions(key gossh.PublicKey, user string, ip net.IP, now time.Time) (*AuthorizedKeyOptions, error) {
+	if cert, ok := key.(*gossh.Certificate); ok {
+		return s.checkCertificate(cert, user, ip, now)
+	}
+
+	keys, err := LoadAuthorizedKeys(s.authKeysPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load authorized keys: %w", err)
+	}
+	entry := findAuthorizedKey(keys, key)
+	if entry == nil {
+		return nil, nil
+	}
+	if err := entry.Options.check(ip, now); err != nil {
+		return nil, err
+	}
+	return &entry.Options, nil
+}
+
+// keyboardInteractiveAuth lets a client holding the pairing code enroll its key
+func (s *SSHServer) keyboardInteractiveAuth(ctx ssh.Context, challenge gossh.KeyboardInteractiveChallenge) bool {
+	return s.pair(ctx.User(), ctx.RemoteAddr().String(), challenge)
+}
+
+// rememberApprovedKey records an approved key in the authorized_keys file,
+// or in the configuration whitelist when the file cannot be written
+func (s *SSHServer) rememberApprovedKey(key gossh.PublicKey, fingerprint, comment string) {
+	if s.authKeysPath != "" {
+		err := AppendAuthorizedKey(s.authKeysPath, key, comment)
+		if err == nil {
+			logger.Infof("SSH key approved and added to %s key=%s", s.authKeysPath, fingerprint)
+			return
 		}
-		logger.Infof("SSH key denied key=%s addr=%s", fingerprint, addr)
-		return false
+		logger.Errorf("Failed to add key to authorized keys: %v", err)
 	}
 
-	// No auth handler, deny by default in whitelist-only mode
-	logger.Infof("SSH key denied (no auth handler) key=%s addr=%s", fingerprint, addr)
-	return false
+	if err := config.AddSSHKeyToWhitelist(fingerprint); err != nil {
+		logger.Errorf("Failed to add key to whitelist: %v", err)
+		return
+	}
+	logger.Infof("SSH key approved and added to whitelist key=%s", fingerprint)
 }
 
 // loggingMiddleware provides custom logging using our internal logger
@@ -239,8 +422,51 @@ func (s *SSHServer) loggingMiddleware() wish.Middleware {
 func (s *SSHServer) sessionHandler() wish.Middleware {
 	return func(h ssh.Handler) ssh.Handler {
 		return func(sess ssh.Session) {
-			// Check if we already have max clients BEFORE accepting the session
+			// Pairing authenticates without a key, such connections only enroll one
+			if sess.PublicKey() == nil {
+				logger.Infof("Rejecting session without a client key addr=%s", sess.RemoteAddr().String())
+				_ = sess.Exit(1)
+				_ = sess.Close()
+				return
+			}
+
+			// Options of the key the session authenticated with
+			addr := sess.RemoteAddr().String()
+			keyOptions, err := s.keyOptions(sess.PublicKey(), sess.User(), remoteIP(sess.RemoteAddr()), time.Now())
+			if err != nil {
+				logger.Warnf("Refusing client addr=%s: %v", addr, err)
+				_ = sess.Exit(1)
+				_ = sess.Close()
+				return
+			}
+
+			// Agree on the protocol before anything else is sent
+			clientHello, agreed, err := acceptHello(sess, newHello(s.features, s.softwareVersion), helloTimeout)
+			if err != nil {
+				logger.Warnf("Refusing client addr=%s: %v", addr, err)
+				_ = sess.Exit(1)
+				_ = sess.Close()
+				return
+			}
+			logger.Infof("Client addr=%s runs waymon %s, protocol v%d with features %v",
+				addr, clientHello.SoftwareVersion, agreed.ProtocolVersion, agreed.Features)
+
+			publicKey := gossh.FingerprintSHA256(identityKey(sess.PublicKey()))
+			id := clientIdentity(publicKey, clientHello.ClientId)
+
 			s.mu.Lock()
+
+			// A client reconnecting before its previous session timed out
+			// takes over from it
+			for sessionID, previous := range s.clients {
+				if previous.id == id {
+					logger.Infof("Client %s reconnected from %s, closing its session from %s", id, addr, previous.addr)
+					delete(s.clients, sessionID)
+					_ = previous.session.Close()
+				}
+			}
+
+			// Check if we already have max clients BEFORE accepting the session
 			if s.maxClients > 0 && len(s.clients) >= s.maxClients {
 				s.mu.Unlock()
 				// Reject the session immediately
@@ -255,30 +481,29 @@ func (s *SSHServer) sessionHandler() wish.Middleware {
 				return
 			}
 
-			// Get client info
-			addr := sess.RemoteAddr().String()
-			var publicKey string
-			if sess.PublicKey() != nil {
-				publicKey = gossh.FingerprintSHA256(sess.PublicKey())
-			}
-
 			// Get session writer for sending input events to client
 			// Use the session directly as writer - it implements io.Writer
 			writer := sess
 
 			// Create and register client entry
 			client := &sshClient{
-				session:   sess,
-				addr:      addr,
-				publicKey: publicKey,
-				writer:    writer,
+				session:    sess,
+				id:         id,
+				addr:       addr,
+				publicKey:  publicKey,
+				writer:     writer,
+				keyOptions: keyOptions,
+
+				protocolVersion: agreed.ProtocolVersion,
+				features:        agreed.Features,
+				softwareVersion: clientHello.SoftwareVersion,
 			}
 			s.clients[sess.Context().SessionID()] = client
 			s.mu.Unlock()
 
 			// Notify connection
 			if s.OnClientConnected != nil {
-				s.OnClientConnected(addr, publicKey)
+				s.OnClientConnected(id, addr, publicKey)
 			}
 
 			// Handle disconnection
@@ -288,21 +513,33 @@ func (s *SSHServer) sessionHandler() wish.Middleware {
 				s.mu.Unlock()
 
 				if s.OnClientDisconnected != nil {
-					s.OnClientDisconnected(addr)
+					s.OnClientDisconnected(id, addr)
 				}
 			}()
 
 			// Log connection info instead of sending to client
 			logger.Infof("Waymon SSH connection established - Public key: %s", publicKey)
 
+			// End the session when the key expires or its allowed hours are over
+			if keyOptions != nil {
+				if until := keyOptions.allowedUntil(time.Now()); !until.IsZero() {
+					timer := time.AfterFunc(time.Until(until), func() {
+						logger.Infof("Closing session of %s: key no longer allowed", addr)
+						_ = sess.Close()
+					})
+					defer timer.Stop()
+				}
+			}
+
 			// Handle mouse events with context
-			s.handleMouseEvents(s.ctx, sess)
+			s.handleMouseEvents(s.ctx, sess, id)
 		}
 	}
 }
 
-// handleMouseEvents reads and processes mouse events from the SSH session
-func (s *SSHServer) handleMouseEvents(ctx context.Context, sess ssh.Session) {
+// handleMouseEvents reads and processes mouse events from the SSH session,
+// marking them as coming from clientID
+func (s *SSHServer) handleMouseEvents(ctx context.Context, sess ssh.Session, clientID string) {
 	// Create channels for coordinating shutdown
 	done := make(chan struct{})
 	defer close(done)
@@ -371,7 +608,7 @@ func (s *SSHServer) handleMouseEvents(ctx context.Context, sess ssh.Session) {
 			// Decode length
 			lengthBuf := result.data
 			length := int(lengthBuf[0])<<24 | int(lengthBuf[1])<<16 | int(lengthBuf[2])<<8 | int(lengthBuf[3])
-			if length <= 0 || length > 4096 {
+			if length <= 0 || length > maxMessageSize {
 				return
 			}
 
@@ -388,6 +625,7 @@ func (s *SSHServer) handleMouseEvents(ctx context.Context, sess ssh.Session) {
 				logger.Debugf("[SSH-SERVER] Failed to unmarshal input event: %v", err)
 				continue
 			}
+			inputEvent.SourceId = clientID
 
 			// Call event handler
 			if s.OnInputEvent != nil {
@@ -423,7 +661,7 @@ func (s *SSHServer) SendInputEventToClient(sessionID string, event *protocol.Inp
 		return fmt.Errorf("client not found: %s", sessionID)
 	}
 
-	return s.writeInputEvent(client.writer, event)
+	return s.writeInputEvent(client, event)
 }
 
 // SendInputEventToAllClients sends an input event to all connected clients
@@ -437,7 +675,7 @@ func (s *SSHServer) SendInputEventToAllClients(event *protocol.InputEvent) error
 
 	var lastErr error
 	for _, client := range clients {
-		if err := s.writeInputEvent(client.writer, event); err != nil {
+		if err := s.writeInputEvent(client, event); err != nil {
 			lastErr = err
 			logger.Errorf("Failed to send input event to client %s: %v", client.addr, err)
 		}
@@ -459,7 +697,28 @@ func (s *SSHServer) GetClientSessions() map[string]string {
 }
 
 // writeInputEvent writes an input event to a client
-func (s *SSHServer) writeInputEvent(w io.Writer, event *protocol.InputEvent) error {
+func (s *SSHServer) writeInputEvent(client *sshClient, event *protocol.InputEvent) error {
+	events := sessionEvents(client.features, event)
+	if len(events) == 0 {
+		logger.Debugf("[SSH-SERVER] Not sending %T to %s: feature %q not agreed", event.Event, client.addr, protocol.FeatureOf(event))
+		return nil
+	}
+
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	for _, event := range events {
+		if err := s.writeMessage(client, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMessage writes one message to a client. Must be called with the
+// client's write lock held.
+func (s *SSHServer) writeMessage(client *sshClient, event *protocol.InputEvent) error {
+	w := client.writer
+
 	logger.Debugf("[SSH-SERVER] writeInputEvent: marshaling event type=%T", event.Event)
 
 	data, err := proto.Marshal(event)
@@ -488,6 +747,7 @@ func (s *SSHServer) writeInputEvent(w io.Writer, event *protocol.InputEvent) err
 		logger.Errorf("[SSH-SERVER] Failed to write data: %v", err)
 		return fmt.Errorf("failed to write data: %w", err)
 	}
+	client.bytesSent.Add(uint64(len(lengthBuf) + length))
 
 	// Check if the writer supports flushing
 	if flusher, ok := w.(interface{ Flush() error }); ok {
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.36.6
-// 	protoc        v6.31.0
+// 	protoc        v6.31.1
 // source: internal/proto/mouse.proto
 
 package proto
@@ -207,6 +207,11 @@ const (
 	IPCMessageType_IPC_MESSAGE_TYPE_STATUS          IPCMessageType = 2
 	IPCMessageType_IPC_MESSAGE_TYPE_STATUS_RESPONSE IPCMessageType = 3
 	IPCMessageType_IPC_MESSAGE_TYPE_ERROR           IPCMessageType = 4
+	IPCMessageType_IPC_MESSAGE_TYPE_AUTH_LIST       IPCMessageType = 5
+	IPCMessageType_IPC_MESSAGE_TYPE_AUTH_DECISION   IPCMessageType = 6
+	IPCMessageType_IPC_MESSAGE_TYPE_AUTH_RESPONSE   IPCMessageType = 7
+	IPCMessageType_IPC_MESSAGE_TYPE_PAIR            IPCMessageType = 8
+	IPCMessageType_IPC_MESSAGE_TYPE_PAIR_RESPONSE   IPCMessageType = 9
 )
 
 // Enum value maps for IPCMessageType.
@@ -217,6 +222,11 @@ var (
 		2: "IPC_MESSAGE_TYPE_STATUS",
 		3: "IPC_MESSAGE_TYPE_STATUS_RESPONSE",
 		4: "IPC_MESSAGE_TYPE_ERROR",
+		5: "IPC_MESSAGE_TYPE_AUTH_LIST",
+		6: "IPC_MESSAGE_TYPE_AUTH_DECISION",
+		7: "IPC_MESSAGE_TYPE_AUTH_RESPONSE",
+		8: "IPC_MESSAGE_TYPE_PAIR",
+		9: "IPC_MESSAGE_TYPE_PAIR_RESPONSE",
 	}
 	IPCMessageType_value = map[string]int32{
 		"IPC_MESSAGE_TYPE_UNSPECIFIED":     0,
@@ -224,6 +234,11 @@ var (
 		"IPC_MESSAGE_TYPE_STATUS":          2,
 		"IPC_MESSAGE_TYPE_STATUS_RESPONSE": 3,
 		"IPC_MESSAGE_TYPE_ERROR":           4,
+		"IPC_MESSAGE_TYPE_AUTH_LIST":       5,
+		"IPC_MESSAGE_TYPE_AUTH_DECISION":   6,
+		"IPC_MESSAGE_TYPE_AUTH_RESPONSE":   7,
+		"IPC_MESSAGE_TYPE_PAIR":            8,
+		"IPC_MESSAGE_TYPE_PAIR_RESPONSE":   9,
 	}
 )
 
@@ -642,6 +657,11 @@ type IPCMessage struct {
 	//	*IPCMessage_StatusQuery
 	//	*IPCMessage_StatusResponse
 	//	*IPCMessage_ErrorResponse
+	//	*IPCMessage_AuthListQuery
+	//	*IPCMessage_AuthDecision
+	//	*IPCMessage_AuthResponse
+	//	*IPCMessage_PairRequest
+	//	*IPCMessage_PairResponse
 	Payload       isIPCMessage_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -727,6 +747,51 @@ func (x *IPCMessage) GetErrorResponse() *ErrorResponse {
 	return nil
 }
 
+func (x *IPCMessage) GetAuthListQuery() *AuthListQuery {
+	if x != nil {
+		if x, ok := x.Payload.(*IPCMessage_AuthListQuery); ok {
+			return x.AuthListQuery
+		}
+	}
+	return nil
+}
+
+func (x *IPCMessage) GetAuthDecision() *AuthDecision {
+	if x != nil {
+		if x, ok := x.Payload.(*IPCMessage_AuthDecision); ok {
+			return x.AuthDecision
+		}
+	}
+	return nil
+}
+
+func (x *IPCMessage) GetAuthResponse() *AuthResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*IPCMessage_AuthResponse); ok {
+			return x.AuthResponse
+		}
+	}
+	return nil
+}
+
+func (x *IPCMessage) GetPairRequest() *PairRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*IPCMessage_PairRequest); ok {
+			return x.PairRequest
+		}
+	}
+	return nil
+}
+
+func (x *IPCMessage) GetPairResponse() *PairResponse {
+	if x != nil {
+		if x, ok := x.Payload.(*IPCMessage_PairResponse); ok {
+			return x.PairResponse
+		}
+	}
+	return nil
+}
+
 type isIPCMessage_Payload interface {
 	isIPCMessage_Payload()
 }
@@ -747,6 +812,26 @@ type IPCMessage_ErrorResponse struct {
 	ErrorResponse *ErrorResponse `protobuf:"bytes,5,opt,name=error_response,json=errorResponse,proto3,oneof"`
 }
 
+type IPCMessage_AuthListQuery struct {
+	AuthListQuery *AuthListQuery `protobuf:"bytes,6,opt,name=auth_list_query,json=authListQuery,proto3,oneof"`
+}
+
+type IPCMessage_AuthDecision struct {
+	AuthDecision *AuthDecision `protobuf:"bytes,7,opt,name=auth_decision,json=authDecision,proto3,oneof"`
+}
+
+type IPCMessage_AuthResponse struct {
+	AuthResponse *AuthResponse `protobuf:"bytes,8,opt,name=auth_response,json=authResponse,proto3,oneof"`
+}
+
+type IPCMessage_PairRequest struct {
+	PairRequest *PairRequest `protobuf:"bytes,9,opt,name=pair_request,json=pairRequest,proto3,oneof"`
+}
+
+type IPCMessage_PairResponse struct {
+	PairResponse *PairResponse `protobuf:"bytes,10,opt,name=pair_response,json=pairResponse,proto3,oneof"`
+}
+
 func (*IPCMessage_SwitchCommand) isIPCMessage_Payload() {}
 
 func (*IPCMessage_StatusQuery) isIPCMessage_Payload() {}
@@ -755,6 +840,16 @@ func (*IPCMessage_StatusResponse) isIPCMessage_Payload() {}
 
 func (*IPCMessage_ErrorResponse) isIPCMessage_Payload() {}
 
+func (*IPCMessage_AuthListQuery) isIPCMessage_Payload() {}
+
+func (*IPCMessage_AuthDecision) isIPCMessage_Payload() {}
+
+func (*IPCMessage_AuthResponse) isIPCMessage_Payload() {}
+
+func (*IPCMessage_PairRequest) isIPCMessage_Payload() {}
+
+func (*IPCMessage_PairResponse) isIPCMessage_Payload() {}
+
 // SwitchCommand represents a switch command
 type SwitchCommand struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -854,6 +949,7 @@ type StatusResponse struct {
 	CurrentComputer int32                  `protobuf:"varint,4,opt,name=current_computer,json=currentComputer,proto3" json:"current_computer,omitempty"` // Index of currently active computer (0 = server)
 	TotalComputers  int32                  `protobuf:"varint,5,opt,name=total_computers,json=totalComputers,proto3" json:"total_computers,omitempty"`    // Total number of computers in rotation
 	ComputerNames   []string               `protobuf:"bytes,6,rep,name=computer_names,json=computerNames,proto3" json:"computer_names,omitempty"`        // Names/IDs of all computers in rotation
+	ClientStats     []*ClientStats         `protobuf:"bytes,7,rep,name=client_stats,json=clientStats,proto3" json:"client_stats,omitempty"`              // Network statistics of connected clients
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -930,6 +1026,130 @@ func (x *StatusResponse) GetComputerNames() []string {
 	return nil
 }
 
+func (x *StatusResponse) GetClientStats() []*ClientStats {
+	if x != nil {
+		return x.ClientStats
+	}
+	return nil
+}
+
+// ClientStats holds live network statistics of a connected client
+type ClientStats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address         string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	RttMs           float64                `protobuf:"fixed64,3,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`                  // Smoothed heartbeat round trip time
+	EventRttMs      float64                `protobuf:"fixed64,4,opt,name=event_rtt_ms,json=eventRttMs,proto3" json:"event_rtt_ms,omitempty"` // Capture to injection and back, for sampled events
+	InjectMs        float64                `protobuf:"fixed64,5,opt,name=inject_ms,json=injectMs,proto3" json:"inject_ms,omitempty"`         // Time the client takes to inject an event
+	JitterMs        float64                `protobuf:"fixed64,6,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`         // Interarrival jitter seen by the client
+	EventsPerSecond float64                `protobuf:"fixed64,7,opt,name=events_per_second,json=eventsPerSecond,proto3" json:"events_per_second,omitempty"`
+	EventsSent      uint64                 `protobuf:"varint,8,opt,name=events_sent,json=eventsSent,proto3" json:"events_sent,omitempty"`
+	EventsDropped   uint64                 `protobuf:"varint,9,opt,name=events_dropped,json=eventsDropped,proto3" json:"events_dropped,omitempty"` // Captured for the client but never sent
+	BytesSent       uint64                 `protobuf:"varint,10,opt,name=bytes_sent,json=bytesSent,proto3" json:"bytes_sent,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ClientStats) Reset() {
+	*x = ClientStats{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientStats) ProtoMessage() {}
+
+func (x *ClientStats) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientStats.ProtoReflect.Descriptor instead.
+func (*ClientStats) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClientStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientStats) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ClientStats) GetRttMs() float64 {
+	if x != nil {
+		return x.RttMs
+	}
+	return 0
+}
+
+func (x *ClientStats) GetEventRttMs() float64 {
+	if x != nil {
+		return x.EventRttMs
+	}
+	return 0
+}
+
+func (x *ClientStats) GetInjectMs() float64 {
+	if x != nil {
+		return x.InjectMs
+	}
+	return 0
+}
+
+func (x *ClientStats) GetJitterMs() float64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+func (x *ClientStats) GetEventsPerSecond() float64 {
+	if x != nil {
+		return x.EventsPerSecond
+	}
+	return 0
+}
+
+func (x *ClientStats) GetEventsSent() uint64 {
+	if x != nil {
+		return x.EventsSent
+	}
+	return 0
+}
+
+func (x *ClientStats) GetEventsDropped() uint64 {
+	if x != nil {
+		return x.EventsDropped
+	}
+	return 0
+}
+
+func (x *ClientStats) GetBytesSent() uint64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -940,7 +1160,7 @@ type ErrorResponse struct {
 
 func (x *ErrorResponse) Reset() {
 	*x = ErrorResponse{}
-	mi := &file_internal_proto_mouse_proto_msgTypes[8]
+	mi := &file_internal_proto_mouse_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -952,7 +1172,7 @@ func (x *ErrorResponse) String() string {
 func (*ErrorResponse) ProtoMessage() {}
 
 func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_mouse_proto_msgTypes[8]
+	mi := &file_internal_proto_mouse_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -965,7 +1185,7 @@ func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorResponse.ProtoReflect.Descriptor instead.
 func (*ErrorResponse) Descriptor() ([]byte, []int) {
-	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{8}
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ErrorResponse) GetError() string {
@@ -975,6 +1195,348 @@ func (x *ErrorResponse) GetError() string {
 	return ""
 }
 
+// AuthListQuery asks for the client keys waiting for approval
+type AuthListQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthListQuery) Reset() {
+	*x = AuthListQuery{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthListQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthListQuery) ProtoMessage() {}
+
+func (x *AuthListQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthListQuery.ProtoReflect.Descriptor instead.
+func (*AuthListQuery) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{10}
+}
+
+// AuthDecision approves or denies keys waiting for approval
+type AuthDecision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"` // SHA256 fingerprint, the "SHA256:" prefix is optional
+	Approve       bool                   `protobuf:"varint,2,opt,name=approve,proto3" json:"approve,omitempty"`
+	All           bool                   `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"` // Deny every pending key, fingerprint is ignored
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthDecision) Reset() {
+	*x = AuthDecision{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthDecision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthDecision) ProtoMessage() {}
+
+func (x *AuthDecision) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthDecision.ProtoReflect.Descriptor instead.
+func (*AuthDecision) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AuthDecision) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *AuthDecision) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+func (x *AuthDecision) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+// AuthResponse lists the keys still waiting for approval
+type AuthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pending       []*PendingAuth         `protobuf:"bytes,1,rep,name=pending,proto3" json:"pending,omitempty"`
+	Resolved      int32                  `protobuf:"varint,2,opt,name=resolved,proto3" json:"resolved,omitempty"` // Keys approved or denied by the decision
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthResponse) Reset() {
+	*x = AuthResponse{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthResponse) ProtoMessage() {}
+
+func (x *AuthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthResponse.ProtoReflect.Descriptor instead.
+func (*AuthResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *AuthResponse) GetPending() []*PendingAuth {
+	if x != nil {
+		return x.Pending
+	}
+	return nil
+}
+
+func (x *AuthResponse) GetResolved() int32 {
+	if x != nil {
+		return x.Resolved
+	}
+	return 0
+}
+
+// PendingAuth is a client key waiting for approval
+type PendingAuth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fingerprint   string                 `protobuf:"bytes,1,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"` // authorized_keys format
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	User          string                 `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+	RequestedAtMs int64                  `protobuf:"varint,5,opt,name=requested_at_ms,json=requestedAtMs,proto3" json:"requested_at_ms,omitempty"`
+	ExpiresAtMs   int64                  `protobuf:"varint,6,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PendingAuth) Reset() {
+	*x = PendingAuth{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendingAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingAuth) ProtoMessage() {}
+
+func (x *PendingAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingAuth.ProtoReflect.Descriptor instead.
+func (*PendingAuth) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PendingAuth) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *PendingAuth) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *PendingAuth) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *PendingAuth) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *PendingAuth) GetRequestedAtMs() int64 {
+	if x != nil {
+		return x.RequestedAtMs
+	}
+	return 0
+}
+
+func (x *PendingAuth) GetExpiresAtMs() int64 {
+	if x != nil {
+		return x.ExpiresAtMs
+	}
+	return 0
+}
+
+// PairRequest asks for a one-time code enrolling a new client
+type PairRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TimeoutSeconds int32                  `protobuf:"varint,1,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // 0 = server default
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PairRequest) Reset() {
+	*x = PairRequest{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PairRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairRequest) ProtoMessage() {}
+
+func (x *PairRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairRequest.ProtoReflect.Descriptor instead.
+func (*PairRequest) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PairRequest) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+// PairResponse carries a pairing code
+type PairResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Code               string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	ExpiresAtMs        int64                  `protobuf:"varint,2,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"`
+	HostKeyFingerprint string                 `protobuf:"bytes,3,opt,name=host_key_fingerprint,json=hostKeyFingerprint,proto3" json:"host_key_fingerprint,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PairResponse) Reset() {
+	*x = PairResponse{}
+	mi := &file_internal_proto_mouse_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PairResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PairResponse) ProtoMessage() {}
+
+func (x *PairResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_mouse_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PairResponse.ProtoReflect.Descriptor instead.
+func (*PairResponse) Descriptor() ([]byte, []int) {
+	return file_internal_proto_mouse_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PairResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *PairResponse) GetExpiresAtMs() int64 {
+	if x != nil {
+		return x.ExpiresAtMs
+	}
+	return 0
+}
+
+func (x *PairResponse) GetHostKeyFingerprint() string {
+	if x != nil {
+		return x.HostKeyFingerprint
+	}
+	return ""
+}
+
 var File_internal_proto_mouse_proto protoreflect.FileDescriptor
 
 const file_internal_proto_mouse_proto_rawDesc = "" +
@@ -1007,20 +1569,26 @@ const file_internal_proto_mouse_proto_rawDesc = "" +
 	"\x05event\"8\n" +
 	"\n" +
 	"EventBatch\x12*\n" +
-	"\x06events\x18\x01 \x03(\v2\x12.waymon.InputEventR\x06events\"\xc0\x02\n" +
+	"\x06events\x18\x01 \x03(\v2\x12.waymon.InputEventR\x06events\"\xf2\x04\n" +
 	"\n" +
 	"IPCMessage\x12*\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x16.waymon.IPCMessageTypeR\x04type\x12>\n" +
 	"\x0eswitch_command\x18\x02 \x01(\v2\x15.waymon.SwitchCommandH\x00R\rswitchCommand\x128\n" +
 	"\fstatus_query\x18\x03 \x01(\v2\x13.waymon.StatusQueryH\x00R\vstatusQuery\x12A\n" +
 	"\x0fstatus_response\x18\x04 \x01(\v2\x16.waymon.StatusResponseH\x00R\x0estatusResponse\x12>\n" +
-	"\x0eerror_response\x18\x05 \x01(\v2\x15.waymon.ErrorResponseH\x00R\rerrorResponseB\t\n" +
+	"\x0eerror_response\x18\x05 \x01(\v2\x15.waymon.ErrorResponseH\x00R\rerrorResponse\x12?\n" +
+	"\x0fauth_list_query\x18\x06 \x01(\v2\x15.waymon.AuthListQueryH\x00R\rauthListQuery\x12;\n" +
+	"\rauth_decision\x18\a \x01(\v2\x14.waymon.AuthDecisionH\x00R\fauthDecision\x12;\n" +
+	"\rauth_response\x18\b \x01(\v2\x14.waymon.AuthResponseH\x00R\fauthResponse\x128\n" +
+	"\fpair_request\x18\t \x01(\v2\x13.waymon.PairRequestH\x00R\vpairRequest\x12;\n" +
+	"\rpair_response\x18\n" +
+	" \x01(\v2\x14.waymon.PairResponseH\x00R\fpairResponseB\t\n" +
 	"\apayload\"e\n" +
 	"\rSwitchCommand\x12\x1b\n" +
 	"\x06enable\x18\x01 \x01(\bH\x00R\x06enable\x88\x01\x01\x12,\n" +
 	"\x06action\x18\x02 \x01(\x0e2\x14.waymon.SwitchActionR\x06actionB\t\n" +
 	"\a_enable\"\r\n" +
-	"\vStatusQuery\"\xe2\x01\n" +
+	"\vStatusQuery\"\x9a\x02\n" +
 	"\x0eStatusResponse\x12\x16\n" +
 	"\x06active\x18\x01 \x01(\bR\x06active\x12\x1c\n" +
 	"\tconnected\x18\x02 \x01(\bR\tconnected\x12\x1f\n" +
@@ -1028,9 +1596,47 @@ const file_internal_proto_mouse_proto_rawDesc = "" +
 	"serverHost\x12)\n" +
 	"\x10current_computer\x18\x04 \x01(\x05R\x0fcurrentComputer\x12'\n" +
 	"\x0ftotal_computers\x18\x05 \x01(\x05R\x0etotalComputers\x12%\n" +
-	"\x0ecomputer_names\x18\x06 \x03(\tR\rcomputerNames\"%\n" +
+	"\x0ecomputer_names\x18\x06 \x03(\tR\rcomputerNames\x126\n" +
+	"\fclient_stats\x18\a \x03(\v2\x13.waymon.ClientStatsR\vclientStats\"\xc1\x02\n" +
+	"\vClientStats\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x15\n" +
+	"\x06rtt_ms\x18\x03 \x01(\x01R\x05rttMs\x12 \n" +
+	"\fevent_rtt_ms\x18\x04 \x01(\x01R\n" +
+	"eventRttMs\x12\x1b\n" +
+	"\tinject_ms\x18\x05 \x01(\x01R\binjectMs\x12\x1b\n" +
+	"\tjitter_ms\x18\x06 \x01(\x01R\bjitterMs\x12*\n" +
+	"\x11events_per_second\x18\a \x01(\x01R\x0feventsPerSecond\x12\x1f\n" +
+	"\vevents_sent\x18\b \x01(\x04R\n" +
+	"eventsSent\x12%\n" +
+	"\x0eevents_dropped\x18\t \x01(\x04R\reventsDropped\x12\x1d\n" +
+	"\n" +
+	"bytes_sent\x18\n" +
+	" \x01(\x04R\tbytesSent\"%\n" +
 	"\rErrorResponse\x12\x14\n" +
-	"\x05error\x18\x01 \x01(\tR\x05error*\xa9\x01\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"\x0f\n" +
+	"\rAuthListQuery\"\\\n" +
+	"\fAuthDecision\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\x12\x18\n" +
+	"\aapprove\x18\x02 \x01(\bR\aapprove\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\bR\x03all\"Y\n" +
+	"\fAuthResponse\x12-\n" +
+	"\apending\x18\x01 \x03(\v2\x13.waymon.PendingAuthR\apending\x12\x1a\n" +
+	"\bresolved\x18\x02 \x01(\x05R\bresolved\"\xc8\x01\n" +
+	"\vPendingAuth\x12 \n" +
+	"\vfingerprint\x18\x01 \x01(\tR\vfingerprint\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04user\x18\x04 \x01(\tR\x04user\x12&\n" +
+	"\x0frequested_at_ms\x18\x05 \x01(\x03R\rrequestedAtMs\x12\"\n" +
+	"\rexpires_at_ms\x18\x06 \x01(\x03R\vexpiresAtMs\"6\n" +
+	"\vPairRequest\x12'\n" +
+	"\x0ftimeout_seconds\x18\x01 \x01(\x05R\x0etimeoutSeconds\"x\n" +
+	"\fPairResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\"\n" +
+	"\rexpires_at_ms\x18\x02 \x01(\x03R\vexpiresAtMs\x120\n" +
+	"\x14host_key_fingerprint\x18\x03 \x01(\tR\x12hostKeyFingerprint*\xa9\x01\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x13\n" +
 	"\x0fEVENT_TYPE_MOVE\x10\x01\x12\x14\n" +
@@ -1051,13 +1657,18 @@ const file_internal_proto_mouse_proto_rawDesc = "" +
 	"\x13SCROLL_DIRECTION_UP\x10\x01\x12\x19\n" +
 	"\x15SCROLL_DIRECTION_DOWN\x10\x02\x12\x19\n" +
 	"\x15SCROLL_DIRECTION_LEFT\x10\x03\x12\x1a\n" +
-	"\x16SCROLL_DIRECTION_RIGHT\x10\x04*\xae\x01\n" +
+	"\x16SCROLL_DIRECTION_RIGHT\x10\x04*\xd5\x02\n" +
 	"\x0eIPCMessageType\x12 \n" +
 	"\x1cIPC_MESSAGE_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
 	"\x17IPC_MESSAGE_TYPE_SWITCH\x10\x01\x12\x1b\n" +
 	"\x17IPC_MESSAGE_TYPE_STATUS\x10\x02\x12$\n" +
 	" IPC_MESSAGE_TYPE_STATUS_RESPONSE\x10\x03\x12\x1a\n" +
-	"\x16IPC_MESSAGE_TYPE_ERROR\x10\x04*\x96\x01\n" +
+	"\x16IPC_MESSAGE_TYPE_ERROR\x10\x04\x12\x1e\n" +
+	"\x1aIPC_MESSAGE_TYPE_AUTH_LIST\x10\x05\x12\"\n" +
+	"\x1eIPC_MESSAGE_TYPE_AUTH_DECISION\x10\x06\x12\"\n" +
+	"\x1eIPC_MESSAGE_TYPE_AUTH_RESPONSE\x10\a\x12\x19\n" +
+	"\x15IPC_MESSAGE_TYPE_PAIR\x10\b\x12\"\n" +
+	"\x1eIPC_MESSAGE_TYPE_PAIR_RESPONSE\x10\t*\x96\x01\n" +
 	"\fSwitchAction\x12\x1d\n" +
 	"\x19SWITCH_ACTION_UNSPECIFIED\x10\x00\x12\x16\n" +
 	"\x12SWITCH_ACTION_NEXT\x10\x01\x12\x1a\n" +
@@ -1078,7 +1689,7 @@ func file_internal_proto_mouse_proto_rawDescGZIP() []byte {
 }
 
 var file_internal_proto_mouse_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_internal_proto_mouse_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_internal_proto_mouse_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_internal_proto_mouse_proto_goTypes = []any{
 	(EventType)(0),         // 0: waymon.EventType
 	(MouseButton)(0),       // 1: waymon.MouseButton
@@ -1093,7 +1704,14 @@ var file_internal_proto_mouse_proto_goTypes = []any{
 	(*SwitchCommand)(nil),  // 10: waymon.SwitchCommand
 	(*StatusQuery)(nil),    // 11: waymon.StatusQuery
 	(*StatusResponse)(nil), // 12: waymon.StatusResponse
-	(*ErrorResponse)(nil),  // 13: waymon.ErrorResponse
+	(*ClientStats)(nil),    // 13: waymon.ClientStats
+	(*ErrorResponse)(nil),  // 14: waymon.ErrorResponse
+	(*AuthListQuery)(nil),  // 15: waymon.AuthListQuery
+	(*AuthDecision)(nil),   // 16: waymon.AuthDecision
+	(*AuthResponse)(nil),   // 17: waymon.AuthResponse
+	(*PendingAuth)(nil),    // 18: waymon.PendingAuth
+	(*PairRequest)(nil),    // 19: waymon.PairRequest
+	(*PairResponse)(nil),   // 20: waymon.PairResponse
 }
 var file_internal_proto_mouse_proto_depIdxs = []int32{
 	0,  // 0: waymon.MouseEvent.type:type_name -> waymon.EventType
@@ -1106,13 +1724,20 @@ var file_internal_proto_mouse_proto_depIdxs = []int32{
 	10, // 7: waymon.IPCMessage.switch_command:type_name -> waymon.SwitchCommand
 	11, // 8: waymon.IPCMessage.status_query:type_name -> waymon.StatusQuery
 	12, // 9: waymon.IPCMessage.status_response:type_name -> waymon.StatusResponse
-	13, // 10: waymon.IPCMessage.error_response:type_name -> waymon.ErrorResponse
-	4,  // 11: waymon.SwitchCommand.action:type_name -> waymon.SwitchAction
-	12, // [12:12] is the sub-list for method output_type
-	12, // [12:12] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	14, // 10: waymon.IPCMessage.error_response:type_name -> waymon.ErrorResponse
+	15, // 11: waymon.IPCMessage.auth_list_query:type_name -> waymon.AuthListQuery
+	16, // 12: waymon.IPCMessage.auth_decision:type_name -> waymon.AuthDecision
+	17, // 13: waymon.IPCMessage.auth_response:type_name -> waymon.AuthResponse
+	19, // 14: waymon.IPCMessage.pair_request:type_name -> waymon.PairRequest
+	20, // 15: waymon.IPCMessage.pair_response:type_name -> waymon.PairResponse
+	4,  // 16: waymon.SwitchCommand.action:type_name -> waymon.SwitchAction
+	13, // 17: waymon.StatusResponse.client_stats:type_name -> waymon.ClientStats
+	18, // 18: waymon.AuthResponse.pending:type_name -> waymon.PendingAuth
+	19, // [19:19] is the sub-list for method output_type
+	19, // [19:19] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_mouse_proto_init() }
@@ -1129,6 +1754,11 @@ func file_internal_proto_mouse_proto_init() {
 		(*IPCMessage_StatusQuery)(nil),
 		(*IPCMessage_StatusResponse)(nil),
 		(*IPCMessage_ErrorResponse)(nil),
+		(*IPCMessage_AuthListQuery)(nil),
+		(*IPCMessage_AuthDecision)(nil),
+		(*IPCMessage_AuthResponse)(nil),
+		(*IPCMessage_PairRequest)(nil),
+		(*IPCMessage_PairResponse)(nil),
 	}
 	file_internal_proto_mouse_proto_msgTypes[5].OneofWrappers = []any{}
 	type x struct{}
@@ -1137,7 +1767,7 @@ func file_internal_proto_mouse_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_mouse_proto_rawDesc), len(file_internal_proto_mouse_proto_rawDesc)),
 			NumEnums:      5,
-			NumMessages:   9,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
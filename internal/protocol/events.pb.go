@@ -70,6 +70,147 @@ func (ScrollType) EnumDescriptor() ([]byte, []int) {
 	return file_internal_protocol_events_proto_rawDescGZIP(), []int{0}
 }
 
+type GestureType int32
+
+const (
+	GestureType_GESTURE_SWIPE GestureType = 0
+	GestureType_GESTURE_PINCH GestureType = 1
+)
+
+// Enum value maps for GestureType.
+var (
+	GestureType_name = map[int32]string{
+		0: "GESTURE_SWIPE",
+		1: "GESTURE_PINCH",
+	}
+	GestureType_value = map[string]int32{
+		"GESTURE_SWIPE": 0,
+		"GESTURE_PINCH": 1,
+	}
+)
+
+func (x GestureType) Enum() *GestureType {
+	p := new(GestureType)
+	*p = x
+	return p
+}
+
+func (x GestureType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GestureType) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_events_proto_enumTypes[1].Descriptor()
+}
+
+func (GestureType) Type() protoreflect.EnumType {
+	return &file_internal_protocol_events_proto_enumTypes[1]
+}
+
+func (x GestureType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GestureType.Descriptor instead.
+func (GestureType) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{1}
+}
+
+type GesturePhase int32
+
+const (
+	GesturePhase_GESTURE_BEGIN  GesturePhase = 0
+	GesturePhase_GESTURE_UPDATE GesturePhase = 1
+	GesturePhase_GESTURE_END    GesturePhase = 2
+)
+
+// Enum value maps for GesturePhase.
+var (
+	GesturePhase_name = map[int32]string{
+		0: "GESTURE_BEGIN",
+		1: "GESTURE_UPDATE",
+		2: "GESTURE_END",
+	}
+	GesturePhase_value = map[string]int32{
+		"GESTURE_BEGIN":  0,
+		"GESTURE_UPDATE": 1,
+		"GESTURE_END":    2,
+	}
+)
+
+func (x GesturePhase) Enum() *GesturePhase {
+	p := new(GesturePhase)
+	*p = x
+	return p
+}
+
+func (x GesturePhase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GesturePhase) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_events_proto_enumTypes[2].Descriptor()
+}
+
+func (GesturePhase) Type() protoreflect.EnumType {
+	return &file_internal_protocol_events_proto_enumTypes[2]
+}
+
+func (x GesturePhase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GesturePhase.Descriptor instead.
+func (GesturePhase) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{2}
+}
+
+type TabletTool int32
+
+const (
+	TabletTool_TABLET_TOOL_PEN    TabletTool = 0
+	TabletTool_TABLET_TOOL_ERASER TabletTool = 1
+)
+
+// Enum value maps for TabletTool.
+var (
+	TabletTool_name = map[int32]string{
+		0: "TABLET_TOOL_PEN",
+		1: "TABLET_TOOL_ERASER",
+	}
+	TabletTool_value = map[string]int32{
+		"TABLET_TOOL_PEN":    0,
+		"TABLET_TOOL_ERASER": 1,
+	}
+)
+
+func (x TabletTool) Enum() *TabletTool {
+	p := new(TabletTool)
+	*p = x
+	return p
+}
+
+func (x TabletTool) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TabletTool) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_protocol_events_proto_enumTypes[3].Descriptor()
+}
+
+func (TabletTool) Type() protoreflect.EnumType {
+	return &file_internal_protocol_events_proto_enumTypes[3]
+}
+
+func (x TabletTool) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TabletTool.Descriptor instead.
+func (TabletTool) EnumDescriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{3}
+}
+
 type ClientStatus int32
 
 const (
@@ -103,11 +244,11 @@ func (x ClientStatus) String() string {
 }
 
 func (ClientStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_internal_protocol_events_proto_enumTypes[1].Descriptor()
+	return file_internal_protocol_events_proto_enumTypes[4].Descriptor()
 }
 
 func (ClientStatus) Type() protoreflect.EnumType {
-	return &file_internal_protocol_events_proto_enumTypes[1]
+	return &file_internal_protocol_events_proto_enumTypes[4]
 }
 
 func (x ClientStatus) Number() protoreflect.EnumNumber {
@@ -116,7 +257,7 @@ func (x ClientStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ClientStatus.Descriptor instead.
 func (ClientStatus) EnumDescriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{1}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{4}
 }
 
 type ControlEvent_Type int32
@@ -128,21 +269,27 @@ const (
 	ControlEvent_RELEASE_CONTROL      ControlEvent_Type = 3
 	ControlEvent_CLIENT_LIST_REQUEST  ControlEvent_Type = 4
 	ControlEvent_CLIENT_LIST_RESPONSE ControlEvent_Type = 5
-	ControlEvent_CLIENT_CONFIG        ControlEvent_Type = 6 // Client sends its configuration
-	ControlEvent_SERVER_SHUTDOWN      ControlEvent_Type = 7 // Server is shutting down gracefully
+	ControlEvent_CLIENT_CONFIG        ControlEvent_Type = 6  // Client sends its configuration
+	ControlEvent_SERVER_SHUTDOWN      ControlEvent_Type = 7  // Server is shutting down gracefully
+	ControlEvent_KEYMAP               ControlEvent_Type = 8  // Server sends the keymap its keys should be read with
+	ControlEvent_PING                 ControlEvent_Type = 9  // Server heartbeat, answered with PONG
+	ControlEvent_PONG                 ControlEvent_Type = 10 // Client answer to PING
 )
 
 // Enum value maps for ControlEvent_Type.
 var (
 	ControlEvent_Type_name = map[int32]string{
-		0: "SWITCH_TO_LOCAL",
-		1: "SWITCH_TO_CLIENT",
-		2: "REQUEST_CONTROL",
-		3: "RELEASE_CONTROL",
-		4: "CLIENT_LIST_REQUEST",
-		5: "CLIENT_LIST_RESPONSE",
-		6: "CLIENT_CONFIG",
-		7: "SERVER_SHUTDOWN",
+		0:  "SWITCH_TO_LOCAL",
+		1:  "SWITCH_TO_CLIENT",
+		2:  "REQUEST_CONTROL",
+		3:  "RELEASE_CONTROL",
+		4:  "CLIENT_LIST_REQUEST",
+		5:  "CLIENT_LIST_RESPONSE",
+		6:  "CLIENT_CONFIG",
+		7:  "SERVER_SHUTDOWN",
+		8:  "KEYMAP",
+		9:  "PING",
+		10: "PONG",
 	}
 	ControlEvent_Type_value = map[string]int32{
 		"SWITCH_TO_LOCAL":      0,
@@ -153,6 +300,9 @@ var (
 		"CLIENT_LIST_RESPONSE": 5,
 		"CLIENT_CONFIG":        6,
 		"SERVER_SHUTDOWN":      7,
+		"KEYMAP":               8,
+		"PING":                 9,
+		"PONG":                 10,
 	}
 )
 
@@ -167,11 +317,11 @@ func (x ControlEvent_Type) String() string {
 }
 
 func (ControlEvent_Type) Descriptor() protoreflect.EnumDescriptor {
-	return file_internal_protocol_events_proto_enumTypes[2].Descriptor()
+	return file_internal_protocol_events_proto_enumTypes[5].Descriptor()
 }
 
 func (ControlEvent_Type) Type() protoreflect.EnumType {
-	return &file_internal_protocol_events_proto_enumTypes[2]
+	return &file_internal_protocol_events_proto_enumTypes[5]
 }
 
 func (x ControlEvent_Type) Number() protoreflect.EnumNumber {
@@ -180,7 +330,7 @@ func (x ControlEvent_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ControlEvent_Type.Descriptor instead.
 func (ControlEvent_Type) EnumDescriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{6, 0}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{10, 0}
 }
 
 // InputEvent is the main event message sent between server and clients
@@ -194,6 +344,14 @@ type InputEvent struct {
 	//	*InputEvent_Keyboard
 	//	*InputEvent_Control
 	//	*InputEvent_MousePosition
+	//	*InputEvent_ClipboardOffer
+	//	*InputEvent_ClipboardRequest
+	//	*InputEvent_ClipboardData
+	//	*InputEvent_LatencySample
+	//	*InputEvent_Hello
+	//	*InputEvent_Frame
+	//	*InputEvent_Gesture
+	//	*InputEvent_TabletTool
 	Event         isInputEvent_Event `protobuf_oneof:"event"`
 	Timestamp     int64              `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	SourceId      string             `protobuf:"bytes,8,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"` // Which server sent this
@@ -292,6 +450,78 @@ func (x *InputEvent) GetMousePosition() *MousePositionEvent {
 	return nil
 }
 
+func (x *InputEvent) GetClipboardOffer() *ClipboardOffer {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_ClipboardOffer); ok {
+			return x.ClipboardOffer
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetClipboardRequest() *ClipboardRequest {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_ClipboardRequest); ok {
+			return x.ClipboardRequest
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetClipboardData() *ClipboardData {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_ClipboardData); ok {
+			return x.ClipboardData
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetLatencySample() *LatencySample {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_LatencySample); ok {
+			return x.LatencySample
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetFrame() *InputFrame {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_Frame); ok {
+			return x.Frame
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetGesture() *GestureEvent {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_Gesture); ok {
+			return x.Gesture
+		}
+	}
+	return nil
+}
+
+func (x *InputEvent) GetTabletTool() *TabletToolEvent {
+	if x != nil {
+		if x, ok := x.Event.(*InputEvent_TabletTool); ok {
+			return x.TabletTool
+		}
+	}
+	return nil
+}
+
 func (x *InputEvent) GetTimestamp() int64 {
 	if x != nil {
 		return x.Timestamp
@@ -334,42 +564,740 @@ type InputEvent_MousePosition struct {
 	MousePosition *MousePositionEvent `protobuf:"bytes,6,opt,name=mouse_position,json=mousePosition,proto3,oneof"`
 }
 
-func (*InputEvent_MouseMove) isInputEvent_Event() {}
-
-func (*InputEvent_MouseButton) isInputEvent_Event() {}
-
-func (*InputEvent_MouseScroll) isInputEvent_Event() {}
+type InputEvent_ClipboardOffer struct {
+	ClipboardOffer *ClipboardOffer `protobuf:"bytes,9,opt,name=clipboard_offer,json=clipboardOffer,proto3,oneof"`
+}
+
+type InputEvent_ClipboardRequest struct {
+	ClipboardRequest *ClipboardRequest `protobuf:"bytes,10,opt,name=clipboard_request,json=clipboardRequest,proto3,oneof"`
+}
+
+type InputEvent_ClipboardData struct {
+	ClipboardData *ClipboardData `protobuf:"bytes,11,opt,name=clipboard_data,json=clipboardData,proto3,oneof"`
+}
+
+type InputEvent_LatencySample struct {
+	LatencySample *LatencySample `protobuf:"bytes,12,opt,name=latency_sample,json=latencySample,proto3,oneof"`
+}
+
+type InputEvent_Hello struct {
+	Hello *Hello `protobuf:"bytes,13,opt,name=hello,proto3,oneof"`
+}
+
+type InputEvent_Frame struct {
+	Frame *InputFrame `protobuf:"bytes,14,opt,name=frame,proto3,oneof"`
+}
+
+type InputEvent_Gesture struct {
+	Gesture *GestureEvent `protobuf:"bytes,15,opt,name=gesture,proto3,oneof"`
+}
+
+type InputEvent_TabletTool struct {
+	TabletTool *TabletToolEvent `protobuf:"bytes,16,opt,name=tablet_tool,json=tabletTool,proto3,oneof"`
+}
+
+func (*InputEvent_MouseMove) isInputEvent_Event() {}
+
+func (*InputEvent_MouseButton) isInputEvent_Event() {}
+
+func (*InputEvent_MouseScroll) isInputEvent_Event() {}
+
+func (*InputEvent_Keyboard) isInputEvent_Event() {}
+
+func (*InputEvent_Control) isInputEvent_Event() {}
+
+func (*InputEvent_MousePosition) isInputEvent_Event() {}
+
+func (*InputEvent_ClipboardOffer) isInputEvent_Event() {}
+
+func (*InputEvent_ClipboardRequest) isInputEvent_Event() {}
+
+func (*InputEvent_ClipboardData) isInputEvent_Event() {}
+
+func (*InputEvent_LatencySample) isInputEvent_Event() {}
+
+func (*InputEvent_Hello) isInputEvent_Event() {}
+
+func (*InputEvent_Frame) isInputEvent_Event() {}
+
+func (*InputEvent_Gesture) isInputEvent_Event() {}
+
+func (*InputEvent_TabletTool) isInputEvent_Event() {}
+
+// Hello opens a session. The client sends its own before anything else and
+// the server answers with the version and features used for the session.
+type Hello struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ProtocolVersion    uint32                 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`            // Newest version spoken, the agreed one in the server answer
+	MinProtocolVersion uint32                 `protobuf:"varint,2,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"` // Oldest version still spoken
+	Features           []string               `protobuf:"bytes,3,rep,name=features,proto3" json:"features,omitempty"`                                                  // Supported features, the agreed ones in the server answer
+	SoftwareVersion    string                 `protobuf:"bytes,4,opt,name=software_version,json=softwareVersion,proto3" json:"software_version,omitempty"`             // Waymon release, for logs
+	Error              string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`                                                        // Set by the server when it refuses the client
+	ClientId           string                 `protobuf:"bytes,6,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                                  // Sent by the client, with its key it identifies the client across connections
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_internal_protocol_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Hello) GetMinProtocolVersion() uint32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *Hello) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *Hello) GetSoftwareVersion() string {
+	if x != nil {
+		return x.SoftwareVersion
+	}
+	return ""
+}
+
+func (x *Hello) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Hello) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+// Events of one hardware frame, everything an input device reported between
+// two EV_SYN/SYN_REPORT. The client injects them together and ends the frame
+// once, so motion and the buttons pressed with it arrive as the device sent
+// them.
+type InputFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*InputEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InputFrame) Reset() {
+	*x = InputFrame{}
+	mi := &file_internal_protocol_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputFrame) ProtoMessage() {}
+
+func (x *InputFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputFrame.ProtoReflect.Descriptor instead.
+func (*InputFrame) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InputFrame) GetEvents() []*InputEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// Mouse movement with relative coordinates
+type MouseMoveEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dx            float64                `protobuf:"fixed64,1,opt,name=dx,proto3" json:"dx,omitempty"`
+	Dy            float64                `protobuf:"fixed64,2,opt,name=dy,proto3" json:"dy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MouseMoveEvent) Reset() {
+	*x = MouseMoveEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MouseMoveEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MouseMoveEvent) ProtoMessage() {}
+
+func (x *MouseMoveEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MouseMoveEvent.ProtoReflect.Descriptor instead.
+func (*MouseMoveEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MouseMoveEvent) GetDx() float64 {
+	if x != nil {
+		return x.Dx
+	}
+	return 0
+}
+
+func (x *MouseMoveEvent) GetDy() float64 {
+	if x != nil {
+		return x.Dy
+	}
+	return 0
+}
+
+// Absolute mouse positioning
+type MousePositionEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"` // Absolute X coordinate
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"` // Absolute Y coordinate
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MousePositionEvent) Reset() {
+	*x = MousePositionEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MousePositionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MousePositionEvent) ProtoMessage() {}
+
+func (x *MousePositionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MousePositionEvent.ProtoReflect.Descriptor instead.
+func (*MousePositionEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MousePositionEvent) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MousePositionEvent) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// Mouse button press/release
+type MouseButtonEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Button        uint32                 `protobuf:"varint,1,opt,name=button,proto3" json:"button,omitempty"`   // Button number (1=left, 2=middle, 3=right, etc.)
+	Pressed       bool                   `protobuf:"varint,2,opt,name=pressed,proto3" json:"pressed,omitempty"` // true=press, false=release
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MouseButtonEvent) Reset() {
+	*x = MouseButtonEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MouseButtonEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MouseButtonEvent) ProtoMessage() {}
+
+func (x *MouseButtonEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MouseButtonEvent.ProtoReflect.Descriptor instead.
+func (*MouseButtonEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MouseButtonEvent) GetButton() uint32 {
+	if x != nil {
+		return x.Button
+	}
+	return 0
+}
+
+func (x *MouseButtonEvent) GetPressed() bool {
+	if x != nil {
+		return x.Pressed
+	}
+	return false
+}
+
+// Mouse scroll/wheel events. Positive values scroll up and right, as evdev
+// reports them.
+type MouseScrollEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dx            float64                `protobuf:"fixed64,1,opt,name=dx,proto3" json:"dx,omitempty"` // Horizontal scroll, in wheel notches for SCROLL_WHEEL
+	Dy            float64                `protobuf:"fixed64,2,opt,name=dy,proto3" json:"dy,omitempty"` // Vertical scroll, in wheel notches for SCROLL_WHEEL
+	Type          ScrollType             `protobuf:"varint,3,opt,name=type,proto3,enum=waymon.protocol.ScrollType" json:"type,omitempty"`
+	Value120X     int32                  `protobuf:"varint,4,opt,name=value120_x,json=value120X,proto3" json:"value120_x,omitempty"` // Horizontal wheel movement in 120ths of a notch, for high-resolution wheels
+	Value120Y     int32                  `protobuf:"varint,5,opt,name=value120_y,json=value120Y,proto3" json:"value120_y,omitempty"` // Vertical wheel movement in 120ths of a notch
+	Stop          bool                   `protobuf:"varint,6,opt,name=stop,proto3" json:"stop,omitempty"`                            // Scrolling ended, for SCROLL_FINGER and SCROLL_CONTINUOUS
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MouseScrollEvent) Reset() {
+	*x = MouseScrollEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MouseScrollEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MouseScrollEvent) ProtoMessage() {}
+
+func (x *MouseScrollEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MouseScrollEvent.ProtoReflect.Descriptor instead.
+func (*MouseScrollEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MouseScrollEvent) GetDx() float64 {
+	if x != nil {
+		return x.Dx
+	}
+	return 0
+}
+
+func (x *MouseScrollEvent) GetDy() float64 {
+	if x != nil {
+		return x.Dy
+	}
+	return 0
+}
+
+func (x *MouseScrollEvent) GetType() ScrollType {
+	if x != nil {
+		return x.Type
+	}
+	return ScrollType_SCROLL_WHEEL
+}
+
+func (x *MouseScrollEvent) GetValue120X() int32 {
+	if x != nil {
+		return x.Value120X
+	}
+	return 0
+}
+
+func (x *MouseScrollEvent) GetValue120Y() int32 {
+	if x != nil {
+		return x.Value120Y
+	}
+	return 0
+}
+
+func (x *MouseScrollEvent) GetStop() bool {
+	if x != nil {
+		return x.Stop
+	}
+	return false
+}
+
+// Multi-finger touchpad gesture, sent as it progresses: a BEGIN once the
+// fingers moved enough to tell a swipe from a pinch, UPDATEs, then an END
+type GestureEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          GestureType            `protobuf:"varint,1,opt,name=type,proto3,enum=waymon.protocol.GestureType" json:"type,omitempty"`
+	Phase         GesturePhase           `protobuf:"varint,2,opt,name=phase,proto3,enum=waymon.protocol.GesturePhase" json:"phase,omitempty"`
+	Fingers       uint32                 `protobuf:"varint,3,opt,name=fingers,proto3" json:"fingers,omitempty"`
+	Dx            float64                `protobuf:"fixed64,4,opt,name=dx,proto3" json:"dx,omitempty"` // Movement of the fingers' center since the last update, in pointer units
+	Dy            float64                `protobuf:"fixed64,5,opt,name=dy,proto3" json:"dy,omitempty"`
+	Scale         float64                `protobuf:"fixed64,6,opt,name=scale,proto3" json:"scale,omitempty"`        // For PINCH: finger spread relative to when the fingers landed, below 1 when pinching in
+	Cancelled     bool                   `protobuf:"varint,7,opt,name=cancelled,proto3" json:"cancelled,omitempty"` // For END: another finger landed, the gesture should have no effect
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GestureEvent) Reset() {
+	*x = GestureEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GestureEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GestureEvent) ProtoMessage() {}
+
+func (x *GestureEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GestureEvent.ProtoReflect.Descriptor instead.
+func (*GestureEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GestureEvent) GetType() GestureType {
+	if x != nil {
+		return x.Type
+	}
+	return GestureType_GESTURE_SWIPE
+}
+
+func (x *GestureEvent) GetPhase() GesturePhase {
+	if x != nil {
+		return x.Phase
+	}
+	return GesturePhase_GESTURE_BEGIN
+}
+
+func (x *GestureEvent) GetFingers() uint32 {
+	if x != nil {
+		return x.Fingers
+	}
+	return 0
+}
+
+func (x *GestureEvent) GetDx() float64 {
+	if x != nil {
+		return x.Dx
+	}
+	return 0
+}
+
+func (x *GestureEvent) GetDy() float64 {
+	if x != nil {
+		return x.Dy
+	}
+	return 0
+}
+
+func (x *GestureEvent) GetScale() float64 {
+	if x != nil {
+		return x.Scale
+	}
+	return 0
+}
+
+func (x *GestureEvent) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+// State of a graphics tablet tool, sent with every hardware frame that changed
+// it. Positions cover the active area of the tablet, so the client maps them
+// onto the monitor of its choice.
+type TabletToolEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tool          TabletTool             `protobuf:"varint,1,opt,name=tool,proto3,enum=waymon.protocol.TabletTool" json:"tool,omitempty"`
+	Proximity     bool                   `protobuf:"varint,2,opt,name=proximity,proto3" json:"proximity,omitempty"`       // Within range of the tablet, false once when it leaves
+	Tip           bool                   `protobuf:"varint,3,opt,name=tip,proto3" json:"tip,omitempty"`                   // Touching the surface
+	X             float64                `protobuf:"fixed64,4,opt,name=x,proto3" json:"x,omitempty"`                      // 0 at the left edge of the area, 1 at the right one
+	Y             float64                `protobuf:"fixed64,5,opt,name=y,proto3" json:"y,omitempty"`                      // 0 at the top edge, 1 at the bottom one
+	Pressure      float64                `protobuf:"fixed64,6,opt,name=pressure,proto3" json:"pressure,omitempty"`        // 0 to 1
+	TiltX         float64                `protobuf:"fixed64,7,opt,name=tilt_x,json=tiltX,proto3" json:"tilt_x,omitempty"` // Degrees from vertical, positive to the right
+	TiltY         float64                `protobuf:"fixed64,8,opt,name=tilt_y,json=tiltY,proto3" json:"tilt_y,omitempty"` // Degrees from vertical, positive towards the user
+	Buttons       uint32                 `protobuf:"varint,9,opt,name=buttons,proto3" json:"buttons,omitempty"`           // Side buttons held, bit 0 for BTN_STYLUS, 1 for BTN_STYLUS2, 2 for BTN_STYLUS3
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TabletToolEvent) Reset() {
+	*x = TabletToolEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TabletToolEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TabletToolEvent) ProtoMessage() {}
+
+func (x *TabletToolEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TabletToolEvent.ProtoReflect.Descriptor instead.
+func (*TabletToolEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TabletToolEvent) GetTool() TabletTool {
+	if x != nil {
+		return x.Tool
+	}
+	return TabletTool_TABLET_TOOL_PEN
+}
+
+func (x *TabletToolEvent) GetProximity() bool {
+	if x != nil {
+		return x.Proximity
+	}
+	return false
+}
+
+func (x *TabletToolEvent) GetTip() bool {
+	if x != nil {
+		return x.Tip
+	}
+	return false
+}
+
+func (x *TabletToolEvent) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *TabletToolEvent) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *TabletToolEvent) GetPressure() float64 {
+	if x != nil {
+		return x.Pressure
+	}
+	return 0
+}
+
+func (x *TabletToolEvent) GetTiltX() float64 {
+	if x != nil {
+		return x.TiltX
+	}
+	return 0
+}
+
+func (x *TabletToolEvent) GetTiltY() float64 {
+	if x != nil {
+		return x.TiltY
+	}
+	return 0
+}
+
+func (x *TabletToolEvent) GetButtons() uint32 {
+	if x != nil {
+		return x.Buttons
+	}
+	return 0
+}
+
+// Keyboard key press/release
+type KeyboardEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           uint32                 `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`             // Key code
+	Pressed       bool                   `protobuf:"varint,2,opt,name=pressed,proto3" json:"pressed,omitempty"`     // true=press, false=release
+	Modifiers     uint32                 `protobuf:"varint,3,opt,name=modifiers,proto3" json:"modifiers,omitempty"` // Modifier mask (Ctrl, Alt, Shift, etc.)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyboardEvent) Reset() {
+	*x = KeyboardEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyboardEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyboardEvent) ProtoMessage() {}
+
+func (x *KeyboardEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyboardEvent.ProtoReflect.Descriptor instead.
+func (*KeyboardEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{9}
+}
 
-func (*InputEvent_Keyboard) isInputEvent_Event() {}
+func (x *KeyboardEvent) GetKey() uint32 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
 
-func (*InputEvent_Control) isInputEvent_Event() {}
+func (x *KeyboardEvent) GetPressed() bool {
+	if x != nil {
+		return x.Pressed
+	}
+	return false
+}
 
-func (*InputEvent_MousePosition) isInputEvent_Event() {}
+func (x *KeyboardEvent) GetModifiers() uint32 {
+	if x != nil {
+		return x.Modifiers
+	}
+	return 0
+}
 
-// Mouse movement with relative coordinates
-type MouseMoveEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Dx            float64                `protobuf:"fixed64,1,opt,name=dx,proto3" json:"dx,omitempty"`
-	Dy            float64                `protobuf:"fixed64,2,opt,name=dy,proto3" json:"dy,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Control messages for switching targets, etc.
+type ControlEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Type            ControlEvent_Type      `protobuf:"varint,1,opt,name=type,proto3,enum=waymon.protocol.ControlEvent_Type" json:"type,omitempty"`
+	TargetId        string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`                       // For SWITCH_TO_CLIENT
+	ClientConfig    *ClientConfig          `protobuf:"bytes,3,opt,name=client_config,json=clientConfig,proto3" json:"client_config,omitempty"`           // For CLIENT_CONFIG
+	LockedModifiers uint32                 `protobuf:"varint,4,opt,name=locked_modifiers,json=lockedModifiers,proto3" json:"locked_modifiers,omitempty"` // For REQUEST_CONTROL: server's Caps/Num Lock as XKB modifier mask
+	Keymap          *Keymap                `protobuf:"bytes,5,opt,name=keymap,proto3" json:"keymap,omitempty"`                                           // For KEYMAP
+	Sequence        uint64                 `protobuf:"varint,6,opt,name=sequence,proto3" json:"sequence,omitempty"`                                      // For PING, echoed by PONG
+	IntervalMs      uint32                 `protobuf:"varint,7,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`                // For PING: time until the next ping
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *MouseMoveEvent) Reset() {
-	*x = MouseMoveEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[1]
+func (x *ControlEvent) Reset() {
+	*x = ControlEvent{}
+	mi := &file_internal_protocol_events_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MouseMoveEvent) String() string {
+func (x *ControlEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MouseMoveEvent) ProtoMessage() {}
+func (*ControlEvent) ProtoMessage() {}
 
-func (x *MouseMoveEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[1]
+func (x *ControlEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -380,49 +1308,86 @@ func (x *MouseMoveEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MouseMoveEvent.ProtoReflect.Descriptor instead.
-func (*MouseMoveEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use ControlEvent.ProtoReflect.Descriptor instead.
+func (*ControlEvent) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *MouseMoveEvent) GetDx() float64 {
+func (x *ControlEvent) GetType() ControlEvent_Type {
 	if x != nil {
-		return x.Dx
+		return x.Type
+	}
+	return ControlEvent_SWITCH_TO_LOCAL
+}
+
+func (x *ControlEvent) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *ControlEvent) GetClientConfig() *ClientConfig {
+	if x != nil {
+		return x.ClientConfig
+	}
+	return nil
+}
+
+func (x *ControlEvent) GetLockedModifiers() uint32 {
+	if x != nil {
+		return x.LockedModifiers
 	}
 	return 0
 }
 
-func (x *MouseMoveEvent) GetDy() float64 {
+func (x *ControlEvent) GetKeymap() *Keymap {
 	if x != nil {
-		return x.Dy
+		return x.Keymap
+	}
+	return nil
+}
+
+func (x *ControlEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
 	}
 	return 0
 }
 
-// Absolute mouse positioning
-type MousePositionEvent struct {
+func (x *ControlEvent) GetIntervalMs() uint32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+// Clipboard announced by the machine that owns it. The contents are
+// only transferred when a ClipboardRequest asks for them.
+type ClipboardOffer struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"` // Absolute X coordinate
-	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"` // Absolute Y coordinate
+	Serial        uint64                 `protobuf:"varint,1,opt,name=serial,proto3" json:"serial,omitempty"` // Changes with every new selection
+	MimeTypes     []string               `protobuf:"bytes,2,rep,name=mime_types,json=mimeTypes,proto3" json:"mime_types,omitempty"`
+	Primary       bool                   `protobuf:"varint,3,opt,name=primary,proto3" json:"primary,omitempty"` // Primary selection instead of the clipboard
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MousePositionEvent) Reset() {
-	*x = MousePositionEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[2]
+func (x *ClipboardOffer) Reset() {
+	*x = ClipboardOffer{}
+	mi := &file_internal_protocol_events_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MousePositionEvent) String() string {
+func (x *ClipboardOffer) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MousePositionEvent) ProtoMessage() {}
+func (*ClipboardOffer) ProtoMessage() {}
 
-func (x *MousePositionEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[2]
+func (x *ClipboardOffer) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -433,49 +1398,58 @@ func (x *MousePositionEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MousePositionEvent.ProtoReflect.Descriptor instead.
-func (*MousePositionEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ClipboardOffer.ProtoReflect.Descriptor instead.
+func (*ClipboardOffer) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *MousePositionEvent) GetX() int32 {
+func (x *ClipboardOffer) GetSerial() uint64 {
 	if x != nil {
-		return x.X
+		return x.Serial
 	}
 	return 0
 }
 
-func (x *MousePositionEvent) GetY() int32 {
+func (x *ClipboardOffer) GetMimeTypes() []string {
 	if x != nil {
-		return x.Y
+		return x.MimeTypes
 	}
-	return 0
+	return nil
 }
 
-// Mouse button press/release
-type MouseButtonEvent struct {
+func (x *ClipboardOffer) GetPrimary() bool {
+	if x != nil {
+		return x.Primary
+	}
+	return false
+}
+
+// Request for offered clipboard contents in one MIME type
+type ClipboardRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Button        uint32                 `protobuf:"varint,1,opt,name=button,proto3" json:"button,omitempty"`   // Button number (1=left, 2=middle, 3=right, etc.)
-	Pressed       bool                   `protobuf:"varint,2,opt,name=pressed,proto3" json:"pressed,omitempty"` // true=press, false=release
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Serial        uint64                 `protobuf:"varint,2,opt,name=serial,proto3" json:"serial,omitempty"` // Serial of the offer being pasted
+	MimeType      string                 `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Primary       bool                   `protobuf:"varint,4,opt,name=primary,proto3" json:"primary,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MouseButtonEvent) Reset() {
-	*x = MouseButtonEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[3]
+func (x *ClipboardRequest) Reset() {
+	*x = ClipboardRequest{}
+	mi := &file_internal_protocol_events_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MouseButtonEvent) String() string {
+func (x *ClipboardRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MouseButtonEvent) ProtoMessage() {}
+func (*ClipboardRequest) ProtoMessage() {}
 
-func (x *MouseButtonEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[3]
+func (x *ClipboardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -486,50 +1460,64 @@ func (x *MouseButtonEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MouseButtonEvent.ProtoReflect.Descriptor instead.
-func (*MouseButtonEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ClipboardRequest.ProtoReflect.Descriptor instead.
+func (*ClipboardRequest) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *MouseButtonEvent) GetButton() uint32 {
+func (x *ClipboardRequest) GetRequestId() uint64 {
 	if x != nil {
-		return x.Button
+		return x.RequestId
 	}
 	return 0
 }
 
-func (x *MouseButtonEvent) GetPressed() bool {
+func (x *ClipboardRequest) GetSerial() uint64 {
 	if x != nil {
-		return x.Pressed
+		return x.Serial
+	}
+	return 0
+}
+
+func (x *ClipboardRequest) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ClipboardRequest) GetPrimary() bool {
+	if x != nil {
+		return x.Primary
 	}
 	return false
 }
 
-// Mouse scroll/wheel events
-type MouseScrollEvent struct {
+// Clipboard contents sent in reply to a ClipboardRequest
+type ClipboardData struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Dx            float64                `protobuf:"fixed64,1,opt,name=dx,proto3" json:"dx,omitempty"` // Horizontal scroll
-	Dy            float64                `protobuf:"fixed64,2,opt,name=dy,proto3" json:"dy,omitempty"` // Vertical scroll
-	Type          ScrollType             `protobuf:"varint,3,opt,name=type,proto3,enum=waymon.protocol.ScrollType" json:"type,omitempty"`
+	RequestId     uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // Set when the contents could not be provided
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MouseScrollEvent) Reset() {
-	*x = MouseScrollEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[4]
+func (x *ClipboardData) Reset() {
+	*x = ClipboardData{}
+	mi := &file_internal_protocol_events_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MouseScrollEvent) String() string {
+func (x *ClipboardData) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MouseScrollEvent) ProtoMessage() {}
+func (*ClipboardData) ProtoMessage() {}
 
-func (x *MouseScrollEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[4]
+func (x *ClipboardData) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,57 +1528,58 @@ func (x *MouseScrollEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MouseScrollEvent.ProtoReflect.Descriptor instead.
-func (*MouseScrollEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ClipboardData.ProtoReflect.Descriptor instead.
+func (*ClipboardData) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *MouseScrollEvent) GetDx() float64 {
+func (x *ClipboardData) GetRequestId() uint64 {
 	if x != nil {
-		return x.Dx
+		return x.RequestId
 	}
 	return 0
 }
 
-func (x *MouseScrollEvent) GetDy() float64 {
+func (x *ClipboardData) GetData() []byte {
 	if x != nil {
-		return x.Dy
+		return x.Data
 	}
-	return 0
+	return nil
 }
 
-func (x *MouseScrollEvent) GetType() ScrollType {
+func (x *ClipboardData) GetError() string {
 	if x != nil {
-		return x.Type
+		return x.Error
 	}
-	return ScrollType_SCROLL_WHEEL
+	return ""
 }
 
-// Keyboard key press/release
-type KeyboardEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           uint32                 `protobuf:"varint,1,opt,name=key,proto3" json:"key,omitempty"`             // Key code
-	Pressed       bool                   `protobuf:"varint,2,opt,name=pressed,proto3" json:"pressed,omitempty"`     // true=press, false=release
-	Modifiers     uint32                 `protobuf:"varint,3,opt,name=modifiers,proto3" json:"modifiers,omitempty"` // Modifier mask (Ctrl, Alt, Shift, etc.)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Input event echoed back by the client, about once a second while it is
+// controlled, so the server can measure latency through the whole pipeline
+type LatencySample struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	EventTimestamp int64                  `protobuf:"varint,1,opt,name=event_timestamp,json=eventTimestamp,proto3" json:"event_timestamp,omitempty"` // Timestamp of the sampled event, server clock
+	InjectUs       int64                  `protobuf:"varint,2,opt,name=inject_us,json=injectUs,proto3" json:"inject_us,omitempty"`                   // Time the client took to inject it
+	JitterUs       int64                  `protobuf:"varint,3,opt,name=jitter_us,json=jitterUs,proto3" json:"jitter_us,omitempty"`                   // Interarrival jitter seen by the client (RFC 3550)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
-func (x *KeyboardEvent) Reset() {
-	*x = KeyboardEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[5]
+func (x *LatencySample) Reset() {
+	*x = LatencySample{}
+	mi := &file_internal_protocol_events_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *KeyboardEvent) String() string {
+func (x *LatencySample) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*KeyboardEvent) ProtoMessage() {}
+func (*LatencySample) ProtoMessage() {}
 
-func (x *KeyboardEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[5]
+func (x *LatencySample) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -601,57 +1590,56 @@ func (x *KeyboardEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use KeyboardEvent.ProtoReflect.Descriptor instead.
-func (*KeyboardEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use LatencySample.ProtoReflect.Descriptor instead.
+func (*LatencySample) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *KeyboardEvent) GetKey() uint32 {
+func (x *LatencySample) GetEventTimestamp() int64 {
 	if x != nil {
-		return x.Key
+		return x.EventTimestamp
 	}
 	return 0
 }
 
-func (x *KeyboardEvent) GetPressed() bool {
+func (x *LatencySample) GetInjectUs() int64 {
 	if x != nil {
-		return x.Pressed
+		return x.InjectUs
 	}
-	return false
+	return 0
 }
 
-func (x *KeyboardEvent) GetModifiers() uint32 {
+func (x *LatencySample) GetJitterUs() int64 {
 	if x != nil {
-		return x.Modifiers
+		return x.JitterUs
 	}
 	return 0
 }
 
-// Control messages for switching targets, etc.
-type ControlEvent struct {
+// XKB keymap for the client's virtual keyboard
+type Keymap struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          ControlEvent_Type      `protobuf:"varint,1,opt,name=type,proto3,enum=waymon.protocol.ControlEvent_Type" json:"type,omitempty"`
-	TargetId      string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`             // For SWITCH_TO_CLIENT
-	ClientConfig  *ClientConfig          `protobuf:"bytes,3,opt,name=client_config,json=clientConfig,proto3" json:"client_config,omitempty"` // For CLIENT_CONFIG
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                            // Short description for logs, e.g. "de(nodeadkeys)"
+	XkbKeymap     string                 `protobuf:"bytes,2,opt,name=xkb_keymap,json=xkbKeymap,proto3" json:"xkb_keymap,omitempty"` // Full keymap in XKB v1 text format
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ControlEvent) Reset() {
-	*x = ControlEvent{}
-	mi := &file_internal_protocol_events_proto_msgTypes[6]
+func (x *Keymap) Reset() {
+	*x = Keymap{}
+	mi := &file_internal_protocol_events_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ControlEvent) String() string {
+func (x *Keymap) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ControlEvent) ProtoMessage() {}
+func (*Keymap) ProtoMessage() {}
 
-func (x *ControlEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[6]
+func (x *Keymap) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_events_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -662,30 +1650,23 @@ func (x *ControlEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ControlEvent.ProtoReflect.Descriptor instead.
-func (*ControlEvent) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{6}
-}
-
-func (x *ControlEvent) GetType() ControlEvent_Type {
-	if x != nil {
-		return x.Type
-	}
-	return ControlEvent_SWITCH_TO_LOCAL
+// Deprecated: Use Keymap.ProtoReflect.Descriptor instead.
+func (*Keymap) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ControlEvent) GetTargetId() string {
+func (x *Keymap) GetName() string {
 	if x != nil {
-		return x.TargetId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ControlEvent) GetClientConfig() *ClientConfig {
+func (x *Keymap) GetXkbKeymap() string {
 	if x != nil {
-		return x.ClientConfig
+		return x.XkbKeymap
 	}
-	return nil
+	return ""
 }
 
 // Client information for server management
@@ -702,7 +1683,7 @@ type ClientInfo struct {
 
 func (x *ClientInfo) Reset() {
 	*x = ClientInfo{}
-	mi := &file_internal_protocol_events_proto_msgTypes[7]
+	mi := &file_internal_protocol_events_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -714,7 +1695,7 @@ func (x *ClientInfo) String() string {
 func (*ClientInfo) ProtoMessage() {}
 
 func (x *ClientInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[7]
+	mi := &file_internal_protocol_events_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -727,7 +1708,7 @@ func (x *ClientInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientInfo.ProtoReflect.Descriptor instead.
 func (*ClientInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{7}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ClientInfo) GetId() string {
@@ -779,7 +1760,7 @@ type ServerInfo struct {
 
 func (x *ServerInfo) Reset() {
 	*x = ServerInfo{}
-	mi := &file_internal_protocol_events_proto_msgTypes[8]
+	mi := &file_internal_protocol_events_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -791,7 +1772,7 @@ func (x *ServerInfo) String() string {
 func (*ServerInfo) ProtoMessage() {}
 
 func (x *ServerInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[8]
+	mi := &file_internal_protocol_events_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -804,7 +1785,7 @@ func (x *ServerInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerInfo.ProtoReflect.Descriptor instead.
 func (*ServerInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{8}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ServerInfo) GetId() string {
@@ -854,7 +1835,7 @@ type ServerCapabilities struct {
 
 func (x *ServerCapabilities) Reset() {
 	*x = ServerCapabilities{}
-	mi := &file_internal_protocol_events_proto_msgTypes[9]
+	mi := &file_internal_protocol_events_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -866,7 +1847,7 @@ func (x *ServerCapabilities) String() string {
 func (*ServerCapabilities) ProtoMessage() {}
 
 func (x *ServerCapabilities) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[9]
+	mi := &file_internal_protocol_events_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -879,7 +1860,7 @@ func (x *ServerCapabilities) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerCapabilities.ProtoReflect.Descriptor instead.
 func (*ServerCapabilities) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{9}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *ServerCapabilities) GetSupportsKeyboard() bool {
@@ -923,7 +1904,7 @@ type ClientConfig struct {
 
 func (x *ClientConfig) Reset() {
 	*x = ClientConfig{}
-	mi := &file_internal_protocol_events_proto_msgTypes[10]
+	mi := &file_internal_protocol_events_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -935,7 +1916,7 @@ func (x *ClientConfig) String() string {
 func (*ClientConfig) ProtoMessage() {}
 
 func (x *ClientConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[10]
+	mi := &file_internal_protocol_events_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -948,7 +1929,7 @@ func (x *ClientConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientConfig.ProtoReflect.Descriptor instead.
 func (*ClientConfig) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{10}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ClientConfig) GetClientId() string {
@@ -996,7 +1977,7 @@ type Monitor struct {
 
 func (x *Monitor) Reset() {
 	*x = Monitor{}
-	mi := &file_internal_protocol_events_proto_msgTypes[11]
+	mi := &file_internal_protocol_events_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1008,7 +1989,7 @@ func (x *Monitor) String() string {
 func (*Monitor) ProtoMessage() {}
 
 func (x *Monitor) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[11]
+	mi := &file_internal_protocol_events_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1021,7 +2002,7 @@ func (x *Monitor) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Monitor.ProtoReflect.Descriptor instead.
 func (*Monitor) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{11}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *Monitor) GetName() string {
@@ -1094,7 +2075,7 @@ type ClientCapabilities struct {
 
 func (x *ClientCapabilities) Reset() {
 	*x = ClientCapabilities{}
-	mi := &file_internal_protocol_events_proto_msgTypes[12]
+	mi := &file_internal_protocol_events_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1106,7 +2087,7 @@ func (x *ClientCapabilities) String() string {
 func (*ClientCapabilities) ProtoMessage() {}
 
 func (x *ClientCapabilities) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_events_proto_msgTypes[12]
+	mi := &file_internal_protocol_events_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1119,7 +2100,7 @@ func (x *ClientCapabilities) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClientCapabilities.ProtoReflect.Descriptor instead.
 func (*ClientCapabilities) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_events_proto_rawDescGZIP(), []int{12}
+	return file_internal_protocol_events_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *ClientCapabilities) GetCanReceiveKeyboard() bool {
@@ -1161,7 +2142,7 @@ var File_internal_protocol_events_proto protoreflect.FileDescriptor
 
 const file_internal_protocol_events_proto_rawDesc = "" +
 	"\n" +
-	"\x1einternal/protocol/events.proto\x12\x0fwaymon.protocol\"\xe9\x03\n" +
+	"\x1einternal/protocol/events.proto\x12\x0fwaymon.protocol\"\xfe\a\n" +
 	"\n" +
 	"InputEvent\x12@\n" +
 	"\n" +
@@ -1170,10 +2151,30 @@ const file_internal_protocol_events_proto_rawDesc = "" +
 	"\fmouse_scroll\x18\x03 \x01(\v2!.waymon.protocol.MouseScrollEventH\x00R\vmouseScroll\x12<\n" +
 	"\bkeyboard\x18\x04 \x01(\v2\x1e.waymon.protocol.KeyboardEventH\x00R\bkeyboard\x129\n" +
 	"\acontrol\x18\x05 \x01(\v2\x1d.waymon.protocol.ControlEventH\x00R\acontrol\x12L\n" +
-	"\x0emouse_position\x18\x06 \x01(\v2#.waymon.protocol.MousePositionEventH\x00R\rmousePosition\x12\x1c\n" +
+	"\x0emouse_position\x18\x06 \x01(\v2#.waymon.protocol.MousePositionEventH\x00R\rmousePosition\x12J\n" +
+	"\x0fclipboard_offer\x18\t \x01(\v2\x1f.waymon.protocol.ClipboardOfferH\x00R\x0eclipboardOffer\x12P\n" +
+	"\x11clipboard_request\x18\n" +
+	" \x01(\v2!.waymon.protocol.ClipboardRequestH\x00R\x10clipboardRequest\x12G\n" +
+	"\x0eclipboard_data\x18\v \x01(\v2\x1e.waymon.protocol.ClipboardDataH\x00R\rclipboardData\x12G\n" +
+	"\x0elatency_sample\x18\f \x01(\v2\x1e.waymon.protocol.LatencySampleH\x00R\rlatencySample\x12.\n" +
+	"\x05hello\x18\r \x01(\v2\x16.waymon.protocol.HelloH\x00R\x05hello\x123\n" +
+	"\x05frame\x18\x0e \x01(\v2\x1b.waymon.protocol.InputFrameH\x00R\x05frame\x129\n" +
+	"\agesture\x18\x0f \x01(\v2\x1d.waymon.protocol.GestureEventH\x00R\agesture\x12C\n" +
+	"\vtablet_tool\x18\x10 \x01(\v2 .waymon.protocol.TabletToolEventH\x00R\n" +
+	"tabletTool\x12\x1c\n" +
 	"\ttimestamp\x18\a \x01(\x03R\ttimestamp\x12\x1b\n" +
 	"\tsource_id\x18\b \x01(\tR\bsourceIdB\a\n" +
-	"\x05event\"0\n" +
+	"\x05event\"\xde\x01\n" +
+	"\x05Hello\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\rR\x0fprotocolVersion\x120\n" +
+	"\x14min_protocol_version\x18\x02 \x01(\rR\x12minProtocolVersion\x12\x1a\n" +
+	"\bfeatures\x18\x03 \x03(\tR\bfeatures\x12)\n" +
+	"\x10software_version\x18\x04 \x01(\tR\x0fsoftwareVersion\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1b\n" +
+	"\tclient_id\x18\x06 \x01(\tR\bclientId\"A\n" +
+	"\n" +
+	"InputFrame\x123\n" +
+	"\x06events\x18\x01 \x03(\v2\x1b.waymon.protocol.InputEventR\x06events\"0\n" +
 	"\x0eMouseMoveEvent\x12\x0e\n" +
 	"\x02dx\x18\x01 \x01(\x01R\x02dx\x12\x0e\n" +
 	"\x02dy\x18\x02 \x01(\x01R\x02dy\"0\n" +
@@ -1182,19 +2183,47 @@ const file_internal_protocol_events_proto_rawDesc = "" +
 	"\x01y\x18\x02 \x01(\x05R\x01y\"D\n" +
 	"\x10MouseButtonEvent\x12\x16\n" +
 	"\x06button\x18\x01 \x01(\rR\x06button\x12\x18\n" +
-	"\apressed\x18\x02 \x01(\bR\apressed\"c\n" +
+	"\apressed\x18\x02 \x01(\bR\apressed\"\xb5\x01\n" +
 	"\x10MouseScrollEvent\x12\x0e\n" +
 	"\x02dx\x18\x01 \x01(\x01R\x02dx\x12\x0e\n" +
 	"\x02dy\x18\x02 \x01(\x01R\x02dy\x12/\n" +
-	"\x04type\x18\x03 \x01(\x0e2\x1b.waymon.protocol.ScrollTypeR\x04type\"Y\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x1b.waymon.protocol.ScrollTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"value120_x\x18\x04 \x01(\x05R\tvalue120X\x12\x1d\n" +
+	"\n" +
+	"value120_y\x18\x05 \x01(\x05R\tvalue120Y\x12\x12\n" +
+	"\x04stop\x18\x06 \x01(\bR\x04stop\"\xe3\x01\n" +
+	"\fGestureEvent\x120\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1c.waymon.protocol.GestureTypeR\x04type\x123\n" +
+	"\x05phase\x18\x02 \x01(\x0e2\x1d.waymon.protocol.GesturePhaseR\x05phase\x12\x18\n" +
+	"\afingers\x18\x03 \x01(\rR\afingers\x12\x0e\n" +
+	"\x02dx\x18\x04 \x01(\x01R\x02dx\x12\x0e\n" +
+	"\x02dy\x18\x05 \x01(\x01R\x02dy\x12\x14\n" +
+	"\x05scale\x18\x06 \x01(\x01R\x05scale\x12\x1c\n" +
+	"\tcancelled\x18\a \x01(\bR\tcancelled\"\xf2\x01\n" +
+	"\x0fTabletToolEvent\x12/\n" +
+	"\x04tool\x18\x01 \x01(\x0e2\x1b.waymon.protocol.TabletToolR\x04tool\x12\x1c\n" +
+	"\tproximity\x18\x02 \x01(\bR\tproximity\x12\x10\n" +
+	"\x03tip\x18\x03 \x01(\bR\x03tip\x12\f\n" +
+	"\x01x\x18\x04 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x05 \x01(\x01R\x01y\x12\x1a\n" +
+	"\bpressure\x18\x06 \x01(\x01R\bpressure\x12\x15\n" +
+	"\x06tilt_x\x18\a \x01(\x01R\x05tiltX\x12\x15\n" +
+	"\x06tilt_y\x18\b \x01(\x01R\x05tiltY\x12\x18\n" +
+	"\abuttons\x18\t \x01(\rR\abuttons\"Y\n" +
 	"\rKeyboardEvent\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\rR\x03key\x12\x18\n" +
 	"\apressed\x18\x02 \x01(\bR\apressed\x12\x1c\n" +
-	"\tmodifiers\x18\x03 \x01(\rR\tmodifiers\"\xe0\x02\n" +
+	"\tmodifiers\x18\x03 \x01(\rR\tmodifiers\"\x99\x04\n" +
 	"\fControlEvent\x126\n" +
 	"\x04type\x18\x01 \x01(\x0e2\".waymon.protocol.ControlEvent.TypeR\x04type\x12\x1b\n" +
 	"\ttarget_id\x18\x02 \x01(\tR\btargetId\x12B\n" +
-	"\rclient_config\x18\x03 \x01(\v2\x1d.waymon.protocol.ClientConfigR\fclientConfig\"\xb6\x01\n" +
+	"\rclient_config\x18\x03 \x01(\v2\x1d.waymon.protocol.ClientConfigR\fclientConfig\x12)\n" +
+	"\x10locked_modifiers\x18\x04 \x01(\rR\x0flockedModifiers\x12/\n" +
+	"\x06keymap\x18\x05 \x01(\v2\x17.waymon.protocol.KeymapR\x06keymap\x12\x1a\n" +
+	"\bsequence\x18\x06 \x01(\x04R\bsequence\x12\x1f\n" +
+	"\vinterval_ms\x18\a \x01(\rR\n" +
+	"intervalMs\"\xd6\x01\n" +
 	"\x04Type\x12\x13\n" +
 	"\x0fSWITCH_TO_LOCAL\x10\x00\x12\x14\n" +
 	"\x10SWITCH_TO_CLIENT\x10\x01\x12\x13\n" +
@@ -1203,7 +2232,36 @@ const file_internal_protocol_events_proto_rawDesc = "" +
 	"\x13CLIENT_LIST_REQUEST\x10\x04\x12\x18\n" +
 	"\x14CLIENT_LIST_RESPONSE\x10\x05\x12\x11\n" +
 	"\rCLIENT_CONFIG\x10\x06\x12\x13\n" +
-	"\x0fSERVER_SHUTDOWN\x10\a\"\xa4\x01\n" +
+	"\x0fSERVER_SHUTDOWN\x10\a\x12\n" +
+	"\n" +
+	"\x06KEYMAP\x10\b\x12\b\n" +
+	"\x04PING\x10\t\x12\b\n" +
+	"\x04PONG\x10\n" +
+	"\"a\n" +
+	"\x0eClipboardOffer\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\x04R\x06serial\x12\x1d\n" +
+	"\n" +
+	"mime_types\x18\x02 \x03(\tR\tmimeTypes\x12\x18\n" +
+	"\aprimary\x18\x03 \x01(\bR\aprimary\"\x80\x01\n" +
+	"\x10ClipboardRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x16\n" +
+	"\x06serial\x18\x02 \x01(\x04R\x06serial\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x18\n" +
+	"\aprimary\x18\x04 \x01(\bR\aprimary\"X\n" +
+	"\rClipboardData\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"r\n" +
+	"\rLatencySample\x12'\n" +
+	"\x0fevent_timestamp\x18\x01 \x01(\x03R\x0eeventTimestamp\x12\x1b\n" +
+	"\tinject_us\x18\x02 \x01(\x03R\binjectUs\x12\x1b\n" +
+	"\tjitter_us\x18\x03 \x01(\x03R\bjitterUs\";\n" +
+	"\x06Keymap\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"xkb_keymap\x18\x02 \x01(\tR\txkbKeymap\"\xa4\x01\n" +
 	"\n" +
 	"ClientInfo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
@@ -1248,7 +2306,18 @@ const file_internal_protocol_events_proto_rawDesc = "" +
 	"ScrollType\x12\x10\n" +
 	"\fSCROLL_WHEEL\x10\x00\x12\x11\n" +
 	"\rSCROLL_FINGER\x10\x01\x12\x15\n" +
-	"\x11SCROLL_CONTINUOUS\x10\x02*U\n" +
+	"\x11SCROLL_CONTINUOUS\x10\x02*3\n" +
+	"\vGestureType\x12\x11\n" +
+	"\rGESTURE_SWIPE\x10\x00\x12\x11\n" +
+	"\rGESTURE_PINCH\x10\x01*F\n" +
+	"\fGesturePhase\x12\x11\n" +
+	"\rGESTURE_BEGIN\x10\x00\x12\x12\n" +
+	"\x0eGESTURE_UPDATE\x10\x01\x12\x0f\n" +
+	"\vGESTURE_END\x10\x02*9\n" +
+	"\n" +
+	"TabletTool\x12\x13\n" +
+	"\x0fTABLET_TOOL_PEN\x10\x00\x12\x16\n" +
+	"\x12TABLET_TOOL_ERASER\x10\x01*U\n" +
 	"\fClientStatus\x12\x0f\n" +
 	"\vCLIENT_IDLE\x10\x00\x12\x1b\n" +
 	"\x17CLIENT_BEING_CONTROLLED\x10\x01\x12\x17\n" +
@@ -1266,46 +2335,71 @@ func file_internal_protocol_events_proto_rawDescGZIP() []byte {
 	return file_internal_protocol_events_proto_rawDescData
 }
 
-var file_internal_protocol_events_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_internal_protocol_events_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_internal_protocol_events_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_internal_protocol_events_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_internal_protocol_events_proto_goTypes = []any{
 	(ScrollType)(0),            // 0: waymon.protocol.ScrollType
-	(ClientStatus)(0),          // 1: waymon.protocol.ClientStatus
-	(ControlEvent_Type)(0),     // 2: waymon.protocol.ControlEvent.Type
-	(*InputEvent)(nil),         // 3: waymon.protocol.InputEvent
-	(*MouseMoveEvent)(nil),     // 4: waymon.protocol.MouseMoveEvent
-	(*MousePositionEvent)(nil), // 5: waymon.protocol.MousePositionEvent
-	(*MouseButtonEvent)(nil),   // 6: waymon.protocol.MouseButtonEvent
-	(*MouseScrollEvent)(nil),   // 7: waymon.protocol.MouseScrollEvent
-	(*KeyboardEvent)(nil),      // 8: waymon.protocol.KeyboardEvent
-	(*ControlEvent)(nil),       // 9: waymon.protocol.ControlEvent
-	(*ClientInfo)(nil),         // 10: waymon.protocol.ClientInfo
-	(*ServerInfo)(nil),         // 11: waymon.protocol.ServerInfo
-	(*ServerCapabilities)(nil), // 12: waymon.protocol.ServerCapabilities
-	(*ClientConfig)(nil),       // 13: waymon.protocol.ClientConfig
-	(*Monitor)(nil),            // 14: waymon.protocol.Monitor
-	(*ClientCapabilities)(nil), // 15: waymon.protocol.ClientCapabilities
+	(GestureType)(0),           // 1: waymon.protocol.GestureType
+	(GesturePhase)(0),          // 2: waymon.protocol.GesturePhase
+	(TabletTool)(0),            // 3: waymon.protocol.TabletTool
+	(ClientStatus)(0),          // 4: waymon.protocol.ClientStatus
+	(ControlEvent_Type)(0),     // 5: waymon.protocol.ControlEvent.Type
+	(*InputEvent)(nil),         // 6: waymon.protocol.InputEvent
+	(*Hello)(nil),              // 7: waymon.protocol.Hello
+	(*InputFrame)(nil),         // 8: waymon.protocol.InputFrame
+	(*MouseMoveEvent)(nil),     // 9: waymon.protocol.MouseMoveEvent
+	(*MousePositionEvent)(nil), // 10: waymon.protocol.MousePositionEvent
+	(*MouseButtonEvent)(nil),   // 11: waymon.protocol.MouseButtonEvent
+	(*MouseScrollEvent)(nil),   // 12: waymon.protocol.MouseScrollEvent
+	(*GestureEvent)(nil),       // 13: waymon.protocol.GestureEvent
+	(*TabletToolEvent)(nil),    // 14: waymon.protocol.TabletToolEvent
+	(*KeyboardEvent)(nil),      // 15: waymon.protocol.KeyboardEvent
+	(*ControlEvent)(nil),       // 16: waymon.protocol.ControlEvent
+	(*ClipboardOffer)(nil),     // 17: waymon.protocol.ClipboardOffer
+	(*ClipboardRequest)(nil),   // 18: waymon.protocol.ClipboardRequest
+	(*ClipboardData)(nil),      // 19: waymon.protocol.ClipboardData
+	(*LatencySample)(nil),      // 20: waymon.protocol.LatencySample
+	(*Keymap)(nil),             // 21: waymon.protocol.Keymap
+	(*ClientInfo)(nil),         // 22: waymon.protocol.ClientInfo
+	(*ServerInfo)(nil),         // 23: waymon.protocol.ServerInfo
+	(*ServerCapabilities)(nil), // 24: waymon.protocol.ServerCapabilities
+	(*ClientConfig)(nil),       // 25: waymon.protocol.ClientConfig
+	(*Monitor)(nil),            // 26: waymon.protocol.Monitor
+	(*ClientCapabilities)(nil), // 27: waymon.protocol.ClientCapabilities
 }
 var file_internal_protocol_events_proto_depIdxs = []int32{
-	4,  // 0: waymon.protocol.InputEvent.mouse_move:type_name -> waymon.protocol.MouseMoveEvent
-	6,  // 1: waymon.protocol.InputEvent.mouse_button:type_name -> waymon.protocol.MouseButtonEvent
-	7,  // 2: waymon.protocol.InputEvent.mouse_scroll:type_name -> waymon.protocol.MouseScrollEvent
-	8,  // 3: waymon.protocol.InputEvent.keyboard:type_name -> waymon.protocol.KeyboardEvent
-	9,  // 4: waymon.protocol.InputEvent.control:type_name -> waymon.protocol.ControlEvent
-	5,  // 5: waymon.protocol.InputEvent.mouse_position:type_name -> waymon.protocol.MousePositionEvent
-	0,  // 6: waymon.protocol.MouseScrollEvent.type:type_name -> waymon.protocol.ScrollType
-	2,  // 7: waymon.protocol.ControlEvent.type:type_name -> waymon.protocol.ControlEvent.Type
-	13, // 8: waymon.protocol.ControlEvent.client_config:type_name -> waymon.protocol.ClientConfig
-	1,  // 9: waymon.protocol.ClientInfo.status:type_name -> waymon.protocol.ClientStatus
-	10, // 10: waymon.protocol.ServerInfo.connected_clients:type_name -> waymon.protocol.ClientInfo
-	12, // 11: waymon.protocol.ServerInfo.capabilities:type_name -> waymon.protocol.ServerCapabilities
-	14, // 12: waymon.protocol.ClientConfig.monitors:type_name -> waymon.protocol.Monitor
-	15, // 13: waymon.protocol.ClientConfig.capabilities:type_name -> waymon.protocol.ClientCapabilities
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	9,  // 0: waymon.protocol.InputEvent.mouse_move:type_name -> waymon.protocol.MouseMoveEvent
+	11, // 1: waymon.protocol.InputEvent.mouse_button:type_name -> waymon.protocol.MouseButtonEvent
+	12, // 2: waymon.protocol.InputEvent.mouse_scroll:type_name -> waymon.protocol.MouseScrollEvent
+	15, // 3: waymon.protocol.InputEvent.keyboard:type_name -> waymon.protocol.KeyboardEvent
+	16, // 4: waymon.protocol.InputEvent.control:type_name -> waymon.protocol.ControlEvent
+	10, // 5: waymon.protocol.InputEvent.mouse_position:type_name -> waymon.protocol.MousePositionEvent
+	17, // 6: waymon.protocol.InputEvent.clipboard_offer:type_name -> waymon.protocol.ClipboardOffer
+	18, // 7: waymon.protocol.InputEvent.clipboard_request:type_name -> waymon.protocol.ClipboardRequest
+	19, // 8: waymon.protocol.InputEvent.clipboard_data:type_name -> waymon.protocol.ClipboardData
+	20, // 9: waymon.protocol.InputEvent.latency_sample:type_name -> waymon.protocol.LatencySample
+	7,  // 10: waymon.protocol.InputEvent.hello:type_name -> waymon.protocol.Hello
+	8,  // 11: waymon.protocol.InputEvent.frame:type_name -> waymon.protocol.InputFrame
+	13, // 12: waymon.protocol.InputEvent.gesture:type_name -> waymon.protocol.GestureEvent
+	14, // 13: waymon.protocol.InputEvent.tablet_tool:type_name -> waymon.protocol.TabletToolEvent
+	6,  // 14: waymon.protocol.InputFrame.events:type_name -> waymon.protocol.InputEvent
+	0,  // 15: waymon.protocol.MouseScrollEvent.type:type_name -> waymon.protocol.ScrollType
+	1,  // 16: waymon.protocol.GestureEvent.type:type_name -> waymon.protocol.GestureType
+	2,  // 17: waymon.protocol.GestureEvent.phase:type_name -> waymon.protocol.GesturePhase
+	3,  // 18: waymon.protocol.TabletToolEvent.tool:type_name -> waymon.protocol.TabletTool
+	5,  // 19: waymon.protocol.ControlEvent.type:type_name -> waymon.protocol.ControlEvent.Type
+	25, // 20: waymon.protocol.ControlEvent.client_config:type_name -> waymon.protocol.ClientConfig
+	21, // 21: waymon.protocol.ControlEvent.keymap:type_name -> waymon.protocol.Keymap
+	4,  // 22: waymon.protocol.ClientInfo.status:type_name -> waymon.protocol.ClientStatus
+	22, // 23: waymon.protocol.ServerInfo.connected_clients:type_name -> waymon.protocol.ClientInfo
+	24, // 24: waymon.protocol.ServerInfo.capabilities:type_name -> waymon.protocol.ServerCapabilities
+	26, // 25: waymon.protocol.ClientConfig.monitors:type_name -> waymon.protocol.Monitor
+	27, // 26: waymon.protocol.ClientConfig.capabilities:type_name -> waymon.protocol.ClientCapabilities
+	27, // [27:27] is the sub-list for method output_type
+	27, // [27:27] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_internal_protocol_events_proto_init() }
@@ -1320,14 +2414,22 @@ func file_internal_protocol_events_proto_init() {
 		(*InputEvent_Keyboard)(nil),
 		(*InputEvent_Control)(nil),
 		(*InputEvent_MousePosition)(nil),
+		(*InputEvent_ClipboardOffer)(nil),
+		(*InputEvent_ClipboardRequest)(nil),
+		(*InputEvent_ClipboardData)(nil),
+		(*InputEvent_LatencySample)(nil),
+		(*InputEvent_Hello)(nil),
+		(*InputEvent_Frame)(nil),
+		(*InputEvent_Gesture)(nil),
+		(*InputEvent_TabletTool)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_protocol_events_proto_rawDesc), len(file_internal_protocol_events_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   13,
+			NumEnums:      6,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
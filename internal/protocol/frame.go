@@ -0,0 +1,29 @@
+package protocol
+
+// NewFrame groups events reported in one hardware frame
+func NewFrame(events []*InputEvent, timestamp int64, sourceID string) *InputEvent {
+	return &InputEvent{
+		Event:     &InputEvent_Frame{Frame: &InputFrame{Events: events}},
+		Timestamp: timestamp,
+		SourceId:  sourceID,
+	}
+}
+
+// Unframe returns the events of a frame, or the event itself when it is not
+// one
+func Unframe(event *InputEvent) []*InputEvent {
+	if frame := event.GetFrame(); frame != nil {
+		return frame.Events
+	}
+	return []*InputEvent{event}
+}
+
+// MotionOf returns the relative motion of an event or of the frame it is
+func MotionOf(event *InputEvent) *MouseMoveEvent {
+	for _, e := range Unframe(event) {
+		if move := e.GetMouseMove(); move != nil {
+			return move
+		}
+	}
+	return nil
+}
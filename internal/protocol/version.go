@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Version is the newest version of the protocol spoken between server and
+// client. It is exchanged in the Hello opening each session and announced to
+// clients browsing the local network.
+const Version = 2
+
+// MinVersion is the oldest version still spoken. Version 1 predates the Hello
+// exchange and is refused.
+const MinVersion = 2
+
+// Features a session can use. Each side lists those it supports in its Hello,
+// and events of a feature the other side lacks are not sent.
+const (
+	FeatureKeyboard         = "keyboard"
+	FeatureMouse            = "mouse"
+	FeatureScroll           = "scroll"
+	FeatureAbsolutePosition = "absolute_position"
+	FeatureClipboard        = "clipboard"
+	FeatureKeymap           = "keymap"
+	FeatureHeartbeat        = "heartbeat"
+	FeatureLatency          = "latency"
+	FeatureFrames           = "frames"
+	FeatureGestures         = "gestures"
+	FeatureTablet           = "tablet"
+)
+
+// Features returns every feature of this version
+func Features() []string {
+	return []string{
+		FeatureKeyboard,
+		FeatureMouse,
+		FeatureScroll,
+		FeatureAbsolutePosition,
+		FeatureClipboard,
+		FeatureKeymap,
+		FeatureHeartbeat,
+		FeatureLatency,
+		FeatureFrames,
+		FeatureGestures,
+		FeatureTablet,
+	}
+}
+
+// Compatible reports whether a peer announcing version may be spoken with.
+// Newer peers are expected to still speak this version.
+func Compatible(version int) bool {
+	return version >= MinVersion
+}
+
+// Negotiate agrees on the version and features of a session from the Hello
+// of each side: the newest version both speak and the features both support.
+func Negotiate(local, remote *Hello) (*Hello, error) {
+	version := min(local.ProtocolVersion, remote.ProtocolVersion)
+	if version < local.MinProtocolVersion || version < remote.MinProtocolVersion {
+		return nil, fmt.Errorf("no common protocol version: v%d-v%d here, v%d-v%d on the other side",
+			local.MinProtocolVersion, local.ProtocolVersion, remote.MinProtocolVersion, remote.ProtocolVersion)
+	}
+
+	var features []string
+	for _, feature := range local.Features {
+		if slices.Contains(remote.Features, feature) {
+			features = append(features, feature)
+		}
+	}
+	return &Hello{
+		ProtocolVersion:    version,
+		MinProtocolVersion: version,
+		Features:           features,
+		SoftwareVersion:    local.SoftwareVersion,
+	}, nil
+}
+
+// FeatureOf returns the feature an event belongs to, or "" for events every
+// session can carry
+func FeatureOf(event *InputEvent) string {
+	switch e := event.Event.(type) {
+	case *InputEvent_Keyboard:
+		return FeatureKeyboard
+	case *InputEvent_MouseMove, *InputEvent_MouseButton:
+		return FeatureMouse
+	case *InputEvent_MouseScroll:
+		return FeatureScroll
+	case *InputEvent_MousePosition:
+		return FeatureAbsolutePosition
+	case *InputEvent_ClipboardOffer, *InputEvent_ClipboardRequest, *InputEvent_ClipboardData:
+		return FeatureClipboard
+	case *InputEvent_LatencySample:
+		return FeatureLatency
+	case *InputEvent_Frame:
+		return FeatureFrames
+	case *InputEvent_Gesture:
+		return FeatureGestures
+	case *InputEvent_TabletTool:
+		return FeatureTablet
+	case *InputEvent_Control:
+		switch e.Control.Type {
+		case ControlEvent_KEYMAP:
+			return FeatureKeymap
+		case ControlEvent_PING, ControlEvent_PONG:
+			return FeatureHeartbeat
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bnema/waymon/internal/ipc"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/network"
+	pb "github.com/bnema/waymon/internal/proto"
+)
+
+// HandleAuthList implements ipc.AuthHandler
+func (cm *ClientManager) HandleAuthList(query *pb.AuthListQuery) (*pb.IPCMessage, error) {
+	return cm.authResponse(0)
+}
+
+// HandleAuthDecision implements ipc.AuthHandler
+func (cm *ClientManager) HandleAuthDecision(decision *pb.AuthDecision) (*pb.IPCMessage, error) {
+	cm.mu.RLock()
+	sshServer := cm.sshServer
+	cm.mu.RUnlock()
+
+	if sshServer == nil {
+		return nil, fmt.Errorf("SSH server not running")
+	}
+
+	if decision.All {
+		denied := sshServer.DenyPendingAuths()
+		logger.Infof("[SERVER-MANAGER] Denied %d pending SSH key(s) over IPC", denied)
+		return cm.authResponse(int32(denied)) //nolint:gosec // pending key count is small
+	}
+
+	if decision.Fingerprint == "" {
+		return nil, fmt.Errorf("fingerprint is required")
+	}
+	if err := sshServer.ResolveAuth(decision.Fingerprint, decision.Approve); err != nil {
+		return nil, err
+	}
+	verdict := "denied"
+	if decision.Approve {
+		verdict = "approved"
+	}
+	logger.Infof("[SERVER-MANAGER] SSH key %s %s over IPC", decision.Fingerprint, verdict)
+	return cm.authResponse(1)
+}
+
+// HandlePairRequest implements ipc.AuthHandler
+func (cm *ClientManager) HandlePairRequest(req *pb.PairRequest) (*pb.IPCMessage, error) {
+	cm.mu.RLock()
+	sshServer := cm.sshServer
+	cm.mu.RUnlock()
+
+	if sshServer == nil {
+		return nil, fmt.Errorf("SSH server not running")
+	}
+
+	timeout := network.DefaultPairingTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	fingerprint, err := sshServer.HostKeyFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	code, err := sshServer.NewPairingCode(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("[SERVER-MANAGER] Pairing code created over IPC, valid for %s", timeout)
+	return ipc.NewPairResponseMessage(code.Code, code.ExpiresAt.UnixMilli(), fingerprint)
+}
+
+// authResponse lists the keys still waiting for approval
+func (cm *ClientManager) authResponse(resolved int32) (*pb.IPCMessage, error) {
+	cm.mu.RLock()
+	sshServer := cm.sshServer
+	cm.mu.RUnlock()
+
+	var pending []*pb.PendingAuth
+	if sshServer != nil {
+		for _, p := range sshServer.PendingAuths() {
+			pending = append(pending, &pb.PendingAuth{
+				Fingerprint:   p.Fingerprint,
+				PublicKey:     p.PublicKey,
+				Address:       p.Address,
+				User:          p.User,
+				RequestedAtMs: p.RequestedAt.UnixMilli(),
+				ExpiresAtMs:   p.ExpiresAt.UnixMilli(),
+			})
+		}
+	}
+	return ipc.NewAuthResponseMessage(pending, resolved)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/input"
+	"github.com/bnema/waymon/internal/logger"
+)
+
+// Binding actions
+const (
+	actionSwitch   = "switch"   // Switch to the client named by client or index
+	actionNext     = "next"     // Next client in the rotation, then back to local
+	actionPrevious = "previous" // Previous client in the rotation
+	actionLocal    = "local"    // Return control to the server
+	actionLock     = "lock"     // Toggle locking the cursor to its current screen
+)
+
+// serverBinding is a validated [[server.bindings]] entry
+type serverBinding struct {
+	keys   string
+	chord  input.Chord
+	action string
+	client string
+	index  int
+}
+
+// parseBinding validates a configured binding
+func parseBinding(entry config.Binding) (serverBinding, error) {
+	chord, err := input.ParseChord(entry.Keys)
+	if err != nil {
+		return serverBinding{}, err
+	}
+
+	b := serverBinding{keys: entry.Keys, chord: chord, action: entry.Action, client: entry.Client, index: entry.Index}
+	switch entry.Action {
+	case actionSwitch:
+		if entry.Client == "" && entry.Index <= 0 {
+			return serverBinding{}, fmt.Errorf("binding %q: switch needs a client or an index", entry.Keys)
+		}
+	case actionNext, actionPrevious, actionLocal, actionLock:
+	default:
+		return serverBinding{}, fmt.Errorf("binding %q: unknown action %q", entry.Keys, entry.Action)
+	}
+	return b, nil
+}
+
+// parseBindings validates the configured bindings, skipping invalid ones
+func parseBindings(entries []config.Binding) []serverBinding {
+	bindings := make([]serverBinding, 0, len(entries))
+	for _, entry := range entries {
+		b, err := parseBinding(entry)
+		if err != nil {
+			logger.Warnf("[SERVER-MANAGER] Ignoring binding: %v", err)
+			continue
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings
+}
+
+// bindingChords returns the chords of the bindings, in the same order
+func bindingChords(bindings []serverBinding) []input.Chord {
+	chords := make([]input.Chord, len(bindings))
+	for i, b := range bindings {
+		chords[i] = b.chord
+	}
+	return chords
+}
+
+// runBinding performs the action of a binding that was pressed
+func (cm *ClientManager) runBinding(b serverBinding) {
+	logger.Infof("[SERVER-MANAGER] Binding %s: %s", b.keys, b.action)
+
+	var err error
+	switch b.action {
+	case actionSwitch:
+		clientID, ok := cm.bindingTarget(b)
+		if !ok {
+			logger.Warnf("[SERVER-MANAGER] Binding %s: no connected client matches", b.keys)
+			return
+		}
+		err = cm.SwitchToClient(clientID)
+	case actionNext:
+		err = cm.switchToNextClientOrLocal()
+	case actionPrevious:
+		err = cm.switchToPreviousClientOrLocal()
+	case actionLocal:
+		err = cm.SwitchToLocal()
+	case actionLock:
+		cm.toggleScreenLock()
+	}
+	if err != nil {
+		logger.Errorf("[SERVER-MANAGER] Binding %s failed: %v", b.keys, err)
+	}
+}
+
+// bindingTarget finds the client a switch binding refers to. Indexes follow
+// the client list shown by the TUI and `waymon switch`.
+func (cm *ClientManager) bindingTarget(b serverBinding) (string, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if b.client != "" {
+		hosts := config.Get().Hosts
+		for id, client := range cm.clients {
+			if clientMatches(client, b.client, hosts) {
+				return id, true
+			}
+		}
+		return "", false
+	}
+
+	clientIDs := cm.clientIDsBySlot()
+	if b.index > len(clientIDs) {
+		return "", false
+	}
+	return clientIDs[b.index-1], true
+}
+
+// toggleScreenLock keeps the cursor on the screen it is on, or lets it cross
+// edges again. Bindings and explicit switches still move control.
+func (cm *ClientManager) toggleScreenLock() {
+	cm.mu.Lock()
+	cm.screenLocked = !cm.screenLocked
+	locked := cm.screenLocked
+	cm.mu.Unlock()
+
+	message := "Cursor unlocked from the current screen"
+	if locked {
+		message = "Cursor locked to the current screen"
+	}
+	logger.Infof("[SERVER-MANAGER] %s", message)
+	if cm.onActivity != nil {
+		cm.onActivity("INFO", message)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/config"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   config.Binding
+		wantErr bool
+	}{
+		{name: "switch by name", entry: config.Binding{Keys: "ctrl+alt+l", Action: "switch", Client: "laptop"}},
+		{name: "switch by index", entry: config.Binding{Keys: "ctrl+alt+1", Action: "switch", Index: 1}},
+		{name: "switch without target", entry: config.Binding{Keys: "ctrl+alt+1", Action: "switch"}, wantErr: true},
+		{name: "next", entry: config.Binding{Keys: "ctrl+alt+right", Action: "next"}},
+		{name: "lock on a mouse button", entry: config.Binding{Keys: "super+btn_extra", Action: "lock"}},
+		{name: "unknown action", entry: config.Binding{Keys: "ctrl+alt+x", Action: "explode"}, wantErr: true},
+		{name: "invalid chord", entry: config.Binding{Keys: "ctrl+alt", Action: "local"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseBinding(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseBinding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBindingTarget(t *testing.T) {
+	cm := &ClientManager{clients: map[string]*ConnectedClient{
+		"10.0.0.3:41000": {ID: "10.0.0.3:41000", Name: "desktop", Address: "10.0.0.3:41000"},
+		"10.0.0.2:41000": {ID: "10.0.0.2:41000", Name: "laptop", Address: "10.0.0.2:41000"},
+	}}
+
+	tests := []struct {
+		name    string
+		binding serverBinding
+		want    string
+		wantOK  bool
+	}{
+		{name: "by name", binding: serverBinding{client: "desktop"}, want: "10.0.0.3:41000", wantOK: true},
+		{name: "first in the client list", binding: serverBinding{index: 1}, want: "10.0.0.2:41000", wantOK: true},
+		{name: "index past the end", binding: serverBinding{index: 3}},
+		{name: "unknown name", binding: serverBinding{client: "tablet"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cm.bindingTarget(tt.binding)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("bindingTarget() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnema/waymon/internal/clipboard"
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// clipboardKey identifies a selection offered to a client
+type clipboardKey struct {
+	clientID string
+	primary  bool
+}
+
+// StartClipboard starts sharing the server clipboard, and the primary selection
+// when enabled, with clients unless disabled or unsupported by the compositor
+func (cm *ClientManager) StartClipboard(ctx context.Context) {
+	cfg := config.Get()
+	if !cfg.Server.ClipboardSync {
+		return
+	}
+
+	clip, err := clipboard.New(ctx, cfg.Server.ClipboardMaxSize, cfg.Server.PrimarySelectionSync)
+	if err != nil {
+		logger.Warnf("[SERVER-MANAGER] Clipboard sharing unavailable: %v", err)
+		return
+	}
+
+	cm.mu.Lock()
+	cm.clipboard = clip
+	cm.mu.Unlock()
+	logger.Info("[SERVER-MANAGER] Clipboard sharing enabled")
+}
+
+// StopClipboard stops sharing the server clipboard
+func (cm *ClientManager) StopClipboard() {
+	cm.mu.Lock()
+	clip := cm.clipboard
+	cm.clipboard = nil
+	cm.mu.Unlock()
+
+	if clip != nil {
+		if err := clip.Close(); err != nil {
+			logger.Errorf("[SERVER-MANAGER] Failed to close clipboard: %v", err)
+		}
+	}
+}
+
+// offerClipboard announces the server clipboard and primary selection to a
+// client that has not seen them yet. Must be called with the lock held.
+func (cm *ClientManager) offerClipboard(client *ConnectedClient) {
+	for _, primary := range []bool{false, true} {
+		cm.offerSelection(client, primary)
+	}
+}
+
+// offerSelection announces the clipboard, or the primary selection, to a
+// client that has not seen it yet. Must be called with the lock held.
+func (cm *ClientManager) offerSelection(client *ConnectedClient, primary bool) {
+	if cm.clipboard == nil || cm.sshServer == nil || !selectionSharedWith(client, primary) {
+		return
+	}
+
+	offer := cm.clipboard.Offer(primary)
+	key := clipboardKey{clientID: client.ID, primary: primary}
+	if offer == nil || cm.clipboardSent[key] == offer.Serial {
+		return
+	}
+
+	inputEvent := &protocol.InputEvent{
+		Event:     &protocol.InputEvent_ClipboardOffer{ClipboardOffer: offer},
+		Timestamp: time.Now().UnixNano(),
+		SourceId:  "server",
+	}
+	if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
+		logger.Warnf("[SERVER-MANAGER] Failed to offer clipboard to %s: %v", client.Name, err)
+		return
+	}
+	cm.clipboardSent[key] = offer.Serial
+	logger.Debugf("[SERVER-MANAGER] Offered %s %d to %s: %v", selectionName(primary), offer.Serial, client.Name, offer.MimeTypes)
+}
+
+// handleClipboardEvent processes clipboard messages from clients and reports
+// whether the event was one
+func (cm *ClientManager) handleClipboardEvent(event *protocol.InputEvent) bool {
+	switch event.Event.(type) {
+	case *protocol.InputEvent_ClipboardOffer, *protocol.InputEvent_ClipboardRequest, *protocol.InputEvent_ClipboardData:
+	default:
+		return false
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	primary := event.GetClipboardOffer().GetPrimary() || event.GetClipboardRequest().GetPrimary()
+	client := cm.clientBySource(event.SourceId)
+	if cm.clipboard == nil || cm.sshServer == nil || client == nil || !selectionSharedWith(client, primary) {
+		logger.Debugf("[SERVER-MANAGER] Ignoring clipboard message from %s", event.SourceId)
+		return true
+	}
+	clip, sshServer := cm.clipboard, cm.sshServer
+	id, name := client.ID, client.Name
+
+	switch e := event.Event.(type) {
+	case *protocol.InputEvent_ClipboardOffer:
+		serial, err := clip.Adopt(e.ClipboardOffer, id, func(req *protocol.ClipboardRequest) error {
+			return sshServer.SendEventToClient(id, &protocol.InputEvent{
+				Event:     &protocol.InputEvent_ClipboardRequest{ClipboardRequest: req},
+				Timestamp: time.Now().UnixNano(),
+				SourceId:  "server",
+			})
+		})
+		if err != nil {
+			logger.Warnf("[SERVER-MANAGER] Failed to adopt %s from %s: %v", selectionName(primary), client.Name, err)
+			return true
+		}
+		cm.clipboardSent[clipboardKey{clientID: client.ID, primary: primary}] = serial
+		logger.Infof("[SERVER-MANAGER] Took %s from %s", selectionName(primary), client.Name)
+
+		// Control may already have moved on to another client
+		if !cm.controllingLocal && cm.activeClientID != client.ID {
+			if active, ok := cm.clients[cm.activeClientID]; ok {
+				cm.offerSelection(active, primary)
+			}
+		}
+
+	case *protocol.InputEvent_ClipboardRequest:
+		go func() {
+			reply := clip.HandleRequest(e.ClipboardRequest)
+			if reply.Error != "" {
+				logger.Warnf("[SERVER-MANAGER] Clipboard request from %s failed: %s", name, reply.Error)
+			}
+			if err := sshServer.SendEventToClient(id, &protocol.InputEvent{
+				Event:     &protocol.InputEvent_ClipboardData{ClipboardData: reply},
+				Timestamp: time.Now().UnixNano(),
+				SourceId:  "server",
+			}); err != nil {
+				logger.Warnf("[SERVER-MANAGER] Failed to send clipboard to %s: %v", name, err)
+			}
+		}()
+
+	case *protocol.InputEvent_ClipboardData:
+		clip.HandleData(id, e.ClipboardData)
+	}
+	return true
+}
+
+// clientBySource finds the client that sent an event. The SSH server marks
+// events with the ID of the client they came from, names and addresses are
+// accepted too. Must be called with the lock held.
+func (cm *ClientManager) clientBySource(sourceID string) *ConnectedClient {
+	if client, exists := cm.clients[sourceID]; exists {
+		return client
+	}
+	for _, client := range cm.clients {
+		if client.Name == sourceID || client.Address == sourceID || client.reportedName == sourceID {
+			return client
+		}
+	}
+	return nil
+}
+
+// selectionSharedWith reports whether the clipboard, or the primary selection,
+// is shared with a client
+func selectionSharedWith(client *ConnectedClient, primary bool) bool {
+	cfg := config.Get()
+	for _, host := range cfg.Hosts {
+		disabled := host.NoClipboard || (primary && host.NoPrimarySelection)
+		if disabled && clientMatches(client, host.Name, cfg.Hosts) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectionName names a selection for logging
+func selectionName(primary bool) string {
+	if primary {
+		return "primary selection"
+	}
+	return "clipboard"
+}
@@ -10,22 +10,19 @@ import (
 	"github.com/bnema/waymon/internal/logger"
 )
 
-// EmergencyRelease provides multiple mechanisms for emergency control release
+// EmergencyRelease provides multiple mechanisms for emergency control release.
+// Unresponsive clients are handled by the heartbeat instead.
 type EmergencyRelease struct {
-	manager          *ClientManager
-	activityTimeout  time.Duration
-	lastActivity     time.Time
-	stopChan         chan struct{}
-	stopOnce         sync.Once
+	manager  *ClientManager
+	stopChan chan struct{}
+	stopOnce sync.Once
 }
 
 // NewEmergencyRelease creates a new emergency release handler
 func NewEmergencyRelease(manager *ClientManager) *EmergencyRelease {
 	return &EmergencyRelease{
-		manager:         manager,
-		activityTimeout: 30 * time.Second, // Auto-release after 30s of inactivity
-		lastActivity:    time.Now(),
-		stopChan:        make(chan struct{}),
+		manager:  manager,
+		stopChan: make(chan struct{}),
 	}
 }
 
@@ -34,10 +31,7 @@ func (er *EmergencyRelease) Start() {
 	// 1. Signal handler for SIGUSR1
 	go er.handleSignals()
 	
-	// 2. Activity timeout monitor
-	go er.monitorActivity()
-	
-	// 3. File-based trigger (check for /tmp/waymon-release)
+	// 2. File-based trigger (check for /tmp/waymon-release)
 	go er.monitorFileTriger()
 	
 	logger.Info("[EMERGENCY] Emergency release mechanisms activated")
@@ -50,11 +44,6 @@ func (er *EmergencyRelease) Stop() {
 	})
 }
 
-// UpdateActivity updates the last activity timestamp
-func (er *EmergencyRelease) UpdateActivity() {
-	er.lastActivity = time.Now()
-}
-
 // handleSignals listens for SIGUSR1 to trigger emergency release
 func (er *EmergencyRelease) handleSignals() {
 	sigChan := make(chan os.Signal, 1)
@@ -71,28 +60,6 @@ func (er *EmergencyRelease) handleSignals() {
 	}
 }
 
-// monitorActivity checks for input activity timeout
-func (er *EmergencyRelease) monitorActivity() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-	
-	for {
-		select {
-		case <-ticker.C:
-			if er.manager.IsControllingLocal() {
-				continue // No timeout when controlling local
-			}
-			
-			if time.Since(er.lastActivity) > er.activityTimeout {
-				logger.Warnf("[EMERGENCY] No activity for %v - triggering emergency release", er.activityTimeout)
-				er.triggerRelease("timeout")
-			}
-		case <-er.stopChan:
-			return
-		}
-	}
-}
-
 // monitorFileTriger checks for presence of /tmp/waymon-release file
 func (er *EmergencyRelease) monitorFileTriger() {
 	ticker := time.NewTicker(1 * time.Second)
@@ -132,7 +99,4 @@ func (er *EmergencyRelease) triggerRelease(reason string) {
 			}
 		}
 	}
-	
-	// Reset activity timer
-	er.lastActivity = time.Now()
 }
\ No newline at end of file
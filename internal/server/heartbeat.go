@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// StartHeartbeat pings clients every interval. A client that leaves misses
+// pings unanswered is treated as gone: if it was being controlled the grab is
+// released and control returns to the server, then its session is closed.
+func (cm *ClientManager) StartHeartbeat(ctx context.Context, interval time.Duration, misses int) {
+	if interval <= 0 {
+		return
+	}
+	if misses < 1 {
+		misses = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cm.mu.Lock()
+	cm.heartbeatCancel = cancel
+	cm.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cm.checkHeartbeats(interval, misses)
+			}
+		}
+	}()
+	logger.Infof("[SERVER-MANAGER] Heartbeat every %v, clients time out after %d missed pings", interval, misses)
+}
+
+// StopHeartbeat stops pinging clients
+func (cm *ClientManager) StopHeartbeat() {
+	cm.mu.Lock()
+	cancel := cm.heartbeatCancel
+	cm.heartbeatCancel = nil
+	cm.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// checkHeartbeats releases clients that stopped answering and pings the others
+func (cm *ClientManager) checkHeartbeats(interval time.Duration, misses int) {
+	timeout := interval * time.Duration(misses)
+
+	cm.mu.Lock()
+	sshServer := cm.sshServer
+	if sshServer == nil {
+		cm.mu.Unlock()
+		return
+	}
+
+	cm.pingSeq++
+	cm.pingSentAt = time.Now()
+	ping := &protocol.InputEvent{
+		Event: &protocol.InputEvent_Control{
+			Control: &protocol.ControlEvent{
+				Type:       protocol.ControlEvent_PING,
+				Sequence:   cm.pingSeq,
+				IntervalMs: uint32(interval.Milliseconds()), //nolint:gosec // interval is configured in milliseconds
+			},
+		},
+		Timestamp: time.Now().UnixNano(),
+		SourceId:  "server",
+	}
+
+	var alive, dead []string
+	for _, client := range cm.clients {
+		switch {
+		case client.unresponsive:
+			// Already being disconnected
+		case !client.supports(protocol.FeatureHeartbeat):
+			// Cannot answer pings
+		case time.Since(client.lastPong) > timeout:
+			client.unresponsive = true
+			dead = append(dead, client.ID)
+			cm.releaseUnresponsive(client, timeout)
+		default:
+			alive = append(alive, client.ID)
+		}
+	}
+	cm.mu.Unlock()
+
+	// Writes to a half-open session can block, keep them off this goroutine
+	for _, id := range dead {
+		go func() {
+			if err := sshServer.DisconnectClient(id); err != nil {
+				logger.Debugf("[SERVER-MANAGER] Failed to close session of %s: %v", id, err)
+			}
+		}()
+	}
+	for _, id := range alive {
+		go func() {
+			if err := sshServer.SendEventToClient(id, ping); err != nil {
+				logger.Debugf("[SERVER-MANAGER] Failed to ping %s: %v", id, err)
+			}
+		}()
+	}
+}
+
+// releaseUnresponsive returns control to the server when the client that
+// stopped answering was being controlled. Nothing is sent to the client, which
+// releases its own injected input when the pings stop. Must be called with
+// the lock held.
+func (cm *ClientManager) releaseUnresponsive(client *ConnectedClient, timeout time.Duration) {
+	logger.Warnf("[SERVER-MANAGER] Client %s did not answer pings for %v, disconnecting", client.Name, timeout)
+
+	if cm.controllingLocal || cm.activeClientID != client.ID {
+		return
+	}
+
+	if err := cm.inputBackend.SetTarget(""); err != nil {
+		logger.Errorf("[SERVER-MANAGER] Failed to release input from unresponsive client: %v", err)
+	}
+	client.Status = protocol.ClientStatus_CLIENT_IDLE
+	cm.activeClientID = ""
+	cm.controllingLocal = true
+	cm.dropped, cm.droppedAt = client.ID, time.Now() // Control comes back if it reconnects
+
+	if cm.onActivity != nil {
+		cm.onActivity("WARN", fmt.Sprintf("Client %s stopped responding - control returned to local", client.Name))
+	}
+}
+
+// handlePong records a heartbeat answer from a client
+func (cm *ClientManager) handlePong(control *protocol.ControlEvent, sourceID string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	client := cm.clientBySource(sourceID)
+	if client == nil {
+		return
+	}
+	client.lastPong = time.Now()
+	if control.Sequence == cm.pingSeq {
+		client.stats.addRTT(client.lastPong.Sub(cm.pingSentAt))
+	}
+	logger.Debugf("[SERVER-MANAGER] Pong %d from %s", control.Sequence, client.Name)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// targetRecorder is an input backend that remembers the last target set
+type targetRecorder struct {
+	target string
+}
+
+func (r *targetRecorder) Start(context.Context) error             { return nil }
+func (r *targetRecorder) Stop() error                             { return nil }
+func (r *targetRecorder) SetTarget(clientID string) error         { r.target = clientID; return nil }
+func (r *targetRecorder) OnInputEvent(func(*protocol.InputEvent)) {}
+
+func TestReleaseUnresponsive(t *testing.T) {
+	tests := []struct {
+		name       string
+		active     string
+		wantLocal  bool
+		wantTarget string
+		wantActive string
+		wantStatus protocol.ClientStatus
+	}{
+		{name: "controlled client returns control to local", active: "laptop", wantLocal: true, wantStatus: protocol.ClientStatus_CLIENT_IDLE},
+		{name: "other client keeps control", active: "desktop", wantTarget: "desktop", wantActive: "desktop", wantStatus: protocol.ClientStatus_CLIENT_IDLE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &targetRecorder{target: tt.active}
+			laptop := &ConnectedClient{ID: "laptop", Name: "laptop", Status: protocol.ClientStatus_CLIENT_IDLE}
+			if tt.active == laptop.ID {
+				laptop.Status = protocol.ClientStatus_CLIENT_BEING_CONTROLLED
+			}
+			cm := &ClientManager{
+				inputBackend:   backend,
+				clients:        map[string]*ConnectedClient{"laptop": laptop},
+				activeClientID: tt.active,
+			}
+
+			cm.releaseUnresponsive(laptop, 3*time.Second)
+
+			if cm.controllingLocal != tt.wantLocal || cm.activeClientID != tt.wantActive {
+				t.Errorf("controllingLocal = %v, activeClientID = %q, want %v, %q", cm.controllingLocal, cm.activeClientID, tt.wantLocal, tt.wantActive)
+			}
+			if backend.target != tt.wantTarget {
+				t.Errorf("backend target = %q, want %q", backend.target, tt.wantTarget)
+			}
+			if laptop.Status != tt.wantStatus {
+				t.Errorf("client status = %v, want %v", laptop.Status, tt.wantStatus)
+			}
+		})
+	}
+}
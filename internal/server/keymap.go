@@ -0,0 +1,82 @@
+package server
+
+import (
+	"time"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/keymap"
+	"github.com/bnema/waymon/internal/logger"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// sendKeymap forwards the keyboard layout to a client so the key codes it
+// receives produce the same characters as on the server. It takes a copy of
+// the client since it runs outside the manager lock.
+func (cm *ClientManager) sendKeymap(client ConnectedClient) {
+	cfg := config.Get()
+	if !cfg.Server.ForwardKeymap || cm.sshServer == nil {
+		return
+	}
+
+	names := keymapNamesFor(&client, cfg)
+	var (
+		xkbKeymap string
+		name      string
+		err       error
+	)
+	if names.Layout != "" {
+		name = names.String()
+		xkbKeymap, err = keymap.Compile(names)
+	} else {
+		name = "compositor"
+		xkbKeymap, err = keymap.FromCompositor()
+	}
+	if err != nil {
+		logger.Warnf("[SERVER-MANAGER] Not forwarding keymap to %s: %v", client.Name, err)
+		return
+	}
+
+	inputEvent := &protocol.InputEvent{
+		Event: &protocol.InputEvent_Control{
+			Control: &protocol.ControlEvent{
+				Type:     protocol.ControlEvent_KEYMAP,
+				TargetId: client.ID,
+				Keymap: &protocol.Keymap{
+					Name:      name,
+					XkbKeymap: xkbKeymap,
+				},
+			},
+		},
+		Timestamp: time.Now().UnixNano(),
+		SourceId:  "server",
+	}
+	if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
+		logger.Warnf("[SERVER-MANAGER] Failed to send keymap to %s: %v", client.Name, err)
+		return
+	}
+	logger.Infof("[SERVER-MANAGER] Sent %s keymap to %s", name, client.Name)
+}
+
+// keymapNamesFor returns the XKB names configured for a client, from its
+// [[hosts]] entry or the server section. An empty layout means the
+// compositor's keymap is used.
+func keymapNamesFor(client *ConnectedClient, cfg *config.Config) keymap.Names {
+	for _, host := range cfg.Hosts {
+		if host.XKBLayout != "" && clientMatches(client, host.Name, cfg.Hosts) {
+			return keymap.Names{
+				Rules:   cfg.Server.XKBRules,
+				Model:   cfg.Server.XKBModel,
+				Layout:  host.XKBLayout,
+				Variant: host.XKBVariant,
+				Options: host.XKBOptions,
+			}
+		}
+	}
+	return keymap.Names{
+		Rules:   cfg.Server.XKBRules,
+		Model:   cfg.Server.XKBModel,
+		Layout:  cfg.Server.XKBLayout,
+		Variant: cfg.Server.XKBVariant,
+		Options: cfg.Server.XKBOptions,
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/keymap"
+)
+
+func TestKeymapNamesFor(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{XKBLayout: "us", XKBOptions: "caps:escape"},
+		Hosts: []config.HostConfig{
+			{Name: "laptop", Address: "10.0.0.2:52525", XKBLayout: "de", XKBVariant: "nodeadkeys"},
+			{Name: "desktop", Address: "10.0.0.3:52525"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		client   *ConnectedClient
+		layout   string
+		expected keymap.Names
+	}{
+		{
+			name:     "host override replaces the server layout",
+			client:   &ConnectedClient{ID: "10.0.0.2:41000", Name: "laptop", Address: "10.0.0.2:41000"},
+			layout:   "us",
+			expected: keymap.Names{Layout: "de", Variant: "nodeadkeys"},
+		},
+		{
+			name:     "host without override uses the server layout",
+			client:   &ConnectedClient{ID: "10.0.0.3:41000", Name: "desktop", Address: "10.0.0.3:41000"},
+			layout:   "us",
+			expected: keymap.Names{Layout: "us", Options: "caps:escape"},
+		},
+		{
+			name:     "no layout reads the compositor keymap",
+			client:   &ConnectedClient{ID: "10.0.0.3:41000", Name: "desktop", Address: "10.0.0.3:41000"},
+			layout:   "",
+			expected: keymap.Names{Options: "caps:escape"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Server.XKBLayout = tt.layout
+			if got := keymapNamesFor(tt.client, cfg); got != tt.expected {
+				t.Errorf("keymapNamesFor() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
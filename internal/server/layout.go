@@ -0,0 +1,474 @@
+package server
+
+import (
+	"math"
+	"net"
+	"strings"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/display"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// screenLayout places the server's monitors and the monitors of every
+// positioned client in a single virtual desktop. The server keeps its own
+// coordinates; each client is shifted so that its display bounds sit against
+// the edge it is attached to.
+type screenLayout struct {
+	serverMonitors []*protocol.Monitor
+	placements     []*clientPlacement
+}
+
+// clientPlacement describes where a client's display sits in the virtual desktop
+type clientPlacement struct {
+	clientID string
+	edge     display.Edge // Edge of the anchor the client is attached to
+	anchorID string       // Empty when attached to the server, otherwise a client ID
+	anchor   rect         // Virtual area the client is attached to
+	bounds   rect         // Client display bounds in client-local coordinates
+	monitors []*protocol.Monitor
+	offsetX  float64 // Added to client-local coordinates to get virtual ones
+	offsetY  float64
+}
+
+// layoutTarget is the machine and local position the cursor enters when it leaves a screen
+type layoutTarget struct {
+	clientID string // Empty means the server
+	x, y     float64
+}
+
+// buildLayout creates the virtual desktop from the server monitors, the
+// connected clients and the configured edge mappings and host positions.
+// Edge mappings attach a client to one edge of a specific server monitor.
+// Host positions attach a client to the outer edge of the server, or of the
+// last client already placed on that side, so several hosts can be chained.
+func (cm *ClientManager) buildLayout(serverMonitors []*protocol.Monitor, clients []*ConnectedClient,
+	hosts []config.HostConfig, mappings []config.EdgeMapping) *screenLayout {
+	layout := &screenLayout{serverMonitors: serverMonitors}
+	if len(serverMonitors) == 0 {
+		return layout
+	}
+
+	placed := make(map[string]bool)
+	serverBounds := cm.calculateTotalDisplayBounds(serverMonitors)
+
+	// Monitor-specific edge mappings take precedence over host positions
+	for _, mapping := range mappings {
+		edge := parseEdge(mapping.Edge)
+		if edge == display.EdgeNone {
+			continue
+		}
+		anchor, ok := cm.mappingAnchor(serverMonitors, serverBounds, mapping.MonitorID)
+		if !ok {
+			continue
+		}
+		client := findLayoutClient(clients, placed, mapping.Host, hosts)
+		if client == nil {
+			continue
+		}
+		layout.place(cm, client, edge, "", anchor)
+		placed[client.ID] = true
+	}
+
+	// Host positions chain outwards from the server on each side
+	type outerEdge struct {
+		id     string
+		bounds rect
+	}
+	outer := make(map[display.Edge]outerEdge)
+	for _, host := range hosts {
+		edge := parseEdge(host.Position)
+		if edge == display.EdgeNone {
+			continue
+		}
+		client := findLayoutClient(clients, placed, host.Name, hosts)
+		if client == nil {
+			continue
+		}
+		anchor, ok := outer[edge]
+		if !ok {
+			anchor = outerEdge{bounds: serverBounds}
+		}
+		p := layout.place(cm, client, edge, anchor.id, anchor.bounds)
+		placed[client.ID] = true
+		outer[edge] = outerEdge{id: client.ID, bounds: p.virtualBounds()}
+	}
+
+	return layout
+}
+
+// place attaches a client to the given edge of the anchor area
+func (l *screenLayout) place(cm *ClientManager, client *ConnectedClient, edge display.Edge, anchorID string, anchor rect) *clientPlacement {
+	bounds := cm.calculateTotalDisplayBounds(client.Monitors)
+	p := &clientPlacement{
+		clientID: client.ID,
+		edge:     edge,
+		anchorID: anchorID,
+		anchor:   anchor,
+		bounds:   bounds,
+		monitors: client.Monitors,
+	}
+
+	// Align the client with the start of the anchor along the shared edge
+	switch edge {
+	case display.EdgeRight:
+		p.offsetX = anchor.maxX - bounds.minX
+		p.offsetY = anchor.minY - bounds.minY
+	case display.EdgeLeft:
+		p.offsetX = anchor.minX - bounds.maxX
+		p.offsetY = anchor.minY - bounds.minY
+	case display.EdgeBottom:
+		p.offsetX = anchor.minX - bounds.minX
+		p.offsetY = anchor.maxY - bounds.minY
+	case display.EdgeTop:
+		p.offsetX = anchor.minX - bounds.minX
+		p.offsetY = anchor.minY - bounds.maxY
+	}
+
+	l.placements = append(l.placements, p)
+	return p
+}
+
+// placement returns the placement of a client, or nil if it is not positioned
+func (l *screenLayout) placement(clientID string) *clientPlacement {
+	if l == nil {
+		return nil
+	}
+	for _, p := range l.placements {
+		if p.clientID == clientID {
+			return p
+		}
+	}
+	return nil
+}
+
+// leave resolves where the cursor goes when it crosses an edge of a screen.
+// fromID is the machine the cursor is on (empty for the server), from is the
+// monitor it is leaving and (x, y) is the position past the edge, both in the
+// local coordinates of that machine. The exit point keeps its relative
+// position along the edge: leaving halfway down a monitor enters halfway down
+// the monitors on the facing side of the target.
+func (l *screenLayout) leave(fromID string, from rect, edge display.Edge, x, y float64) (layoutTarget, bool) {
+	if l == nil || edge == display.EdgeNone {
+		return layoutTarget{}, false
+	}
+
+	// Translate into virtual coordinates
+	var offsetX, offsetY float64
+	self := l.placement(fromID)
+	if fromID != "" {
+		if self == nil {
+			return layoutTarget{}, false
+		}
+		offsetX, offsetY = self.offsetX, self.offsetY
+	}
+	vx, vy := x+offsetX, y+offsetY
+	vfrom := rect{
+		minX: from.minX + offsetX, minY: from.minY + offsetY,
+		maxX: from.maxX + offsetX, maxY: from.maxY + offsetY,
+	}
+	fraction := edgeFraction(from, edge, x, y)
+
+	// Moving outwards onto a client attached to this screen
+	for _, p := range l.placements {
+		if p.anchorID != fromID || p.edge != edge || !p.touches(vfrom, vx, vy) {
+			continue
+		}
+		lx, ly := entryPoint(p.monitors, p.bounds, oppositeEdge(edge), fraction)
+		return layoutTarget{clientID: p.clientID, x: lx, y: ly}, true
+	}
+
+	// Moving back onto whatever this client is attached to
+	if self != nil && oppositeEdge(self.edge) == edge {
+		if self.anchorID == "" {
+			lx, ly := entryPoint(l.serverMonitors, self.anchor, self.edge, fraction)
+			return layoutTarget{x: lx, y: ly}, true
+		}
+		if anchor := l.placement(self.anchorID); anchor != nil {
+			lx, ly := entryPoint(anchor.monitors, anchor.bounds, self.edge, fraction)
+			return layoutTarget{clientID: anchor.clientID, x: lx, y: ly}, true
+		}
+	}
+
+	return layoutTarget{}, false
+}
+
+// edgeFraction returns how far along the crossed edge of r the point lies, from 0 to 1
+func edgeFraction(r rect, edge display.Edge, x, y float64) float64 {
+	var pos, start, length float64
+	switch edge {
+	case display.EdgeLeft, display.EdgeRight:
+		pos, start, length = y, r.minY, r.maxY-r.minY
+	case display.EdgeTop, display.EdgeBottom:
+		pos, start, length = x, r.minX, r.maxX-r.minX
+	default:
+		return 0.5
+	}
+	if length <= 0 {
+		return 0.5
+	}
+	return math.Max(0, math.Min(1, (pos-start)/length))
+}
+
+// entryPoint returns the point on the given side of a machine's monitors at
+// fraction along that side. Only monitors inside within are considered, which
+// lets an edge mapping target a single server monitor. The fraction is scaled
+// over the combined height (left/right) or width (top/bottom) of the monitors
+// lining that side.
+func entryPoint(monitors []*protocol.Monitor, within rect, side display.Edge, fraction float64) (float64, float64) {
+	var candidates []rect
+	for _, monitor := range monitors {
+		r := monitorRect(monitor)
+		if r.minX >= within.minX && r.maxX <= within.maxX && r.minY >= within.minY && r.maxY <= within.maxY {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		return clampToRect((within.minX+within.maxX)/2, (within.minY+within.maxY)/2, within)
+	}
+
+	// Keep the monitors lining the entered side
+	vertical := side == display.EdgeLeft || side == display.EdgeRight
+	sideOf := func(r rect) float64 {
+		switch side {
+		case display.EdgeLeft:
+			return r.minX
+		case display.EdgeRight:
+			return -r.maxX
+		case display.EdgeTop:
+			return r.minY
+		default:
+			return -r.maxY
+		}
+	}
+	extreme := sideOf(candidates[0])
+	for _, r := range candidates[1:] {
+		extreme = math.Min(extreme, sideOf(r))
+	}
+	var lining []rect
+	span := rect{minX: math.Inf(1), minY: math.Inf(1), maxX: math.Inf(-1), maxY: math.Inf(-1)}
+	for _, r := range candidates {
+		if sideOf(r) != extreme {
+			continue
+		}
+		lining = append(lining, r)
+		span.minX, span.minY = math.Min(span.minX, r.minX), math.Min(span.minY, r.minY)
+		span.maxX, span.maxY = math.Max(span.maxX, r.maxX), math.Max(span.maxY, r.maxY)
+	}
+
+	// Scale along the side, then snap onto the closest lining monitor
+	pos := span.minX + fraction*(span.maxX-span.minX)
+	if vertical {
+		pos = span.minY + fraction*(span.maxY-span.minY)
+	}
+	best := lining[0]
+	bestDistance := math.Inf(1)
+	for _, r := range lining {
+		start, end := r.minX, r.maxX
+		if vertical {
+			start, end = r.minY, r.maxY
+		}
+		distance := math.Max(0, math.Max(start-pos, pos-(end-1)))
+		if distance < bestDistance {
+			best, bestDistance = r, distance
+		}
+	}
+
+	var x, y float64
+	switch side {
+	case display.EdgeLeft:
+		x, y = best.minX, pos
+	case display.EdgeRight:
+		x, y = best.maxX-1, pos
+	case display.EdgeTop:
+		x, y = pos, best.minY
+	default:
+		x, y = pos, best.maxY-1
+	}
+	return clampToRect(math.Round(x), math.Round(y), best)
+}
+
+// touches reports whether leaving the area from through the placement's edge
+// at virtual position (vx, vy) lands on this placement
+func (p *clientPlacement) touches(from rect, vx, vy float64) bool {
+	switch p.edge {
+	case display.EdgeRight:
+		return from.maxX >= p.anchor.maxX && vy >= p.anchor.minY && vy < p.anchor.maxY
+	case display.EdgeLeft:
+		return from.minX <= p.anchor.minX && vy >= p.anchor.minY && vy < p.anchor.maxY
+	case display.EdgeBottom:
+		return from.maxY >= p.anchor.maxY && vx >= p.anchor.minX && vx < p.anchor.maxX
+	case display.EdgeTop:
+		return from.minY <= p.anchor.minY && vx >= p.anchor.minX && vx < p.anchor.maxX
+	}
+	return false
+}
+
+// virtualBounds returns the client's display bounds in virtual coordinates
+func (p *clientPlacement) virtualBounds() rect {
+	return rect{
+		minX: p.bounds.minX + p.offsetX,
+		minY: p.bounds.minY + p.offsetY,
+		maxX: p.bounds.maxX + p.offsetX,
+		maxY: p.bounds.maxY + p.offsetY,
+	}
+}
+
+// mappingAnchor returns the server area an edge mapping refers to
+func (cm *ClientManager) mappingAnchor(monitors []*protocol.Monitor, serverBounds rect, monitorID string) (rect, bool) {
+	if monitorID == "*" {
+		return serverBounds, true
+	}
+	for _, monitor := range monitors {
+		if monitor.Name == monitorID || (monitorID == "primary" && monitor.Primary) {
+			return monitorRect(monitor), true
+		}
+	}
+	if monitorID == "primary" {
+		if main := cm.findMainMonitor(monitors); main != nil {
+			return monitorRect(main), true
+		}
+	}
+	return rect{}, false
+}
+
+// findLayoutClient finds an unplaced client with monitors that matches a host reference
+func findLayoutClient(clients []*ConnectedClient, placed map[string]bool, ref string, hosts []config.HostConfig) *ConnectedClient {
+	for _, client := range clients {
+		if placed[client.ID] || len(client.Monitors) == 0 {
+			continue
+		}
+		if clientMatches(client, ref, hosts) {
+			return client
+		}
+	}
+	return nil
+}
+
+// clientMatches reports whether a host reference names the client. The
+// reference may be a client name, a client address or the name of a [[hosts]] entry.
+func clientMatches(client *ConnectedClient, ref string, hosts []config.HostConfig) bool {
+	if ref == "" {
+		return false
+	}
+
+	refs := []string{ref}
+	for _, host := range hosts {
+		if host.Name == ref && host.Address != "" {
+			refs = append(refs, host.Address)
+		}
+	}
+
+	for _, r := range refs {
+		if client.ID == r || strings.EqualFold(client.Name, r) || client.Address == r ||
+			(addressHost(client.Address) != "" && addressHost(client.Address) == addressHost(r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// addressHost strips the port from an address
+func addressHost(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// parseEdge converts a configured edge or position name to an edge
+func parseEdge(name string) display.Edge {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "left":
+		return display.EdgeLeft
+	case "right":
+		return display.EdgeRight
+	case "top":
+		return display.EdgeTop
+	case "bottom":
+		return display.EdgeBottom
+	default:
+		return display.EdgeNone
+	}
+}
+
+// oppositeEdge returns the edge facing the given one
+func oppositeEdge(edge display.Edge) display.Edge {
+	switch edge {
+	case display.EdgeLeft:
+		return display.EdgeRight
+	case display.EdgeRight:
+		return display.EdgeLeft
+	case display.EdgeTop:
+		return display.EdgeBottom
+	case display.EdgeBottom:
+		return display.EdgeTop
+	default:
+		return display.EdgeNone
+	}
+}
+
+// crossedEdge returns the edge of r that the point (x, y) lies beyond
+func crossedEdge(r rect, x, y float64) display.Edge {
+	var edge display.Edge
+	var overshoot float64
+	if d := r.minX - x; d > overshoot {
+		edge, overshoot = display.EdgeLeft, d
+	}
+	if d := x - (r.maxX - 1); d > overshoot {
+		edge, overshoot = display.EdgeRight, d
+	}
+	if d := r.minY - y; d > overshoot {
+		edge, overshoot = display.EdgeTop, d
+	}
+	if d := y - (r.maxY - 1); d > overshoot {
+		edge = display.EdgeBottom
+	}
+	return edge
+}
+
+// clampToRect constrains a point to the pixels inside r
+func clampToRect(x, y float64, r rect) (float64, float64) {
+	if x < r.minX {
+		x = r.minX
+	} else if x > r.maxX-1 {
+		x = r.maxX - 1
+	}
+	if y < r.minY {
+		y = r.minY
+	} else if y > r.maxY-1 {
+		y = r.maxY - 1
+	}
+	return x, y
+}
+
+// monitorRect returns the bounds of a monitor
+func monitorRect(monitor *protocol.Monitor) rect {
+	return rect{
+		minX: float64(monitor.X),
+		minY: float64(monitor.Y),
+		maxX: float64(monitor.X + monitor.Width),
+		maxY: float64(monitor.Y + monitor.Height),
+	}
+}
+
+// exitRect returns the bounds of the monitor a cursor at (x, y) is leaving,
+// falling back to the given bounds when the point lies between monitors
+func exitRect(monitors []*protocol.Monitor, bounds rect, x, y float64) rect {
+	x, y = clampToRect(x, y, bounds)
+	if monitor := monitorAt(monitors, x, y); monitor != nil {
+		return monitorRect(monitor)
+	}
+	return bounds
+}
+
+// monitorAt returns the monitor containing the given point
+func monitorAt(monitors []*protocol.Monitor, x, y float64) *protocol.Monitor {
+	for _, monitor := range monitors {
+		r := monitorRect(monitor)
+		if x >= r.minX && x < r.maxX && y >= r.minY && y < r.maxY {
+			return monitor
+		}
+	}
+	return nil
+}
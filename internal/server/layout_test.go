@@ -0,0 +1,298 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/display"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+func TestBuildLayout(t *testing.T) {
+	cm := &ClientManager{}
+
+	serverMonitors := []*protocol.Monitor{
+		{Name: "DP-1", X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true},
+		{Name: "DP-2", X: 1920, Y: 0, Width: 2560, Height: 1440},
+	}
+	laptop := &ConnectedClient{
+		ID:       "10.0.0.2:40000",
+		Name:     "laptop",
+		Address:  "10.0.0.2:40000",
+		Monitors: []*protocol.Monitor{{Name: "eDP-1", X: 0, Y: 0, Width: 1920, Height: 1200}},
+	}
+	tablet := &ConnectedClient{
+		ID:       "10.0.0.3:40000",
+		Name:     "tablet",
+		Address:  "10.0.0.3:40000",
+		Monitors: []*protocol.Monitor{{Name: "DSI-1", X: 0, Y: 0, Width: 1280, Height: 800}},
+	}
+
+	tests := []struct {
+		name     string
+		hosts    []config.HostConfig
+		mappings []config.EdgeMapping
+		expected map[string]rect // client ID -> virtual bounds
+	}{
+		{
+			name:     "host on the right of the server",
+			hosts:    []config.HostConfig{{Name: "laptop", Position: "right"}},
+			expected: map[string]rect{laptop.ID: {minX: 4480, minY: 0, maxX: 6400, maxY: 1200}},
+		},
+		{
+			name:     "host matched by address",
+			hosts:    []config.HostConfig{{Name: "work", Address: "10.0.0.2:52525", Position: "left"}},
+			expected: map[string]rect{laptop.ID: {minX: -1920, minY: 0, maxX: 0, maxY: 1200}},
+		},
+		{
+			name: "hosts on the same side are chained",
+			hosts: []config.HostConfig{
+				{Name: "laptop", Position: "right"},
+				{Name: "tablet", Position: "right"},
+			},
+			expected: map[string]rect{
+				laptop.ID: {minX: 4480, minY: 0, maxX: 6400, maxY: 1200},
+				tablet.ID: {minX: 6400, minY: 0, maxX: 7680, maxY: 800},
+			},
+		},
+		{
+			name:     "edge mapping on a specific monitor",
+			mappings: []config.EdgeMapping{{MonitorID: "DP-1", Edge: "bottom", Host: "tablet"}},
+			expected: map[string]rect{tablet.ID: {minX: 0, minY: 1080, maxX: 1280, maxY: 1880}},
+		},
+		{
+			name:     "edge mapping takes precedence over host position",
+			hosts:    []config.HostConfig{{Name: "laptop", Position: "right"}},
+			mappings: []config.EdgeMapping{{MonitorID: "primary", Edge: "top", Host: "laptop"}},
+			expected: map[string]rect{laptop.ID: {minX: 0, minY: -1200, maxX: 1920, maxY: 0}},
+		},
+		{
+			name:     "unknown host is ignored",
+			hosts:    []config.HostConfig{{Name: "desktop", Position: "right"}},
+			expected: map[string]rect{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := cm.buildLayout(serverMonitors, []*ConnectedClient{laptop, tablet}, tt.hosts, tt.mappings)
+			if len(layout.placements) != len(tt.expected) {
+				t.Fatalf("buildLayout() placed %d clients, want %d", len(layout.placements), len(tt.expected))
+			}
+			for id, want := range tt.expected {
+				p := layout.placement(id)
+				if p == nil {
+					t.Fatalf("buildLayout() did not place client %s", id)
+				}
+				if got := p.virtualBounds(); got != want {
+					t.Errorf("virtualBounds(%s) = %+v, want %+v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutLeave(t *testing.T) {
+	cm := &ClientManager{}
+
+	serverMonitors := []*protocol.Monitor{
+		{Name: "DP-1", X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true},
+	}
+	laptop := &ConnectedClient{
+		ID:       "laptop",
+		Name:     "laptop",
+		Monitors: []*protocol.Monitor{{X: 0, Y: 0, Width: 1280, Height: 720}},
+	}
+	layout := cm.buildLayout(serverMonitors, []*ConnectedClient{laptop},
+		[]config.HostConfig{{Name: "laptop", Position: "right"}}, nil)
+	serverRect := monitorRect(serverMonitors[0])
+	laptopRect := rect{minX: 0, minY: 0, maxX: 1280, maxY: 720}
+
+	tests := []struct {
+		name     string
+		fromID   string
+		from     rect
+		edge     display.Edge
+		x, y     float64
+		ok       bool
+		expected layoutTarget
+	}{
+		{
+			name:     "server right edge enters the client",
+			from:     serverRect,
+			edge:     display.EdgeRight,
+			x:        1925,
+			y:        540,
+			ok:       true,
+			expected: layoutTarget{clientID: "laptop", x: 0, y: 360},
+		},
+		{
+			name:     "entry is scaled by monitor height",
+			from:     serverRect,
+			edge:     display.EdgeRight,
+			x:        1921,
+			y:        1000,
+			ok:       true,
+			expected: layoutTarget{clientID: "laptop", x: 0, y: 667},
+		},
+		{
+			name: "server left edge is not mapped",
+			from: serverRect,
+			edge: display.EdgeLeft,
+			x:    -3,
+			y:    300,
+		},
+		{
+			name:     "client left edge returns to the server",
+			fromID:   "laptop",
+			from:     laptopRect,
+			edge:     display.EdgeLeft,
+			x:        -2,
+			y:        500,
+			ok:       true,
+			expected: layoutTarget{x: 1919, y: 750},
+		},
+		{
+			name:   "client right edge leads nowhere",
+			fromID: "laptop",
+			from:   laptopRect,
+			edge:   display.EdgeRight,
+			x:      1285,
+			y:      500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := layout.leave(tt.fromID, tt.from, tt.edge, tt.x, tt.y)
+			if ok != tt.ok {
+				t.Fatalf("leave() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != tt.expected {
+				t.Errorf("leave() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLayoutLeaveChained(t *testing.T) {
+	cm := &ClientManager{}
+
+	serverMonitors := []*protocol.Monitor{
+		{Name: "DP-1", X: 0, Y: 0, Width: 1920, Height: 1080, Primary: true},
+	}
+	laptop := &ConnectedClient{
+		ID:       "laptop",
+		Name:     "laptop",
+		Monitors: []*protocol.Monitor{{X: 0, Y: 0, Width: 1280, Height: 720}},
+	}
+	tablet := &ConnectedClient{
+		ID:       "tablet",
+		Name:     "tablet",
+		Monitors: []*protocol.Monitor{{X: 0, Y: 0, Width: 800, Height: 1280}},
+	}
+	layout := cm.buildLayout(serverMonitors, []*ConnectedClient{laptop, tablet},
+		[]config.HostConfig{{Name: "laptop", Position: "right"}, {Name: "tablet", Position: "right"}}, nil)
+
+	tests := []struct {
+		name     string
+		fromID   string
+		from     rect
+		edge     display.Edge
+		x, y     float64
+		expected layoutTarget
+	}{
+		{
+			name:     "laptop right edge hops to the tablet",
+			fromID:   "laptop",
+			from:     rect{minX: 0, minY: 0, maxX: 1280, maxY: 720},
+			edge:     display.EdgeRight,
+			x:        1283,
+			y:        400,
+			expected: layoutTarget{clientID: "tablet", x: 0, y: 711},
+		},
+		{
+			name:     "tablet left edge returns to the laptop",
+			fromID:   "tablet",
+			from:     rect{minX: 0, minY: 0, maxX: 800, maxY: 1280},
+			edge:     display.EdgeLeft,
+			x:        -4,
+			y:        1000,
+			expected: layoutTarget{clientID: "laptop", x: 1279, y: 563},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := layout.leave(tt.fromID, tt.from, tt.edge, tt.x, tt.y)
+			if !ok {
+				t.Fatalf("leave() found no target")
+			}
+			if got != tt.expected {
+				t.Errorf("leave() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	stacked := []*protocol.Monitor{
+		{Name: "top", X: 0, Y: 0, Width: 1920, Height: 1080},
+		{Name: "bottom", X: 0, Y: 1080, Width: 1920, Height: 1080},
+	}
+	sideBySide := []*protocol.Monitor{
+		{Name: "left", X: 0, Y: 0, Width: 1920, Height: 1080},
+		{Name: "right", X: 1920, Y: 0, Width: 2560, Height: 1440},
+	}
+	all := rect{minX: -10000, minY: -10000, maxX: 10000, maxY: 10000}
+
+	tests := []struct {
+		name      string
+		monitors  []*protocol.Monitor
+		within    rect
+		side      display.Edge
+		fraction  float64
+		expectedX float64
+		expectedY float64
+	}{
+		{"left side spans stacked monitors", stacked, all, display.EdgeLeft, 0.75, 0, 1620},
+		{"right side uses outermost monitor", sideBySide, all, display.EdgeRight, 0.5, 4479, 720},
+		{"top side spans both monitors", sideBySide, all, display.EdgeTop, 0.25, 1120, 0},
+		{"bottom side uses the lowest monitor", sideBySide, all, display.EdgeBottom, 0.1, 2176, 1439},
+		{"within limits the target monitor", sideBySide, rect{minX: 0, minY: 0, maxX: 1920, maxY: 1080}, display.EdgeBottom, 0.5, 960, 1079},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := entryPoint(tt.monitors, tt.within, tt.side, tt.fraction)
+			if x != tt.expectedX || y != tt.expectedY {
+				t.Errorf("entryPoint() = (%v, %v), want (%v, %v)", x, y, tt.expectedX, tt.expectedY)
+			}
+		})
+	}
+}
+
+func TestCrossedEdge(t *testing.T) {
+	r := rect{minX: 0, minY: 0, maxX: 1920, maxY: 1080}
+
+	tests := []struct {
+		name     string
+		x, y     float64
+		expected display.Edge
+	}{
+		{"inside", 100, 100, display.EdgeNone},
+		{"left", -5, 100, display.EdgeLeft},
+		{"right", 1920, 100, display.EdgeRight},
+		{"top", 100, -1, display.EdgeTop},
+		{"bottom", 100, 1090, display.EdgeBottom},
+		{"corner prefers larger overshoot", 1925, -20, display.EdgeTop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crossedEdge(r, tt.x, tt.y); got != tt.expected {
+				t.Errorf("crossedEdge(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.expected)
+			}
+		})
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/bnema/waymon/internal/clipboard"
 	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/display"
 	"github.com/bnema/waymon/internal/input"
 	"github.com/bnema/waymon/internal/ipc"
 	"github.com/bnema/waymon/internal/logger"
@@ -36,11 +39,43 @@ type ClientManager struct {
 	// Cursor position tracking for each client
 	clientCursors map[string]*cursorState
 
+	// Keys and buttons held down on each client, released when control moves away
+	clientPressed map[string]*input.PressedState
+
+	// Virtual desktop used for screen edge switching
+	serverMonitors []*protocol.Monitor
+	layout         *screenLayout
+	localCursor    *cursorState // Server cursor, tracked from local relative motion
+	screenLocked   bool         // Edge switching suspended by the lock binding
+
+	// Places the server cursor where it comes back from a client, nil when the
+	// compositor offers no virtual pointer
+	pointerWarper *input.PointerWarper
+
+	// Clipboard shared with clients, and the selection serials last offered to each
+	clipboard     *clipboard.Clipboard
+	clipboardSent map[clipboardKey]uint64
+
 	// Emergency release cooldown
 	emergencyReleaseTime time.Time
 	emergencyCooldown    time.Duration
+
+	// Heartbeat sent to clients
+	pingSeq         uint64
+	pingSentAt      time.Time // When the ping numbered pingSeq went out
+	heartbeatCancel context.CancelFunc
+
+	// Clients remembered across reconnects, and the one that dropped while
+	// being controlled, which gets control back if it returns in time
+	registry  *clientRegistry
+	dropped   string
+	droppedAt time.Time
 }
 
+// controlResumeWindow is how long a client that dropped while being controlled
+// may take to reconnect and get control back
+const controlResumeWindow = time.Minute
+
 // cursorState tracks cursor position for a client
 type cursorState struct {
 	x, y   float64
@@ -55,15 +90,30 @@ type rect struct {
 
 // ConnectedClient represents a client that can receive input
 type ConnectedClient struct {
-	ID          string
+	ID          string // Key fingerprint and client ID, the same on every connection
 	Name        string
 	Address     string
+	Slot        int // Position in the client list, kept across reconnects
 	Status      protocol.ClientStatus
 	ConnectedAt time.Time
 
 	// Client configuration received on connect
-	Monitors     []*protocol.Monitor
-	Capabilities *protocol.ClientCapabilities
+	Monitors      []*protocol.Monitor
+	Capabilities  *protocol.ClientCapabilities
+	keymapSent    bool     // Keyboard layout already forwarded on this connection
+	features      []string // Agreed when the session opened
+	resumeControl bool     // Reconnected after dropping while controlled, takes control once configured
+
+	// Restrictions from the client's authorized_keys entry
+	reportedName string // Name the client gave itself, kept apart when Name is fixed
+	fixedName    bool   // Name set by waymon-name, not replaced by the reported one
+	noKeyboard   bool   // Only mouse input is forwarded
+
+	// Heartbeat state
+	lastPong     time.Time // Last answer to a ping, or when the client connected
+	unresponsive bool      // Timed out and being disconnected
+
+	stats *linkStats // Latency and throughput of the connection
 }
 
 // NewClientManager creates a new client manager for the server
@@ -77,10 +127,28 @@ func NewClientManager(inputBackend input.InputBackend) (*ClientManager, error) {
 		inputBackend:     inputBackend,
 		controllingLocal: true, // Start by controlling local system
 		clientCursors:    make(map[string]*cursorState),
+		clientPressed:    make(map[string]*input.PressedState),
+		clipboardSent:    make(map[clipboardKey]uint64),
+		registry:         &clientRegistry{records: make(map[string]*clientRecord)},
 		emergencyCooldown: 5 * time.Second, // 5 second cooldown after emergency release
 	}, nil
 }
 
+// LoadClientRegistry reads what is remembered about clients from path, and
+// keeps it up to date there. Without it clients are only remembered until
+// the server stops.
+func (cm *ClientManager) LoadClientRegistry(path string) error {
+	registry, err := loadClientRegistry(path)
+	if err != nil {
+		return err
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.registry = registry
+	return nil
+}
+
 // Start starts the client manager SSH server
 func (cm *ClientManager) Start(ctx context.Context, port int) error {
 	// Get config for SSH server
@@ -122,6 +190,11 @@ func (cm *ClientManager) Stop() error {
 
 	// Note: Client disconnections are handled by SSH server
 
+	for id := range cm.clients {
+		cm.rememberCursor(id)
+	}
+	cm.saveRegistry()
+
 	cm.clients = make(map[string]*ConnectedClient)
 	cm.activeClientID = ""
 	cm.controllingLocal = true
@@ -132,6 +205,12 @@ func (cm *ClientManager) Stop() error {
 
 // SwitchToClient switches input control to the specified client
 func (cm *ClientManager) SwitchToClient(clientID string) error {
+	return cm.switchToClientAt(clientID, nil)
+}
+
+// switchToClientAt switches input control to the specified client. When entry
+// is set the cursor is placed there instead of the center of the main monitor.
+func (cm *ClientManager) switchToClientAt(clientID string, entry *layoutTarget) error {
 	logger.Debugf("[SERVER-MANAGER] SwitchToClient called: clientID=%s", clientID)
 
 	cm.mu.Lock()
@@ -152,11 +231,18 @@ func (cm *ClientManager) SwitchToClient(clientID string) error {
 
 	logger.Debugf("[SERVER-MANAGER] Found client: name=%s, address=%s", client.Name, client.Address)
 
+	// Control moved on, a client that dropped no longer gets it back
+	cm.dropped = ""
+
 	// Update previous client status
 	if cm.activeClientID != "" {
 		if prevClient, exists := cm.clients[cm.activeClientID]; exists {
+			cm.releasePressed(prevClient)
 			prevClient.Status = protocol.ClientStatus_CLIENT_IDLE
 			logger.Debugf("[SERVER-MANAGER] Previous client %s status set to IDLE", prevClient.Name)
+
+			// It only offers its clipboard once it learns control moved on
+			cm.sendReleaseControl(prevClient)
 		}
 	}
 
@@ -187,6 +273,9 @@ func (cm *ClientManager) SwitchToClient(clientID string) error {
 			Type:     protocol.ControlEvent_REQUEST_CONTROL,
 			TargetId: serverName, // Send server name so client knows who's controlling
 		}
+		if locks, ok := cm.inputBackend.(interface{ LockedModifiers() uint32 }); ok {
+			controlEvent.LockedModifiers = locks.LockedModifiers()
+		}
 		inputEvent := &protocol.InputEvent{
 			Event: &protocol.InputEvent_Control{
 				Control: controlEvent,
@@ -196,38 +285,61 @@ func (cm *ClientManager) SwitchToClient(clientID string) error {
 		}
 
 		logger.Infof("[SERVER-MANAGER] Sending REQUEST_CONTROL event to client %s at %s", client.Name, client.Address)
-		if err := cm.sshServer.SendEventToClient(client.Address, inputEvent); err != nil {
+		if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
 			logger.Errorf("[SERVER-MANAGER] Failed to send control request to client: %v", err)
 		} else {
 			logger.Infof("[SERVER-MANAGER] Successfully sent control request to client %s", client.Name)
 		}
+		cm.offerClipboard(client)
 
-		// Position cursor at center of main monitor (monitor at 0,0)
-		if err := cm.positionCursorOnMainMonitor(client); err != nil {
-			logger.Warnf("[SERVER-MANAGER] Failed to position cursor on main monitor: %v", err)
-		}
-
-		// Initialize cursor state for this client
-		if len(client.Monitors) > 0 {
-			bounds := cm.calculateTotalDisplayBounds(client.Monitors)
-
-			// Find center position (same logic as positionCursorOnMainMonitor)
-			var centerX, centerY float64
-			if mainMonitor := cm.findMainMonitor(client.Monitors); mainMonitor != nil {
-				centerX = float64(mainMonitor.X + (mainMonitor.Width / 2))
-				centerY = float64(mainMonitor.Y + (mainMonitor.Height / 2))
-			} else {
-				// Fallback to center of total bounds
-				centerX = (bounds.minX + bounds.maxX) / 2
-				centerY = (bounds.minY + bounds.maxY) / 2
+		if entry != nil && len(client.Monitors) > 0 {
+			// Continue the movement where the cursor crossed into this client
+			if err := cm.positionCursorAt(client, int32(entry.x), int32(entry.y)); err != nil {
+				logger.Warnf("[SERVER-MANAGER] Failed to position cursor at entry point: %v", err)
 			}
-
 			cm.clientCursors[clientID] = &cursorState{
-				x:      centerX,
-				y:      centerY,
-				bounds: bounds,
+				x:      entry.x,
+				y:      entry.y,
+				bounds: cm.calculateTotalDisplayBounds(client.Monitors),
+			}
+			logger.Debugf("[SERVER-MANAGER] Initialized cursor state for client %s at entry point (%.0f,%.0f)",
+				client.Name, entry.x, entry.y)
+		} else if last, ok := cm.clientCursors[clientID]; ok && len(client.Monitors) > 0 && cm.switchPositionFor(client) == "last" {
+			// Put the cursor back where it was when this client was last controlled
+			last.bounds = cm.calculateTotalDisplayBounds(client.Monitors)
+			last.x, last.y = cm.constrainCursorPosition(last.x, last.y, last.bounds)
+			if err := cm.positionCursorAt(client, int32(last.x), int32(last.y)); err != nil {
+				logger.Warnf("[SERVER-MANAGER] Failed to restore last cursor position: %v", err)
+			}
+			logger.Debugf("[SERVER-MANAGER] Restored cursor for client %s at (%.0f,%.0f)", client.Name, last.x, last.y)
+		} else {
+			// Position cursor at center of main monitor (monitor at 0,0)
+			if err := cm.positionCursorOnMainMonitor(client); err != nil {
+				logger.Warnf("[SERVER-MANAGER] Failed to position cursor on main monitor: %v", err)
+			}
+
+			// Initialize cursor state for this client
+			if len(client.Monitors) > 0 {
+				bounds := cm.calculateTotalDisplayBounds(client.Monitors)
+
+				// Find center position (same logic as positionCursorOnMainMonitor)
+				var centerX, centerY float64
+				if mainMonitor := cm.findMainMonitor(client.Monitors); mainMonitor != nil {
+					centerX = float64(mainMonitor.X + (mainMonitor.Width / 2))
+					centerY = float64(mainMonitor.Y + (mainMonitor.Height / 2))
+				} else {
+					// Fallback to center of total bounds
+					centerX = (bounds.minX + bounds.maxX) / 2
+					centerY = (bounds.minY + bounds.maxY) / 2
+				}
+
+				cm.clientCursors[clientID] = &cursorState{
+					x:      centerX,
+					y:      centerY,
+					bounds: bounds,
+				}
+				logger.Debugf("[SERVER-MANAGER] Initialized cursor state for client %s", client.Name)
 			}
-			logger.Debugf("[SERVER-MANAGER] Initialized cursor state for client %s", client.Name)
 		}
 	} else {
 		logger.Error("[SERVER-MANAGER] No SSH server available to send control request")
@@ -248,6 +360,9 @@ func (cm *ClientManager) SwitchToLocal() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	// Staying local was asked for, a client that dropped does not take over
+	cm.dropped = ""
+
 	// Check if we're already controlling local
 	if cm.controllingLocal {
 		// Silently skip if already controlling local
@@ -257,27 +372,11 @@ func (cm *ClientManager) SwitchToLocal() error {
 	// Update previous client status and notify them
 	if cm.activeClientID != "" {
 		if prevClient, exists := cm.clients[cm.activeClientID]; exists {
+			cm.releasePressed(prevClient)
 			prevClient.Status = protocol.ClientStatus_CLIENT_IDLE
 
 			// Send release control event to previous client
-			if cm.sshServer != nil {
-				controlEvent := &protocol.ControlEvent{
-					Type:     protocol.ControlEvent_RELEASE_CONTROL,
-					TargetId: cm.activeClientID,
-				}
-				inputEvent := &protocol.InputEvent{
-					Event: &protocol.InputEvent_Control{
-						Control: controlEvent,
-					},
-					Timestamp: time.Now().UnixNano(),
-					SourceId:  "server",
-				}
-				if err := cm.sshServer.SendEventToClient(prevClient.Address, inputEvent); err != nil {
-					logger.Errorf("Failed to send control release to previous client: %v", err)
-				} else {
-					logger.Debugf("Sent control release to previous client %s", prevClient.Name)
-				}
-			}
+			cm.sendReleaseControl(prevClient)
 		}
 	}
 
@@ -303,10 +402,7 @@ func (cm *ClientManager) SwitchToLocal() error {
 // SwitchToNextClient switches to the next available client
 func (cm *ClientManager) SwitchToNextClient() error {
 	cm.mu.RLock()
-	clientIDs := make([]string, 0, len(cm.clients))
-	for id := range cm.clients {
-		clientIDs = append(clientIDs, id)
-	}
+	clientIDs := cm.clientIDsBySlot()
 	cm.mu.RUnlock()
 
 	if len(clientIDs) == 0 {
@@ -327,18 +423,35 @@ func (cm *ClientManager) SwitchToNextClient() error {
 	return cm.SwitchToClient(clientIDs[nextIndex])
 }
 
-// GetConnectedClients returns a list of connected clients
+// GetConnectedClients returns a list of connected clients in slot order
 func (cm *ClientManager) GetConnectedClients() []*ConnectedClient {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
 	clients := make([]*ConnectedClient, 0, len(cm.clients))
-	for _, client := range cm.clients {
-		clients = append(clients, client)
+	for _, id := range cm.clientIDsBySlot() {
+		clients = append(clients, cm.clients[id])
 	}
 	return clients
 }
 
+// clientIDsBySlot returns the IDs of the connected clients in slot order, the
+// order of the client list. Must be called with the lock held.
+func (cm *ClientManager) clientIDsBySlot() []string {
+	clientIDs := make([]string, 0, len(cm.clients))
+	for id := range cm.clients {
+		clientIDs = append(clientIDs, id)
+	}
+	sort.Slice(clientIDs, func(i, j int) bool {
+		a, b := cm.clients[clientIDs[i]], cm.clients[clientIDs[j]]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.ID < b.ID
+	})
+	return clientIDs
+}
+
 // GetActiveClient returns the currently controlled client
 func (cm *ClientManager) GetActiveClient() *ConnectedClient {
 	cm.mu.RLock()
@@ -359,6 +472,10 @@ func (cm *ClientManager) IsControllingLocal() bool {
 
 // HandleInputEvent processes input events and routes them to the appropriate target
 func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
+	if cm.handleLatencySample(event) || cm.handleClipboardEvent(event) {
+		return
+	}
+
 	// Handle control events specially
 	if controlEvent := event.GetControl(); controlEvent != nil {
 		logger.Debugf("[SERVER-MANAGER] handleInputEvent called: type=%T, timestamp=%d, sourceId=%s",
@@ -400,8 +517,15 @@ func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
 
 	logger.Debugf("[SERVER-MANAGER] Routing event to client: %s (%s)", client.Name, client.Address)
 
+	if client.noKeyboard {
+		if event = withoutKeyboard(event); event == nil {
+			logger.Debugf("[SERVER-MANAGER] Keyboard disabled for client %s, dropping key event", client.Name)
+			return
+		}
+	}
+
 	// Handle mouse move events with cursor constraints
-	if mouseMoveEvent := event.GetMouseMove(); mouseMoveEvent != nil {
+	if mouseMoveEvent := protocol.MotionOf(event); mouseMoveEvent != nil {
 		// Get or create cursor state for this client
 		cursor, exists := cm.clientCursors[cm.activeClientID]
 		if !exists || len(client.Monitors) == 0 {
@@ -432,15 +556,27 @@ func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
 
 			// Modify the event to reflect constrained movement
 			if hitBoundary {
-				// Only send event if there's actual movement
-				if actualDx == 0 && actualDy == 0 {
-					logger.Debugf("[SERVER-MANAGER] Mouse movement fully constrained, not sending event")
-					return
-				}
+				// Leaving through an edge that leads to the server or another client
+				edge := crossedEdge(cursor.bounds, newX, newY)
+				from := exitRect(client.Monitors, cursor.bounds, newX, newY)
+				if target, ok := cm.layout.leave(cm.activeClientID, from, edge, newX, newY); ok && !cm.screenLocked {
+					// The switch waits for the lock, so the buttons, keys and
+					// scrolling of the frame still reach this client first
+					go cm.switchAcrossEdge(cm.activeClientID, edge, target)
+					if event = withoutMotion(event); event == nil {
+						return
+					}
+				} else {
+					// Only send event if there's actual movement, the rest of a frame still goes
+					if actualDx == 0 && actualDy == 0 && event.GetFrame() == nil {
+						logger.Debugf("[SERVER-MANAGER] Mouse movement fully constrained, not sending event")
+						return
+					}
 
-				// Update the event with constrained movement
-				mouseMoveEvent.Dx = actualDx
-				mouseMoveEvent.Dy = actualDy
+					// Update the event with constrained movement
+					mouseMoveEvent.Dx = actualDx
+					mouseMoveEvent.Dy = actualDy
+				}
 			}
 		}
 	}
@@ -455,11 +591,19 @@ func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
 		}
 	}
 
+	// Remember what is held down so it can be released when control moves away
+	if pressed, exists := cm.clientPressed[cm.activeClientID]; exists {
+		pressed.Observe(event)
+	}
+
 	// Send input event to the client via SSH
 	if cm.sshServer != nil {
-		if err := cm.sshServer.SendEventToClient(client.Address, event); err != nil {
+		if err := cm.sshServer.SendEventToClient(client.ID, event); err != nil {
+			client.stats.eventDropped()
 			logger.Errorf("[SERVER-MANAGER] Failed to send input event to client %s: %v", cm.activeClientID, err)
 		} else {
+			client.stats.eventSent(time.Now())
+
 			// Log input activity with more user-friendly messages
 			eventType := "input"
 			switch event.Event.(type) {
@@ -471,6 +615,12 @@ func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
 				eventType = "mouse scroll"
 			case *protocol.InputEvent_Keyboard:
 				eventType = "keyboard"
+			case *protocol.InputEvent_Frame:
+				eventType = "mouse"
+			case *protocol.InputEvent_Gesture:
+				eventType = "touchpad gesture"
+			case *protocol.InputEvent_TabletTool:
+				eventType = "tablet"
 			}
 			message := fmt.Sprintf("Injecting %s input into %s (%s)", eventType, client.Name, client.Address)
 			logger.Debugf("[SERVER-MANAGER] %s", message)
@@ -499,6 +649,129 @@ func (cm *ClientManager) HandleInputEvent(event *protocol.InputEvent) {
 	}
 }
 
+// HandleLocalInputEvent tracks input that stays on the server while it controls
+// the local system, and switches to a client when the cursor crosses an edge
+// that the screen layout connects to that client.
+//
+// The server cursor is followed from relative motion, so compositor pointer
+// acceleration makes it drift. Pushing against any screen edge clamps the
+// tracked position back in line with the real cursor.
+func (cm *ClientManager) HandleLocalInputEvent(event *protocol.InputEvent) {
+	move := protocol.MotionOf(event)
+	if move == nil {
+		return
+	}
+
+	cm.mu.Lock()
+	if !cm.controllingLocal || cm.localCursor == nil || len(cm.serverMonitors) == 0 {
+		cm.mu.Unlock()
+		return
+	}
+
+	cursor := cm.localCursor
+	current := monitorAt(cm.serverMonitors, cursor.x, cursor.y)
+	if current == nil {
+		current = cm.findMainMonitor(cm.serverMonitors)
+	}
+	newX := cursor.x + move.Dx
+	newY := cursor.y + move.Dy
+
+	// Still on one of the server's monitors
+	if monitorAt(cm.serverMonitors, newX, newY) != nil {
+		cursor.x, cursor.y = newX, newY
+		cm.mu.Unlock()
+		return
+	}
+
+	from := monitorRect(current)
+	edge := crossedEdge(from, newX, newY)
+	target, ok := cm.layout.leave("", from, edge, newX, newY)
+	inCooldown := time.Since(cm.emergencyReleaseTime) < cm.emergencyCooldown
+	locked := cm.screenLocked
+
+	// Hold the cursor at the edge, the compositor does the same
+	cursor.x, cursor.y = clampToRect(newX, newY, from)
+	cm.mu.Unlock()
+
+	if !ok || inCooldown || locked {
+		return
+	}
+
+	logger.Infof("[SERVER-MANAGER] Cursor crossed %s edge of %s, switching to client %s",
+		edge.String(), current.Name, target.clientID)
+	if err := cm.switchToClientAt(target.clientID, &target); err != nil {
+		logger.Errorf("[SERVER-MANAGER] Failed to switch to client on edge crossing: %v", err)
+	}
+}
+
+// withoutKeyboard returns an event with its key events left out, nil if
+// nothing remains
+func withoutKeyboard(event *protocol.InputEvent) *protocol.InputEvent {
+	return withoutEvents(event, func(e *protocol.InputEvent) bool { return e.GetKeyboard() != nil })
+}
+
+// withoutMotion returns an event with its pointer motion left out, nil if
+// nothing remains
+func withoutMotion(event *protocol.InputEvent) *protocol.InputEvent {
+	return withoutEvents(event, func(e *protocol.InputEvent) bool { return e.GetMouseMove() != nil })
+}
+
+// withoutEvents returns an event or frame with the events matching drop left
+// out, nil if nothing remains
+func withoutEvents(event *protocol.InputEvent, drop func(*protocol.InputEvent) bool) *protocol.InputEvent {
+	frame := event.GetFrame()
+	if frame == nil {
+		if drop(event) {
+			return nil
+		}
+		return event
+	}
+
+	var events []*protocol.InputEvent
+	for _, e := range frame.Events {
+		if !drop(e) {
+			events = append(events, e)
+		}
+	}
+	switch len(events) {
+	case 0:
+		return nil
+	case 1:
+		return events[0]
+	case len(frame.Events):
+		return event
+	}
+	return protocol.NewFrame(events, event.Timestamp, event.SourceId)
+}
+
+// switchAcrossEdge hands control to the machine the cursor moved onto from a client's edge
+func (cm *ClientManager) switchAcrossEdge(fromID string, edge display.Edge, target layoutTarget) {
+	cm.mu.Lock()
+	if cm.controllingLocal || cm.activeClientID != fromID {
+		// An earlier event already moved control away from this client
+		cm.mu.Unlock()
+		return
+	}
+
+	if target.clientID == "" {
+		logger.Infof("[SERVER-MANAGER] Cursor left %s edge of client %s, returning to local", edge.String(), fromID)
+		if err := cm.switchToLocalInternal(); err != nil {
+			logger.Errorf("[SERVER-MANAGER] Failed to return to local on edge crossing: %v", err)
+		} else {
+			cm.warpLocalCursor(target.x, target.y)
+		}
+		cm.mu.Unlock()
+		return
+	}
+	cm.mu.Unlock()
+
+	logger.Infof("[SERVER-MANAGER] Cursor left %s edge of client %s, switching to client %s",
+		edge.String(), fromID, target.clientID)
+	if err := cm.switchToClientAt(target.clientID, &target); err != nil {
+		logger.Errorf("[SERVER-MANAGER] Failed to switch to client on edge crossing: %v", err)
+	}
+}
+
 // handleControlEvent processes control events from clients
 func (cm *ClientManager) handleControlEvent(controlEvent *protocol.ControlEvent, sourceID string) {
 	switch controlEvent.Type {
@@ -522,6 +795,8 @@ func (cm *ClientManager) handleControlEvent(controlEvent *protocol.ControlEvent,
 		if err := cm.SwitchToClient(sourceID); err != nil {
 			logger.Errorf("Failed to grant control to client %s: %v", sourceID, err)
 		}
+	case protocol.ControlEvent_PONG:
+		cm.handlePong(controlEvent, sourceID)
 	case protocol.ControlEvent_RELEASE_CONTROL:
 		logger.Infof("Client %s released control", sourceID)
 		// Release control and switch back to local
@@ -546,22 +821,24 @@ func (cm *ClientManager) updateClientConfiguration(config *protocol.ClientConfig
 	}
 
 	// Find the client by multiple methods:
-	// 1. Exact ID match
-	// 2. Exact name match
-	// 3. Address-based match (since registration uses address as ID)
-	// 4. Source ID match (the SSH session ID)
+	// 1. Source ID match (the SSH server marks events with the client ID)
+	// 2. Exact ID match
+	// 3. Exact name match
+	// 4. Address-based match
 	// 5. If only one client connected, assume it's that client
-	var targetClient *ConnectedClient
-
-	// Try exact matches first
-	for id, client := range cm.clients {
-		// Check if the client ID matches the config ID, name, or source ID
-		if client.ID == config.ClientId || client.Name == config.ClientName ||
-			id == config.ClientId || id == sourceID || client.Address == sourceID {
-			targetClient = client
-			logger.Debugf("[SERVER-MANAGER] Found client by match: id=%s, name=%s, address=%s",
-				client.ID, client.Name, client.Address)
-			break
+	targetClient := cm.clients[sourceID]
+
+	// Then the other exact matches
+	if targetClient == nil {
+		for id, client := range cm.clients {
+			// Check if the client ID matches the config ID, name, or source ID
+			if client.ID == config.ClientId || client.Name == config.ClientName || client.reportedName == config.ClientName ||
+				id == config.ClientId || client.Address == sourceID {
+				targetClient = client
+				logger.Debugf("[SERVER-MANAGER] Found client by match: id=%s, name=%s, address=%s",
+					client.ID, client.Name, client.Address)
+				break
+			}
 		}
 	}
 
@@ -580,10 +857,22 @@ func (cm *ClientManager) updateClientConfiguration(config *protocol.ClientConfig
 		targetClient.Capabilities = config.Capabilities
 
 		// Update name to use the client-provided name instead of address
-		if config.ClientName != "" && targetClient.Name != config.ClientName {
+		targetClient.reportedName = config.ClientName
+		if config.ClientName != "" && targetClient.Name != config.ClientName && !targetClient.fixedName {
 			logger.Debugf("[SERVER-MANAGER] Updating client name from '%s' to '%s'", targetClient.Name, config.ClientName)
 			targetClient.Name = config.ClientName
 		}
+		if rec := cm.registry.lookup(targetClient.ID); rec != nil && config.ClientName != "" && rec.Name != config.ClientName {
+			rec.Name = config.ClientName
+			cm.saveRegistry()
+		}
+
+		cm.rebuildLayout()
+
+		if !targetClient.keymapSent && !targetClient.noKeyboard && targetClient.supports(protocol.FeatureKeymap) {
+			targetClient.keymapSent = true
+			go cm.sendKeymap(*targetClient)
+		}
 
 		logger.Infof("[SERVER-MANAGER] Updated client configuration for %s: %d monitors, compositor: %s",
 			targetClient.Name, len(config.Monitors), config.Capabilities.WaylandCompositor)
@@ -610,6 +899,12 @@ func (cm *ClientManager) updateClientConfiguration(config *protocol.ClientConfig
 				logger.Debugf("[SERVER-MANAGER] Updated cursor bounds for active client %s", targetClient.Name)
 			}
 		}
+
+		// The client knows its monitors now, hand back the control it lost
+		if targetClient.resumeControl {
+			targetClient.resumeControl = false
+			go cm.resumeControl(targetClient.ID)
+		}
 	} else {
 		logger.Warnf("[SERVER-MANAGER] Received client config from unknown client: %s (source: %s)", config.ClientName, sourceID)
 		logger.Warnf("[SERVER-MANAGER] Available clients: %v", func() []string {
@@ -622,76 +917,200 @@ func (cm *ClientManager) updateClientConfiguration(config *protocol.ClientConfig
 	}
 }
 
-// RegisterClient registers a new client connection
+// supports reports whether the client agreed on a feature
+func (c *ConnectedClient) supports(feature string) bool {
+	return slices.Contains(c.features, feature)
+}
+
+// RegisterClient registers a new client connection. A client seen before gets
+// back its slot, name and cursor, and the control it had if it dropped while
+// being controlled.
 func (cm *ClientManager) RegisterClient(id, name, address string) {
 	logger.Debugf("[SERVER-MANAGER] RegisterClient called: id=%s, name=%s, address=%s", id, name, address)
 
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
+	if previous, exists := cm.clients[id]; exists {
+		// The client reconnected before its previous session ended. Anything
+		// held down there was released by the client itself.
+		logger.Infof("[SERVER-MANAGER] Client %s reconnected from %s, replacing its session from %s", previous.Name, address, previous.Address)
+		delete(cm.clientPressed, id)
+		cm.forgetClient(previous)
+	}
+
+	rec := cm.registry.record(id)
+	rec.LastSeen = time.Now()
+	if rec.Name != "" {
+		name = rec.Name
+	}
+
 	client := &ConnectedClient{
 		ID:          id,
 		Name:        name,
 		Address:     address,
+		Slot:        rec.Slot,
 		Status:      protocol.ClientStatus_CLIENT_IDLE,
 		ConnectedAt: time.Now(),
+		lastPong:    time.Now(),
+		stats:       &linkStats{},
+	}
+
+	// Apply the options of the key the client authenticated with
+	if cm.sshServer != nil {
+		client.features = cm.sshServer.ClientFeatures(id)
+		if opts := cm.sshServer.ClientKeyOptions(id); opts != nil {
+			if opts.ClientName != "" {
+				client.Name = opts.ClientName
+				client.fixedName = true
+			}
+			client.noKeyboard = opts.NoKeyboard
+		}
+	}
+
+	// Pick up where the client was
+	if rec.Cursor != nil {
+		cm.clientCursors[id] = &cursorState{x: rec.Cursor.X, y: rec.Cursor.Y}
+	}
+	if cm.dropped == id && cm.controllingLocal && time.Since(cm.droppedAt) < controlResumeWindow {
+		client.resumeControl = true
+		cm.dropped = ""
 	}
 
 	cm.clients[id] = client
-	logger.Infof("[SERVER-MANAGER] Registered client: %s (%s) from %s", name, id, address)
+	cm.clientPressed[id] = input.NewPressedState()
+	cm.rebuildLayout()
+	cm.saveRegistry()
+	logger.Infof("[SERVER-MANAGER] Registered client: %s (%s) from %s in slot %d", client.Name, id, address, client.Slot)
 	logger.Debugf("[SERVER-MANAGER] Total clients: %d", len(cm.clients))
 
 	// Notify UI if callback is set
 	if cm.onActivity != nil {
 		// Force a UI refresh by sending an activity notification
 		// The UI will refresh its client list when it receives this
-		cm.onActivity("INFO", fmt.Sprintf("Client connected: %s (%s)", name, address))
+		cm.onActivity("INFO", fmt.Sprintf("Client connected: %s (%s)", client.Name, address))
 	}
 }
 
-// UnregisterClient removes a client connection
-func (cm *ClientManager) UnregisterClient(id string) {
+// UnregisterClient removes a client connection. The address tells the session
+// that ended apart from a newer one of the same client.
+func (cm *ClientManager) UnregisterClient(id, address string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
 	client, exists := cm.clients[id]
-	if !exists {
+	if !exists || client.Address != address {
 		return
 	}
 
+	cm.forgetClient(client)
+	cm.rebuildLayout()
+
+	logger.Infof("Unregistered client: %s (%s)", client.Name, id)
+
+	// Notify UI if callback is set
+	if cm.onActivity != nil {
+		// Force a UI refresh by sending an activity notification
+		// The UI will refresh its client list when it receives this
+		cm.onActivity("INFO", fmt.Sprintf("Client disconnected: %s (%s)", client.Name, client.Address))
+	}
+}
+
+// forgetClient removes a client whose session ended, returning control to the
+// server if it had it and remembering where its cursor was. Must be called
+// with the lock held.
+func (cm *ClientManager) forgetClient(client *ConnectedClient) {
+	id := client.ID
+
 	// If this was the active client, switch to local and release input
 	if cm.activeClientID == id {
 		logger.Infof("[SERVER-MANAGER] Active client %s disconnected, switching to local", client.Name)
-		
+
+		// The session may still be draining, try to lift anything held down
+		cm.releasePressed(client)
+
 		// Release input capture
 		if cm.inputBackend != nil {
 			if err := cm.inputBackend.SetTarget(""); err != nil {
 				logger.Errorf("[SERVER-MANAGER] Failed to release input on client disconnect: %v", err)
 			}
 		}
-		
+
 		cm.activeClientID = ""
 		cm.controllingLocal = true
-		
+		cm.dropped, cm.droppedAt = id, time.Now()
+
 		// Send notification to UI if available
 		if cm.onActivity != nil {
 			cm.onActivity("WARN", fmt.Sprintf("Client %s disconnected - control returned to local", client.Name))
 		}
 	}
 
+	cm.rememberCursor(id)
+	cm.saveRegistry()
+
 	// Remove client
 	delete(cm.clients, id)
 
-	// Clean up cursor state
+	// Clean up cursor and pressed state
 	delete(cm.clientCursors, id)
+	delete(cm.clientPressed, id)
+	delete(cm.clipboardSent, clipboardKey{clientID: id})
+	delete(cm.clipboardSent, clipboardKey{clientID: id, primary: true})
+}
 
-	logger.Infof("Unregistered client: %s (%s)", client.Name, id)
+// resumeControl gives control back to a client that reconnected after
+// dropping while it was being controlled, with the cursor where it was
+func (cm *ClientManager) resumeControl(clientID string) {
+	cm.mu.RLock()
+	client, exists := cm.clients[clientID]
+	if !exists || !cm.controllingLocal {
+		// Gone again, or control was taken in the meantime
+		cm.mu.RUnlock()
+		return
+	}
+	var entry *layoutTarget
+	if cursor, ok := cm.clientCursors[clientID]; ok && len(client.Monitors) > 0 {
+		x, y := cm.constrainCursorPosition(cursor.x, cursor.y, cm.calculateTotalDisplayBounds(client.Monitors))
+		entry = &layoutTarget{clientID: clientID, x: x, y: y}
+	}
+	cm.mu.RUnlock()
 
-	// Notify UI if callback is set
-	if cm.onActivity != nil {
-		// Force a UI refresh by sending an activity notification
-		// The UI will refresh its client list when it receives this
-		cm.onActivity("INFO", fmt.Sprintf("Client disconnected: %s (%s)", client.Name, client.Address))
+	logger.Infof("[SERVER-MANAGER] Client %s is back, resuming control", client.Name)
+	if err := cm.switchToClientAt(clientID, entry); err != nil {
+		logger.Errorf("[SERVER-MANAGER] Failed to resume control of client %s: %v", client.Name, err)
+	}
+}
+
+// rememberCursor records the cursor position of a client for its next
+// connection. Must be called with the lock held.
+func (cm *ClientManager) rememberCursor(clientID string) {
+	cursor, exists := cm.clientCursors[clientID]
+	rec := cm.registry.lookup(clientID)
+	if !exists || rec == nil {
+		return
+	}
+	rec.Cursor = &savedCursor{X: cursor.x, Y: cursor.y}
+	rec.LastSeen = time.Now()
+}
+
+// SaveClientRegistry records where the cursor of every connected client is,
+// for their next connection after the server restarts
+func (cm *ClientManager) SaveClientRegistry() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	for id := range cm.clients {
+		cm.rememberCursor(id)
+	}
+	cm.saveRegistry()
+}
+
+// saveRegistry writes the client records, a failure only costing what is
+// remembered. Must be called with the lock held.
+func (cm *ClientManager) saveRegistry() {
+	if err := cm.registry.save(); err != nil {
+		logger.Warnf("[SERVER-MANAGER] Failed to save client state: %v", err)
 	}
 }
 
@@ -702,6 +1121,50 @@ func (cm *ClientManager) SetOnActivity(callback func(level, message string)) {
 	cm.onActivity = callback
 }
 
+// SetServerMonitors sets the server's own monitors, used as the origin of the screen layout
+func (cm *ClientManager) SetServerMonitors(monitors []*protocol.Monitor) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.serverMonitors = monitors
+	cm.localCursor = nil
+	if mainMonitor := cm.findMainMonitor(monitors); mainMonitor != nil {
+		cm.localCursor = &cursorState{
+			x:      float64(mainMonitor.X + mainMonitor.Width/2),
+			y:      float64(mainMonitor.Y + mainMonitor.Height/2),
+			bounds: cm.calculateTotalDisplayBounds(monitors),
+		}
+	}
+	cm.rebuildLayout()
+}
+
+// rebuildLayout recomputes the screen layout, callers must hold the lock
+func (cm *ClientManager) rebuildLayout() {
+	cfg := config.Get()
+	if !cfg.Server.EdgeSwitching || len(cm.serverMonitors) == 0 {
+		cm.layout = nil
+		return
+	}
+
+	clients := make([]*ConnectedClient, 0, len(cm.clients))
+	for _, client := range cm.clients {
+		clients = append(clients, client)
+	}
+	// Sort for consistent placement when several clients match
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Slot != clients[j].Slot {
+			return clients[i].Slot < clients[j].Slot
+		}
+		return clients[i].ID < clients[j].ID
+	})
+
+	cm.layout = cm.buildLayout(cm.serverMonitors, clients, cfg.Hosts, cfg.Client.EdgeMappings)
+	for _, p := range cm.layout.placements {
+		logger.Debugf("[SERVER-MANAGER] Layout: client %s on %s edge at offset (%.0f,%.0f)",
+			p.clientID, p.edge.String(), p.offsetX, p.offsetY)
+	}
+}
+
 // SetSSHServer sets the SSH server for sending events to clients
 func (cm *ClientManager) SetSSHServer(sshServer *network.SSHServer) {
 	cm.mu.Lock()
@@ -714,6 +1177,7 @@ func (cm *ClientManager) MarkEmergencyRelease() {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 	cm.emergencyReleaseTime = time.Now()
+	cm.dropped = ""
 	logger.Infof("[SERVER-MANAGER] Emergency release marked - cooldown period: %v", cm.emergencyCooldown)
 }
 
@@ -741,7 +1205,7 @@ func (cm *ClientManager) NotifyShutdown() {
 
 	// Send to all connected clients
 	for _, client := range cm.clients {
-		if err := cm.sshServer.SendEventToClient(client.Address, inputEvent); err != nil {
+		if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
 			logger.Errorf("Failed to send shutdown notification to client %s: %v", client.Name, err)
 		} else {
 			logger.Infof("Sent shutdown notification to client %s (%s)", client.Name, client.Address)
@@ -755,6 +1219,51 @@ func (cm *ClientManager) NotifyShutdown() {
 	}
 }
 
+// sendReleaseControl tells a client it is no longer being controlled. Must be
+// called with the lock held.
+func (cm *ClientManager) sendReleaseControl(client *ConnectedClient) {
+	if cm.sshServer == nil {
+		return
+	}
+
+	inputEvent := &protocol.InputEvent{
+		Event: &protocol.InputEvent_Control{
+			Control: &protocol.ControlEvent{
+				Type:     protocol.ControlEvent_RELEASE_CONTROL,
+				TargetId: client.ID,
+			},
+		},
+		Timestamp: time.Now().UnixNano(),
+		SourceId:  "server",
+	}
+	if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
+		logger.Errorf("Failed to send control release to previous client: %v", err)
+	} else {
+		logger.Debugf("Sent control release to previous client %s", client.Name)
+	}
+}
+
+// releasePressed sends a release for every key and button still held down on
+// a client. Must be called with the lock held.
+func (cm *ClientManager) releasePressed(client *ConnectedClient) {
+	pressed, exists := cm.clientPressed[client.ID]
+	if !exists || !pressed.Any() {
+		return
+	}
+
+	events := pressed.Release("server")
+	if cm.sshServer == nil {
+		return
+	}
+	for _, event := range events {
+		if err := cm.sshServer.SendEventToClient(client.ID, event); err != nil {
+			logger.Warnf("[SERVER-MANAGER] Failed to release held input on client %s: %v", client.Name, err)
+			return
+		}
+	}
+	logger.Infof("[SERVER-MANAGER] Released %d held keys/buttons/tools on client %s", len(events), client.Name)
+}
+
 // positionCursorOnMainMonitor positions the cursor at the center of the main monitor (monitor at 0,0)
 func (cm *ClientManager) positionCursorOnMainMonitor(client *ConnectedClient) error {
 	if len(client.Monitors) == 0 {
@@ -791,29 +1300,47 @@ func (cm *ClientManager) positionCursorOnMainMonitor(client *ConnectedClient) er
 	centerX := mainMonitor.X + (mainMonitor.Width / 2)
 	centerY := mainMonitor.Y + (mainMonitor.Height / 2)
 
-	// Create cursor position event
-	positionEvent := &protocol.MousePositionEvent{
-		X: centerX,
-		Y: centerY,
+	if err := cm.positionCursorAt(client, centerX, centerY); err != nil {
+		return err
 	}
 
+	logger.Infof("Positioned cursor at center of main monitor: %s (%dx%d at %d,%d) -> cursor at (%d,%d)",
+		mainMonitor.Name, mainMonitor.Width, mainMonitor.Height,
+		mainMonitor.X, mainMonitor.Y, centerX, centerY)
+
+	return nil
+}
+
+// switchPositionFor returns where the cursor goes when switching to a client
+// without crossing an edge: "center" of the main monitor or its "last" position
+func (cm *ClientManager) switchPositionFor(client *ConnectedClient) string {
+	cfg := config.Get()
+	for _, host := range cfg.Hosts {
+		if host.SwitchPosition != "" && clientMatches(client, host.Name, cfg.Hosts) {
+			return host.SwitchPosition
+		}
+	}
+	return cfg.Server.SwitchPosition
+}
+
+// positionCursorAt moves the client's cursor to an absolute position
+func (cm *ClientManager) positionCursorAt(client *ConnectedClient, x, y int32) error {
 	inputEvent := &protocol.InputEvent{
 		Event: &protocol.InputEvent_MousePosition{
-			MousePosition: positionEvent,
+			MousePosition: &protocol.MousePositionEvent{
+				X: x,
+				Y: y,
+			},
 		},
 		Timestamp: time.Now().UnixNano(),
 		SourceId:  "server",
 	}
 
 	// Send the positioning event to the client
-	if err := cm.sshServer.SendEventToClient(client.Address, inputEvent); err != nil {
+	if err := cm.sshServer.SendEventToClient(client.ID, inputEvent); err != nil {
 		return fmt.Errorf("failed to send cursor position event: %w", err)
 	}
 
-	logger.Infof("Positioned cursor at center of main monitor: %s (%dx%d at %d,%d) -> cursor at (%d,%d)",
-		mainMonitor.Name, mainMonitor.Width, mainMonitor.Height,
-		mainMonitor.X, mainMonitor.Y, centerX, centerY)
-
 	return nil
 }
 
@@ -949,14 +1476,8 @@ func (cm *ClientManager) HandleStatusQuery(query *pb.StatusQuery) (*pb.IPCMessag
 	computerNames := []string{"server"} // Server is always index 0
 	currentIndex := int32(0)            // Default to server
 
-	// Get all connected clients
-	clientIDs := make([]string, 0, len(cm.clients))
-	for id := range cm.clients {
-		clientIDs = append(clientIDs, id)
-	}
-
-	// Sort client IDs for consistent ordering
-	sort.Strings(clientIDs)
+	// Get all connected clients, in slot order
+	clientIDs := cm.clientIDsBySlot()
 	for i, id := range clientIDs {
 		client := cm.clients[id]
 		computerNames = append(computerNames, client.Name)
@@ -976,7 +1497,7 @@ func (cm *ClientManager) HandleStatusQuery(query *pb.StatusQuery) (*pb.IPCMessag
 	// Server host is ourselves
 	serverHost := "localhost"
 
-	return ipc.NewStatusResponseMessage(
+	msg, err := ipc.NewStatusResponseMessage(
 		active,
 		connected,
 		serverHost,
@@ -984,6 +1505,11 @@ func (cm *ClientManager) HandleStatusQuery(query *pb.StatusQuery) (*pb.IPCMessag
 		int32(len(computerNames)), //nolint:gosec // computer count conversion is safe
 		computerNames,
 	)
+	if err != nil {
+		return nil, err
+	}
+	msg.GetStatusResponse().ClientStats = cm.clientStatsMessages(clientIDs)
+	return msg, nil
 }
 
 // switchToNextClientOrLocal switches to the next client in rotation, or to local if only one client
@@ -991,13 +1517,8 @@ func (cm *ClientManager) switchToNextClientOrLocal() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	// Get sorted list of client IDs
-	clientIDs := make([]string, 0, len(cm.clients))
-	for id := range cm.clients {
-		clientIDs = append(clientIDs, id)
-	}
-	// Sort for consistent ordering
-	sort.Strings(clientIDs)
+	// Get client IDs in slot order
+	clientIDs := cm.clientIDsBySlot()
 
 	if len(clientIDs) == 0 {
 		// No clients, stay on local
@@ -1046,13 +1567,8 @@ func (cm *ClientManager) switchToPreviousClientOrLocal() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	// Get sorted list of client IDs
-	clientIDs := make([]string, 0, len(cm.clients))
-	for id := range cm.clients {
-		clientIDs = append(clientIDs, id)
-	}
-	// Sort for consistent ordering
-	sort.Strings(clientIDs)
+	// Get client IDs in slot order
+	clientIDs := cm.clientIDsBySlot()
 
 	if len(clientIDs) == 0 {
 		// No clients, stay on local
@@ -1100,27 +1616,11 @@ func (cm *ClientManager) switchToLocalInternal() error {
 	// Update previous client status and notify them
 	if cm.activeClientID != "" {
 		if prevClient, exists := cm.clients[cm.activeClientID]; exists {
+			cm.releasePressed(prevClient)
 			prevClient.Status = protocol.ClientStatus_CLIENT_IDLE
 
 			// Send release control event to previous client
-			if cm.sshServer != nil {
-				controlEvent := &protocol.ControlEvent{
-					Type:     protocol.ControlEvent_RELEASE_CONTROL,
-					TargetId: cm.activeClientID,
-				}
-				inputEvent := &protocol.InputEvent{
-					Event: &protocol.InputEvent_Control{
-						Control: controlEvent,
-					},
-					Timestamp: time.Now().UnixNano(),
-					SourceId:  "server",
-				}
-				if err := cm.sshServer.SendEventToClient(prevClient.Address, inputEvent); err != nil {
-					logger.Errorf("Failed to send control release to previous client: %v", err)
-				} else {
-					logger.Debugf("Sent control release to previous client %s", prevClient.Name)
-				}
-			}
+			cm.sendReleaseControl(prevClient)
 		}
 	}
 
@@ -185,3 +185,25 @@ func TestFindMainMonitor(t *testing.T) {
 		})
 	}
 }
+
+func TestWithoutMotion(t *testing.T) {
+	move := &protocol.InputEvent{Event: &protocol.InputEvent_MouseMove{MouseMove: &protocol.MouseMoveEvent{Dx: 5}}}
+	click := &protocol.InputEvent{Event: &protocol.InputEvent_MouseButton{MouseButton: &protocol.MouseButtonEvent{Button: 1, Pressed: true}}}
+	scroll := &protocol.InputEvent{Event: &protocol.InputEvent_MouseScroll{MouseScroll: &protocol.MouseScrollEvent{Dy: 1}}}
+
+	if got := withoutMotion(move); got != nil {
+		t.Errorf("withoutMotion(move) = %v, want nil", got)
+	}
+	if got := withoutMotion(click); got != click {
+		t.Errorf("withoutMotion(click) = %v, want the click", got)
+	}
+
+	// A click crossing an edge with the motion is kept
+	if got := withoutMotion(protocol.NewFrame([]*protocol.InputEvent{move, click}, 1, "mouse")); got != click {
+		t.Errorf("withoutMotion(move, click) = %v, want the click", got)
+	}
+	got := withoutMotion(protocol.NewFrame([]*protocol.InputEvent{move, scroll, click}, 1, "mouse"))
+	if frame := got.GetFrame(); frame == nil || len(frame.Events) != 2 || frame.Events[0] != scroll || frame.Events[1] != click {
+		t.Errorf("withoutMotion(move, scroll, click) = %v, want a frame of the scroll and click", got)
+	}
+}
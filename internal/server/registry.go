@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// clientRecord is what the server remembers about a client between connections
+type clientRecord struct {
+	Name     string       `json:"name,omitempty"`   // Name the client last reported
+	Slot     int          `json:"slot"`             // Position in the client list, from 1
+	Cursor   *savedCursor `json:"cursor,omitempty"` // Where the cursor was when control last left it
+	LastSeen time.Time    `json:"last_seen"`
+}
+
+// savedCursor is a cursor position in the client's desktop coordinates
+type savedCursor struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// clientRegistry keeps the records of every client seen, keyed by client ID,
+// in a JSON file. The ClientManager lock guards it. A nil registry remembers
+// nothing.
+type clientRegistry struct {
+	path    string // Empty keeps the records in memory only
+	records map[string]*clientRecord
+}
+
+// loadClientRegistry reads the records saved at path, a missing file being an
+// empty registry
+func loadClientRegistry(path string) (*clientRegistry, error) {
+	r := &clientRegistry{path: path, records: make(map[string]*clientRecord)}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from the server configuration
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client state: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.records); err != nil {
+		return nil, fmt.Errorf("failed to parse client state %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// lookup returns the record of a client, or nil if it was never seen
+func (r *clientRegistry) lookup(id string) *clientRecord {
+	if r == nil {
+		return nil
+	}
+	return r.records[id]
+}
+
+// record returns the record of a client, giving a client seen for the first
+// time the slot after the last one taken
+func (r *clientRegistry) record(id string) *clientRecord {
+	if r == nil {
+		return &clientRecord{}
+	}
+	if rec, ok := r.records[id]; ok {
+		return rec
+	}
+
+	slot := 0
+	for _, rec := range r.records {
+		slot = max(slot, rec.Slot)
+	}
+	rec := &clientRecord{Slot: slot + 1}
+	r.records[id] = rec
+	return rec
+}
+
+// save writes the records back to the file
+func (r *clientRegistry) save() error {
+	if r == nil || r.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode client state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return fmt.Errorf("failed to create client state directory: %w", err)
+	}
+
+	// Replace the file in one step so an interrupted write keeps the old records
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write client state: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to replace client state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+func TestClientRegistrySlots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clients.json")
+	registry, err := loadClientRegistry(path)
+	if err != nil {
+		t.Fatalf("loadClientRegistry() on a missing file failed: %v", err)
+	}
+
+	registry.record("SHA256:aaa/laptop").Name = "laptop"
+	registry.record("SHA256:bbb/desktop")
+	registry.record("SHA256:aaa/laptop").Cursor = &savedCursor{X: 640, Y: 480}
+	if err := registry.save(); err != nil {
+		t.Fatalf("save() failed: %v", err)
+	}
+
+	reloaded, err := loadClientRegistry(path)
+	if err != nil {
+		t.Fatalf("loadClientRegistry() failed: %v", err)
+	}
+	laptop := reloaded.lookup("SHA256:aaa/laptop")
+	if laptop == nil || laptop.Slot != 1 || laptop.Name != "laptop" || laptop.Cursor == nil || *laptop.Cursor != (savedCursor{X: 640, Y: 480}) {
+		t.Errorf("laptop record = %+v, want slot 1 named laptop with its cursor", laptop)
+	}
+	if desktop := reloaded.lookup("SHA256:bbb/desktop"); desktop == nil || desktop.Slot != 2 {
+		t.Errorf("desktop record = %+v, want slot 2", desktop)
+	}
+	if tablet := reloaded.record("SHA256:ccc/tablet"); tablet.Slot != 3 {
+		t.Errorf("new client got slot %d, want 3", tablet.Slot)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	const laptop, desktop = "SHA256:aaa/laptop", "SHA256:bbb/desktop"
+	monitors := []*protocol.Monitor{{Name: "eDP-1", Width: 1920, Height: 1080, Primary: true}}
+
+	backend := &targetRecorder{}
+	cm, err := NewClientManager(backend)
+	if err != nil {
+		t.Fatalf("NewClientManager() failed: %v", err)
+	}
+	if err := cm.LoadClientRegistry(filepath.Join(t.TempDir(), "clients.json")); err != nil {
+		t.Fatalf("LoadClientRegistry() failed: %v", err)
+	}
+
+	cm.RegisterClient(laptop, "10.0.0.2:41000", "10.0.0.2:41000")
+	cm.RegisterClient(desktop, "10.0.0.3:41000", "10.0.0.3:41000")
+	if err := cm.SwitchToClient(laptop); err != nil {
+		t.Fatalf("SwitchToClient() failed: %v", err)
+	}
+	cm.clientCursors[laptop] = &cursorState{x: 300, y: 200}
+
+	// The laptop drops while controlled and comes back from another port
+	cm.UnregisterClient(laptop, "10.0.0.2:41000")
+	if !cm.IsControllingLocal() || backend.target != "" {
+		t.Fatalf("Control not returned to local when the active client dropped")
+	}
+	cm.RegisterClient(laptop, "10.0.0.2:41500", "10.0.0.2:41500")
+
+	// Its old session ending late must not remove it
+	cm.UnregisterClient(laptop, "10.0.0.2:41000")
+
+	clients := cm.GetConnectedClients()
+	if len(clients) != 2 || clients[0].ID != laptop || clients[0].Slot != 1 || clients[1].ID != desktop {
+		t.Fatalf("Client list = %+v, want the laptop back in slot 1 before the desktop", clients)
+	}
+	if cursor := cm.clientCursors[laptop]; cursor == nil || cursor.x != 300 || cursor.y != 200 {
+		t.Errorf("Cursor not restored: %+v", cursor)
+	}
+	if !clients[0].resumeControl {
+		t.Fatalf("Reconnected client not marked to resume control")
+	}
+
+	clients[0].Monitors = monitors
+	cm.resumeControl(laptop)
+	if cm.IsControllingLocal() || backend.target != laptop {
+		t.Errorf("Control not resumed: local = %v, target = %q", cm.IsControllingLocal(), backend.target)
+	}
+
+	// Once control moved elsewhere, a drop is not followed by a resume
+	if err := cm.SwitchToClient(desktop); err != nil {
+		t.Fatalf("SwitchToClient() failed: %v", err)
+	}
+	cm.UnregisterClient(laptop, "10.0.0.2:41500")
+	cm.RegisterClient(laptop, "10.0.0.2:42000", "10.0.0.2:42000")
+	if client := cm.GetConnectedClients()[0]; client.resumeControl {
+		t.Errorf("Client that was not controlled marked to resume control")
+	}
+}
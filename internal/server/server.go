@@ -8,8 +8,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bnema/waymon/internal/config"
+	"github.com/bnema/waymon/internal/discovery"
+	"github.com/bnema/waymon/internal/display"
 	"github.com/bnema/waymon/internal/input"
 	"github.com/bnema/waymon/internal/logger"
 	"github.com/bnema/waymon/internal/network"
@@ -23,8 +26,10 @@ type Server struct {
 	sshServer     *network.SSHServer
 	clientManager *ClientManager
 	emergency     *EmergencyRelease
+	advertiser    *discovery.Advertiser
 
 	// Synchronization
+	mu       sync.Mutex
 	wg       sync.WaitGroup
 	stopOnce sync.Once
 }
@@ -66,6 +71,15 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start input backend: %w", err)
 	}
 
+	// Share the clipboard with clients as control moves
+	s.clientManager.StartClipboard(ctx)
+
+	// Place the server cursor where it comes back across a screen edge
+	s.clientManager.StartPointerWarp(ctx)
+
+	// Ping clients so an unresponsive one cannot keep input captured
+	s.clientManager.StartHeartbeat(ctx, time.Duration(s.config.Server.HeartbeatInterval)*time.Millisecond, s.config.Server.HeartbeatMisses)
+
 	// Initialize emergency release mechanisms
 	s.emergency = NewEmergencyRelease(s.clientManager)
 	s.emergency.Start()
@@ -104,9 +118,51 @@ func (s *Server) initClientManager() error {
 
 	logger.Info("Server: Client manager now shares the server's input backend")
 
+	// Reconnecting clients get back their slot and cursor
+	if err := clientManager.LoadClientRegistry(config.GetClientStatePath()); err != nil {
+		logger.Warnf("Server: Clients will only be remembered until the server stops: %v", err)
+	}
+
+	// Server monitors anchor the screen layout used for edge switching
+	if s.config.Server.EdgeSwitching {
+		s.loadServerMonitors()
+	}
+
 	return nil
 }
 
+// loadServerMonitors detects the server's monitors and hands them to the client manager
+func (s *Server) loadServerMonitors() {
+	disp, err := display.New()
+	if err != nil {
+		logger.Warnf("Server: Failed to detect monitors, edge switching disabled: %v", err)
+		return
+	}
+	defer func() {
+		if err := disp.Close(); err != nil {
+			logger.Errorf("Failed to close display: %v", err)
+		}
+	}()
+
+	monitors := disp.GetMonitors()
+	protocolMonitors := make([]*protocol.Monitor, len(monitors))
+	for i, mon := range monitors {
+		protocolMonitors[i] = &protocol.Monitor{
+			Name:    mon.Name,
+			X:       mon.X,
+			Y:       mon.Y,
+			Width:   mon.Width,
+			Height:  mon.Height,
+			Primary: mon.Primary,
+			Scale:   mon.Scale,
+		}
+		logger.Debugf("Server: Monitor %s (%dx%d at %d,%d)", mon.Name, mon.Width, mon.Height, mon.X, mon.Y)
+	}
+
+	s.clientManager.SetServerMonitors(protocolMonitors)
+	logger.Infof("Server: Edge switching enabled with %d local monitors", len(protocolMonitors))
+}
+
 // initInput initializes the input handler
 func (s *Server) initInput() error {
 	// Server needs evdev backend for actual input capture
@@ -130,6 +186,45 @@ func (s *Server) initInput() error {
 				}
 			}
 		})
+
+		// Hotkeys from [[server.bindings]], swallowed before reaching clients
+		bindings := parseBindings(s.config.Server.Bindings)
+		if len(bindings) > 0 {
+			allDevices.SetBindings(bindingChords(bindings), func(index int) {
+				if s.clientManager != nil {
+					s.clientManager.runBinding(bindings[index])
+				}
+			})
+			logger.Infof("Server: %d hotkey bindings active", len(bindings))
+		}
+
+		// Every hardware frame is forwarded unless motion merging is configured
+		policy, err := input.ParseCoalescePolicy(s.config.Server.MotionCoalescing)
+		if err != nil {
+			logger.Warnf("Server: %v, forwarding every frame", err)
+		}
+		allDevices.SetMotionCoalescing(policy, time.Duration(s.config.Server.CoalesceInterval)*time.Millisecond)
+		allDevices.SetTouchpadOptions(s.config.Server.TouchpadSpeed, s.config.Server.TapToClick)
+
+		// The heartbeat releases the grab when a client stops answering, so an
+		// idle but healthy session is not cut short
+		if s.config.Server.HeartbeatInterval > 0 {
+			allDevices.SetGrabTimeout(0)
+		}
+
+		// Events lost to a full capture queue count against the client they were for
+		allDevices.OnDroppedEvent(func(target string) {
+			if s.clientManager != nil && target != "" {
+				s.clientManager.recordDropped(target)
+			}
+		})
+
+		// Local motion drives the tracked server cursor for edge switching
+		allDevices.OnLocalInputEvent(func(event *protocol.InputEvent) {
+			if s.clientManager != nil {
+				s.clientManager.HandleLocalInputEvent(event)
+			}
+		})
 	}
 
 	// NOTE: We set up the callback BEFORE starting the backend
@@ -138,11 +233,6 @@ func (s *Server) initInput() error {
 	s.inputBackend.OnInputEvent(func(event *protocol.InputEvent) {
 		logger.Debugf("Server: Received input event from backend: %T", event.Event)
 
-		// Update emergency release activity tracking
-		if s.emergency != nil {
-			s.emergency.UpdateActivity()
-		}
-
 		if s.clientManager != nil {
 			s.clientManager.HandleInputEvent(event)
 		} else {
@@ -170,6 +260,13 @@ func (s *Server) initNetwork() error {
 	// Create SSH server
 	s.sshServer = network.NewSSHServer(s.config.Server.Port, hostKeyPath, authKeysPath)
 	s.sshServer.SetMaxClients(s.config.Server.MaxClients)
+	s.sshServer.SetFeatures(s.features())
+	if s.config.Server.ApprovalTimeout > 0 {
+		s.sshServer.SetAuthTimeout(time.Duration(s.config.Server.ApprovalTimeout) * time.Second)
+	}
+	if s.config.Server.SSHTrustedCAKeysPath != "" {
+		s.sshServer.SetTrustedCAKeys(expandPath(s.config.Server.SSHTrustedCAKeysPath), s.config.Server.SSHCertPrincipals)
+	}
 
 	// Event handler is set up by the command layer (cmd/server.go)
 	// This allows the handler to be set after the SSH server is created
@@ -177,15 +274,58 @@ func (s *Server) initNetwork() error {
 	return nil
 }
 
+// features returns the features offered to clients, leaving out those turned
+// off in the configuration
+func (s *Server) features() []string {
+	var features []string
+	for _, feature := range protocol.Features() {
+		if feature == protocol.FeatureClipboard && !s.config.Server.ClipboardSync {
+			continue
+		}
+		features = append(features, feature)
+	}
+	return features
+}
+
 // runNetworkServer runs the SSH server
 func (s *Server) runNetworkServer(ctx context.Context) {
 	defer s.wg.Done()
 
 	if err := s.sshServer.Start(ctx); err != nil {
 		logger.Errorf("Network server error: %v", err)
+		return
+	}
+
+	if s.config.Server.Advertise {
+		s.startAdvertising(ctx)
 	}
 }
 
+// startAdvertising announces the server to clients on the local network. The
+// host key exists once the SSH server is started.
+func (s *Server) startAdvertising(ctx context.Context) {
+	fingerprint, err := s.sshServer.HostKeyFingerprint()
+	if err != nil {
+		logger.Warnf("Server: LAN discovery disabled: %v", err)
+		return
+	}
+
+	advertiser := discovery.NewAdvertiser(discovery.Service{
+		Name:            s.GetName(),
+		Port:            s.GetPort(),
+		ProtocolVersion: protocol.Version,
+		Fingerprint:     fingerprint,
+	})
+	if err := advertiser.Start(ctx); err != nil {
+		logger.Warnf("Server: LAN discovery disabled: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.advertiser = advertiser
+	s.mu.Unlock()
+}
+
 // Note: Event processing is now handled by ClientManager.HandleInputEvent
 // which receives events via the OnInputEvent callback from the SSH server
 
@@ -206,6 +346,21 @@ func (s *Server) Stop() {
 			s.clientManager.NotifyShutdown()
 		}
 
+		if s.clientManager != nil {
+			s.clientManager.StopHeartbeat()
+			s.clientManager.StopClipboard()
+			s.clientManager.StopPointerWarp()
+			s.clientManager.SaveClientRegistry()
+		}
+
+		s.mu.Lock()
+		advertiser := s.advertiser
+		s.mu.Unlock()
+		if advertiser != nil {
+			logger.Debug("Server.Stop: Withdrawing LAN announcement")
+			advertiser.Stop()
+		}
+
 		if s.sshServer != nil {
 			logger.Debug("Server.Stop: Stopping SSH server")
 			s.sshServer.Stop()
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/bnema/waymon/internal/proto"
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+// LinkStats are live network statistics of a client connection
+type LinkStats struct {
+	RTT             time.Duration // Smoothed heartbeat round trip time
+	EventRTT        time.Duration // Capture to injection and back, for sampled events
+	InjectTime      time.Duration // Time the client takes to inject an event
+	Jitter          time.Duration // Interarrival jitter seen by the client
+	EventsPerSecond float64
+	EventsSent      uint64
+	EventsDropped   uint64 // Captured for the client but never sent
+	BytesSent       uint64
+}
+
+// linkStats accumulates the statistics of a client. Its own lock lets the
+// input path update it while holding only the manager's read lock.
+type linkStats struct {
+	mu         sync.Mutex
+	rtt        time.Duration
+	eventRTT   time.Duration
+	injectTime time.Duration
+	jitter     time.Duration
+	sent       uint64
+	dropped    uint64
+
+	// Events sent in the current one second window, and the rate of the last one
+	windowStart time.Time
+	windowCount uint64
+	rate        float64
+}
+
+// rateWindow is the period events per second are counted over
+const rateWindow = time.Second
+
+// smooth blends a new sample into an average the way TCP smooths its RTT
+func smooth(average, sample time.Duration) time.Duration {
+	if average == 0 {
+		return sample
+	}
+	return average + (sample-average)/8
+}
+
+// eventSent counts an input event sent to the client
+func (s *linkStats) eventSent(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent++
+	s.rollWindow(now)
+	s.windowCount++
+}
+
+// eventDropped counts an input event that never reached the client
+func (s *linkStats) eventDropped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped++
+}
+
+// addRTT records a heartbeat round trip
+func (s *linkStats) addRTT(rtt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rtt = smooth(s.rtt, rtt)
+}
+
+// addSample records an input event echoed back by the client
+func (s *linkStats) addSample(sample *protocol.LatencySample, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rtt := now.Sub(time.Unix(0, sample.EventTimestamp)); rtt > 0 {
+		s.eventRTT = smooth(s.eventRTT, rtt)
+	}
+	s.injectTime = smooth(s.injectTime, time.Duration(sample.InjectUs)*time.Microsecond)
+	s.jitter = time.Duration(sample.JitterUs) * time.Microsecond
+}
+
+// rollWindow starts a new counting window once the current one is over.
+// Must be called with the lock held.
+func (s *linkStats) rollWindow(now time.Time) {
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < rateWindow {
+		return
+	}
+	if elapsed < 2*rateWindow {
+		s.rate = float64(s.windowCount) / elapsed.Seconds()
+	} else {
+		s.rate = 0 // Nothing was sent for a whole window
+	}
+	s.windowStart = now
+	s.windowCount = 0
+}
+
+// snapshot returns the current statistics
+func (s *linkStats) snapshot(now time.Time) LinkStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollWindow(now)
+	return LinkStats{
+		RTT:             s.rtt,
+		EventRTT:        s.eventRTT,
+		InjectTime:      s.injectTime,
+		Jitter:          s.jitter,
+		EventsPerSecond: s.rate,
+		EventsSent:      s.sent,
+		EventsDropped:   s.dropped,
+	}
+}
+
+// ClientStats returns the network statistics of a connected client
+func (cm *ClientManager) ClientStats(clientID string) (LinkStats, bool) {
+	cm.mu.RLock()
+	client, exists := cm.clients[clientID]
+	sshServer := cm.sshServer
+	cm.mu.RUnlock()
+
+	if !exists {
+		return LinkStats{}, false
+	}
+	stats := client.stats.snapshot(time.Now())
+	if sshServer != nil {
+		stats.BytesSent = sshServer.BytesSent(client.ID)
+	}
+	return stats, true
+}
+
+// handleLatencySample records a sample echoed by a client and reports whether
+// the event was one
+func (cm *ClientManager) handleLatencySample(event *protocol.InputEvent) bool {
+	sample := event.GetLatencySample()
+	if sample == nil {
+		return false
+	}
+
+	cm.mu.RLock()
+	client := cm.clientBySource(event.SourceId)
+	cm.mu.RUnlock()
+
+	if client != nil {
+		client.stats.addSample(sample, time.Now())
+	}
+	return true
+}
+
+// recordDropped counts an event the capture dropped for a client
+func (cm *ClientManager) recordDropped(clientID string) {
+	cm.mu.RLock()
+	client, exists := cm.clients[clientID]
+	cm.mu.RUnlock()
+
+	if exists {
+		client.stats.eventDropped()
+	}
+}
+
+// clientStatsMessages returns the statistics of every client for the IPC
+// status response, in client list order. Must be called with the lock held.
+func (cm *ClientManager) clientStatsMessages(clientIDs []string) []*pb.ClientStats {
+	now := time.Now()
+	messages := make([]*pb.ClientStats, 0, len(clientIDs))
+	for _, id := range clientIDs {
+		client := cm.clients[id]
+		stats := client.stats.snapshot(now)
+		if cm.sshServer != nil {
+			stats.BytesSent = cm.sshServer.BytesSent(client.ID)
+		}
+		messages = append(messages, &pb.ClientStats{
+			Name:            client.Name,
+			Address:         client.Address,
+			RttMs:           milliseconds(stats.RTT),
+			EventRttMs:      milliseconds(stats.EventRTT),
+			InjectMs:        milliseconds(stats.InjectTime),
+			JitterMs:        milliseconds(stats.Jitter),
+			EventsPerSecond: stats.EventsPerSecond,
+			EventsSent:      stats.EventsSent,
+			EventsDropped:   stats.EventsDropped,
+			BytesSent:       stats.BytesSent,
+		})
+	}
+	return messages
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bnema/waymon/internal/protocol"
+)
+
+func TestLinkStatsRate(t *testing.T) {
+	start := time.Unix(1000, 0)
+	stats := &linkStats{windowStart: start}
+
+	for i := 0; i < 50; i++ {
+		stats.eventSent(start.Add(time.Duration(i) * 20 * time.Millisecond))
+	}
+	stats.eventDropped()
+
+	got := stats.snapshot(start.Add(time.Second))
+	if got.EventsSent != 50 || got.EventsDropped != 1 {
+		t.Errorf("sent, dropped = %d, %d, want 50, 1", got.EventsSent, got.EventsDropped)
+	}
+	if got.EventsPerSecond != 50 {
+		t.Errorf("EventsPerSecond = %v, want 50", got.EventsPerSecond)
+	}
+
+	// Nothing sent for longer than a window
+	if got := stats.snapshot(start.Add(5 * time.Second)); got.EventsPerSecond != 0 {
+		t.Errorf("EventsPerSecond after idle = %v, want 0", got.EventsPerSecond)
+	}
+}
+
+func TestLinkStatsLatency(t *testing.T) {
+	stats := &linkStats{}
+	now := time.Unix(1000, 0)
+
+	stats.addRTT(8 * time.Millisecond)
+	stats.addRTT(16 * time.Millisecond)
+	stats.addSample(&protocol.LatencySample{
+		EventTimestamp: now.Add(-12 * time.Millisecond).UnixNano(),
+		InjectUs:       300,
+		JitterUs:       1500,
+	}, now)
+
+	got := stats.snapshot(now)
+	if got.RTT != 9*time.Millisecond {
+		t.Errorf("RTT = %v, want 9ms", got.RTT)
+	}
+	if got.EventRTT != 12*time.Millisecond {
+		t.Errorf("EventRTT = %v, want 12ms", got.EventRTT)
+	}
+	if got.InjectTime != 300*time.Microsecond || got.Jitter != 1500*time.Microsecond {
+		t.Errorf("InjectTime, Jitter = %v, %v, want 300µs, 1.5ms", got.InjectTime, got.Jitter)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+
+	"github.com/bnema/waymon/internal/input"
+	"github.com/bnema/waymon/internal/logger"
+)
+
+// StartPointerWarp creates the virtual pointer that moves the server cursor to
+// the spot it enters the server at, when control comes back across a screen
+// edge. Without the server monitors there is no edge to come back across.
+func (cm *ClientManager) StartPointerWarp(ctx context.Context) {
+	cm.mu.RLock()
+	monitors := cm.serverMonitors
+	cm.mu.RUnlock()
+	if len(monitors) == 0 {
+		return
+	}
+
+	warper, err := input.NewPointerWarper(ctx, monitors)
+	if err != nil {
+		logger.Warnf("[SERVER-MANAGER] Cursor warping unavailable, the cursor resumes where it left the server: %v", err)
+		return
+	}
+
+	cm.mu.Lock()
+	cm.pointerWarper = warper
+	cm.mu.Unlock()
+	logger.Info("[SERVER-MANAGER] Cursor warping enabled")
+}
+
+// StopPointerWarp removes the virtual pointer
+func (cm *ClientManager) StopPointerWarp() {
+	cm.mu.Lock()
+	warper := cm.pointerWarper
+	cm.pointerWarper = nil
+	cm.mu.Unlock()
+
+	if warper != nil {
+		if err := warper.Close(); err != nil {
+			logger.Errorf("[SERVER-MANAGER] Failed to close virtual pointer: %v", err)
+		}
+	}
+}
+
+// warpLocalCursor moves the server cursor to where it enters the server, in
+// server layout coordinates, along with its tracked position. Without a
+// virtual pointer the cursor stays where it left the server. Must be called
+// with the lock held.
+func (cm *ClientManager) warpLocalCursor(x, y float64) {
+	if cm.pointerWarper == nil || cm.localCursor == nil {
+		return
+	}
+
+	if err := cm.pointerWarper.Warp(x, y); err != nil {
+		logger.Warnf("[SERVER-MANAGER] Failed to warp cursor to %.0f,%.0f: %v", x, y, err)
+		return
+	}
+	cm.localCursor.x, cm.localCursor.y = x, y
+	logger.Debugf("[SERVER-MANAGER] Warped cursor to %.0f,%.0f", x, y)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/bnema/waymon/internal/client"
+	"github.com/bnema/waymon/internal/discovery"
+	"github.com/bnema/waymon/internal/protocol"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,12 +19,23 @@ type ControlStatusMsg struct {
 	Status client.ControlStatus
 }
 
+// discoveredServersMsg carries the servers found browsing the LAN
+type discoveredServersMsg struct {
+	services []discovery.Service
+	err      error
+}
+
+// hostKeyPromptTimeout is how long an unanswered host key prompt waits before
+// the server is refused
+const hostKeyPromptTimeout = 2 * time.Minute
+
 // ClientModel represents the refactored UI model for the client
 type ClientModel struct {
 	BaseModel // Embed base model functionality
 
 	serverAddr    string
 	inputReceiver *client.InputReceiver
+	connect       func(address, fingerprint string) // Starts connecting to another server
 	version       string
 
 	// Connection state
@@ -31,6 +44,16 @@ type ClientModel struct {
 	waitingApproval bool
 	controlStatus   client.ControlStatus
 
+	// Server key waiting to be trusted on first connection
+	pendingHostKey   *HostKeyPromptMsg
+	announcedHostKey string // Fingerprint announced by the discovered server
+
+	// Servers found on the LAN, listed while browsing
+	discovering bool
+	browsing    bool
+	discovered  []discovery.Service
+	selected    int
+
 	// Message display
 	message       string
 	messageType   string
@@ -38,23 +61,26 @@ type ClientModel struct {
 }
 
 // NewClientModel creates a new refactored client UI model
-func NewClientModel(serverAddr string, inputReceiver *client.InputReceiver, version string) *ClientModel {
+func NewClientModel(serverAddr string, inputReceiver *client.InputReceiver, connect func(address, fingerprint string), version string) *ClientModel {
 	return &ClientModel{
 		serverAddr:    serverAddr,
 		inputReceiver: inputReceiver,
+		connect:       connect,
 		version:       version,
 	}
 }
 
 // Init initializes the client model
 func (m *ClientModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{tea.EnterAltScreen}
 	if m.base != nil {
-		return tea.Batch(
-			m.base.TickSpinner(),
-			tea.EnterAltScreen,
-		)
+		cmds = append(cmds, m.base.TickSpinner())
+	}
+	// Without a server to connect to, look for one
+	if m.serverAddr == "" {
+		cmds = append(cmds, m.discoverServers())
 	}
-	return tea.EnterAltScreen
+	return tea.Batch(cmds...)
 }
 
 // OnShutdown implements UIModel interface
@@ -92,6 +118,18 @@ func (m *ClientModel) SetProgram(p *tea.Program) {
 		m.inputReceiver.SetOnReconnectStatus(func(status string) {
 			p.Send(ReconnectingMsg{Status: status})
 		})
+
+		m.inputReceiver.SetHostKeyPrompt(func(host, fingerprint string) bool {
+			response := make(chan bool, 1)
+			p.Send(HostKeyPromptMsg{Host: host, Fingerprint: fingerprint, ResponseChan: response})
+			select {
+			case accepted := <-response:
+				return accepted
+			case <-time.After(hostKeyPromptTimeout):
+				p.Send(LogMsg{Entry: LogEntry{Level: "warn", Message: "Host key prompt timed out - server not trusted"}})
+				return false
+			}
+		})
 	}
 }
 
@@ -116,7 +154,49 @@ func (m *ClientModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Answer a pending host key prompt first
+		if m.pendingHostKey != nil {
+			switch msg.String() {
+			case "y", "Y":
+				m.answerHostKey(true)
+				return m, nil
+			case "n", "N", "q":
+				m.answerHostKey(false)
+				if msg.String() != "q" {
+					return m, nil
+				}
+			default:
+				return m, nil
+			}
+		}
+
+		// Pick a discovered server
+		if m.browsing {
+			switch msg.String() {
+			case "up", "k":
+				if m.selected > 0 {
+					m.selected--
+				}
+				return m, nil
+			case "down", "j":
+				if m.selected < len(m.discovered)-1 {
+					m.selected++
+				}
+				return m, nil
+			case "enter":
+				return m, m.connectToDiscovered(m.discovered[m.selected])
+			case "esc":
+				m.browsing = false
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
+		case "d":
+			if !m.connected && !m.discovering && m.connect != nil {
+				return m, m.discoverServers()
+			}
+
 		case "r":
 			if m.controlStatus.BeingControlled {
 				// When being controlled, 'r' requests release
@@ -147,8 +227,22 @@ func (m *ClientModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case discoveredServersMsg:
+		m.discovering = false
+		switch {
+		case msg.err != nil:
+			m.SetMessage("error", fmt.Sprintf("LAN discovery failed: %v", msg.err))
+		case len(msg.services) == 0:
+			m.SetMessage("info", "No servers found on the local network - press [d] to search again")
+		case !m.connected:
+			m.discovered = msg.services
+			m.selected = 0
+			m.browsing = true
+		}
+
 	case ConnectedMsg:
 		m.connected = true
+		m.browsing = false
 		m.reconnecting = false
 		m.waitingApproval = false
 		m.SetMessage("success", "Connected to server")
@@ -167,6 +261,10 @@ func (m *ClientModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.controlStatus = client.ControlStatus{}
 		m.SetMessage("info", msg.Status)
 
+	case HostKeyPromptMsg:
+		m.pendingHostKey = &msg
+		m.base.AddLogEntry("warn", fmt.Sprintf("New server host key for %s: %s", msg.Host, msg.Fingerprint))
+
 	case WaitingApprovalMsg:
 		m.waitingApproval = true
 		m.SetMessage("info", "Waiting for server approval...")
@@ -208,8 +306,13 @@ func (m *ClientModel) View() string {
 	// Calculate available space for logs
 	statusBarHeight := 1
 	waitingPromptHeight := 0
-	if m.waitingApproval {
+	switch {
+	case m.pendingHostKey != nil && m.announcedHostKey != "":
+		waitingPromptHeight = 5
+	case m.waitingApproval || m.pendingHostKey != nil:
 		waitingPromptHeight = 4
+	case m.browsing:
+		waitingPromptHeight = len(m.discovered) + 3
 	}
 	controlStatusHeight := 3 // Control status section
 
@@ -223,9 +326,15 @@ func (m *ClientModel) View() string {
 	output.WriteString("\n")
 
 	// 2. If waiting for approval, show it
-	if m.waitingApproval {
+	if m.pendingHostKey != nil {
+		output.WriteString(m.renderHostKeyPrompt())
+		output.WriteString("\n")
+	} else if m.waitingApproval {
 		output.WriteString(m.renderWaitingPrompt())
 		output.WriteString("\n")
+	} else if m.browsing {
+		output.WriteString(m.renderDiscoveredServers())
+		output.WriteString("\n")
 	}
 
 	// 3. Render control status
@@ -249,6 +358,10 @@ func (m *ClientModel) renderClientStatusBar() string {
 		statusText = fmt.Sprintf("Connected to %s", m.serverAddr)
 	case m.reconnecting:
 		statusText = fmt.Sprintf("Reconnecting to %s", m.serverAddr)
+	case m.discovering:
+		statusText = "Searching the local network for servers..."
+	case m.serverAddr == "":
+		statusText = "No server selected"
 	default:
 		statusText = fmt.Sprintf("Disconnected from %s", m.serverAddr)
 	}
@@ -308,7 +421,7 @@ func (m *ClientModel) renderControlStatus() string {
 
 		// Show controls for disconnected state
 		controlsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-		controls := "  Controls: [r] Reconnect • [q] Quit"
+		controls := "  Controls: [r] Reconnect • [d] Discover servers • [q] Quit"
 		output.WriteString(controlsStyle.Render(controls))
 	}
 
@@ -332,6 +445,95 @@ func (m *ClientModel) renderWaitingPrompt() string {
 	return prompt.String()
 }
 
+// renderHostKeyPrompt renders the prompt to trust a new server host key
+func (m *ClientModel) renderHostKeyPrompt() string {
+	promptStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+	serverStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("247"))
+
+	var prompt strings.Builder
+	prompt.WriteString(promptStyle.Render("🔑 UNKNOWN SERVER: "))
+	prompt.WriteString(serverStyle.Render(m.pendingHostKey.Host))
+	prompt.WriteString("\n")
+	prompt.WriteString(infoStyle.Render("Host key fingerprint: " + m.pendingHostKey.Fingerprint))
+	prompt.WriteString("\n")
+	if m.announcedHostKey != "" && m.pendingHostKey.Fingerprint == m.announcedHostKey {
+		// Anyone on the LAN can announce, so this does not replace comparing
+		prompt.WriteString(infoStyle.Render("It matches the key the server announced on the local network."))
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString(infoStyle.Render("Compare it with the fingerprint the server logs at startup. Trust this server? [y/n]"))
+
+	return prompt.String()
+}
+
+// renderDiscoveredServers renders the servers found on the LAN
+func (m *ClientModel) renderDiscoveredServers() string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("247"))
+
+	var list strings.Builder
+	list.WriteString(headerStyle.Render("Servers on the local network:"))
+	list.WriteString("\n")
+	for i, s := range m.discovered {
+		line := fmt.Sprintf("%s (%s)", s.Name, s.Address())
+		if !protocol.Compatible(s.ProtocolVersion) {
+			line += fmt.Sprintf(" - protocol v%d, incompatible", s.ProtocolVersion)
+		}
+		if i == m.selected {
+			list.WriteString(selectedStyle.Render("  ▶ " + line))
+		} else {
+			list.WriteString(infoStyle.Render("    " + line))
+		}
+		list.WriteString("\n")
+	}
+	list.WriteString(infoStyle.Render("[↑/↓] Select • [enter] Connect • [esc] Close"))
+
+	return list.String()
+}
+
+// discoverServers returns a command that browses the LAN for servers
+func (m *ClientModel) discoverServers() tea.Cmd {
+	m.discovering = true
+	return func() tea.Msg {
+		services, err := discovery.Browse(context.Background(), discovery.DefaultBrowseTimeout)
+		return discoveredServersMsg{services: services, err: err}
+	}
+}
+
+// connectToDiscovered returns a command that starts connecting to a server
+// found on the LAN. The server must then present the host key it announced.
+func (m *ClientModel) connectToDiscovered(service discovery.Service) tea.Cmd {
+	m.browsing = false
+	m.serverAddr = service.Address()
+	m.announcedHostKey = service.Fingerprint
+	m.SetMessage("info", fmt.Sprintf("Connecting to %s at %s", service.Name, m.serverAddr))
+
+	// Waits for an attempt in progress, so keep it out of Update
+	address := m.serverAddr
+	return func() tea.Msg {
+		if m.connect != nil {
+			m.connect(address, service.Fingerprint)
+		}
+		return nil
+	}
+}
+
+// answerHostKey answers the pending host key prompt
+func (m *ClientModel) answerHostKey(accepted bool) {
+	select {
+	case m.pendingHostKey.ResponseChan <- accepted:
+	default:
+	}
+	if accepted {
+		m.SetMessage("success", fmt.Sprintf("Trusted host key of %s", m.pendingHostKey.Host))
+	} else {
+		m.SetMessage("error", fmt.Sprintf("Refused host key of %s", m.pendingHostKey.Host))
+	}
+	m.pendingHostKey = nil
+}
+
 // renderClientLogs renders the recent log entries
 func (m *ClientModel) renderClientLogs(maxLines int) string {
 	logs := m.base.GetLogs()
@@ -390,9 +592,9 @@ func (m *ClientModel) requestControlRelease() tea.Cmd {
 }
 
 // RunClientUI runs the client UI with proper lifecycle management
-func RunClientUI(ctx context.Context, serverAddr string, inputReceiver *client.InputReceiver, version string) error {
+func RunClientUI(ctx context.Context, serverAddr string, inputReceiver *client.InputReceiver, connect func(address, fingerprint string), version string) error {
 	// Create the model
-	model := NewClientModel(serverAddr, inputReceiver, version)
+	model := NewClientModel(serverAddr, inputReceiver, connect, version)
 
 	// Create program runner with configuration
 	config := ProgramConfig{
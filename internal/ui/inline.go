@@ -629,6 +629,15 @@ type (
 		Fingerprint  string
 		ResponseChan chan bool
 	}
+	SSHAuthResolvedMsg struct {
+		Fingerprint string
+		Approved    bool
+	}
+	HostKeyPromptMsg struct {
+		Host         string
+		Fingerprint  string
+		ResponseChan chan bool
+	}
 	SSHAuthApprovedMsg  struct{ Fingerprint string }
 	SSHAuthDeniedMsg    struct{ Fingerprint string }
 	LogMsg              struct{ Entry LogEntry }
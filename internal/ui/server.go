@@ -17,6 +17,16 @@ import (
 // Message types for server UI
 type RefreshClientListMsg struct{}
 
+// statsTickMsg redraws the client list so its network statistics stay current
+type statsTickMsg struct{}
+
+// statsTick schedules the next statistics refresh
+func statsTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return statsTickMsg{}
+	})
+}
+
 // ServerModel represents the refactored server UI model
 type ServerModel struct {
 	BaseModel // Embed base model functionality
@@ -121,9 +131,10 @@ func (m *ServerModel) Init() tea.Cmd {
 		return tea.Batch(
 			m.base.TickSpinner(),
 			tea.EnterAltScreen,
+			statsTick(),
 		)
 	}
-	return tea.EnterAltScreen
+	return tea.Batch(tea.EnterAltScreen, statsTick())
 }
 
 // OnShutdown implements UIModel interface
@@ -349,6 +360,19 @@ func (m *ServerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.authChannel = msg.ResponseChan
 		m.base.AddLogEntry("warn", fmt.Sprintf("SSH auth request from %s", msg.ClientAddr))
 
+	case SSHAuthResolvedMsg:
+		// Decided with 'waymon auth' or timed out while the prompt was shown
+		if m.pendingAuth != nil && m.pendingAuth.Fingerprint == msg.Fingerprint {
+			verdict := "Denied"
+			if msg.Approved {
+				verdict = "Approved"
+			}
+			m.base.AddLogEntry("info", fmt.Sprintf("%s connection from %s", verdict, m.pendingAuth.ClientAddr))
+			m.pendingAuth = nil
+			m.authChannel = nil
+			m.updateViewport()
+		}
+
 	case LogMsg:
 		if m.base != nil {
 			m.base.AddLogEntry(msg.Entry.Level, msg.Entry.Message)
@@ -358,6 +382,9 @@ func (m *ServerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case RefreshClientListMsg:
 		m.refreshClientList()
 
+	case statsTickMsg:
+		cmds = append(cmds, statsTick())
+
 	case SetClientManagerMsg:
 		m.clientManager = msg.ClientManager.(*server.ClientManager)
 		m.refreshClientList()
@@ -466,6 +493,13 @@ func (m *ServerModel) renderClientList() string {
 			}
 			content.WriteString(clientLine)
 			content.WriteString("\n")
+
+			if m.clientManager != nil {
+				if stats, ok := m.clientManager.ClientStats(client.ID); ok {
+					content.WriteString(m.idleStyle.Render("        " + formatLinkStats(stats)))
+					content.WriteString("\n")
+				}
+			}
 		}
 	}
 
@@ -480,6 +514,46 @@ func (m *ServerModel) renderClientList() string {
 	return m.clientListStyle.Render(content.String())
 }
 
+// formatLinkStats summarizes the network statistics of a client on one line
+func formatLinkStats(stats server.LinkStats) string {
+	parts := []string{fmt.Sprintf("rtt %s", formatLatency(stats.RTT))}
+	if stats.EventRTT > 0 {
+		parts = append(parts,
+			fmt.Sprintf("event %s", formatLatency(stats.EventRTT)),
+			fmt.Sprintf("inject %s", formatLatency(stats.InjectTime)),
+			fmt.Sprintf("jitter %s", formatLatency(stats.Jitter)))
+	}
+	parts = append(parts,
+		fmt.Sprintf("%.0f ev/s", stats.EventsPerSecond),
+		fmt.Sprintf("%d dropped", stats.EventsDropped),
+		fmt.Sprintf("%s sent", formatBytes(stats.BytesSent)))
+	return strings.Join(parts, " · ")
+}
+
+// formatLatency shows a latency in milliseconds, or "-" before it is measured
+func formatLatency(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+// formatBytes shows a byte count with a binary unit
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
 func (m *ServerModel) renderControls() string {
 	controls := []string{
 		"[1-5] Switch to client",
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bnema/waymon/internal/client"
+	"github.com/bnema/waymon/internal/config"
 	"github.com/bnema/waymon/internal/input"
 	"github.com/bnema/waymon/internal/network"
 	"github.com/bnema/waymon/internal/protocol"
@@ -173,6 +174,12 @@ func testEndToEndFlow(tempDir string) TestResult {
 		}
 	}
 
+	// Trust the throwaway server key without touching the user's known_hosts
+	cfg := config.Get()
+	cfg.Client.KnownHostsPath = tempDir + "/known_hosts"
+	config.Set(cfg)
+	inputReceiver.SetHostKeyPrompt(func(host, fingerprint string) bool { return true })
+
 	// Connect client
 	fmt.Println(dimStyle.Render("4. Connecting client to server..."))
 	if err := inputReceiver.Connect(ctx, clientKeyPath); err != nil {
@@ -139,6 +139,7 @@ func testSSHConnection(tempDir string) TestResult {
 
 	// Create client
 	client := network.NewSSHClient(clientKeyPath)
+	client.SetHostKeyCallback(ssh.InsecureIgnoreHostKey()) //nolint:gosec // throwaway test server key
 	
 	// Connect
 	if err := client.Connect(ctx, fmt.Sprintf("localhost:%d", *port)); err != nil {
@@ -210,6 +211,7 @@ func testEventTransmission(tempDir string) TestResult {
 
 	// Connect client
 	client := network.NewSSHClient(clientKeyPath)
+	client.SetHostKeyCallback(ssh.InsecureIgnoreHostKey()) //nolint:gosec // throwaway test server key
 	if err := client.Connect(ctx, fmt.Sprintf("localhost:%d", *port+1)); err != nil {
 		return TestResult{
 			Name:    "Event Transmission",
@@ -337,6 +339,7 @@ func testHighThroughput(tempDir string) TestResult {
 
 	// Connect client
 	client := network.NewSSHClient(clientKeyPath)
+	client.SetHostKeyCallback(ssh.InsecureIgnoreHostKey()) //nolint:gosec // throwaway test server key
 	if err := client.Connect(ctx, fmt.Sprintf("localhost:%d", *port+2)); err != nil {
 		return TestResult{
 			Name:    "High Throughput",
@@ -429,7 +432,7 @@ func testClientServerManagers(tempDir string) TestResult {
 	connectedClients := make(map[string]string)
 	var clientMu sync.Mutex
 	
-	sshServer.OnClientConnected = func(addr, publicKey string) {
+	sshServer.OnClientConnected = func(clientID, addr, publicKey string) {
 		clientMu.Lock()
 		connectedClients[addr] = publicKey
 		clientMu.Unlock()
@@ -444,6 +447,7 @@ func testClientServerManagers(tempDir string) TestResult {
 
 	// Create SSH client
 	sshClient := network.NewSSHClient(clientKeyPath)
+	sshClient.SetHostKeyCallback(ssh.InsecureIgnoreHostKey()) //nolint:gosec // throwaway test server key
 	
 	// Connect
 	if err := sshClient.Connect(ctx, fmt.Sprintf("localhost:%d", *port+3)); err != nil {